@@ -20,6 +20,11 @@ import (
 	"github.com/joselitophala/budget-planner-backend/internal/database"
 	"github.com/joselitophala/budget-planner-backend/internal/handlers"
 	_ "github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/networth"
+	"github.com/joselitophala/budget-planner-backend/internal/notify"
+	"github.com/joselitophala/budget-planner-backend/internal/push"
+	"github.com/joselitophala/budget-planner-backend/internal/realtime"
+	"github.com/joselitophala/budget-planner-backend/internal/totals"
 )
 
 func main() {
@@ -45,12 +50,78 @@ func main() {
 	// 	log.Fatalf("Failed to run migrations: %v", err)
 	// }
 
-	// Initialize JWT client
-	jwtClient, err := auth.NewJWTClient(os.Getenv("JWT_SECRET"))
+	// Initialize JWT client: Clerk JWKS in every environment, HMAC secret only in development
+	var jwtClient *auth.JWTClient
+	if cfg.ClerkJWKSURL != "" {
+		jwtClient, err = auth.NewJWKSClient(auth.JWKSOptions{
+			URL:               cfg.ClerkJWKSURL,
+			Issuer:            cfg.ClerkIssuer,
+			Audience:          cfg.ClerkAudience,
+			AuthorizedParties: cfg.ClerkAuthorizedParties,
+			ClockSkew:         cfg.JWTClockSkew,
+		})
+	} else {
+		log.Println("WARNING: CLERK_JWKS_URL not set, falling back to HMAC (JWT_SECRET) token verification for development")
+		jwtClient, err = auth.NewJWTClient(cfg.JWTSecret)
+	}
 	if err != nil {
 		log.Fatalf("Failed to initialize JWT client: %v", err)
 	}
 
+	// Email outbox worker: SMTP when configured, otherwise emails are only logged
+	var notifier notify.Notifier = notify.LogNotifier{}
+	if cfg.SMTPHost != "" {
+		smtpNotifier, err := notify.NewSMTPNotifier(notify.SMTPOptions{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.EmailFrom,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize SMTP notifier: %v", err)
+		}
+		notifier = smtpNotifier
+	} else {
+		log.Println("WARNING: SMTP_HOST not set, emails will be logged instead of sent")
+	}
+	outbox := notify.NewOutbox(db.Queries, notifier, notify.OutboxOptions{
+		AppURL:       cfg.AppURL,
+		PollInterval: cfg.EmailPollInterval,
+		MaxAttempts:  cfg.EmailMaxAttempts,
+		Summaries:    cfg.EmailMonthlySummaries,
+	})
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go outbox.Run(workerCtx)
+
+	// Web Push: enabled when VAPID keys are configured
+	var pushSender *push.Sender
+	if cfg.VAPIDPublicKey != "" {
+		pushSender, err = push.NewSender(push.VAPIDOptions{
+			PublicKey:  cfg.VAPIDPublicKey,
+			PrivateKey: cfg.VAPIDPrivateKey,
+			Subject:    cfg.VAPIDSubject,
+			AllowLocal: cfg.IsDevelopment(),
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize push sender: %v", err)
+		}
+		go push.NewOutbox(db.Queries, pushSender, push.OutboxOptions{}).Run(workerCtx)
+	} else {
+		log.Println("WARNING: VAPID keys not set, Web Push notifications disabled")
+	}
+
+	// Real-time change events, fanned out from Postgres LISTEN/NOTIFY
+	hub := realtime.NewHub(db.Pool, db.Queries)
+	go hub.Run(workerCtx)
+
+	// Monthly net worth snapshots
+	go networth.RunSnapshots(workerCtx, db.Queries)
+
+	// Consistency checks for the monthly totals analytics read from
+	go totals.RunChecks(workerCtx, db.Pool, db.Queries)
+
 	// Create router
 	r := chi.NewRouter()
 
@@ -60,7 +131,7 @@ func main() {
 	r.Use(chiMiddleware.Logger)
 	r.Use(chiMiddleware.Recoverer)
 	r.Use(chiMiddleware.Timeout(60 * time.Second))
-	r.Use(chiMiddleware.AllowContentType("application/json"))
+	r.Use(chiMiddleware.AllowContentType("application/json", "text/csv"))
 
 	// CORS configuration
 	r.Use(cors.Handler(cors.Options{
@@ -80,12 +151,41 @@ func main() {
 	userHandler := handlers.NewUserHandler(db.Queries)
 	categoryHandler := handlers.NewCategoryHandler(db.Queries)
 	budgetHandler := handlers.NewBudgetHandler(db.Queries)
-	transactionHandler := handlers.NewTransactionHandler(db.Queries)
-	syncHandler := handlers.NewSyncHandler(db.Queries)
+	transactionHandler := handlers.NewTransactionHandler(db.Queries, db.Pool)
+	syncHandler := handlers.NewSyncHandler(db.Queries, db.Pool)
 	paymentMethodHandler := handlers.NewPaymentMethodHandler(db.Queries)
 	reflectionHandler := handlers.NewReflectionHandler(db.Queries)
-	sharingHandler := handlers.NewSharingHandler(db.Queries)
+	sharingHandler := handlers.NewSharingHandler(db.Queries, db.Pool)
+	splitHandler := handlers.NewSplitHandler(db.Queries, db.Pool)
 	analyticsHandler := handlers.NewAnalyticsHandler(db.Queries)
+	forecastHandler := handlers.NewForecastHandler(db.Queries)
+	reportHandler := handlers.NewReportHandler(db.Queries)
+	insightHandler := handlers.NewInsightHandler(db.Queries)
+	subscriptionHandler := handlers.NewSubscriptionHandler(db.Queries, db.Pool)
+	apiTokenHandler := handlers.NewAPITokenHandler(db.Queries)
+	activityHandler := handlers.NewActivityHandler(db.Queries)
+	historyHandler := handlers.NewHistoryHandler(db.Queries, db.Pool)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(db.Queries, db.Pool)
+	goalHandler := handlers.NewGoalHandler(db.Queries)
+	debtHandler := handlers.NewDebtHandler(db.Queries)
+	assetHandler := handlers.NewAssetHandler(db.Queries, db.Pool)
+	netWorthHandler := handlers.NewNetWorthHandler(db.Queries)
+	incomeHandler := handlers.NewIncomeHandler(db.Queries)
+	envelopeHandler := handlers.NewEnvelopeHandler(db.Queries, db.Pool)
+	alertHandler := handlers.NewAlertHandler(db.Queries, db.Pool)
+	pushHandler := handlers.NewPushHandler(db.Queries, pushSender, cfg.IsDevelopment())
+	eventHandler := handlers.NewEventHandler(db.Queries, hub)
+
+	var webhookHandler *handlers.WebhookHandler
+	if cfg.ClerkWebhookSecret != "" {
+		verifier, err := auth.NewWebhookVerifier(cfg.ClerkWebhookSecret)
+		if err != nil {
+			log.Fatalf("Failed to initialize webhook verifier: %v", err)
+		}
+		webhookHandler = handlers.NewWebhookHandler(db.Queries, verifier)
+	} else {
+		log.Println("WARNING: CLERK_WEBHOOK_SECRET not set, Clerk user sync webhook disabled")
+	}
 
 	// Health check endpoint (no auth required)
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -104,6 +204,11 @@ func main() {
 			r.Get("/me", authHandler.GetCurrentUser) // Requires auth in handler
 		})
 
+		// Webhooks (public, verified by signature)
+		if webhookHandler != nil {
+			r.Post("/webhooks/clerk", webhookHandler.ClerkWebhook)
+		}
+
 		// Protected routes (require authentication)
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.RequireAuth())
@@ -128,27 +233,26 @@ func main() {
 			r.Route("/budgets", func(r chi.Router) {
 				r.Get("/", budgetHandler.ListBudgets)
 				r.Post("/", budgetHandler.CreateBudget)
-				r.Get("/{month}", budgetHandler.GetBudgetByMonth)
+				r.Get("/{date}", budgetHandler.GetBudgetForDate)
 				r.Route("/{id}", func(r chi.Router) {
 					r.Get("/", budgetHandler.GetBudget)
 					r.Put("/", budgetHandler.UpdateBudget)
 					r.Delete("/", budgetHandler.DeleteBudget)
 					r.Get("/categories", budgetHandler.GetBudgetCategories)
 					r.Post("/categories", budgetHandler.AddBudgetCategory)
+					r.Get("/balances", splitHandler.GetBalances)
+					r.Get("/settlements", splitHandler.ListSettlements)
+					r.Post("/settlements", splitHandler.CreateSettlement)
+					r.Get("/envelopes", envelopeHandler.GetEnvelopes)
+					r.Get("/envelopes/moves", envelopeHandler.ListEnvelopeMoves)
+					r.Post("/envelopes/moves", envelopeHandler.MoveEnvelope)
+					r.Get("/revisions", historyHandler.ListBudgetRevisions)
+					r.Post("/restore", historyHandler.RestoreBudget)
 				})
 				r.Put("/categories/{categoryId}", budgetHandler.UpdateBudgetCategory)
 				r.Delete("/categories/{categoryId}", budgetHandler.RemoveBudgetCategory)
-			})
-
-			// Transactions routes
-			r.Route("/transactions", func(r chi.Router) {
-				r.Get("/", transactionHandler.ListTransactions)
-				r.Post("/", transactionHandler.CreateTransaction)
-				r.Route("/{id}", func(r chi.Router) {
-					r.Get("/", transactionHandler.GetTransaction)
-					r.Put("/", transactionHandler.UpdateTransaction)
-					r.Delete("/", transactionHandler.DeleteTransaction)
-				})
+				r.Get("/categories/{categoryId}/revisions", historyHandler.ListBudgetCategoryRevisions)
+				r.Post("/categories/{categoryId}/restore", historyHandler.RestoreBudgetCategory)
 			})
 
 			// Payment Methods routes
@@ -162,6 +266,108 @@ func main() {
 				})
 			})
 
+			// Savings goals routes
+			r.Route("/goals", func(r chi.Router) {
+				r.Get("/", goalHandler.ListGoals)
+				r.Post("/", goalHandler.CreateGoal)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", goalHandler.GetGoal)
+					r.Put("/", goalHandler.UpdateGoal)
+					r.Delete("/", goalHandler.DeleteGoal)
+					r.Get("/contributions", goalHandler.ListContributions)
+					r.Post("/contributions", goalHandler.AddContribution)
+				})
+			})
+
+			// Debt routes
+			r.Route("/debts", func(r chi.Router) {
+				r.Get("/", debtHandler.ListDebts)
+				r.Post("/", debtHandler.CreateDebt)
+				r.Get("/plan", debtHandler.GetPayoffPlan)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", debtHandler.GetDebt)
+					r.Put("/", debtHandler.UpdateDebt)
+					r.Delete("/", debtHandler.DeleteDebt)
+					r.Get("/payments", debtHandler.ListPayments)
+					r.Post("/payments", debtHandler.AddPayment)
+				})
+			})
+
+			// Tracked assets and their valuations
+			r.Route("/assets", func(r chi.Router) {
+				r.Get("/", assetHandler.ListAssets)
+				r.Post("/", assetHandler.CreateAsset)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", assetHandler.GetAsset)
+					r.Put("/", assetHandler.UpdateAsset)
+					r.Delete("/", assetHandler.DeleteAsset)
+					r.Post("/valuations", assetHandler.AddValuation)
+					r.Delete("/valuations/{valuationId}", assetHandler.DeleteValuation)
+				})
+			})
+
+			// Net worth across accounts, assets and debts
+			r.Route("/net-worth", func(r chi.Router) {
+				r.Get("/", netWorthHandler.GetNetWorth)
+				r.Get("/history", netWorthHandler.GetNetWorthHistory)
+			})
+
+			// Expected income and how it compares with what arrived
+			r.Route("/income-sources", func(r chi.Router) {
+				r.Get("/", incomeHandler.ListIncomeSources)
+				r.Post("/", incomeHandler.CreateIncomeSource)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Put("/", incomeHandler.UpdateIncomeSource)
+					r.Delete("/", incomeHandler.DeleteIncomeSource)
+					r.Put("/expected/{month}", incomeHandler.SetExpectedIncome)
+					r.Delete("/expected/{month}", incomeHandler.ClearExpectedIncome)
+				})
+			})
+			r.Get("/income/{month}", incomeHandler.GetIncomePlan)
+
+			// Alert rules and the notification inbox
+			r.Route("/alerts", func(r chi.Router) {
+				r.Get("/", alertHandler.ListAlertRules)
+				r.Post("/", alertHandler.CreateAlertRule)
+				r.Put("/{id}", alertHandler.UpdateAlertRule)
+				r.Delete("/{id}", alertHandler.DeleteAlertRule)
+			})
+			r.Route("/notifications", func(r chi.Router) {
+				r.Get("/", alertHandler.ListNotifications)
+				r.Get("/unread-count", alertHandler.GetUnreadCount)
+				r.Post("/read-all", alertHandler.MarkAllNotificationsRead)
+				r.Put("/{id}", alertHandler.UpdateNotification)
+				r.Delete("/{id}", alertHandler.DeleteNotification)
+			})
+
+			// Spending insights (unusual activity)
+			r.Route("/insights", func(r chi.Router) {
+				r.Get("/", insightHandler.ListInsights)
+				r.Post("/{id}/dismiss", insightHandler.DismissInsight)
+				r.Delete("/{id}/dismiss", insightHandler.RestoreInsight)
+			})
+
+			// Subscriptions detected from regular charges
+			r.Route("/subscriptions", func(r chi.Router) {
+				r.Get("/", subscriptionHandler.ListSubscriptions)
+				r.Post("/{id}/recurring", subscriptionHandler.MakeRecurring)
+				r.Post("/{id}/cancel", subscriptionHandler.CancelSubscription)
+				r.Delete("/{id}/cancel", subscriptionHandler.RestoreSubscription)
+			})
+
+			// Web Push subscriptions
+			r.Route("/push", func(r chi.Router) {
+				r.Get("/vapid-public-key", pushHandler.GetVAPIDPublicKey)
+				r.Get("/subscriptions", pushHandler.ListSubscriptions)
+				r.Post("/subscriptions", pushHandler.Subscribe)
+				r.Delete("/subscriptions", pushHandler.Unsubscribe)
+				r.Delete("/subscriptions/{id}", pushHandler.Unsubscribe)
+				r.Post("/test", pushHandler.SendTest)
+			})
+
+			// Server-Sent Events stream of changes the user can see
+			r.Get("/events", eventHandler.Stream)
+
 			// Sync routes
 			r.Route("/sync", func(r chi.Router) {
 				r.Post("/push", syncHandler.Push)
@@ -172,7 +378,8 @@ func main() {
 
 			// Reflections routes
 			r.Route("/reflections", func(r chi.Router) {
-				r.Get("/month/{month}", reflectionHandler.GetReflectionByMonth)
+				r.Get("/date/{date}", reflectionHandler.GetReflectionByDate)
+				r.Get("/month/{date}", reflectionHandler.GetReflectionByDate) // YYYY-MM, kept for older clients
 				r.Post("/", reflectionHandler.CreateReflection)
 				r.Route("/{id}", func(r chi.Router) {
 					r.Put("/", reflectionHandler.UpdateReflection)
@@ -189,19 +396,69 @@ func main() {
 					r.Put("/respond", sharingHandler.RespondToInvitation)
 					r.Delete("/", sharingHandler.CancelInvitation)
 				})
-				r.Get("/budgets/{budgetId}", sharingHandler.GetBudgetSharing)
+				r.Route("/budgets/{budgetId}", func(r chi.Router) {
+					r.Get("/", sharingHandler.GetBudgetSharing)
+					r.Post("/leave", sharingHandler.LeaveBudget)
+					r.Post("/transfer", sharingHandler.TransferOwnership)
+				})
 				r.Route("/access/{id}", func(r chi.Router) {
+					r.Put("/", sharingHandler.UpdateAccess)
 					r.Delete("/", sharingHandler.RemoveAccess)
 				})
 				r.Get("/shared-with-me", sharingHandler.GetSharedBudgets)
 			})
 
+			// Activity feed
+			r.Get("/activity", activityHandler.ListActivity)
+
+			// Exchange rates used to convert transactions to the home currency
+			r.Get("/exchange-rates", exchangeRateHandler.ListExchangeRates)
+
+			// Admin routes (Clerk users listed in ADMIN_CLERK_USER_IDS)
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(auth.RequireAdmin(cfg.AdminClerkUserIDs))
+				r.Post("/exchange-rates/import", exchangeRateHandler.ImportExchangeRates)
+			})
+
+			// API token management (session only)
+			r.Route("/api-tokens", func(r chi.Router) {
+				r.Get("/", apiTokenHandler.ListAPITokens)
+				r.Post("/", apiTokenHandler.CreateAPIToken)
+				r.Delete("/{id}", apiTokenHandler.RevokeAPIToken)
+			})
+		})
+
+		// Routes that also accept personal API tokens; every route declares its scope
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware.RequireAuthOrAPIToken())
+
+			// Transactions routes
+			r.Route("/transactions", func(r chi.Router) {
+				r.With(auth.RequireScope(auth.ScopeReadTransactions)).Get("/", transactionHandler.ListTransactions)
+				r.With(auth.RequireScope(auth.ScopeWriteTransactions)).Post("/", transactionHandler.CreateTransaction)
+				r.Route("/{id}", func(r chi.Router) {
+					r.With(auth.RequireScope(auth.ScopeReadTransactions)).Get("/", transactionHandler.GetTransaction)
+					r.With(auth.RequireScope(auth.ScopeWriteTransactions)).Put("/", transactionHandler.UpdateTransaction)
+					r.With(auth.RequireScope(auth.ScopeWriteTransactions)).Delete("/", transactionHandler.DeleteTransaction)
+					r.With(auth.RequireScope(auth.ScopeReadTransactions)).Get("/revisions", historyHandler.ListTransactionRevisions)
+					r.With(auth.RequireScope(auth.ScopeWriteTransactions)).Post("/restore", historyHandler.RestoreTransaction)
+					r.With(auth.SessionOnly).Get("/split", splitHandler.GetSplit)
+					r.With(auth.SessionOnly).Put("/split", splitHandler.SetSplit)
+					r.With(auth.SessionOnly).Delete("/split", splitHandler.RemoveSplit)
+				})
+			})
+
 			// Analytics routes
 			r.Route("/analytics", func(r chi.Router) {
-				r.Get("/dashboard/{month}", analyticsHandler.GetDashboard)
-				r.Get("/spending/{month}", analyticsHandler.GetSpendingReport)
+				r.Use(auth.RequireScope(auth.ScopeReadAnalytics))
+				r.Get("/dashboard/{date}", analyticsHandler.GetDashboard)
+				r.Get("/spending/{date}", analyticsHandler.GetSpendingReport)
 				r.Get("/trends", analyticsHandler.GetTrends)
 				r.Get("/category/{categoryId}", analyticsHandler.GetCategoryReport)
+				r.Get("/compare", reportHandler.GetComparison)
+				r.Get("/rolling-averages", reportHandler.GetRollingAverages)
+				r.Get("/custom", reportHandler.GetCustomReport)
+				r.Get("/forecast", forecastHandler.GetForecast)
 			})
 		})
 	})
@@ -230,6 +487,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
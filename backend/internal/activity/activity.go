@@ -0,0 +1,145 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// Resource types recorded in the activity log
+const (
+	ResourceBudget         = "budget"
+	ResourceBudgetCategory = "budget_category"
+	ResourceCategory       = "category"
+	ResourcePaymentMethod  = "payment_method"
+	ResourceTransaction    = "transaction"
+	ResourceShare          = "share"
+	ResourceSettlement     = "settlement"
+	ResourceSync           = "sync"
+	ResourceExchangeRate   = "exchange_rate"
+	ResourceSavingsGoal    = "savings_goal"
+	ResourceDebt           = "debt"
+	ResourceAsset          = "asset"
+	ResourceIncomeSource   = "income_source"
+	ResourceEnvelopeMove   = "envelope_move"
+)
+
+// Entry describes a single mutation to record
+type Entry struct {
+	UserID       string
+	Action       string // e.g. "transaction.updated"
+	ResourceType string
+	ResourceID   string
+	BudgetID     string      // set when the resource belongs to a budget so collaborators see the entry
+	Before       interface{} // state before the change (nil for creates)
+	After        interface{} // state after the change (nil for deletes)
+	Details      map[string]interface{}
+}
+
+// Change is a single field that differs between the before and after states
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// ignoredFields are bookkeeping fields left out of diffs
+var ignoredFields = map[string]bool{"updatedAt": true, "createdAt": true}
+
+// Record writes e to the activity log along with the request ID, client IP and user agent of r
+func Record(ctx context.Context, q *models.Queries, r *http.Request, e Entry) error {
+	details := make(map[string]interface{}, len(e.Details)+3)
+	for k, v := range e.Details {
+		details[k] = v
+	}
+
+	before, err := toMap(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := toMap(e.After)
+	if err != nil {
+		return err
+	}
+	if before != nil {
+		details["before"] = before
+	}
+	if after != nil {
+		details["after"] = after
+	}
+	if before != nil && after != nil {
+		changes := Diff(before, after)
+		if len(changes) == 0 {
+			// Nothing actually changed, so there is nothing to audit
+			return nil
+		}
+		details["changes"] = changes
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity details: %w", err)
+	}
+
+	_, err = q.CreateActivityLog(ctx, models.CreateActivityLogParams{
+		UserID:       utils.PgUUID(e.UserID),
+		Action:       e.Action,
+		ResourceType: utils.PgText(e.ResourceType),
+		ResourceID:   utils.PgUUID(e.ResourceID),
+		BudgetID:     utils.PgUUID(e.BudgetID),
+		Details:      data,
+		RequestID:    utils.PgText(chiMiddleware.GetReqID(r.Context())),
+		IpAddress:    utils.ClientIP(r),
+		UserAgent:    utils.PgText(r.UserAgent()),
+	})
+	return err
+}
+
+// Diff returns the fields whose values differ between before and after, keyed by JSON field name
+func Diff(before, after map[string]interface{}) map[string]Change {
+	changes := make(map[string]Change)
+	for k, from := range before {
+		if ignoredFields[k] {
+			continue
+		}
+		if to, ok := after[k]; !ok || !reflect.DeepEqual(from, to) {
+			changes[k] = Change{From: from, To: after[k]}
+		}
+	}
+	for k, to := range after {
+		if ignoredFields[k] {
+			continue
+		}
+		if _, ok := before[k]; !ok {
+			changes[k] = Change{From: nil, To: to}
+		}
+	}
+	return changes
+}
+
+// toMap converts a response struct into its JSON object form
+func toMap(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode activity state: %w", err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to encode activity state: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,445 @@
+// Package alerts evaluates users' alert rules and records the resulting notifications
+// in their in-app inbox, pushing them to their devices and emailing them for rules
+// that ask for it.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/notify"
+	"github.com/joselitophala/budget-planner-backend/internal/periods"
+	"github.com/joselitophala/budget-planner-backend/internal/push"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// Rule kinds
+const (
+	// KindCategoryThreshold fires when a category's spending reaches a percentage of its limit
+	KindCategoryThreshold = "category_threshold"
+	// KindBudgetExceeded fires when a budget's total spending passes its total limit
+	KindBudgetExceeded = "budget_exceeded"
+	// KindLargeTransaction fires when a single expense is at least an amount
+	KindLargeTransaction = "large_transaction"
+	// KindBillDue fires a number of days before a recurring expense is next due
+	KindBillDue = "bill_due"
+)
+
+// Kinds lists the valid rule kinds
+var Kinds = map[string]bool{
+	KindCategoryThreshold: true,
+	KindBudgetExceeded:    true,
+	KindLargeTransaction:  true,
+	KindBillDue:           true,
+}
+
+// Notification is a message for a user's inbox
+type Notification struct {
+	UserID    string
+	Kind      string
+	Title     string
+	Body      string
+	Data      map[string]interface{}
+	DedupeKey string // a notification with a key the user already has is dropped
+	Email     bool   // also email the user when the notification is new
+}
+
+// Notify stores n in the user's inbox and reports whether it was new. New
+// notifications are also pushed to the user's devices, and emailed when n.Email
+// is set. The notification and its deliveries are written together, so a failure
+// leaves nothing behind for the dedupe key to block a retry.
+func Notify(ctx context.Context, pool *pgxpool.Pool, q *models.Queries, n Notification) (bool, error) {
+	data, err := json.Marshal(n.Data)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode notification data: %w", err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+	q = q.WithTx(tx)
+
+	_, err = q.CreateNotification(ctx, models.CreateNotificationParams{
+		UserID:    n.UserID,
+		Kind:      n.Kind,
+		Title:     n.Title,
+		Body:      n.Body,
+		Data:      data,
+		DedupeKey: n.DedupeKey,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Every device the user subscribed gets it too; alerts with the same key replace
+	// each other on screen
+	_, err = push.Enqueue(ctx, q, n.UserID, push.Message{
+		Title: n.Title,
+		Body:  n.Body,
+		URL:   "/notifications",
+		Tag:   n.DedupeKey,
+		Kind:  n.Kind,
+		Data:  n.Data,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to queue push notification: %w", err)
+	}
+
+	if n.Email {
+		user, err := q.GetCurrentUser(ctx, n.UserID)
+		if err != nil {
+			return false, fmt.Errorf("failed to look up alert recipient: %w", err)
+		}
+		_, err = notify.Enqueue(ctx, q, notify.Email{
+			UserID:   n.UserID,
+			To:       user.Email,
+			Template: notify.TemplateAlert,
+			Data: map[string]interface{}{
+				"kind":  n.Kind,
+				"title": n.Title,
+				"body":  n.Body,
+			},
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to queue alert email: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CheckTransaction evaluates the rules a write of t can trigger. Expenses in a budget
+// are checked against the rules of everyone with access to the budget; recurring
+// expenses also check the owner's upcoming bills.
+func CheckTransaction(ctx context.Context, pool *pgxpool.Pool, q *models.Queries, t models.Transaction) error {
+	if t.Deleted.Bool || t.Type.String != "expense" {
+		return nil
+	}
+
+	var errs []error
+	if t.BudgetID.Valid {
+		errs = append(errs, checkBudget(ctx, pool, q, t))
+	} else {
+		rules, err := q.ListUserAlertRulesByKind(ctx, models.ListUserAlertRulesByKindParams{
+			UserID: utils.UUIDToString(t.UserID),
+			Kind:   KindLargeTransaction,
+		})
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			errs = append(errs, checkLargeTransaction(ctx, pool, q, rule, t))
+		}
+	}
+	if t.IsRecurring.Bool {
+		errs = append(errs, CheckBills(ctx, pool, q, utils.UUIDToString(t.UserID), time.Now()))
+	}
+	return errors.Join(errs...)
+}
+
+// CheckBills notifies userID of recurring expenses that fall due within the window
+// of one of their bill_due rules
+func CheckBills(ctx context.Context, pool *pgxpool.Pool, q *models.Queries, userID string, today time.Time) error {
+	rules, err := q.ListUserAlertRulesByKind(ctx, models.ListUserAlertRulesByKindParams{
+		UserID: userID,
+		Kind:   KindBillDue,
+	})
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	bills, err := q.ListRecurringExpenses(ctx, utils.PgUUID(userID))
+	if err != nil {
+		return err
+	}
+
+	today = truncateDay(today)
+	var errs []error
+	for _, bill := range bills {
+		due, ok := NextOccurrence(utils.DateToTime(bill.TransactionDate), bill.RecurrencePattern, today)
+		if !ok {
+			continue
+		}
+		days := int(due.Sub(today).Hours() / 24)
+		for _, rule := range rules {
+			if days > int(rule.DaysBefore.Int32) {
+				continue
+			}
+			name := utils.TextToString(bill.Description)
+			if name == "" {
+				name = "A recurring bill"
+			}
+			_, err := Notify(ctx, pool, q, Notification{
+				UserID: userID,
+				Kind:   KindBillDue,
+				Title:  fmt.Sprintf("%s is due %s", name, dueIn(days)),
+				Body:   fmt.Sprintf("%s %s due on %s.", money.FromNumeric(bill.Amount), bill.Currency, due.Format("Monday, January 2")),
+				Data: map[string]interface{}{
+					"transactionId": bill.ID,
+					"dueDate":       due.Format("2006-01-02"),
+					"ruleId":        rule.ID,
+				},
+				// One reminder per occurrence, however many rules cover it
+				DedupeKey: fmt.Sprintf("%s:%s:%s", KindBillDue, bill.ID, due.Format("2006-01-02")),
+				Email:     rule.Email,
+			})
+			errs = append(errs, err)
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recurrence is the recurrence_pattern of a recurring transaction,
+// e.g. {"frequency": "monthly", "interval": 1, "endDate": null}
+type recurrence struct {
+	Frequency string  `json:"frequency"`
+	Interval  int     `json:"interval"`
+	EndDate   *string `json:"endDate"`
+}
+
+// NextOccurrence returns the first occurrence on or after day of a transaction dated
+// start and repeating by pattern. The transaction itself is the occurrence on start,
+// so the result is always after start. It returns false if the pattern isn't
+// understood or the recurrence has ended.
+func NextOccurrence(start time.Time, pattern []byte, day time.Time) (time.Time, bool) {
+	var p recurrence
+	if len(pattern) == 0 || json.Unmarshal(pattern, &p) != nil {
+		return time.Time{}, false
+	}
+	if p.Interval < 1 {
+		p.Interval = 1
+	}
+
+	start, day = truncateDay(start), truncateDay(day)
+	var stepDays, stepMonths int
+	switch p.Frequency {
+	case "daily":
+		stepDays = p.Interval
+	case "weekly":
+		stepDays = 7 * p.Interval
+	case "biweekly":
+		stepDays = 14 * p.Interval
+	case "monthly":
+		stepMonths = p.Interval
+	case "yearly":
+		stepMonths = 12 * p.Interval
+	default:
+		return time.Time{}, false
+	}
+	next := func(n int) time.Time {
+		if stepMonths > 0 {
+			return addMonthsClamped(start, n*stepMonths)
+		}
+		return start.AddDate(0, 0, n*stepDays)
+	}
+
+	// Start from the last occurrence at or before day, or close to it
+	n := 1
+	if day.After(start) {
+		if stepMonths > 0 {
+			n = ((day.Year()-start.Year())*12+int(day.Month()-start.Month()))/stepMonths - 1
+		} else {
+			n = int(day.Sub(start).Hours()/24) / stepDays
+		}
+		if n < 1 {
+			n = 1
+		}
+	}
+	due := next(n)
+	for due.Before(day) {
+		n++
+		due = next(n)
+	}
+
+	if p.EndDate != nil {
+		if end, err := time.Parse("2006-01-02", *p.EndDate); err == nil && due.After(end) {
+			return time.Time{}, false
+		}
+	}
+	return due, true
+}
+
+func checkBudget(ctx context.Context, pool *pgxpool.Pool, q *models.Queries, t models.Transaction) error {
+	budgetID := utils.UUIDToString(t.BudgetID)
+	rules, err := q.ListBudgetAlertRules(ctx, budgetID)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	budget, err := q.GetBudgetByID(ctx, budgetID)
+	if err != nil {
+		return err
+	}
+	period := periods.Period{Start: utils.DateToTime(budget.Month), End: utils.DateToTime(budget.PeriodEnd)}
+	budgetName := utils.TextToString(budget.Name)
+	if budgetName == "" {
+		budgetName = period.Label() + " budget"
+	}
+
+	var category *models.GetBudgetCategoriesRow
+	var categorySpent money.Amount
+	if t.CategoryID.Valid {
+		rows, err := q.GetBudgetCategories(ctx, t.BudgetID)
+		if err != nil {
+			return err
+		}
+		for i := range rows {
+			if rows[i].CategoryID == t.CategoryID {
+				category = &rows[i]
+				break
+			}
+		}
+		if category != nil {
+			result, err := q.GetCategorySpent(ctx, models.GetCategorySpentParams{BudgetID: t.BudgetID, CategoryID: t.CategoryID})
+			if err != nil {
+				return err
+			}
+			if categorySpent, err = money.FromAny(result); err != nil {
+				return err
+			}
+		}
+	}
+
+	var budgetSpent *money.Amount
+	var errs []error
+	for _, rule := range rules {
+		switch rule.Kind {
+		case KindCategoryThreshold:
+			if category == nil || (rule.CategoryID.Valid && rule.CategoryID != t.CategoryID) {
+				continue
+			}
+			limit := money.FromNumeric(category.LimitAmount)
+			threshold := int(rule.ThresholdPercent.Int32)
+			if limit <= 0 || money.Percent(categorySpent, limit) < float64(threshold) {
+				continue
+			}
+			title := fmt.Sprintf("%s has reached %d%% of its limit", category.Name, threshold)
+			if threshold >= 100 {
+				title = fmt.Sprintf("%s is over its limit", category.Name)
+			}
+			_, err := Notify(ctx, pool, q, Notification{
+				UserID: rule.UserID,
+				Kind:   KindCategoryThreshold,
+				Title:  title,
+				Body:   fmt.Sprintf("%s of %s spent in %s.", categorySpent, limit, budgetName),
+				Data: map[string]interface{}{
+					"budgetId":         budgetID,
+					"categoryId":       utils.UUIDToString(t.CategoryID),
+					"budgetCategoryId": category.ID,
+					"thresholdPercent": threshold,
+					"spent":            categorySpent,
+					"limit":            limit,
+					"ruleId":           rule.ID,
+				},
+				DedupeKey: fmt.Sprintf("%s:%s:%s:%d:%s", KindCategoryThreshold, budgetID, utils.UUIDToString(t.CategoryID), threshold, period.Start.Format("2006-01")),
+				Email:     rule.Email,
+			})
+			errs = append(errs, err)
+
+		case KindBudgetExceeded:
+			limit := money.FromNumeric(budget.TotalLimit)
+			if budgetSpent == nil {
+				result, err := q.GetBudgetSpent(ctx, t.BudgetID)
+				if err != nil {
+					return errors.Join(append(errs, err)...)
+				}
+				spent, err := money.FromAny(result)
+				if err != nil {
+					return errors.Join(append(errs, err)...)
+				}
+				budgetSpent = &spent
+			}
+			spent := *budgetSpent
+			if limit <= 0 || spent <= limit {
+				continue
+			}
+			_, err := Notify(ctx, pool, q, Notification{
+				UserID: rule.UserID,
+				Kind:   KindBudgetExceeded,
+				Title:  fmt.Sprintf("%s is over budget", budgetName),
+				Body:   fmt.Sprintf("%s spent against a limit of %s.", spent, limit),
+				Data: map[string]interface{}{
+					"budgetId": budgetID,
+					"spent":    spent,
+					"limit":    limit,
+					"ruleId":   rule.ID,
+				},
+				DedupeKey: fmt.Sprintf("%s:%s:%s", KindBudgetExceeded, budgetID, period.Start.Format("2006-01")),
+				Email:     rule.Email,
+			})
+			errs = append(errs, err)
+
+		case KindLargeTransaction:
+			errs = append(errs, checkLargeTransaction(ctx, pool, q, rule, t))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkLargeTransaction(ctx context.Context, pool *pgxpool.Pool, q *models.Queries, rule models.AlertRule, t models.Transaction) error {
+	amount := money.FromNumeric(t.HomeAmount)
+	threshold := money.FromNumeric(rule.Amount)
+	if amount < threshold {
+		return nil
+	}
+
+	title := "Large transaction recorded"
+	if description := utils.TextToString(t.Description); description != "" {
+		title = "Large transaction: " + description
+	}
+	_, err := Notify(ctx, pool, q, Notification{
+		UserID: rule.UserID,
+		Kind:   KindLargeTransaction,
+		Title:  title,
+		Body:   fmt.Sprintf("%s %s on %s.", money.FromNumeric(t.Amount), t.Currency, utils.DateToTime(t.TransactionDate).Format("January 2")),
+		Data: map[string]interface{}{
+			"transactionId": t.ID,
+			"budgetId":      utils.UUIDToString(t.BudgetID),
+			"amount":        amount,
+			"ruleId":        rule.ID,
+		},
+		DedupeKey: fmt.Sprintf("%s:%s", KindLargeTransaction, t.ID),
+		Email:     rule.Email,
+	})
+	return err
+}
+
+// addMonthsClamped adds months to t, keeping the day but clamping it to the
+// target month's length so Jan 31 + 1 month is Feb 28 rather than Mar 3
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+func dueIn(days int) string {
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	}
+	return fmt.Sprintf("in %d days", days)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// RequireAdmin allows only the given Clerk users through, and only with a session JWT.
+// An empty list disables admin endpoints entirely.
+func RequireAdmin(clerkUserIDs []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := GetTokenScopes(r); ok {
+				utils.Forbidden(w, "API tokens cannot access this endpoint")
+				return
+			}
+			clerkUserID, ok := GetClerkUserID(r)
+			if !ok || !slices.Contains(clerkUserIDs, clerkUserID) {
+				utils.Forbidden(w, "Admin access required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
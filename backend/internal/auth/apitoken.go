@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// APITokenPrefix marks personal API tokens so they can be told apart from JWTs
+const APITokenPrefix = "bp_"
+
+// API token scopes
+const (
+	ScopeReadTransactions  = "read:transactions"
+	ScopeWriteTransactions = "write:transactions"
+	ScopeReadAnalytics     = "read:analytics"
+)
+
+// Scopes lists every scope an API token can be granted
+var Scopes = []string{ScopeReadTransactions, ScopeWriteTransactions, ScopeReadAnalytics}
+
+// IsValidScope reports whether scope is a known API token scope
+func IsValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// IsAPIToken reports whether the bearer token is a personal API token rather than a JWT
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// GenerateAPIToken creates a new random API token.
+// Only the hash is stored; the token itself is shown to the user once.
+func GenerateAPIToken() (token, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	token = APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, token[:len(APITokenPrefix)+6], HashAPIToken(token), nil
+}
+
+// HashAPIToken returns the hex SHA-256 of a token as stored in api_tokens.token_hash
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetTokenScopes returns the scopes of the API token used for the request.
+// ok is false when the request was authenticated with a session JWT.
+func GetTokenScopes(r *http.Request) ([]string, bool) {
+	scopes, ok := r.Context().Value(TokenScopesKey).([]string)
+	return scopes, ok
+}
+
+// RequireScope rejects API token requests that weren't granted scope.
+// Session (JWT) requests are not scope-limited.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if scopes, ok := GetTokenScopes(r); ok && !slices.Contains(scopes, scope) {
+				utils.Forbidden(w, fmt.Sprintf("API token is missing the %s scope", scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SessionOnly rejects requests authenticated with an API token
+func SessionOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := GetTokenScopes(r); ok {
+			utils.Forbidden(w, "API tokens cannot access this endpoint")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
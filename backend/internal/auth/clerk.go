@@ -1,19 +1,46 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTClient wraps JWT validation
+// JWTClient wraps JWT validation.
+// Tokens are verified against a JWKS endpoint (Clerk) or, in development only,
+// a shared HMAC secret.
 type JWTClient struct {
-	secret string
+	secret            string
+	jwks              *JWKSCache
+	issuer            string
+	audience          []string
+	authorizedParties []string
+	clockSkew         time.Duration
 }
 
-// NewJWTClient creates a new JWT client for token validation
+// JWKSOptions configures verification of RS256/ES256 tokens signed with keys published at a JWKS URL
+type JWKSOptions struct {
+	URL               string        // e.g. https://<clerk-frontend-api>/.well-known/jwks.json
+	Issuer            string        // expected iss claim
+	Audience          []string      // accepted aud values (optional)
+	AuthorizedParties []string      // accepted azp values, usually the frontend origins (optional)
+	ClockSkew         time.Duration // leeway applied to exp, nbf and iat
+	HTTPClient        *http.Client  // optional client used to fetch the key set
+}
+
+// asymmetricMethods are the signing algorithms accepted from a JWKS issuer
+var asymmetricMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// hmacMethods are the signing algorithms accepted in HMAC development mode
+var hmacMethods = []string{"HS256", "HS384", "HS512"}
+
+// NewJWTClient creates a new JWT client that validates HMAC tokens signed with a shared secret.
+// This mode is intended for local development only.
 func NewJWTClient(secret string) (*JWTClient, error) {
 	if secret == "" {
 		return nil, fmt.Errorf("JWT secret is required")
@@ -22,30 +49,122 @@ func NewJWTClient(secret string) (*JWTClient, error) {
 	return &JWTClient{secret: secret}, nil
 }
 
+// NewJWKSClient creates a new JWT client that validates tokens against keys published at a JWKS URL
+func NewJWKSClient(opts JWKSOptions) (*JWTClient, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("JWKS URL is required")
+	}
+	if opts.Issuer == "" {
+		return nil, fmt.Errorf("JWT issuer is required")
+	}
+
+	return &JWTClient{
+		jwks:              NewJWKSCache(opts.URL, opts.HTTPClient),
+		issuer:            opts.Issuer,
+		audience:          opts.Audience,
+		authorizedParties: opts.AuthorizedParties,
+		clockSkew:         opts.ClockSkew,
+	}, nil
+}
+
 // VerifyToken verifies a JWT token and returns the user ID (sub claim)
-func (c *JWTClient) VerifyToken(tokenString string) (string, error) {
+func (c *JWTClient) VerifyToken(ctx context.Context, tokenString string) (string, error) {
+	claims, err := c.VerifyClaims(ctx, tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	if sub, ok := claims["sub"].(string); ok && sub != "" {
+		return sub, nil
+	}
+	return "", fmt.Errorf("invalid token: missing sub claim")
+}
+
+// VerifyClaims verifies a JWT token and returns all of its claims. ctx bounds
+// fetching the signing keys, if they need to be.
+func (c *JWTClient) VerifyClaims(ctx context.Context, tokenString string) (jwt.MapClaims, error) {
 	// Remove "Bearer " prefix if present
 	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithLeeway(c.clockSkew),
+		jwt.WithExpirationRequired(),
+	}
+	if c.jwks != nil {
+		opts = append(opts, jwt.WithValidMethods(asymmetricMethods), jwt.WithIssuer(c.issuer))
+	} else {
+		opts = append(opts, jwt.WithValidMethods(hmacMethods))
+	}
+
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		return c.key(ctx, token)
+	}
+	token, err := jwt.Parse(tokenString, keyFunc, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if err := c.validateAudience(claims); err != nil {
+		return nil, err
+	}
+	if err := c.validateAuthorizedParty(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// key resolves the verification key for a token
+func (c *JWTClient) key(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	if c.jwks == nil {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(c.secret), nil
-	})
+	}
 
-	if err != nil {
-		return "", fmt.Errorf("invalid token: %w", err)
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token is missing kid header")
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if sub, ok := claims["sub"].(string); ok {
-			return sub, nil
+	return c.jwks.Key(ctx, kid)
+}
+
+// validateAudience checks the aud claim when audiences are configured
+func (c *JWTClient) validateAudience(claims jwt.MapClaims) error {
+	if len(c.audience) == 0 {
+		return nil
+	}
+
+	aud, err := claims.GetAudience()
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+	for _, a := range aud {
+		if slices.Contains(c.audience, a) {
+			return nil
 		}
-		return "", fmt.Errorf("invalid token: missing sub claim")
 	}
+	return fmt.Errorf("invalid token: audience not accepted")
+}
 
-	return "", fmt.Errorf("invalid token")
+// validateAuthorizedParty checks the azp claim (the origin that requested the token) when configured
+func (c *JWTClient) validateAuthorizedParty(claims jwt.MapClaims) error {
+	if len(c.authorizedParties) == 0 {
+		return nil
+	}
+
+	azp, _ := claims["azp"].(string)
+	if azp == "" || !slices.Contains(c.authorizedParties, azp) {
+		return fmt.Errorf("invalid token: authorized party not accepted")
+	}
+	return nil
 }
 
 // ExtractTokenFromRequest extracts the JWT token from the Authorization header
@@ -0,0 +1,353 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testIssuer = "https://clerk.example.test"
+
+// jwksServer is a local stand-in for the issuer's JWKS endpoint
+type jwksServer struct {
+	*httptest.Server
+	hits atomic.Int32
+
+	mu   sync.Mutex
+	keys map[string]*ecdsa.PrivateKey
+	// gate, when set, holds requests until it's closed; entered gets a value as each
+	// held request arrives
+	gate    chan struct{}
+	entered chan struct{}
+}
+
+func newJWKSServer(t *testing.T, kids ...string) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: make(map[string]*ecdsa.PrivateKey)}
+	for _, kid := range kids {
+		s.keys[kid] = newSigningKey(t)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.hits.Add(1)
+	s.mu.Lock()
+	gate, entered := s.gate, s.entered
+	keys := make([]map[string]string, 0, len(s.keys))
+	for kid, key := range s.keys {
+		keys = append(keys, map[string]string{
+			"kty": "EC",
+			"kid": kid,
+			"use": "sig",
+			"alg": "ES256",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.PublicKey.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.PublicKey.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+	s.mu.Unlock()
+
+	if gate != nil {
+		entered <- struct{}{}
+		select {
+		case <-gate:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
+
+// rotate replaces the published keys with a new one for kid
+func (s *jwksServer) rotate(t *testing.T, kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = map[string]*ecdsa.PrivateKey{kid: newSigningKey(t)}
+}
+
+// hold makes requests wait until the returned function is called
+func (s *jwksServer) hold() (entered <-chan struct{}, release func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gate, s.entered = make(chan struct{}), make(chan struct{}, 8)
+	gate := s.gate
+	return s.entered, func() { close(gate) }
+}
+
+func (s *jwksServer) key(kid string) *ecdsa.PrivateKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[kid]
+}
+
+func newSigningKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func newTestJWKSClient(t *testing.T, url string) *JWTClient {
+	t.Helper()
+	client, err := NewJWKSClient(JWKSOptions{
+		URL:               url,
+		Issuer:            testIssuer,
+		Audience:          []string{"budget-api"},
+		AuthorizedParties: []string{"https://app.example.test"},
+		ClockSkew:         30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewJWKSClient: %v", err)
+	}
+	return client
+}
+
+// validClaims are claims the test client accepts
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub": "user_123",
+		"iss": testIssuer,
+		"aud": "budget-api",
+		"azp": "https://app.example.test",
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+func sign(t *testing.T, kid string, key *ecdsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyClaims(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	client := newTestJWKSClient(t, server.URL)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+		ok     bool
+	}{
+		{"valid", func(jwt.MapClaims) {}, true},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.test" }, false},
+		{"missing issuer", func(c jwt.MapClaims) { delete(c, "iss") }, false},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "another-api" }, false},
+		{"one accepted audience", func(c jwt.MapClaims) { c["aud"] = []string{"another-api", "budget-api"} }, true},
+		{"wrong authorized party", func(c jwt.MapClaims) { c["azp"] = "https://evil.example.test" }, false},
+		{"missing authorized party", func(c jwt.MapClaims) { delete(c, "azp") }, false},
+		{"missing expiry", func(c jwt.MapClaims) { delete(c, "exp") }, false},
+		{"expired within leeway", func(c jwt.MapClaims) { c["exp"] = now.Add(-10 * time.Second).Unix() }, true},
+		{"expired beyond leeway", func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() }, false},
+		{"not yet valid within leeway", func(c jwt.MapClaims) { c["nbf"] = now.Add(10 * time.Second).Unix() }, true},
+		{"not yet valid beyond leeway", func(c jwt.MapClaims) { c["nbf"] = now.Add(time.Minute).Unix() }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.modify(claims)
+			_, err := client.VerifyClaims(context.Background(), sign(t, "key-1", server.key("key-1"), claims))
+			if tt.ok && err != nil {
+				t.Fatalf("VerifyClaims: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("VerifyClaims accepted the token")
+			}
+		})
+	}
+}
+
+func TestVerifyClaimsRejectsOtherSigners(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	client := newTestJWKSClient(t, server.URL)
+
+	// Right kid, wrong key
+	token := sign(t, "key-1", newSigningKey(t), validClaims())
+	if _, err := client.VerifyClaims(context.Background(), token); err == nil {
+		t.Fatal("VerifyClaims accepted a token signed with an unpublished key")
+	}
+
+	// HMAC tokens are never accepted from a JWKS issuer
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	hmac.Header["kid"] = "key-1"
+	signed, err := hmac.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	if _, err := client.VerifyClaims(context.Background(), signed); err == nil {
+		t.Fatal("VerifyClaims accepted an HMAC token")
+	}
+}
+
+func TestUnknownKidRefreshIsRateLimited(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	client := newTestJWKSClient(t, server.URL)
+	ctx := context.Background()
+
+	if _, err := client.VerifyClaims(ctx, sign(t, "key-1", server.key("key-1"), validClaims())); err != nil {
+		t.Fatalf("VerifyClaims: %v", err)
+	}
+	if got := server.hits.Load(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", got)
+	}
+
+	// Cached keys don't refetch
+	if _, err := client.VerifyClaims(ctx, sign(t, "key-1", server.key("key-1"), validClaims())); err != nil {
+		t.Fatalf("VerifyClaims: %v", err)
+	}
+	if got := server.hits.Load(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", got)
+	}
+
+	// An unknown kid refetches once the minimum interval has passed, and not again
+	// within it
+	client.jwks.mu.Lock()
+	client.jwks.lastAttempt = time.Now().Add(-time.Minute)
+	client.jwks.mu.Unlock()
+	unknown := sign(t, "made-up", newSigningKey(t), validClaims())
+	for i := 0; i < 3; i++ {
+		if _, err := client.VerifyClaims(ctx, unknown); err == nil {
+			t.Fatal("VerifyClaims accepted a token with an unknown kid")
+		}
+	}
+	if got := server.hits.Load(); got != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", got)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	client := newTestJWKSClient(t, server.URL)
+	client.jwks.minRefresh = 0
+	ctx := context.Background()
+
+	oldToken := sign(t, "key-1", server.key("key-1"), validClaims())
+	if _, err := client.VerifyClaims(ctx, oldToken); err != nil {
+		t.Fatalf("VerifyClaims: %v", err)
+	}
+
+	server.rotate(t, "key-2")
+	newToken := sign(t, "key-2", server.key("key-2"), validClaims())
+	if _, err := client.VerifyClaims(ctx, newToken); err != nil {
+		t.Fatalf("VerifyClaims after rotation: %v", err)
+	}
+
+	// The retired key goes once the cache expires and is refetched
+	client.jwks.mu.Lock()
+	client.jwks.fetchedAt = time.Now().Add(-2 * client.jwks.ttl)
+	client.jwks.mu.Unlock()
+	if _, err := client.VerifyClaims(ctx, oldToken); err == nil {
+		t.Fatal("VerifyClaims accepted a token signed with a retired key")
+	}
+}
+
+func TestUnreachableJWKS(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	key := server.key("key-1")
+	client := newTestJWKSClient(t, server.URL)
+	ctx := context.Background()
+
+	token := sign(t, "key-1", key, validClaims())
+	if _, err := client.VerifyClaims(ctx, token); err != nil {
+		t.Fatalf("VerifyClaims: %v", err)
+	}
+	server.Close()
+
+	// A known key is served from a stale cache when the endpoint is down
+	client.jwks.mu.Lock()
+	client.jwks.fetchedAt = time.Now().Add(-2 * client.jwks.ttl)
+	client.jwks.mu.Unlock()
+	if _, err := client.VerifyClaims(ctx, token); err != nil {
+		t.Fatalf("VerifyClaims with a stale cache: %v", err)
+	}
+
+	// Without a cached key there's nothing to verify against
+	down := newTestJWKSClient(t, server.URL)
+	_, err := down.VerifyClaims(ctx, token)
+	if err == nil || !strings.Contains(err.Error(), "failed to fetch JWKS") {
+		t.Fatalf("VerifyClaims error = %v, want a fetch failure", err)
+	}
+}
+
+func TestRefreshDoesNotBlockCachedKeys(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	client := newTestJWKSClient(t, server.URL)
+	client.jwks.minRefresh = 0
+	ctx := context.Background()
+
+	token := sign(t, "key-1", server.key("key-1"), validClaims())
+	if _, err := client.VerifyClaims(ctx, token); err != nil {
+		t.Fatalf("VerifyClaims: %v", err)
+	}
+
+	// An unknown kid starts a refetch that hangs until released
+	entered, release := server.hold()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = client.VerifyClaims(ctx, sign(t, "key-2", newSigningKey(t), validClaims()))
+	}()
+	<-entered
+
+	verified := make(chan error, 1)
+	go func() {
+		_, err := client.VerifyClaims(ctx, token)
+		verified <- err
+	}()
+	select {
+	case err := <-verified:
+		if err != nil {
+			t.Fatalf("VerifyClaims during a refetch: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("verifying with a cached key waited for the refetch")
+	}
+
+	release()
+	<-done
+}
+
+func TestVerifyClaimsUsesRequestContext(t *testing.T) {
+	server := newJWKSServer(t, "key-1")
+	client := newTestJWKSClient(t, server.URL)
+	entered, release := server.hold()
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.VerifyClaims(ctx, sign(t, "key-1", server.key("key-1"), validClaims()))
+	<-entered
+	if err == nil {
+		t.Fatal("VerifyClaims succeeded without the key set")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("VerifyClaims took %v, want it to stop with its context", elapsed)
+	}
+}
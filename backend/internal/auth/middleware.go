@@ -2,12 +2,16 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/joselitophala/budget-planner-backend/internal/models"
 	"github.com/joselitophala/budget-planner-backend/internal/utils"
 )
 
+// DefaultCurrency is the currency of users who haven't chosen one (users.currency default)
+const DefaultCurrency = "PHP"
+
 // contextKey is a custom type for context keys to avoid collisions
 type contextKey string
 
@@ -16,6 +20,10 @@ const (
 	UserIDKey contextKey = "userID"
 	// ClerkUserIDKey is the context key for the Clerk user ID (kept for compatibility)
 	ClerkUserIDKey contextKey = "clerkUserID"
+	// TokenScopesKey is the context key for the scopes of the API token used, if any
+	TokenScopesKey contextKey = "tokenScopes"
+	// CurrencyKey is the context key for the user's home currency
+	CurrencyKey contextKey = "currency"
 )
 
 // User represents the authenticated user in the database
@@ -32,6 +40,7 @@ type Middleware struct {
 	jwt       *JWTClient
 	queries   *models.Queries
 	requireAuth bool
+	allowAPITokens bool
 }
 
 // NewMiddleware creates a new auth middleware
@@ -58,8 +67,13 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		if IsAPIToken(token) {
+			m.authenticateAPIToken(w, r, next, token)
+			return
+		}
+
 		// Verify token
-		userID, err := m.jwt.VerifyToken(token)
+		claims, err := m.jwt.VerifyClaims(r.Context(), token)
 		if err != nil {
 			if m.requireAuth {
 				utils.Unauthorized(w, "Invalid token")
@@ -69,13 +83,15 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		// Look up user in our database (assuming userID matches clerk_user_id)
-		user, err := m.queries.GetUserByClerkID(r.Context(), userID)
+		// Look up the user, provisioning them on their first authenticated request
+		user, err := ProvisionUser(r.Context(), m.queries, IdentityFromClaims(claims))
 		if err != nil {
-			// User exists in auth system but not in our database
-			// This could mean they haven't completed onboarding
 			if m.requireAuth {
-				utils.NotFound(w, "User not found in database")
+				if errors.Is(err, ErrUserDeleted) {
+					utils.Forbidden(w, "User account has been deleted")
+					return
+				}
+				utils.InternalError(w, "Failed to load user")
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -86,11 +102,50 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, UserIDKey, user.ID)
 		ctx = context.WithValue(ctx, ClerkUserIDKey, user.ClerkUserID)
+		ctx = context.WithValue(ctx, CurrencyKey, utils.TextToString(user.Currency))
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// authenticateAPIToken resolves a personal API token to its user and scopes
+func (m *Middleware) authenticateAPIToken(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	if !m.allowAPITokens {
+		if m.requireAuth {
+			utils.Forbidden(w, "API tokens cannot access this endpoint")
+			return
+		}
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	apiToken, err := m.queries.GetActiveAPITokenByHash(r.Context(), HashAPIToken(token))
+	if err != nil {
+		if m.requireAuth {
+			utils.Unauthorized(w, "Invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	// Last-used tracking is best effort and shouldn't fail the request
+	_ = m.queries.TouchAPIToken(r.Context(), apiToken.ID)
+
+	scopes := apiToken.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	ctx := r.Context()
+	ctx = context.WithValue(ctx, UserIDKey, apiToken.UserID)
+	ctx = context.WithValue(ctx, ClerkUserIDKey, apiToken.ClerkUserID)
+	ctx = context.WithValue(ctx, TokenScopesKey, scopes)
+	ctx = context.WithValue(ctx, CurrencyKey, utils.TextToString(apiToken.Currency))
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // OptionalAuth creates an auth middleware that doesn't require authentication
 // but will add user context if a valid token is provided
 func (m *Middleware) OptionalAuth() func(http.Handler) http.Handler {
@@ -106,6 +161,16 @@ func (m *Middleware) RequireAuth() func(http.Handler) http.Handler {
 	}
 }
 
+// RequireAuthOrAPIToken creates an auth middleware that also accepts personal API tokens.
+// Routes behind it must declare their scope with RequireScope (or use SessionOnly).
+func (m *Middleware) RequireAuthOrAPIToken() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		mw := NewMiddleware(m.jwt, m.queries, true)
+		mw.allowAPITokens = true
+		return mw.Authenticate(next)
+	}
+}
+
 // Helper functions to get user context from request
 
 // GetUserID retrieves the user ID from the request context
@@ -120,6 +185,14 @@ func GetClerkUserID(r *http.Request) (string, bool) {
 	return clerkUserID, ok
 }
 
+// GetCurrency returns the user's home currency, falling back to the default currency
+func GetCurrency(r *http.Request) string {
+	if currency, ok := r.Context().Value(CurrencyKey).(string); ok && currency != "" {
+		return currency
+	}
+	return DefaultCurrency
+}
+
 // MustGetUserID retrieves the user ID or panics (for use in handlers where auth is required)
 func MustGetUserID(r *http.Request) string {
 	userID, ok := GetUserID(r)
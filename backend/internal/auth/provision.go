@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// ErrUserDeleted is returned when a token belongs to a user whose account has been deleted
+var ErrUserDeleted = errors.New("user account has been deleted")
+
+// Identity is the user profile carried in a verified session token
+type Identity struct {
+	ClerkUserID string
+	Email       string
+	Name        string
+}
+
+// IdentityFromClaims reads the user profile from token claims.
+// Email and name are only present when the Clerk session token template includes them.
+func IdentityFromClaims(claims jwt.MapClaims) Identity {
+	id := Identity{
+		ClerkUserID: claimString(claims, "sub"),
+		Email:       claimString(claims, "email", "email_address", "primary_email"),
+		Name:        claimString(claims, "name", "full_name"),
+	}
+
+	if id.Name == "" {
+		first := claimString(claims, "given_name", "first_name")
+		last := claimString(claims, "family_name", "last_name")
+		id.Name = strings.TrimSpace(first + " " + last)
+	}
+
+	return id
+}
+
+// PlaceholderEmail is used until the real address arrives from a token claim or webhook
+func PlaceholderEmail(clerkUserID string) string {
+	return clerkUserID + "@placeholder.local"
+}
+
+// ProvisionUser returns the user for the identity, creating it on first sign-in
+func ProvisionUser(ctx context.Context, q *models.Queries, id Identity) (models.User, error) {
+	if id.ClerkUserID == "" {
+		return models.User{}, fmt.Errorf("identity is missing a user ID")
+	}
+
+	user, err := q.GetUserByClerkID(ctx, id.ClerkUserID)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.User{}, err
+	}
+
+	email := strings.ToLower(id.Email)
+	if email == "" {
+		email = PlaceholderEmail(id.ClerkUserID)
+	}
+
+	params := models.ProvisionUserParams{
+		ClerkUserID: id.ClerkUserID,
+		Email:       email,
+		Name:        utils.PgText(id.Name),
+	}
+	user, err = q.ProvisionUser(ctx, params)
+
+	// Another account already uses this email; provision with a placeholder and
+	// let the webhook sync settle the address later
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "users_email_key" {
+		params.Email = PlaceholderEmail(id.ClerkUserID)
+		user, err = q.ProvisionUser(ctx, params)
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to provision user: %w", err)
+	}
+
+	// The row already existed but was soft-deleted (e.g. via the Clerk webhook)
+	if user.Deleted.Valid && user.Deleted.Bool {
+		return models.User{}, ErrUserDeleted
+	}
+
+	return user, nil
+}
+
+func claimString(claims jwt.MapClaims, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := claims[key].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
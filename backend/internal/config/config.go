@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -22,9 +24,35 @@ type Config struct {
 	ClerkPublishableKey string
 	ClerkJWTKey        string
 
+	// JWT verification
+	JWTSecret              string        // HMAC secret, development only
+	ClerkJWKSURL           string        // JWKS endpoint for RS256/ES256 tokens
+	ClerkIssuer            string        // expected iss claim
+	ClerkAudience          []string      // accepted aud values (optional)
+	ClerkAuthorizedParties []string      // accepted azp values (optional)
+	JWTClockSkew           time.Duration // leeway for exp/nbf/iat
+	ClerkWebhookSecret     string        // Svix signing secret for Clerk webhooks
+	AdminClerkUserIDs      []string      // Clerk users allowed to use admin endpoints
+
 	// CORS
 	AllowedOrigins []string
 
+	// Email
+	SMTPHost              string // email is logged instead of sent when empty
+	SMTPPort              int
+	SMTPUsername          string
+	SMTPPassword          string
+	EmailFrom             string
+	AppURL                string // base URL of the web app, for links in emails
+	EmailPollInterval     time.Duration
+	EmailMaxAttempts      int
+	EmailMonthlySummaries bool
+
+	// Web Push (VAPID); push is disabled unless both keys are set
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	VAPIDSubject    string // mailto: or https: contact for push services
+
 	// Sync Settings
 	SyncBatchSize       int
 	SyncRetryAttempts   int
@@ -47,7 +75,27 @@ func Load() (*Config, error) {
 		ClerkSecretKey:     getEnv("CLERK_SECRET_KEY", ""),
 		ClerkPublishableKey: getEnv("CLERK_PUBLISHABLE_KEY", ""),
 		ClerkJWTKey:        getEnv("CLERK_JWT_KEY", ""),
+		JWTSecret:          getEnv("JWT_SECRET", ""),
+		ClerkJWKSURL:       getEnv("CLERK_JWKS_URL", ""),
+		ClerkIssuer:        getEnv("CLERK_ISSUER", ""),
+		ClerkAudience:      getEnvSlice("CLERK_AUDIENCE", nil),
+		ClerkAuthorizedParties: getEnvSlice("CLERK_AUTHORIZED_PARTIES", nil),
+		JWTClockSkew:       getEnvDuration("JWT_CLOCK_SKEW", 30*time.Second),
+		ClerkWebhookSecret: getEnv("CLERK_WEBHOOK_SECRET", ""),
+		AdminClerkUserIDs:  getEnvSlice("ADMIN_CLERK_USER_IDS", nil),
 		AllowedOrigins:     getEnvSlice("ALLOWED_ORIGINS", []string{"http://localhost:5173", "http://127.0.0.1:5173"}),
+		SMTPHost:           getEnv("SMTP_HOST", ""),
+		SMTPPort:           getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		EmailFrom:          getEnv("EMAIL_FROM", "Budget Planner <no-reply@localhost>"),
+		AppURL:             getEnv("APP_URL", "http://localhost:5173"),
+		EmailPollInterval:  getEnvDuration("EMAIL_POLL_INTERVAL", 10*time.Second),
+		EmailMaxAttempts:   getEnvInt("EMAIL_MAX_ATTEMPTS", 8),
+		EmailMonthlySummaries: getEnvBool("EMAIL_MONTHLY_SUMMARIES", true),
+		VAPIDPublicKey:     getEnv("VAPID_PUBLIC_KEY", ""),
+		VAPIDPrivateKey:    getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:       getEnv("VAPID_SUBJECT", ""),
 		SyncBatchSize:      getEnvInt("SYNC_BATCH_SIZE", 50),
 		SyncRetryAttempts:  getEnvInt("SYNC_RETRY_ATTEMPTS", 3),
 		SyncRetryDelay:     getEnvDuration("SYNC_RETRY_DELAY", 5*time.Second),
@@ -74,6 +122,18 @@ func (c *Config) Validate() error {
 	if c.ClerkPublishableKey == "" {
 		return fmt.Errorf("CLERK_PUBLISHABLE_KEY is required")
 	}
+	if c.ClerkJWKSURL != "" && c.ClerkIssuer == "" {
+		return fmt.Errorf("CLERK_ISSUER is required when CLERK_JWKS_URL is set")
+	}
+	if (c.VAPIDPublicKey == "") != (c.VAPIDPrivateKey == "") {
+		return fmt.Errorf("VAPID_PUBLIC_KEY and VAPID_PRIVATE_KEY must be set together")
+	}
+	if c.VAPIDPublicKey != "" && c.VAPIDSubject == "" {
+		return fmt.Errorf("VAPID_SUBJECT is required when VAPID keys are set")
+	}
+	if c.ClerkJWKSURL == "" && !c.IsDevelopment() {
+		return fmt.Errorf("CLERK_JWKS_URL is required outside development")
+	}
 	return nil
 }
 
@@ -111,6 +171,15 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
 func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
 	if val := os.Getenv(key); val != "" {
 		if dur, err := time.ParseDuration(val); err == nil {
@@ -122,8 +191,13 @@ func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
 
 func getEnvSlice(key string, defaultVal []string) []string {
 	if val := os.Getenv(key); val != "" {
-		// Simple split by comma - for production you might want a more robust parser
-		return []string{val}
+		var parts []string
+		for _, part := range strings.Split(val, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return parts
 	}
 	return defaultVal
 }
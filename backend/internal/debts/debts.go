@@ -0,0 +1,221 @@
+// Package debts simulates avalanche and snowball payoff schedules.
+package debts
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+// MaxMonths caps a simulation; plans that would run longer report PaidOff false
+const MaxMonths = 600
+
+// Strategy decides which debt receives money beyond the minimum payments
+type Strategy string
+
+const (
+	// Avalanche pays the highest APR first, minimizing interest
+	Avalanche Strategy = "avalanche"
+	// Snowball pays the smallest balance first, clearing debts soonest
+	Snowball Strategy = "snowball"
+)
+
+// Valid reports whether s is a known strategy
+func (s Strategy) Valid() bool {
+	return s == Avalanche || s == Snowball
+}
+
+// Debt is a debt's current state
+type Debt struct {
+	ID             string
+	Name           string
+	Balance        money.Amount
+	APR            float64 // annual percentage rate, e.g. 19.99
+	MinimumPayment money.Amount
+	DueDay         int
+}
+
+// Payment is one debt's part of a month in the schedule. Schedules are returned to
+// clients as they are, hence the JSON tags.
+type Payment struct {
+	DebtID   string       `json:"debtId"`
+	Payment  money.Amount `json:"payment"`
+	Interest money.Amount `json:"interest"`
+	Balance  money.Amount `json:"balance"` // after the payment
+}
+
+// Month is one month of a schedule
+type Month struct {
+	Month     string       `json:"month"` // YYYY-MM
+	Payments  []Payment    `json:"payments"`
+	TotalPaid money.Amount `json:"totalPaid"`
+	Remaining money.Amount `json:"remaining"`
+}
+
+// DebtResult summarizes one debt within a plan
+type DebtResult struct {
+	DebtID     string
+	Name       string
+	Order      int // position in the strategy's priority, from 1
+	PayoffDate *time.Time
+	Interest   money.Amount
+	TotalPaid  money.Amount
+}
+
+// Plan is the outcome of paying off a set of debts with a strategy
+type Plan struct {
+	Strategy      Strategy
+	ExtraPayment  money.Amount
+	MonthlyBudget money.Amount // the minimums plus the extra payment, kept up as debts are paid off
+	PaidOff       bool         // false if the budget doesn't outpace interest within MaxMonths
+	Months        int
+	PayoffDate    *time.Time
+	TotalInterest money.Amount
+	TotalPaid     money.Amount
+	Debts         []DebtResult // in priority order
+	Schedule      []Month
+}
+
+// state is a debt's balance during a simulation
+type state struct {
+	Debt
+	result *DebtResult
+}
+
+// Simulate pays off debts month by month starting the month after start. Every month each
+// balance accrues a month of interest, each debt gets its minimum payment, and the rest of
+// the budget goes to debts in the strategy's order. The minimums of paid-off debts roll
+// over, so the monthly budget stays the same until everything is paid.
+func Simulate(list []Debt, strategy Strategy, extra money.Amount, start time.Time) Plan {
+	debts := make([]*state, 0, len(list))
+	plan := Plan{Strategy: strategy, ExtraPayment: extra, MonthlyBudget: extra}
+	for _, d := range list {
+		if d.Balance <= 0 {
+			continue
+		}
+		debts = append(debts, &state{Debt: d})
+		plan.MonthlyBudget += d.MinimumPayment
+	}
+	sort.SliceStable(debts, func(i, j int) bool {
+		a, b := debts[i], debts[j]
+		if strategy == Snowball {
+			if a.Balance != b.Balance {
+				return a.Balance < b.Balance
+			}
+			return a.APR > b.APR
+		}
+		if a.APR != b.APR {
+			return a.APR > b.APR
+		}
+		return a.Balance < b.Balance
+	})
+	plan.Debts = make([]DebtResult, len(debts))
+	for i, d := range debts {
+		plan.Debts[i] = DebtResult{DebtID: d.ID, Name: d.Name, Order: i + 1}
+		d.result = &plan.Debts[i]
+	}
+
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	remaining := totalBalance(debts)
+	for remaining > 0 && plan.Months < MaxMonths {
+		month = month.AddDate(0, 1, 0)
+		budget := plan.MonthlyBudget
+		// Payments has room for every debt so the pointers kept in payments stay valid
+		row := Month{Month: month.Format("2006-01"), Payments: make([]Payment, 0, len(debts))}
+
+		payments := make(map[*state]*Payment, len(debts))
+		for _, d := range debts {
+			if d.Balance == 0 {
+				continue
+			}
+			interest := money.Round(d.Balance.Float64() * d.APR / 1200)
+			d.Balance += interest
+			d.result.Interest += interest
+			plan.TotalInterest += interest
+			row.Payments = append(row.Payments, Payment{DebtID: d.ID, Interest: interest})
+			payments[d] = &row.Payments[len(row.Payments)-1]
+		}
+
+		pay := func(d *state, amount money.Amount) {
+			if amount > d.Balance {
+				amount = d.Balance
+			}
+			if amount > budget {
+				amount = budget
+			}
+			if amount <= 0 {
+				return
+			}
+			d.Balance -= amount
+			budget -= amount
+			payments[d].Payment += amount
+			d.result.TotalPaid += amount
+			row.TotalPaid += amount
+		}
+		for _, d := range debts {
+			if payments[d] != nil {
+				pay(d, d.MinimumPayment)
+			}
+		}
+		for _, d := range debts {
+			if payments[d] != nil {
+				pay(d, budget)
+			}
+		}
+
+		for _, d := range debts {
+			p := payments[d]
+			if p == nil {
+				continue
+			}
+			p.Balance = d.Balance
+			if d.Balance == 0 {
+				due := dueDate(month, d.DueDay)
+				d.result.PayoffDate = &due
+			}
+		}
+
+		plan.TotalPaid += row.TotalPaid
+		plan.Months++
+		previous := remaining
+		remaining = totalBalance(debts)
+		row.Remaining = remaining
+		plan.Schedule = append(plan.Schedule, row)
+
+		// With a fixed budget, a month that doesn't reduce the total never will
+		if remaining >= previous {
+			break
+		}
+	}
+
+	plan.PaidOff = remaining == 0
+	if plan.PaidOff {
+		for _, d := range plan.Debts {
+			if d.PayoffDate != nil && (plan.PayoffDate == nil || d.PayoffDate.After(*plan.PayoffDate)) {
+				plan.PayoffDate = d.PayoffDate
+			}
+		}
+	}
+	return plan
+}
+
+// dueDate returns the due day within month, clamped to the month's last day
+func dueDate(month time.Time, day int) time.Time {
+	last := month.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	if day < 1 {
+		day = 1
+	}
+	return time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+func totalBalance(debts []*state) money.Amount {
+	var sum money.Amount
+	for _, d := range debts {
+		sum += d.Balance
+	}
+	return sum
+}
@@ -0,0 +1,152 @@
+// Package envelopes works out balances for envelope budgeting. Income lands in an
+// unassigned pool and is assigned to category envelopes; spending comes out of its
+// category's envelope, or out of the pool if the category has none. Whatever is left
+// carries over to the owner's next envelope budget.
+package envelopes
+
+import (
+	"errors"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+// Errors returned by CheckMove
+var (
+	ErrSameEnvelope = errors.New("money must move between two different envelopes")
+	ErrNoEnvelope   = errors.New("the category has no envelope in this budget")
+	ErrOverdrawn    = errors.New("not enough money available to move")
+)
+
+// Total is a budget's assigned amount, income and spending in one category.
+// CategoryID is empty for uncategorized transactions.
+type Total struct {
+	BudgetID   string
+	CategoryID string
+	Envelope   bool // the budget has an envelope for the category
+	Assigned   money.Amount
+	Income     money.Amount
+	Spent      money.Amount
+}
+
+// Envelope is a category's balance in a budget
+type Envelope struct {
+	CategoryID string
+	Assigned   money.Amount
+	Spent      money.Amount
+	Carryover  money.Amount // left over from earlier budgets
+	Available  money.Amount // assigned less spent plus carryover
+}
+
+// Balances are an envelope budget's envelopes and unassigned pool
+type Balances struct {
+	Envelopes []Envelope
+	Income    money.Amount
+	Assigned  money.Amount
+	// Unbudgeted is spending in categories without an envelope, which comes out of
+	// the pool
+	Unbudgeted money.Amount
+	// Carryover is the pool left from earlier budgets, including the balances of
+	// envelopes this budget dropped
+	Carryover  money.Amount
+	Unassigned money.Amount
+}
+
+// Compute works out the balances of budgetID from the totals of its owner's envelope
+// budgets up to and including it, grouped by budget, oldest first
+func Compute(budgetID string, totals []Total) Balances {
+	carried := make(map[string]money.Amount)
+	var pool money.Amount
+	for len(totals) > 0 {
+		n := 1
+		for n < len(totals) && totals[n].BudgetID == totals[0].BudgetID {
+			n++
+		}
+		b := settle(totals[:n], carried, pool)
+		if totals[0].BudgetID == budgetID {
+			return b
+		}
+		carried = make(map[string]money.Amount, len(b.Envelopes))
+		for _, e := range b.Envelopes {
+			carried[e.CategoryID] = e.Available
+		}
+		pool = b.Unassigned
+		totals = totals[n:]
+	}
+	// Nothing assigned, received or spent in the budget yet
+	return settle(nil, carried, pool)
+}
+
+// Envelope returns the envelope for categoryID, if the budget has one
+func (b Balances) Envelope(categoryID string) (Envelope, bool) {
+	for _, e := range b.Envelopes {
+		if e.CategoryID == categoryID {
+			return e, true
+		}
+	}
+	return Envelope{}, false
+}
+
+// Available returns what spending in categoryID draws on: its envelope's balance, or
+// the unassigned pool if it has none
+func (b Balances) Available(categoryID string) money.Amount {
+	if e, ok := b.Envelope(categoryID); ok {
+		return e.Available
+	}
+	return b.Unassigned
+}
+
+// Source returns where spending in categoryID comes out of: the category itself if it
+// has an envelope, "" for the pool
+func (b Balances) Source(categoryID string) string {
+	if _, ok := b.Envelope(categoryID); ok {
+		return categoryID
+	}
+	return ""
+}
+
+// CheckMove validates moving amount from one envelope to another, "" being the
+// pool. Strict budgets can't move more than the source has available.
+func (b Balances) CheckMove(from, to string, amount money.Amount, strict bool) error {
+	if from == to {
+		return ErrSameEnvelope
+	}
+	if _, ok := b.Envelope(from); from != "" && !ok {
+		return ErrNoEnvelope
+	}
+	if strict && amount > b.Available(from) {
+		return ErrOverdrawn
+	}
+	return nil
+}
+
+// settle works out one budget's balances from its totals and what earlier budgets
+// left in each envelope and the pool
+func settle(totals []Total, carried map[string]money.Amount, pool money.Amount) Balances {
+	b := Balances{Carryover: pool}
+	kept := make(map[string]bool)
+	for _, t := range totals {
+		b.Income += t.Income
+		if !t.Envelope {
+			b.Unbudgeted += t.Spent
+			continue
+		}
+		kept[t.CategoryID] = true
+		e := Envelope{
+			CategoryID: t.CategoryID,
+			Assigned:   t.Assigned,
+			Spent:      t.Spent,
+			Carryover:  carried[t.CategoryID],
+		}
+		e.Available = e.Assigned - e.Spent + e.Carryover
+		b.Envelopes = append(b.Envelopes, e)
+		b.Assigned += t.Assigned
+	}
+	// Envelopes the budget dropped return their balance to the pool
+	for categoryID, amount := range carried {
+		if !kept[categoryID] {
+			b.Carryover += amount
+		}
+	}
+	b.Unassigned = b.Carryover + b.Income - b.Assigned - b.Unbudgeted
+	return b
+}
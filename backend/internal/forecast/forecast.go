@@ -0,0 +1,335 @@
+// Package forecast projects spending and income over the coming days from the
+// current month's pace, past day-of-week patterns and scheduled transactions.
+package forecast
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+// HistoryDays is how far before the current month past spending counts toward the pace
+const HistoryDays = 90
+
+// MaxDays caps how far ahead the daily curve goes
+const MaxDays = 90
+
+// minPatternDays is how much history it takes before day-of-week patterns are used
+const minPatternDays = 28
+
+// Flow is a day's spending or income in one category with one payment method.
+// Empty IDs group uncategorized transactions and ones without a payment method.
+type Flow struct {
+	Date            time.Time
+	Type            string // expense or income
+	CategoryID      string
+	PaymentMethodID string
+	Amount          money.Amount
+}
+
+// Scheduled is a known upcoming transaction: the next occurrence of a recurring
+// transaction or a debt's minimum payment on its due date
+type Scheduled struct {
+	Date            time.Time
+	Kind            string // recurring or bill
+	SourceID        string // the recurring transaction or the debt
+	Name            string
+	Type            string // expense or income
+	CategoryID      string
+	PaymentMethodID string
+	Amount          money.Amount
+}
+
+// Input is what a forecast is made from
+type Input struct {
+	Today time.Time
+	Days  int // length of the daily curve, from tomorrow
+
+	// Flows are the non-scheduled transactions from HistoryStart(Today) through Today
+	Flows []Flow
+	// Scheduled are the known transactions after Today through Horizon(Today, Days)
+	Scheduled []Scheduled
+	// StartingBalance is where the daily balance curve starts from
+	StartingBalance money.Amount
+}
+
+// Totals are projected expenses and income
+type Totals struct {
+	Expenses money.Amount
+	Income   money.Amount
+}
+
+// Day is one day of the projected curve
+type Day struct {
+	Date     time.Time
+	Expenses money.Amount
+	Income   money.Amount
+	Balance  money.Amount // StartingBalance plus every projected day's income less expenses so far
+}
+
+// Result is a forecast. The month totals cover tomorrow through the end of the
+// current month; they add to what was already spent this month.
+type Result struct {
+	MonthEnd       time.Time
+	Month          Totals
+	Categories     map[string]Totals // by category ID, "" for uncategorized
+	PaymentMethods map[string]Totals // by payment method ID, "" for none
+	Daily          []Day
+}
+
+// HistoryStart returns the first day whose transactions are used for a forecast made on today
+func HistoryStart(today time.Time) time.Time {
+	return monthStart(truncateDay(today)).AddDate(0, 0, -HistoryDays)
+}
+
+// Horizon returns the last day a forecast of days from today looks at: the end of
+// the curve or of the current month, whichever is later
+func Horizon(today time.Time, days int) time.Time {
+	today = truncateDay(today)
+	end := today.AddDate(0, 0, days)
+	if monthEnd := monthStart(today).AddDate(0, 1, -1); monthEnd.After(end) {
+		return monthEnd
+	}
+	return end
+}
+
+// key groups flows that share a pace
+type key struct {
+	typ        string
+	categoryID string
+	methodID   string
+}
+
+// rate is the expected amount per day for a key
+type rate struct {
+	key
+	daily float64
+}
+
+// Project makes a forecast.
+//
+// Each category and payment method's daily spending blends this month's pace so
+// far with the average over the history before the month, trusting this month more
+// as it goes on, and is then shaped by how spending was spread over the days of the
+// week in the history, e.g. more on weekends. Income arrives in lumps, so a month's
+// pace says little about the rest of it; unscheduled income is spread evenly at its
+// historical average, and regular pay is best entered as a recurring transaction.
+// Scheduled transactions are added on their dates on top of the pace.
+func Project(in Input) Result {
+	today := truncateDay(in.Today)
+	start := monthStart(today)
+	monthEnd := start.AddDate(0, 1, -1)
+	historyStart := HistoryStart(today)
+
+	// Sum the history before the month and the month so far separately
+	past := make(map[key]float64)
+	current := make(map[key]float64)
+	var weekdayTotals [7]float64
+	var firstPast time.Time
+	for _, f := range in.Flows {
+		date := truncateDay(f.Date)
+		if date.Before(historyStart) || date.After(today) {
+			continue
+		}
+		k := key{typ: f.Type, categoryID: f.CategoryID, methodID: f.PaymentMethodID}
+		if !date.Before(start) {
+			current[k] += f.Amount.Float64()
+			continue
+		}
+		past[k] += f.Amount.Float64()
+		if f.Type == "expense" {
+			weekdayTotals[date.Weekday()] += f.Amount.Float64()
+		}
+		if firstPast.IsZero() || date.Before(firstPast) {
+			firstPast = date
+		}
+	}
+
+	// History only counts from the user's first transaction in it, so new users
+	// aren't averaged against days they weren't tracking
+	pastDays := 0
+	if !firstPast.IsZero() {
+		pastDays = daysBetween(firstPast, start)
+	}
+	elapsed := today.Day()
+	weight := 1.0
+	if pastDays > 0 {
+		weight = float64(elapsed) / float64(monthEnd.Day())
+	}
+
+	paces := make(map[key]float64)
+	for k, total := range current {
+		if k.typ != "income" {
+			paces[k] += weight * total / float64(elapsed)
+		}
+	}
+	for k, total := range past {
+		if k.typ == "income" {
+			paces[k] += total / float64(pastDays)
+		} else {
+			paces[k] += (1 - weight) * total / float64(pastDays)
+		}
+	}
+	// A fixed order keeps the sums, and so their rounding, the same between runs
+	rates := make([]rate, 0, len(paces))
+	for k, daily := range paces {
+		rates = append(rates, rate{key: k, daily: daily})
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		a, b := rates[i].key, rates[j].key
+		if a.typ != b.typ {
+			return a.typ < b.typ
+		}
+		if a.categoryID != b.categoryID {
+			return a.categoryID < b.categoryID
+		}
+		return a.methodID < b.methodID
+	})
+
+	weekday := weekdayFactors(weekdayTotals, firstPast, start, pastDays)
+
+	scheduled := make(map[time.Time][]Scheduled)
+	for _, s := range in.Scheduled {
+		date := truncateDay(s.Date)
+		scheduled[date] = append(scheduled[date], s)
+	}
+
+	days := in.Days
+	if days > MaxDays {
+		days = MaxDays
+	}
+	horizon := Horizon(today, days)
+
+	categories := make(map[string]*sums)
+	methods := make(map[string]*sums)
+	add := func(m map[string]*sums, id, typ string, amount float64) {
+		if m[id] == nil {
+			m[id] = &sums{}
+		}
+		m[id].add(typ, amount)
+	}
+
+	var month sums
+	result := Result{MonthEnd: monthEnd, Daily: make([]Day, 0, days)}
+	balance := in.StartingBalance
+	for date := today.AddDate(0, 0, 1); !date.After(horizon); date = date.AddDate(0, 0, 1) {
+		inMonth := !date.After(monthEnd)
+		var day sums
+		for _, r := range rates {
+			amount := r.daily
+			if r.typ == "expense" {
+				amount *= weekday[date.Weekday()]
+			}
+			day.add(r.typ, amount)
+			if inMonth {
+				add(categories, r.categoryID, r.typ, amount)
+				add(methods, r.methodID, r.typ, amount)
+			}
+		}
+		for _, s := range scheduled[date] {
+			amount := s.Amount.Float64()
+			day.add(s.Type, amount)
+			if inMonth {
+				add(categories, s.CategoryID, s.Type, amount)
+				add(methods, s.PaymentMethodID, s.Type, amount)
+			}
+		}
+		if inMonth {
+			month.expenses += day.expenses
+			month.income += day.income
+		}
+
+		if len(result.Daily) < days {
+			totals := day.totals()
+			balance += totals.Income - totals.Expenses
+			result.Daily = append(result.Daily, Day{
+				Date:     date,
+				Expenses: totals.Expenses,
+				Income:   totals.Income,
+				Balance:  balance,
+			})
+		}
+	}
+
+	result.Month = month.totals()
+	result.Categories = make(map[string]Totals, len(categories))
+	for id, s := range categories {
+		result.Categories[id] = s.totals()
+	}
+	result.PaymentMethods = make(map[string]Totals, len(methods))
+	for id, s := range methods {
+		result.PaymentMethods[id] = s.totals()
+	}
+	return result
+}
+
+// SortScheduled orders scheduled transactions by date, then name
+func SortScheduled(list []Scheduled) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if !list[i].Date.Equal(list[j].Date) {
+			return list[i].Date.Before(list[j].Date)
+		}
+		return list[i].Name < list[j].Name
+	})
+}
+
+// weekdayFactors returns how much each day of the week's spending differs from the
+// daily average over the history [first, end). Without enough history every day
+// counts the same.
+func weekdayFactors(totals [7]float64, first, end time.Time, pastDays int) [7]float64 {
+	factors := [7]float64{1, 1, 1, 1, 1, 1, 1}
+	if pastDays < minPatternDays {
+		return factors
+	}
+	var sum float64
+	for _, t := range totals {
+		sum += t
+	}
+	if sum <= 0 {
+		return factors
+	}
+	average := sum / float64(pastDays)
+
+	var counts [7]int
+	for date := first; date.Before(end); date = date.AddDate(0, 0, 1) {
+		counts[date.Weekday()]++
+	}
+	for i := range factors {
+		if counts[i] > 0 {
+			factors[i] = totals[i] / float64(counts[i]) / average
+		}
+	}
+	return factors
+}
+
+// sums accumulates unrounded projections
+type sums struct {
+	expenses float64
+	income   float64
+}
+
+func (s *sums) add(typ string, amount float64) {
+	if typ == "income" {
+		s.income += amount
+	} else {
+		s.expenses += amount
+	}
+}
+
+func (s sums) totals() Totals {
+	return Totals{Expenses: money.Round(s.expenses), Income: money.Round(s.income)}
+}
+
+// daysBetween counts the days from from up to, but not including, to
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours()/24 + 0.5)
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,120 @@
+// Package goals computes progress and projections for savings goals.
+package goals
+
+import (
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+// PaceWindowDays is how far back contributions count toward the current saving pace
+const PaceWindowDays = 90
+
+// Status summarizes where a goal stands
+type Status string
+
+const (
+	// StatusCompleted means the target amount has been saved
+	StatusCompleted Status = "completed"
+	// StatusOnTrack means the current pace reaches the target by the target date
+	StatusOnTrack Status = "on_track"
+	// StatusBehind means the current pace misses the target date
+	StatusBehind Status = "behind"
+	// StatusOverdue means the target date has passed without reaching the target
+	StatusOverdue Status = "overdue"
+	// StatusActive is an incomplete goal without a target date
+	StatusActive Status = "active"
+)
+
+// Goal is the target a goal is saving toward
+type Goal struct {
+	Target     money.Amount
+	TargetDate *time.Time
+}
+
+// Progress is the computed state of a goal as of a given day
+type Progress struct {
+	Saved     money.Amount
+	Remaining money.Amount // never negative
+	Percent   float64      // capped at 100
+
+	// MonthsLeft counts the monthly contributions still possible, including the current month.
+	// RequiredMonthly is what each of them must be to reach the target on time.
+	// Both are unset for goals without a target date and for completed goals.
+	MonthsLeft      int
+	RequiredMonthly *money.Amount
+
+	// MonthlyPace is the average net monthly contribution over the last PaceWindowDays.
+	// ProjectedCompletion is when the goal completes at that pace, unset if the pace isn't positive.
+	MonthlyPace         money.Amount
+	ProjectedCompletion *time.Time
+
+	Status Status
+}
+
+// Compute returns the progress of goal given the total saved so far and the net
+// contributions made in the last PaceWindowDays before today
+func Compute(goal Goal, saved, recent money.Amount, today time.Time) Progress {
+	today = truncateDay(today)
+	p := Progress{
+		Saved:       saved,
+		Remaining:   goal.Target - saved,
+		Percent:     money.Percent(saved, goal.Target),
+		MonthlyPace: money.Round(recent.Float64() * 30 / PaceWindowDays),
+	}
+	if p.Remaining < 0 {
+		p.Remaining = 0
+	}
+	if p.Percent > 100 {
+		p.Percent = 100
+	}
+	if p.Percent < 0 {
+		p.Percent = 0
+	}
+
+	if p.Remaining == 0 {
+		p.Status = StatusCompleted
+		return p
+	}
+
+	if recent > 0 {
+		// Days until done at the recent daily rate, rounded up
+		days := (int64(p.Remaining)*PaceWindowDays + int64(recent) - 1) / int64(recent)
+		done := today.AddDate(0, 0, int(days))
+		p.ProjectedCompletion = &done
+	}
+
+	if goal.TargetDate == nil {
+		p.Status = StatusActive
+		return p
+	}
+
+	target := truncateDay(*goal.TargetDate)
+	p.MonthsLeft = MonthsLeft(today, target)
+	required := money.FromCents((p.Remaining.Cents() + int64(p.MonthsLeft) - 1) / int64(p.MonthsLeft))
+	p.RequiredMonthly = &required
+
+	switch {
+	case target.Before(today):
+		p.Status = StatusOverdue
+	case p.ProjectedCompletion != nil && !p.ProjectedCompletion.After(target):
+		p.Status = StatusOnTrack
+	default:
+		p.Status = StatusBehind
+	}
+	return p
+}
+
+// MonthsLeft returns the number of calendar months from today's month through the
+// target's month inclusive, and at least 1 so an overdue goal is due in full now
+func MonthsLeft(today, target time.Time) int {
+	months := (target.Year()-today.Year())*12 + int(target.Month()-today.Month()) + 1
+	if months < 1 {
+		return 1
+	}
+	return months
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
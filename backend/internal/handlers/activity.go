@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// ActivityHandler handles activity feed requests
+type ActivityHandler struct {
+	queries *models.Queries
+}
+
+// NewActivityHandler creates a new activity handler
+func NewActivityHandler(queries *models.Queries) *ActivityHandler {
+	return &ActivityHandler{queries: queries}
+}
+
+// ActivityActor is the user who made a change
+type ActivityActor struct {
+	ID    string  `json:"id"`
+	Name  *string `json:"name,omitempty"`
+	Email string  `json:"email,omitempty"`
+}
+
+// ActivityResponse represents an activity log entry in API responses
+type ActivityResponse struct {
+	ID           string          `json:"id"`
+	Action       string          `json:"action"`
+	ResourceType *string         `json:"resourceType,omitempty"`
+	ResourceID   *string         `json:"resourceId,omitempty"`
+	BudgetID     *string         `json:"budgetId,omitempty"`
+	Actor        *ActivityActor  `json:"actor,omitempty"`
+	Details      json.RawMessage `json:"details,omitempty"`
+	RequestID    *string         `json:"requestId,omitempty"`
+	IPAddress    *string         `json:"ipAddress,omitempty"`
+	CreatedAt    string          `json:"createdAt"`
+}
+
+// ListActivity returns the activity feed: the user's own changes plus changes
+// made by anyone on budgets they own or that are shared with them.
+// Supports budgetId, resourceType, resourceId, action, actorId, since, until, limit and offset.
+func (h *ActivityHandler) ListActivity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	query := r.URL.Query()
+	params := models.ListActivityParams{
+		UserID:       utils.PgUUID(userID),
+		BudgetID:     utils.PgUUID(query.Get("budgetId")),
+		ResourceType: utils.PgText(query.Get("resourceType")),
+		ResourceID:   utils.PgUUID(query.Get("resourceId")),
+		Action:       utils.PgText(query.Get("action")),
+		ActorID:      utils.PgUUID(query.Get("actorId")),
+		PageLimit:    50,
+		PageOffset:   0,
+	}
+
+	for key, dst := range map[string]*pgtype.Timestamptz{"since": &params.Since, "until": &params.Until} {
+		if s := query.Get(key); s != "" {
+			t, err := parseActivityTime(s)
+			if err != nil {
+				utils.BadRequest(w, "Invalid "+key+" format. Use RFC3339 or YYYY-MM-DD")
+				return
+			}
+			*dst = utils.PgTimestamptz(t)
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := parseInt(limitStr); err == nil && l > 0 && l <= 200 {
+			params.PageLimit = int32(l)
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := parseInt(offsetStr); err == nil && o >= 0 {
+			params.PageOffset = int32(o)
+		}
+	}
+
+	entries, err := h.queries.ListActivity(r.Context(), params)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch activity")
+		return
+	}
+
+	response := make([]ActivityResponse, len(entries))
+	for i, e := range entries {
+		response[i] = activityToResponse(e, userID)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// recordActivity records a mutation in the activity log.
+// The change has already happened, so a logging failure doesn't fail the request.
+func recordActivity(r *http.Request, q *models.Queries, e activity.Entry) {
+	if err := activity.Record(r.Context(), q, r, e); err != nil {
+		log.Printf("activity log: failed to record %s: %v", e.Action, err)
+	}
+}
+
+// Helper functions
+
+func activityToResponse(e models.ListActivityRow, viewerID string) ActivityResponse {
+	resp := ActivityResponse{
+		ID:           e.ID,
+		Action:       e.Action,
+		ResourceType: utils.TextToStringPtr(e.ResourceType),
+		ResourceID:   uuidPtrToString(e.ResourceID),
+		BudgetID:     uuidPtrToString(e.BudgetID),
+		RequestID:    utils.TextToStringPtr(e.RequestID),
+		CreatedAt:    utils.TimestamptzToTime(e.CreatedAt).Format(time.RFC3339),
+	}
+	if len(e.Details) > 0 {
+		resp.Details = json.RawMessage(e.Details)
+	}
+	if e.UserID.Valid {
+		actorID := utils.FormatUUID(e.UserID)
+		resp.Actor = &ActivityActor{
+			ID:    actorID,
+			Name:  utils.TextToStringPtr(e.UserName),
+			Email: utils.TextToString(e.UserEmail),
+		}
+		// Collaborators see who changed what, but not each other's IP addresses
+		if actorID == viewerID && e.IpAddress != nil {
+			ip := e.IpAddress.String()
+			resp.IPAddress = &ip
+		}
+	}
+	return resp
+}
+
+// parseActivityTime accepts an RFC3339 timestamp or a plain date
+func parseActivityTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/alerts"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// AlertHandler handles alert rule and notification inbox requests
+type AlertHandler struct {
+	queries *models.Queries
+	pool    *pgxpool.Pool
+}
+
+// NewAlertHandler creates a new alert handler
+func NewAlertHandler(queries *models.Queries, pool *pgxpool.Pool) *AlertHandler {
+	return &AlertHandler{queries: queries, pool: pool}
+}
+
+// AlertRuleResponse represents an alert rule in API responses
+type AlertRuleResponse struct {
+	ID               string        `json:"id"`
+	Kind             string        `json:"kind"`
+	CategoryID       *string       `json:"categoryId,omitempty"`
+	ThresholdPercent *int32        `json:"thresholdPercent,omitempty"`
+	Amount           *money.Amount `json:"amount,omitempty"`
+	DaysBefore       *int32        `json:"daysBefore,omitempty"`
+	Enabled          bool          `json:"enabled"`
+	Email            bool          `json:"email"`
+	CreatedAt        string        `json:"createdAt"`
+	UpdatedAt        string        `json:"updatedAt"`
+}
+
+// CreateAlertRuleRequest represents the create alert rule request. Which fields are
+// required depends on kind: thresholdPercent for category_threshold (with an optional
+// categoryId, otherwise every category), amount for large_transaction and daysBefore
+// for bill_due. Notifications are also emailed unless email is false.
+type CreateAlertRuleRequest struct {
+	Kind             string        `json:"kind"`
+	CategoryID       *string       `json:"categoryId,omitempty"`
+	ThresholdPercent *int32        `json:"thresholdPercent,omitempty"`
+	Amount           *money.Amount `json:"amount,omitempty"`
+	DaysBefore       *int32        `json:"daysBefore,omitempty"`
+	Enabled          *bool         `json:"enabled,omitempty"`
+	Email            *bool         `json:"email,omitempty"`
+}
+
+// UpdateAlertRuleRequest represents the update alert rule request. The kind can't change.
+type UpdateAlertRuleRequest struct {
+	CategoryID       *string       `json:"categoryId,omitempty"`
+	ThresholdPercent *int32        `json:"thresholdPercent,omitempty"`
+	Amount           *money.Amount `json:"amount,omitempty"`
+	DaysBefore       *int32        `json:"daysBefore,omitempty"`
+	Enabled          *bool         `json:"enabled,omitempty"`
+	Email            *bool         `json:"email,omitempty"`
+}
+
+// NotificationResponse represents an inbox notification in API responses
+type NotificationResponse struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data"`
+	Read      bool            `json:"read"`
+	ReadAt    *string         `json:"readAt,omitempty"`
+	CreatedAt string          `json:"createdAt"`
+}
+
+// NotificationListResponse is a page of the inbox with the total unread count
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unreadCount"`
+}
+
+// UpdateNotificationRequest marks a notification read or unread
+type UpdateNotificationRequest struct {
+	Read bool `json:"read"`
+}
+
+// ListAlertRules returns the current user's alert rules
+func (h *AlertHandler) ListAlertRules(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	rules, err := h.queries.ListAlertRules(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch alert rules")
+		return
+	}
+
+	response := make([]AlertRuleResponse, len(rules))
+	for i, rule := range rules {
+		response[i] = alertRuleToResponse(rule)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// CreateAlertRule creates an alert rule
+func (h *AlertHandler) CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var req CreateAlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	if !alerts.Kinds[req.Kind] {
+		utils.BadRequest(w, "Invalid kind. Use category_threshold, budget_exceeded, large_transaction or bill_due")
+		return
+	}
+	switch {
+	case req.Kind == alerts.KindCategoryThreshold && req.ThresholdPercent == nil:
+		utils.BadRequest(w, "thresholdPercent is required for category_threshold alerts")
+		return
+	case req.Kind == alerts.KindLargeTransaction && req.Amount == nil:
+		utils.BadRequest(w, "amount is required for large_transaction alerts")
+		return
+	case req.Kind == alerts.KindBillDue && req.DaysBefore == nil:
+		utils.BadRequest(w, "daysBefore is required for bill_due alerts")
+		return
+	}
+	if !h.validateRule(w, r, userID, req.Kind, req.CategoryID, req.ThresholdPercent, req.Amount, req.DaysBefore) {
+		return
+	}
+
+	enabled, email := true, true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	if req.Email != nil {
+		email = *req.Email
+	}
+
+	rule, err := h.queries.CreateAlertRule(r.Context(), models.CreateAlertRuleParams{
+		UserID:           userID,
+		Kind:             req.Kind,
+		CategoryID:       utils.PgUUIDPtr(req.CategoryID),
+		ThresholdPercent: utils.PgInt4Ptr(req.ThresholdPercent),
+		Amount:           money.NumericPtr(req.Amount),
+		DaysBefore:       utils.PgInt4Ptr(req.DaysBefore),
+		Enabled:          enabled,
+		Email:            email,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to create alert rule")
+		return
+	}
+
+	utils.SendCreated(w, alertRuleToResponse(rule))
+}
+
+// UpdateAlertRule updates an alert rule
+func (h *AlertHandler) UpdateAlertRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	before, ok := h.ownRule(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req UpdateAlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+	if !h.validateRule(w, r, userID, before.Kind, req.CategoryID, req.ThresholdPercent, req.Amount, req.DaysBefore) {
+		return
+	}
+
+	rule, err := h.queries.UpdateAlertRule(r.Context(), models.UpdateAlertRuleParams{
+		ID:               before.ID,
+		CategoryID:       utils.PgUUIDPtr(req.CategoryID),
+		ThresholdPercent: utils.PgInt4Ptr(req.ThresholdPercent),
+		Amount:           money.NumericPtr(req.Amount),
+		DaysBefore:       utils.PgInt4Ptr(req.DaysBefore),
+		Enabled:          pgBoolPtr(req.Enabled),
+		Email:            pgBoolPtr(req.Email),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to update alert rule")
+		return
+	}
+
+	utils.SendSuccess(w, alertRuleToResponse(rule))
+}
+
+// DeleteAlertRule deletes an alert rule. Notifications it already raised stay in the inbox.
+func (h *AlertHandler) DeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	rule, ok := h.ownRule(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.queries.DeleteAlertRule(r.Context(), rule.ID); err != nil {
+		utils.InternalError(w, "Failed to delete alert rule")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Alert rule deleted successfully",
+	})
+}
+
+// ListNotifications returns the current user's inbox, newest first.
+// Supports unread=true, limit and offset. Upcoming bills are checked first since
+// they come due without any write to trigger them.
+func (h *AlertHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	query := r.URL.Query()
+	params := models.ListNotificationsParams{
+		UserID:     userID,
+		UnreadOnly: query.Get("unread") == "true",
+		PageLimit:  50,
+		PageOffset: 0,
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := parseInt(limitStr); err == nil && l > 0 && l <= 200 {
+			params.PageLimit = int32(l)
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := parseInt(offsetStr); err == nil && o >= 0 {
+			params.PageOffset = int32(o)
+		}
+	}
+
+	if err := alerts.CheckBills(r.Context(), h.pool, h.queries, userID, time.Now()); err != nil {
+		log.Printf("alerts: failed to check bills for %s: %v", userID, err)
+	}
+
+	notifications, err := h.queries.ListNotifications(r.Context(), params)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch notifications")
+		return
+	}
+	unread, err := h.queries.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch notifications")
+		return
+	}
+
+	response := NotificationListResponse{
+		Notifications: make([]NotificationResponse, len(notifications)),
+		UnreadCount:   unread,
+	}
+	for i, n := range notifications {
+		response.Notifications[i] = notificationToResponse(n)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// GetUnreadCount returns the number of unread notifications, for badges
+func (h *AlertHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	unread, err := h.queries.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch notifications")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]int64{"unreadCount": unread})
+}
+
+// UpdateNotification marks a notification read or unread
+func (h *AlertHandler) UpdateNotification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var req UpdateNotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	notification, err := h.queries.SetNotificationRead(r.Context(), models.SetNotificationReadParams{
+		Read:   req.Read,
+		ID:     r.PathValue("id"),
+		UserID: userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.NotFound(w, "Notification not found")
+		return
+	}
+	if err != nil {
+		utils.InternalError(w, "Failed to update notification")
+		return
+	}
+
+	utils.SendSuccess(w, notificationToResponse(notification))
+}
+
+// MarkAllNotificationsRead marks every unread notification read
+func (h *AlertHandler) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	updated, err := h.queries.MarkAllNotificationsRead(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to update notifications")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]int64{"updated": updated})
+}
+
+// DeleteNotification removes a notification from the inbox
+func (h *AlertHandler) DeleteNotification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	deleted, err := h.queries.DeleteNotification(r.Context(), models.DeleteNotificationParams{
+		ID:     r.PathValue("id"),
+		UserID: userID,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to delete notification")
+		return
+	}
+	if deleted == 0 {
+		utils.NotFound(w, "Notification not found")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Notification deleted successfully",
+	})
+}
+
+// checkAlerts evaluates alert rules after a transaction write. Failures are logged
+// rather than failing the write, which has already happened.
+func checkAlerts(r *http.Request, pool *pgxpool.Pool, q *models.Queries, t models.Transaction) {
+	if err := alerts.CheckTransaction(r.Context(), pool, q, t); err != nil {
+		log.Printf("alerts: failed to check transaction %s: %v", t.ID, err)
+	}
+}
+
+// Helper functions
+
+// ownRule loads the alert rule in the path, responding with 404 unless it belongs to userID
+func (h *AlertHandler) ownRule(w http.ResponseWriter, r *http.Request, userID string) (models.AlertRule, bool) {
+	rule, err := h.queries.GetAlertRuleByID(r.Context(), r.PathValue("id"))
+	if err != nil || rule.UserID != userID {
+		utils.NotFound(w, "Alert rule not found")
+		return models.AlertRule{}, false
+	}
+	return rule, true
+}
+
+// validateRule checks the fields that are set against the rule's kind, responding with 400 if one is invalid
+func (h *AlertHandler) validateRule(w http.ResponseWriter, r *http.Request, userID, kind string, categoryID *string, threshold *int32, amount *money.Amount, daysBefore *int32) bool {
+	switch {
+	case categoryID != nil && kind != alerts.KindCategoryThreshold,
+		threshold != nil && kind != alerts.KindCategoryThreshold,
+		amount != nil && kind != alerts.KindLargeTransaction,
+		daysBefore != nil && kind != alerts.KindBillDue:
+		utils.BadRequest(w, "Field does not apply to "+kind+" alerts")
+		return false
+	case threshold != nil && (*threshold < 1 || *threshold > 1000):
+		utils.BadRequest(w, "thresholdPercent must be between 1 and 1000")
+		return false
+	case amount != nil && *amount <= 0:
+		utils.BadRequest(w, "amount must be positive")
+		return false
+	case daysBefore != nil && (*daysBefore < 0 || *daysBefore > 60):
+		utils.BadRequest(w, "daysBefore must be between 0 and 60")
+		return false
+	}
+	if !checkAmounts(w, r, amount) {
+		return false
+	}
+	if categoryID != nil {
+		category, err := h.queries.GetCategoryByID(r.Context(), *categoryID)
+		if err != nil || (category.UserID.Valid && !utils.UUIDEquals(category.UserID, userID)) {
+			utils.BadRequest(w, "Category not found")
+			return false
+		}
+	}
+	return true
+}
+
+func alertRuleToResponse(rule models.AlertRule) AlertRuleResponse {
+	return AlertRuleResponse{
+		ID:               rule.ID,
+		Kind:             rule.Kind,
+		CategoryID:       uuidPtrToString(rule.CategoryID),
+		ThresholdPercent: utils.Int4ToInt32(rule.ThresholdPercent),
+		Amount:           money.FromNumericPtr(rule.Amount),
+		DaysBefore:       utils.Int4ToInt32(rule.DaysBefore),
+		Enabled:          rule.Enabled,
+		Email:            rule.Email,
+		CreatedAt:        utils.TimestamptzToTime(rule.CreatedAt).Format(time.RFC3339),
+		UpdatedAt:        utils.TimestamptzToTime(rule.UpdatedAt).Format(time.RFC3339),
+	}
+}
+
+func notificationToResponse(n models.Notification) NotificationResponse {
+	resp := NotificationResponse{
+		ID:        n.ID,
+		Kind:      n.Kind,
+		Title:     n.Title,
+		Body:      n.Body,
+		Data:      json.RawMessage(n.Data),
+		Read:      n.ReadAt.Valid,
+		CreatedAt: utils.TimestamptzToTime(n.CreatedAt).Format(time.RFC3339),
+	}
+	if n.ReadAt.Valid {
+		s := n.ReadAt.Time.Format(time.RFC3339)
+		resp.ReadAt = &s
+	}
+	return resp
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/income"
 	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
 	"github.com/joselitophala/budget-planner-backend/internal/utils"
 )
 
@@ -20,36 +22,72 @@ func NewAnalyticsHandler(queries *models.Queries) *AnalyticsHandler {
 	return &AnalyticsHandler{queries: queries}
 }
 
-// DashboardSummary represents the dashboard summary for a month
+// DashboardSummary represents the dashboard summary for a budget period
 type DashboardSummary struct {
-	Month              string  `json:"month"`
-	TotalBudget        float64 `json:"totalBudget"`
-	TotalSpent         float64 `json:"totalSpent"`
-	Remaining          float64 `json:"remaining"`
-	BudgetUsedPercent  float64 `json:"budgetUsedPercent"`
-	TransactionCount   int32   `json:"transactionCount"`
-	TopCategories      []CategorySpending `json:"topCategories"`
+	Month              string               `json:"month"`
+	PeriodType         string               `json:"periodType"`
+	PeriodStart        string               `json:"periodStart"`
+	PeriodEnd          string               `json:"periodEnd"` // the period's last day
+	TotalBudget        money.Amount         `json:"totalBudget"`
+	TotalSpent         money.Amount         `json:"totalSpent"`
+	Remaining          money.Amount         `json:"remaining"`
+	BudgetUsedPercent  float64              `json:"budgetUsedPercent"`
+	TotalIncome        money.Amount         `json:"totalIncome"`
+	ExpectedIncome     money.Amount         `json:"expectedIncome"` // from the user's income sources
+	IncomeVariance     money.Amount         `json:"incomeVariance"` // totalIncome less expectedIncome
+	SavingsRate        *float64             `json:"savingsRate"`    // share of income not spent, null without income
+	Allocated          money.Amount         `json:"allocated"`      // the budget's category limits
+	LeftToBudget       money.Amount         `json:"leftToBudget"`   // expected income, or actual without sources, less allocated
+	BudgetStatus       string               `json:"budgetStatus"`   // under, balanced or over allocated
+	TransactionCount   int32                `json:"transactionCount"`
+	TopCategories      []CategorySpending   `json:"topCategories"`
 	RecentTransactions []TransactionSummary `json:"recentTransactions"`
 }
 
 // CategorySpending represents spending by category
 type CategorySpending struct {
-	CategoryID   string  `json:"categoryId"`
-	CategoryName string  `json:"categoryName"`
-	Amount       float64 `json:"amount"`
-	Percent      float64 `json:"percent"`
+	CategoryID   string       `json:"categoryId"`
+	CategoryName string       `json:"categoryName"`
+	Amount       money.Amount `json:"amount"`
+	Percent      float64      `json:"percent"`
 }
 
 // TransactionSummary represents a transaction summary
 type TransactionSummary struct {
-	ID          string  `json:"id"`
-	Amount      float64 `json:"amount"`
-	Description string  `json:"description"`
-	Date        string  `json:"date"`
-	Category    string  `json:"category,omitempty"`
+	ID             string        `json:"id"`
+	Amount         money.Amount  `json:"amount"` // in the home currency
+	OriginalAmount *money.Amount `json:"originalAmount,omitempty"`
+	Currency       string        `json:"currency,omitempty"` // set with originalAmount for foreign currency transactions
+	Description    string        `json:"description"`
+	Date           string        `json:"date"`
+	Category       string        `json:"category,omitempty"`
 }
 
-// GetDashboard returns the dashboard summary for a specific month
+// SpendingReportItem represents a budget category's spending in the spending report
+type SpendingReportItem struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	Icon       *string      `json:"icon"`
+	Color      *string      `json:"color"`
+	TotalSpent money.Amount `json:"totalSpent"`
+	Percentage int32        `json:"percentage"` // of the category limit
+}
+
+// TrendPoint represents a month of income and expenses
+type TrendPoint struct {
+	Month    string       `json:"month"`
+	Expenses money.Amount `json:"expenses"`
+	Income   money.Amount `json:"income"`
+}
+
+// CategoryReportPoint represents a day of spending in a category
+type CategoryReportPoint struct {
+	Date             string       `json:"date"`
+	Total            money.Amount `json:"total"`
+	TransactionCount int64        `json:"transactionCount"`
+}
+
+// GetDashboard returns the dashboard summary for the budget period including a date
 func (h *AnalyticsHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
 	userID, ok := auth.GetUserID(r)
 	if !ok {
@@ -57,25 +95,25 @@ func (h *AnalyticsHandler) GetDashboard(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	monthStr := r.PathValue("month")
-	if monthStr == "" {
-		monthStr = time.Now().Format("2006-01")
+	dateStr := r.PathValue("date")
+	if dateStr == "" {
+		dateStr = time.Now().Format("2006-01-02")
 	}
 
-	// Parse month
-	month, err := time.Parse("2006-01", monthStr)
+	// Parse date, or month for its first day
+	date, err := parseBudgetDate(dateStr)
 	if err != nil {
-		utils.BadRequest(w, "Invalid month format. Use YYYY-MM")
+		utils.BadRequest(w, "Invalid date format. Use YYYY-MM-DD or YYYY-MM")
 		return
 	}
 
-	// Get budget for this month
-	budget, err := h.queries.GetBudgetByMonth(r.Context(), models.GetBudgetByMonthParams{
+	// Get the budget whose period includes the date
+	budget, err := h.queries.GetBudgetForDate(r.Context(), models.GetBudgetForDateParams{
 		UserID: utils.PgUUID(userID),
-		Month:  utils.PgDate(month),
+		Date:   utils.PgDate(date),
 	})
 	if err != nil {
-		utils.NotFound(w, "Budget not found for this month")
+		utils.NotFound(w, "Budget not found for this date")
 		return
 	}
 
@@ -105,35 +143,66 @@ func (h *AnalyticsHandler) GetDashboard(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Build response
-	totalLimit := utils.NumericToFloat64(budget.TotalLimit)
-	totalSpent := toFloat64(spending.TotalSpent)
+	totalLimit := money.FromNumeric(budget.TotalLimit)
+	totalSpent, err := money.FromAny(spending.TotalSpent)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch dashboard data")
+		return
+	}
+	totalIncome, err := money.FromAny(spending.TotalIncome)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch dashboard data")
+		return
+	}
 	transactionCount := int(spending.TransactionCount)
 
+	// Expected income over the budget's period and how much of it the budget's
+	// categories have been given
+	period := budgetPeriod(budget)
+	plan, err := loadPeriodIncomePlan(r.Context(), h.queries, userID, period, map[string]money.Amount{"": totalIncome})
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch income sources")
+		return
+	}
+	allocated, err := budgetAllocated(r.Context(), h.queries, budget.ID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch budget categories")
+		return
+	}
+	allocation := income.Allocate(plan, allocated)
+
 	summary := DashboardSummary{
-		Month:             monthStr,
+		Month:             period.Start.Format("2006-01"),
+		PeriodType:        budget.PeriodType,
+		PeriodStart:       period.Start.Format("2006-01-02"),
+		PeriodEnd:         period.Last().Format("2006-01-02"),
 		TotalBudget:       totalLimit,
 		TotalSpent:        totalSpent,
 		Remaining:         totalLimit - totalSpent,
-		BudgetUsedPercent: 0,
+		BudgetUsedPercent: money.Percent(totalSpent, totalLimit),
+		TotalIncome:       totalIncome,
+		ExpectedIncome:    plan.Expected,
+		IncomeVariance:    plan.Variance,
+		SavingsRate:       income.SavingsRate(totalIncome, totalSpent),
+		Allocated:         allocation.Allocated,
+		LeftToBudget:      allocation.LeftToBudget,
+		BudgetStatus:      allocation.Status,
 		TransactionCount:  int32(transactionCount),
 	}
-	if totalLimit > 0 {
-		summary.BudgetUsedPercent = (totalSpent / totalLimit) * 100
-	}
 
 	// Build category spending
 	summary.TopCategories = make([]CategorySpending, len(categorySpending))
 	for i, cat := range categorySpending {
-		amount := toFloat64(cat.TotalSpent)
-		percent := 0.0
-		if totalSpent > 0 {
-			percent = (amount / totalSpent) * 100
+		amount, err := money.FromAny(cat.TotalSpent)
+		if err != nil {
+			utils.InternalError(w, "Failed to fetch category spending")
+			return
 		}
 		summary.TopCategories[i] = CategorySpending{
 			CategoryID:   cat.ID,
 			CategoryName: cat.Name,
 			Amount:       amount,
-			Percent:      percent,
+			Percent:      money.Percent(amount, totalSpent),
 		}
 	}
 
@@ -142,17 +211,22 @@ func (h *AnalyticsHandler) GetDashboard(w http.ResponseWriter, r *http.Request)
 	for i, t := range recent {
 		summary.RecentTransactions[i] = TransactionSummary{
 			ID:          t.ID,
-			Amount:      utils.NumericToFloat64(t.Amount),
+			Amount:      money.FromNumeric(t.HomeAmount),
 			Description: utils.TextToString(t.Description),
 			Date:        utils.DateToTime(t.TransactionDate).Format("2006-01-02"),
 			Category:    utils.TextToString(t.CategoryName),
 		}
+		if t.Currency != auth.GetCurrency(r) {
+			original := money.FromNumeric(t.Amount)
+			summary.RecentTransactions[i].OriginalAmount = &original
+			summary.RecentTransactions[i].Currency = t.Currency
+		}
 	}
 
 	utils.SendSuccess(w, summary)
 }
 
-// GetSpendingReport returns a detailed spending report for a month
+// GetSpendingReport returns a detailed spending report for the budget period including a date
 func (h *AnalyticsHandler) GetSpendingReport(w http.ResponseWriter, r *http.Request) {
 	userID, ok := auth.GetUserID(r)
 	if !ok {
@@ -160,25 +234,25 @@ func (h *AnalyticsHandler) GetSpendingReport(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	monthStr := r.PathValue("month")
-	if monthStr == "" {
-		monthStr = time.Now().Format("2006-01")
+	dateStr := r.PathValue("date")
+	if dateStr == "" {
+		dateStr = time.Now().Format("2006-01-02")
 	}
 
-	// Parse month
-	month, err := time.Parse("2006-01", monthStr)
+	// Parse date, or month for its first day
+	date, err := parseBudgetDate(dateStr)
 	if err != nil {
-		utils.BadRequest(w, "Invalid month format. Use YYYY-MM")
+		utils.BadRequest(w, "Invalid date format. Use YYYY-MM-DD or YYYY-MM")
 		return
 	}
 
-	// Get budget for this month
-	budget, err := h.queries.GetBudgetByMonth(r.Context(), models.GetBudgetByMonthParams{
+	// Get the budget whose period includes the date
+	budget, err := h.queries.GetBudgetForDate(r.Context(), models.GetBudgetForDateParams{
 		UserID: utils.PgUUID(userID),
-		Month:  utils.PgDate(month),
+		Date:   utils.PgDate(date),
 	})
 	if err != nil {
-		utils.NotFound(w, "Budget not found for this month")
+		utils.NotFound(w, "Budget not found for this date")
 		return
 	}
 
@@ -189,7 +263,24 @@ func (h *AnalyticsHandler) GetSpendingReport(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	utils.SendSuccess(w, categorySpending)
+	report := make([]SpendingReportItem, len(categorySpending))
+	for i, cat := range categorySpending {
+		totalSpent, err := money.FromAny(cat.TotalSpent)
+		if err != nil {
+			utils.InternalError(w, "Failed to fetch spending report")
+			return
+		}
+		report[i] = SpendingReportItem{
+			ID:         cat.ID,
+			Name:       cat.Name,
+			Icon:       utils.TextToStringPtr(cat.Icon),
+			Color:      utils.TextToStringPtr(cat.Color),
+			TotalSpent: totalSpent,
+			Percentage: cat.Percentage,
+		}
+	}
+
+	utils.SendSuccess(w, report)
 }
 
 // GetTrends returns historical spending trends
@@ -208,21 +299,31 @@ func (h *AnalyticsHandler) GetTrends(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Calculate date range (last N months)
-	endDate := time.Now().AddDate(0, 1, 0).Truncate(time.Hour * 24)
-	startDate := endDate.AddDate(0, -months, 0)
+	// Calculate month range (last N months, plus next month for scheduled transactions)
+	now := time.Now()
+	endMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	startMonth := endMonth.AddDate(0, -months, 0)
 
 	trends, err := h.queries.GetSpendingTrends(r.Context(), models.GetSpendingTrendsParams{
-		UserID:          utils.PgUUID(userID),
-		TransactionDate: utils.PgDate(startDate),
-		TransactionDate_2: utils.PgDate(endDate),
+		UserID:     utils.PgUUID(userID),
+		StartMonth: utils.PgDate(startMonth),
+		EndMonth:   utils.PgDate(endMonth),
 	})
 	if err != nil {
 		utils.InternalError(w, "Failed to fetch trends")
 		return
 	}
 
-	utils.SendSuccess(w, trends)
+	points := make([]TrendPoint, len(trends))
+	for i, t := range trends {
+		points[i] = TrendPoint{
+			Month:    utils.DateToTime(t.Month).Format("2006-01"),
+			Expenses: money.FromNumeric(t.Expenses),
+			Income:   money.FromNumeric(t.Income),
+		}
+	}
+
+	utils.SendSuccess(w, points)
 }
 
 // GetCategoryReport returns a detailed report for a specific category
@@ -265,23 +366,19 @@ func (h *AnalyticsHandler) GetCategoryReport(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	utils.SendSuccess(w, report)
-}
-
-// Helper functions
-func toFloat64(v interface{}) float64 {
-	switch val := v.(type) {
-	case int64:
-		return float64(val)
-	case float64:
-		return val
-	case int32:
-		return float64(val)
-	default:
-		return 0
+	points := make([]CategoryReportPoint, len(report))
+	for i, day := range report {
+		points[i] = CategoryReportPoint{
+			Date:             utils.DateToTime(day.Date).Format("2006-01-02"),
+			Total:            money.FromNumeric(day.Total),
+			TransactionCount: day.TransactionCount,
+		}
 	}
+
+	utils.SendSuccess(w, points)
 }
 
+// Helper functions
 func parseMonthInt(s string) (int, error) {
 	var i int
 	_, err := fmt.Sscanf(s, "%d", &i)
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// APITokenHandler handles personal API token requests
+type APITokenHandler struct {
+	queries *models.Queries
+}
+
+// NewAPITokenHandler creates a new API token handler
+func NewAPITokenHandler(queries *models.Queries) *APITokenHandler {
+	return &APITokenHandler{queries: queries}
+}
+
+// APITokenResponse represents an API token in API responses (never includes the secret)
+type APITokenResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expiresAt,omitempty"`
+	LastUsedAt *string  `json:"lastUsedAt,omitempty"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+// CreatedAPITokenResponse includes the plaintext token, returned only once on creation
+type CreatedAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
+
+// CreateAPITokenRequest represents the create API token request
+type CreateAPITokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// ListAPITokens returns the current user's active API tokens
+func (h *APITokenHandler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	tokens, err := h.queries.ListAPITokensByUser(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch API tokens")
+		return
+	}
+
+	response := make([]APITokenResponse, len(tokens))
+	for i, t := range tokens {
+		response[i] = apiTokenToResponse(t)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// CreateAPIToken creates a named, scoped API token and returns it once
+func (h *APITokenHandler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		utils.BadRequest(w, "Name is required and must be at most 100 characters")
+		return
+	}
+
+	if len(req.Scopes) == 0 {
+		utils.BadRequest(w, "At least one scope is required")
+		return
+	}
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !auth.IsValidScope(scope) {
+			utils.BadRequest(w, fmt.Sprintf("Invalid scope %q. Must be one of: %s", scope, strings.Join(auth.Scopes, ", ")))
+			return
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			utils.BadRequest(w, "Expiry must be in the future")
+			return
+		}
+		expiresAt = utils.PgTimestamptz(*req.ExpiresAt)
+	}
+
+	token, prefix, hash, err := auth.GenerateAPIToken()
+	if err != nil {
+		utils.InternalError(w, "Failed to generate API token")
+		return
+	}
+
+	created, err := h.queries.CreateAPIToken(r.Context(), models.CreateAPITokenParams{
+		UserID:      userID,
+		Name:        req.Name,
+		TokenPrefix: prefix,
+		TokenHash:   hash,
+		Scopes:      scopes,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to create API token")
+		return
+	}
+
+	utils.SendCreated(w, CreatedAPITokenResponse{
+		APITokenResponse: apiTokenToResponse(created),
+		Token:            token,
+	})
+}
+
+// RevokeAPIToken revokes one of the current user's API tokens
+func (h *APITokenHandler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	tokenID := r.PathValue("id")
+	if tokenID == "" {
+		utils.BadRequest(w, "Token ID is required")
+		return
+	}
+
+	rows, err := h.queries.RevokeAPIToken(r.Context(), models.RevokeAPITokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to revoke API token")
+		return
+	}
+	if rows == 0 {
+		utils.NotFound(w, "API token not found")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "API token revoked successfully",
+	})
+}
+
+// Helper functions
+
+func apiTokenToResponse(t models.ApiToken) APITokenResponse {
+	resp := APITokenResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Prefix:    t.TokenPrefix,
+		Scopes:    t.Scopes,
+		CreatedAt: t.CreatedAt.Time.Format(time.RFC3339),
+	}
+	if resp.Scopes == nil {
+		resp.Scopes = []string{}
+	}
+	if t.ExpiresAt.Valid {
+		s := t.ExpiresAt.Time.Format(time.RFC3339)
+		resp.ExpiresAt = &s
+	}
+	if t.LastUsedAt.Valid {
+		s := t.LastUsedAt.Time.Format(time.RFC3339)
+		resp.LastUsedAt = &s
+	}
+	return resp
+}
@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/networth"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// AssetHandler handles tracked asset and valuation requests
+type AssetHandler struct {
+	queries *models.Queries
+	pool    *pgxpool.Pool
+}
+
+// NewAssetHandler creates a new asset handler
+func NewAssetHandler(queries *models.Queries, pool *pgxpool.Pool) *AssetHandler {
+	return &AssetHandler{queries: queries, pool: pool}
+}
+
+// AssetResponse represents an asset and its latest valuation in API responses
+type AssetResponse struct {
+	ID           string                   `json:"id"`
+	Name         string                   `json:"name"`
+	AssetClass   string                   `json:"assetClass"`
+	Notes        *string                  `json:"notes,omitempty"`
+	CurrentValue *money.Amount            `json:"currentValue"` // null until the asset is valued
+	ValuedOn     *string                  `json:"valuedOn"`
+	Valuations   []AssetValuationResponse `json:"valuations,omitempty"`
+	CreatedAt    string                   `json:"createdAt"`
+	UpdatedAt    string                   `json:"updatedAt"`
+}
+
+// AssetValuationResponse represents what an asset was worth on a date
+type AssetValuationResponse struct {
+	ID       string       `json:"id"`
+	Value    money.Amount `json:"value"`
+	ValuedOn string       `json:"valuedOn"`
+}
+
+// CreateAssetRequest represents the create asset request
+type CreateAssetRequest struct {
+	Name       string        `json:"name"`
+	AssetClass string        `json:"assetClass"`
+	Notes      *string       `json:"notes,omitempty"`
+	Value      *money.Amount `json:"value,omitempty"`    // the first valuation, in the home currency
+	ValuedOn   *string       `json:"valuedOn,omitempty"` // defaults to today
+}
+
+// UpdateAssetRequest represents the update asset request. Values change through valuations.
+type UpdateAssetRequest struct {
+	Name       *string `json:"name,omitempty"`
+	AssetClass *string `json:"assetClass,omitempty"`
+	Notes      *string `json:"notes,omitempty"`
+}
+
+// AssetValuationRequest represents the add valuation request. A valuation on a date
+// that already has one replaces it.
+type AssetValuationRequest struct {
+	Value    money.Amount `json:"value"`              // in the home currency
+	ValuedOn *string      `json:"valuedOn,omitempty"` // defaults to today
+}
+
+// ListAssets returns the current user's assets with their latest valuations
+func (h *AssetHandler) ListAssets(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	assets, err := h.queries.ListAssets(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch assets")
+		return
+	}
+
+	response := make([]AssetResponse, len(assets))
+	for i, a := range assets {
+		response[i] = assetToResponse(models.Asset{
+			ID:         a.ID,
+			UserID:     a.UserID,
+			Name:       a.Name,
+			AssetClass: a.AssetClass,
+			Notes:      a.Notes,
+			CreatedAt:  a.CreatedAt,
+			UpdatedAt:  a.UpdatedAt,
+			Deleted:    a.Deleted,
+		}, nil)
+		if a.ValuedOn.Valid {
+			value := money.FromNumeric(a.CurrentValue)
+			valuedOn := utils.DateToTime(a.ValuedOn).Format("2006-01-02")
+			response[i].CurrentValue = &value
+			response[i].ValuedOn = &valuedOn
+		}
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// GetAsset returns an asset with its valuation history, newest first
+func (h *AssetHandler) GetAsset(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	asset, ok := h.ownAsset(w, r, userID)
+	if !ok {
+		return
+	}
+
+	valuations, err := h.queries.ListAssetValuations(r.Context(), asset.ID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch valuations")
+		return
+	}
+
+	response := assetToResponse(asset, valuations)
+	response.Valuations = make([]AssetValuationResponse, len(valuations))
+	for i, v := range valuations {
+		response.Valuations[i] = valuationToResponse(v)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// CreateAsset creates an asset, with its first valuation if a value is given
+func (h *AssetHandler) CreateAsset(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var req CreateAssetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		utils.BadRequest(w, "Name is required and must be at most 100 characters")
+		return
+	}
+	if req.AssetClass == "" {
+		req.AssetClass = networth.ClassOther
+	}
+	if !validateAssetClass(w, &req.AssetClass) {
+		return
+	}
+	var valuedOn time.Time
+	if req.Value != nil {
+		if valuedOn, ok = validateValuation(w, r, *req.Value, req.ValuedOn); !ok {
+			return
+		}
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		utils.InternalError(w, "Failed to create asset")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := h.queries.WithTx(tx)
+
+	asset, err := qtx.CreateAsset(r.Context(), models.CreateAssetParams{
+		UserID:     userID,
+		Name:       req.Name,
+		AssetClass: req.AssetClass,
+		Notes:      utils.PgTextPtr(req.Notes),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to create asset")
+		return
+	}
+	var valuations []models.AssetValuation
+	if req.Value != nil {
+		valuation, err := qtx.UpsertAssetValuation(r.Context(), models.UpsertAssetValuationParams{
+			AssetID:  asset.ID,
+			Value:    req.Value.Numeric(),
+			ValuedOn: utils.PgDate(valuedOn),
+		})
+		if err != nil {
+			utils.InternalError(w, "Failed to create asset")
+			return
+		}
+		valuations = append(valuations, valuation)
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to create asset")
+		return
+	}
+
+	response := assetToResponse(asset, valuations)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "asset.created",
+		ResourceType: activity.ResourceAsset,
+		ResourceID:   asset.ID,
+		After:        response,
+	})
+
+	utils.SendCreated(w, response)
+}
+
+// UpdateAsset updates an asset's name, class or notes
+func (h *AssetHandler) UpdateAsset(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	before, ok := h.ownAsset(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req UpdateAssetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	var name *string
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		if trimmed == "" || len(trimmed) > 100 {
+			utils.BadRequest(w, "Name must be between 1 and 100 characters")
+			return
+		}
+		name = &trimmed
+	}
+	if !validateAssetClass(w, req.AssetClass) {
+		return
+	}
+
+	asset, err := h.queries.UpdateAsset(r.Context(), models.UpdateAssetParams{
+		ID:         before.ID,
+		Name:       utils.PgTextPtr(name),
+		AssetClass: utils.PgTextPtr(req.AssetClass),
+		Notes:      utils.PgTextPtr(req.Notes),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to update asset")
+		return
+	}
+
+	valuations, err := h.queries.ListAssetValuations(r.Context(), asset.ID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch valuations")
+		return
+	}
+
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "asset.updated",
+		ResourceType: activity.ResourceAsset,
+		ResourceID:   asset.ID,
+		Before:       assetToResponse(before, nil),
+		After:        assetToResponse(asset, nil),
+	})
+
+	utils.SendSuccess(w, assetToResponse(asset, valuations))
+}
+
+// DeleteAsset soft deletes an asset
+func (h *AssetHandler) DeleteAsset(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	before, ok := h.ownAsset(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.queries.DeleteAsset(r.Context(), before.ID); err != nil {
+		utils.InternalError(w, "Failed to delete asset")
+		return
+	}
+
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "asset.deleted",
+		ResourceType: activity.ResourceAsset,
+		ResourceID:   before.ID,
+		Before:       assetToResponse(before, nil),
+	})
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Asset deleted successfully",
+	})
+}
+
+// AddValuation records what an asset is worth on a date
+func (h *AssetHandler) AddValuation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	asset, ok := h.ownAsset(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req AssetValuationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+	valuedOn, ok := validateValuation(w, r, req.Value, req.ValuedOn)
+	if !ok {
+		return
+	}
+
+	valuation, err := h.queries.UpsertAssetValuation(r.Context(), models.UpsertAssetValuationParams{
+		AssetID:  asset.ID,
+		Value:    req.Value.Numeric(),
+		ValuedOn: utils.PgDate(valuedOn),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to add valuation")
+		return
+	}
+
+	response := valuationToResponse(valuation)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "asset.valued",
+		ResourceType: activity.ResourceAsset,
+		ResourceID:   asset.ID,
+		Details: map[string]interface{}{
+			"value":    response.Value,
+			"valuedOn": response.ValuedOn,
+		},
+	})
+
+	utils.SendCreated(w, response)
+}
+
+// DeleteValuation removes one of an asset's valuations
+func (h *AssetHandler) DeleteValuation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	asset, ok := h.ownAsset(w, r, userID)
+	if !ok {
+		return
+	}
+
+	rows, err := h.queries.DeleteAssetValuation(r.Context(), models.DeleteAssetValuationParams{
+		ID:      r.PathValue("valuationId"),
+		AssetID: asset.ID,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to delete valuation")
+		return
+	}
+	if rows == 0 {
+		utils.NotFound(w, "Valuation not found")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Valuation deleted successfully",
+	})
+}
+
+// Helper functions
+
+// ownAsset loads the asset in the path, responding with 404 unless it belongs to userID
+func (h *AssetHandler) ownAsset(w http.ResponseWriter, r *http.Request, userID string) (models.Asset, bool) {
+	assetID := r.PathValue("id")
+	if assetID == "" {
+		utils.BadRequest(w, "Asset ID is required")
+		return models.Asset{}, false
+	}
+
+	asset, err := h.queries.GetAssetByID(r.Context(), assetID)
+	if err != nil || asset.UserID != userID {
+		utils.NotFound(w, "Asset not found")
+		return models.Asset{}, false
+	}
+	return asset, true
+}
+
+// validateAssetClass responds with 400 if class is set and isn't an asset class
+func validateAssetClass(w http.ResponseWriter, class *string) bool {
+	if class == nil {
+		return true
+	}
+	for _, c := range networth.AssetClasses {
+		if *class == c {
+			return true
+		}
+	}
+	utils.BadRequest(w, "Invalid asset class. Use cash, property, vehicle, investment or other")
+	return false
+}
+
+// validateValuation checks a valuation's value and date, responding with 400 if
+// either is invalid. The date defaults to today.
+func validateValuation(w http.ResponseWriter, r *http.Request, value money.Amount, date *string) (time.Time, bool) {
+	if value < 0 {
+		utils.BadRequest(w, "Value must not be negative")
+		return time.Time{}, false
+	}
+	if !checkAmounts(w, r, &value) {
+		return time.Time{}, false
+	}
+	valuedOn := time.Now()
+	if parsed, ok := parseOptionalDate(w, date, "valuation date"); !ok {
+		return time.Time{}, false
+	} else if parsed != nil {
+		if parsed.After(time.Now()) {
+			utils.BadRequest(w, "Valuation date must not be in the future")
+			return time.Time{}, false
+		}
+		valuedOn = *parsed
+	}
+	return valuedOn, true
+}
+
+// assetToResponse converts an asset, taking its current value from the newest of
+// valuations if there are any
+func assetToResponse(a models.Asset, valuations []models.AssetValuation) AssetResponse {
+	resp := AssetResponse{
+		ID:         a.ID,
+		Name:       a.Name,
+		AssetClass: a.AssetClass,
+		Notes:      utils.TextToStringPtr(a.Notes),
+		CreatedAt:  utils.TimestamptzToTime(a.CreatedAt).Format(time.RFC3339),
+		UpdatedAt:  utils.TimestamptzToTime(a.UpdatedAt).Format(time.RFC3339),
+	}
+	var latest *models.AssetValuation
+	for i, v := range valuations {
+		if latest == nil || utils.DateToTime(v.ValuedOn).After(utils.DateToTime(latest.ValuedOn)) {
+			latest = &valuations[i]
+		}
+	}
+	if latest != nil {
+		value := money.FromNumeric(latest.Value)
+		valuedOn := utils.DateToTime(latest.ValuedOn).Format("2006-01-02")
+		resp.CurrentValue = &value
+		resp.ValuedOn = &valuedOn
+	}
+	return resp
+}
+
+func valuationToResponse(v models.AssetValuation) AssetValuationResponse {
+	return AssetValuationResponse{
+		ID:       v.ID,
+		Value:    money.FromNumeric(v.Value),
+		ValuedOn: utils.DateToTime(v.ValuedOn).Format("2006-01-02"),
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/joselitophala/budget-planner-backend/internal/auth"
@@ -50,21 +51,20 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify the JWT token
-	clerkUserID, err := h.jwt.VerifyToken(req.Token)
+	claims, err := h.jwt.VerifyClaims(r.Context(), req.Token)
 	if err != nil {
 		utils.Unauthorized(w, "Invalid token")
 		return
 	}
 
-	// Look up user in our database
-	user, err := h.queries.GetUserByClerkID(r.Context(), clerkUserID)
+	// Look up the user, creating them on first sign-in
+	user, err := auth.ProvisionUser(r.Context(), h.queries, auth.IdentityFromClaims(claims))
 	if err != nil {
-		// User exists in auth system but not in our database
-		// They need to complete onboarding
-		utils.SendJSON(w, http.StatusAccepted, map[string]interface{}{
-			"needsOnboarding": true,
-			"clerkUserID":     clerkUserID,
-		})
+		if errors.Is(err, auth.ErrUserDeleted) {
+			utils.Forbidden(w, "User account has been deleted")
+			return
+		}
+		utils.InternalError(w, "Failed to load user")
 		return
 	}
 
@@ -94,11 +94,45 @@ func (h *AuthHandler) CompleteOnboarding(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Users are normally provisioned on their first authenticated request;
+	// onboarding then just fills in their profile, which requires their token
+	existing, err := h.queries.GetUserByClerkID(r.Context(), req.ClerkUserID)
+	if err == nil {
+		token, err := auth.ExtractTokenFromRequest(r)
+		if err != nil {
+			utils.Conflict(w, "User already exists")
+			return
+		}
+		clerkUserID, err := h.jwt.VerifyToken(r.Context(), token)
+		if err != nil || clerkUserID != existing.ClerkUserID {
+			utils.Unauthorized(w, "Invalid token")
+			return
+		}
+
+		user, err := h.queries.UpdateUser(r.Context(), models.UpdateUserParams{
+			ID:       existing.ID,
+			Name:     utils.PgText(req.Name),
+			Currency: utils.PgText(req.Currency),
+		})
+		if err != nil {
+			utils.InternalError(w, "Failed to update user")
+			return
+		}
+
+		utils.SendSuccess(w, UserResponse{
+			ID:       user.ID,
+			Email:    user.Email,
+			Name:     utils.TextToString(user.Name),
+			Currency: utils.TextToString(user.Currency),
+		})
+		return
+	}
+
 	// Create user in our database
-	// For now, we'll use a placeholder email since we don't have Clerk to fetch it
+	// The real email is filled in by the Clerk webhook
 	user, err := h.queries.CreateUser(r.Context(), models.CreateUserParams{
 		ClerkUserID: req.ClerkUserID,
-		Email:       req.ClerkUserID + "@placeholder.local", // Placeholder
+		Email:       auth.PlaceholderEmail(req.ClerkUserID),
 		Name:        utils.PgText(req.Name),
 		Currency:    utils.PgText(req.Currency),
 	})
@@ -3,12 +3,19 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
 	"github.com/joselitophala/budget-planner-backend/internal/auth"
 	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/periods"
 	"github.com/joselitophala/budget-planner-backend/internal/utils"
 )
 
@@ -24,52 +31,76 @@ func NewBudgetHandler(queries *models.Queries) *BudgetHandler {
 
 // BudgetResponse represents a budget in API responses
 type BudgetResponse struct {
-	ID         string  `json:"id"`
-	UserID     string  `json:"userId"`
-	Name       *string `json:"name,omitempty"`
-	Month      string  `json:"month"`
-	TotalLimit float64 `json:"totalLimit"`
-	Spent      float64 `json:"spent"`
-	Remaining  float64 `json:"remaining"`
-	CreatedAt  string  `json:"createdAt"`
-	UpdatedAt  string  `json:"updatedAt"`
+	ID         string       `json:"id"`
+	UserID     string       `json:"userId"`
+	Name       *string      `json:"name,omitempty"`
+	Month      string       `json:"month"`
+	TotalLimit money.Amount `json:"totalLimit"`
+	Spent      money.Amount `json:"spent"`
+	Remaining  money.Amount `json:"remaining"`
+	CreatedAt  string       `json:"createdAt"`
+	UpdatedAt  string       `json:"updatedAt"`
+
+	// Month is the first day of the budget period and PeriodEnd its last
+	PeriodType string `json:"periodType"`
+	PeriodEnd  string `json:"periodEnd"`
+
+	// EnvelopeMode assigns income to category envelopes; StrictEnvelopes rejects
+	// expenses and moves that would overdraw one
+	EnvelopeMode    bool `json:"envelopeMode"`
+	StrictEnvelopes bool `json:"strictEnvelopes"`
+
+	// Goals lists the owner's savings goals for the budget month; only the owner sees them
+	Goals []BudgetGoalLine `json:"goals,omitempty"`
 }
 
 // BudgetCategoryResponse represents a budget category in API responses
 type BudgetCategoryResponse struct {
-	ID          string  `json:"id"`
-	BudgetID    string  `json:"budgetId"`
-	CategoryID  string  `json:"categoryId"`
-	Name        string  `json:"name"`
-	Icon        *string `json:"icon,omitempty"`
-	Color       string  `json:"color"`
-	LimitAmount float64 `json:"limitAmount"`
-	Spent       float64 `json:"spent"`
-	Remaining   float64 `json:"remaining"`
+	ID          string       `json:"id"`
+	BudgetID    string       `json:"budgetId"`
+	CategoryID  string       `json:"categoryId"`
+	Name        string       `json:"name"`
+	Icon        *string      `json:"icon,omitempty"`
+	Color       string       `json:"color"`
+	LimitAmount money.Amount `json:"limitAmount"`
+	Spent       money.Amount `json:"spent"`
+	Remaining   money.Amount `json:"remaining"`
 }
 
 // CreateBudgetRequest represents the create budget request
 type CreateBudgetRequest struct {
-	Name       string  `json:"name"`
-	Month      string  `json:"month"` // Format: YYYY-MM-DD (first day of month)
-	TotalLimit float64 `json:"totalLimit"`
+	Name       string       `json:"name"`
+	Month      string       `json:"month"` // Format: YYYY-MM-DD (first day of the period)
+	TotalLimit money.Amount `json:"totalLimit"`
+
+	// PeriodType defaults to monthly. A semi-monthly period runs to the next of two
+	// paydays, and a custom one to EndDate (YYYY-MM-DD, inclusive). A monthly period
+	// takes one payday, defaulting to the one of the monthly budget it follows.
+	PeriodType string  `json:"periodType,omitempty"`
+	EndDate    *string `json:"endDate,omitempty"`
+	Paydays    []int   `json:"paydays,omitempty"`
+
+	EnvelopeMode    bool `json:"envelopeMode,omitempty"`
+	StrictEnvelopes bool `json:"strictEnvelopes,omitempty"`
 }
 
 // UpdateBudgetRequest represents the update budget request
 type UpdateBudgetRequest struct {
-	Name       *string  `json:"name,omitempty"`
-	TotalLimit *float64 `json:"totalLimit,omitempty"`
+	Name            *string       `json:"name,omitempty"`
+	TotalLimit      *money.Amount `json:"totalLimit,omitempty"`
+	EnvelopeMode    *bool         `json:"envelopeMode,omitempty"`
+	StrictEnvelopes *bool         `json:"strictEnvelopes,omitempty"`
 }
 
 // AddBudgetCategoryRequest represents the request to add a category to a budget
 type AddBudgetCategoryRequest struct {
-	CategoryID  string  `json:"categoryId"`
-	LimitAmount float64 `json:"limitAmount"`
+	CategoryID  string       `json:"categoryId"`
+	LimitAmount money.Amount `json:"limitAmount"`
 }
 
 // UpdateBudgetCategoryRequest represents the request to update a budget category
 type UpdateBudgetCategoryRequest struct {
-	LimitAmount *float64 `json:"limitAmount,omitempty"`
+	LimitAmount *money.Amount `json:"limitAmount,omitempty"`
 }
 
 // ListBudgets returns all budgets for the current user
@@ -89,7 +120,7 @@ func (h *BudgetHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
 	response := make([]BudgetResponse, len(budgets))
 	for i, budget := range budgets {
 		spent, _ := h.getBudgetSpent(r.Context(), budget.ID)
-		totalLimit := utils.NumericToFloat64(budget.TotalLimit)
+		totalLimit := money.FromNumeric(budget.TotalLimit)
 		name := utils.TextToStringPtr(budget.Name)
 		response[i] = BudgetResponse{
 			ID:         budget.ID,
@@ -101,45 +132,52 @@ func (h *BudgetHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
 			Remaining:  totalLimit - spent,
 			CreatedAt:  utils.TimestamptzToTime(budget.CreatedAt).Format(time.RFC3339),
 			UpdatedAt:  utils.TimestamptzToTime(budget.UpdatedAt).Format(time.RFC3339),
+
+			PeriodType: budget.PeriodType,
+			PeriodEnd:  budgetPeriod(budget).Last().Format("2006-01-02"),
+
+			EnvelopeMode:    budget.EnvelopeMode,
+			StrictEnvelopes: budget.StrictEnvelopes,
 		}
 	}
 
 	utils.SendSuccess(w, response)
 }
 
-// GetBudgetByMonth returns a budget for a specific month
-func (h *BudgetHandler) GetBudgetByMonth(w http.ResponseWriter, r *http.Request) {
+// GetBudgetForDate returns the budget whose period includes a date. A month
+// (YYYY-MM) stands for its first day.
+func (h *BudgetHandler) GetBudgetForDate(w http.ResponseWriter, r *http.Request) {
 	userID, ok := auth.GetUserID(r)
 	if !ok {
 		utils.Unauthorized(w, "Not authenticated")
 		return
 	}
 
-	monthStr := r.PathValue("month")
-	if monthStr == "" {
-		utils.BadRequest(w, "Month is required (format: YYYY-MM)")
+	dateStr := r.PathValue("date")
+	if dateStr == "" {
+		utils.BadRequest(w, "Date is required (format: YYYY-MM-DD or YYYY-MM)")
 		return
 	}
 
-	// Parse month string to date (first day of month)
-	month, err := time.Parse("2006-01", monthStr)
+	date, err := parseBudgetDate(dateStr)
 	if err != nil {
-		utils.BadRequest(w, "Invalid month format. Use YYYY-MM")
+		utils.BadRequest(w, "Invalid date format. Use YYYY-MM-DD or YYYY-MM")
 		return
 	}
 
-	budget, err := h.queries.GetBudgetByMonth(r.Context(), models.GetBudgetByMonthParams{
+	budget, err := h.queries.GetBudgetForDate(r.Context(), models.GetBudgetForDateParams{
 		UserID: utils.PgUUID(userID),
-		Month:  utils.PgDate(month),
+		Date:   utils.PgDate(date),
 	})
 	if err != nil {
-		utils.NotFound(w, "Budget not found for this month")
+		utils.NotFound(w, "Budget not found for this date")
 		return
 	}
 
 	spent, _ := h.getBudgetSpent(r.Context(), budget.ID)
-	totalLimit := utils.NumericToFloat64(budget.TotalLimit)
+	totalLimit := money.FromNumeric(budget.TotalLimit)
 	name := utils.TextToStringPtr(budget.Name)
+	goalLines, _ := budgetGoalLines(r.Context(), h.queries, userID, utils.DateToTime(budget.Month))
 
 	utils.SendSuccess(w, BudgetResponse{
 		ID:         budget.ID,
@@ -151,6 +189,13 @@ func (h *BudgetHandler) GetBudgetByMonth(w http.ResponseWriter, r *http.Request)
 		Remaining:  totalLimit - spent,
 		CreatedAt:  utils.TimestamptzToTime(budget.CreatedAt).Format(time.RFC3339),
 		UpdatedAt:  utils.TimestamptzToTime(budget.UpdatedAt).Format(time.RFC3339),
+		Goals:      goalLines,
+
+		PeriodType: budget.PeriodType,
+		PeriodEnd:  budgetPeriod(budget).Last().Format("2006-01-02"),
+
+		EnvelopeMode:    budget.EnvelopeMode,
+		StrictEnvelopes: budget.StrictEnvelopes,
 	})
 }
 
@@ -169,10 +214,16 @@ func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
 	}
 
 	spent, _ := h.getBudgetSpent(r.Context(), budget.ID)
-	totalLimit := utils.NumericToFloat64(budget.TotalLimit)
+	totalLimit := money.FromNumeric(budget.TotalLimit)
 	name := utils.TextToStringPtr(budget.Name)
 	userID := utils.UUIDToString(budget.UserID)
 
+	// Savings goals are personal, so members of a shared budget don't see the owner's
+	var goalLines []BudgetGoalLine
+	if requester, ok := auth.GetUserID(r); ok && utils.UUIDEquals(budget.UserID, requester) {
+		goalLines, _ = budgetGoalLines(r.Context(), h.queries, userID, utils.DateToTime(budget.Month))
+	}
+
 	utils.SendSuccess(w, BudgetResponse{
 		ID:         budget.ID,
 		UserID:     userID,
@@ -183,6 +234,13 @@ func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
 		Remaining:  totalLimit - spent,
 		CreatedAt:  utils.TimestamptzToTime(budget.CreatedAt).Format(time.RFC3339),
 		UpdatedAt:  utils.TimestamptzToTime(budget.UpdatedAt).Format(time.RFC3339),
+		Goals:      goalLines,
+
+		PeriodType: budget.PeriodType,
+		PeriodEnd:  budgetPeriod(budget).Last().Format("2006-01-02"),
+
+		EnvelopeMode:    budget.EnvelopeMode,
+		StrictEnvelopes: budget.StrictEnvelopes,
 	})
 }
 
@@ -196,7 +254,10 @@ func (h *BudgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
 
 	var req CreateBudgetRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
+		return
+	}
+	if !checkAmounts(w, r, &req.TotalLimit) {
 		return
 	}
 
@@ -207,18 +268,64 @@ func (h *BudgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.PeriodType == "" {
+		req.PeriodType = periods.Monthly
+	}
+	var endDate *time.Time
+	if req.EndDate != nil {
+		end, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil {
+			utils.BadRequest(w, "Invalid end date format. Use YYYY-MM-DD")
+			return
+		}
+		endDate = &end
+	}
+	if req.PeriodType == periods.Monthly && req.Paydays == nil {
+		paydays, err := monthlyPayday(r.Context(), h.queries, utils.PgUUID(userID), month)
+		if err != nil {
+			utils.InternalError(w, "Failed to create budget")
+			return
+		}
+		req.Paydays = paydays
+	}
+	period, err := periods.Starting(req.PeriodType, month, req.Paydays, endDate)
+	if err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
 	budget, err := h.queries.CreateBudget(r.Context(), models.CreateBudgetParams{
 		UserID:     utils.PgUUID(userID),
 		Name:       utils.PgText(req.Name),
-		Month:      utils.PgDate(month),
-		TotalLimit: utils.PgNumeric(req.TotalLimit),
+		Month:      utils.PgDate(period.Start),
+		TotalLimit: req.TotalLimit.Numeric(),
+
+		EnvelopeMode:    req.EnvelopeMode,
+		StrictEnvelopes: req.StrictEnvelopes,
+
+		PeriodType: req.PeriodType,
+		PeriodEnd:  utils.PgDate(period.End),
 	})
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23P01" {
+			utils.Conflict(w, "Budget overlaps another budget's period")
+			return
+		}
 		utils.InternalError(w, "Failed to create budget")
 		return
 	}
 
-	totalLimit := utils.NumericToFloat64(budget.TotalLimit)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "budget.created",
+		ResourceType: activity.ResourceBudget,
+		ResourceID:   budget.ID,
+		BudgetID:     budget.ID,
+		After:        budget,
+	})
+
+	totalLimit := money.FromNumeric(budget.TotalLimit)
 	name := utils.TextToStringPtr(budget.Name)
 
 	utils.SendCreated(w, BudgetResponse{
@@ -231,6 +338,12 @@ func (h *BudgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
 		Remaining:  totalLimit,
 		CreatedAt:  utils.TimestamptzToTime(budget.CreatedAt).Format(time.RFC3339),
 		UpdatedAt:  utils.TimestamptzToTime(budget.UpdatedAt).Format(time.RFC3339),
+
+		PeriodType: budget.PeriodType,
+		PeriodEnd:  budgetPeriod(budget).Last().Format("2006-01-02"),
+
+		EnvelopeMode:    budget.EnvelopeMode,
+		StrictEnvelopes: budget.StrictEnvelopes,
 	})
 }
 
@@ -244,22 +357,45 @@ func (h *BudgetHandler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
 
 	var req UpdateBudgetRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
+		return
+	}
+	if !checkAmounts(w, r, req.TotalLimit) {
+		return
+	}
+
+	before, err := h.queries.GetBudgetByID(r.Context(), budgetID)
+	if err != nil {
+		utils.NotFound(w, "Budget not found")
 		return
 	}
 
 	budget, err := h.queries.UpdateBudget(r.Context(), models.UpdateBudgetParams{
 		ID:         budgetID,
 		Name:       utils.PgTextPtr(req.Name),
-		TotalLimit: utils.PgNumericPtr(req.TotalLimit),
+		TotalLimit: money.NumericPtr(req.TotalLimit),
+
+		EnvelopeMode:    pgBoolPtr(req.EnvelopeMode),
+		StrictEnvelopes: pgBoolPtr(req.StrictEnvelopes),
 	})
 	if err != nil {
 		utils.InternalError(w, "Failed to update budget")
 		return
 	}
 
+	actorID, _ := auth.GetUserID(r)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       actorID,
+		Action:       "budget.updated",
+		ResourceType: activity.ResourceBudget,
+		ResourceID:   budget.ID,
+		BudgetID:     budget.ID,
+		Before:       before,
+		After:        budget,
+	})
+
 	spent, _ := h.getBudgetSpent(r.Context(), budget.ID)
-	totalLimit := utils.NumericToFloat64(budget.TotalLimit)
+	totalLimit := money.FromNumeric(budget.TotalLimit)
 	name := utils.TextToStringPtr(budget.Name)
 	userID := utils.UUIDToString(budget.UserID)
 
@@ -273,6 +409,12 @@ func (h *BudgetHandler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
 		Remaining:  totalLimit - spent,
 		CreatedAt:  utils.TimestamptzToTime(budget.CreatedAt).Format(time.RFC3339),
 		UpdatedAt:  utils.TimestamptzToTime(budget.UpdatedAt).Format(time.RFC3339),
+
+		PeriodType: budget.PeriodType,
+		PeriodEnd:  budgetPeriod(budget).Last().Format("2006-01-02"),
+
+		EnvelopeMode:    budget.EnvelopeMode,
+		StrictEnvelopes: budget.StrictEnvelopes,
 	})
 }
 
@@ -284,12 +426,28 @@ func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.queries.DeleteBudget(r.Context(), budgetID)
+	before, err := h.queries.GetBudgetByID(r.Context(), budgetID)
+	if err != nil {
+		utils.NotFound(w, "Budget not found")
+		return
+	}
+
+	err = h.queries.DeleteBudget(r.Context(), budgetID)
 	if err != nil {
 		utils.InternalError(w, "Failed to delete budget")
 		return
 	}
 
+	userID, _ := auth.GetUserID(r)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "budget.deleted",
+		ResourceType: activity.ResourceBudget,
+		ResourceID:   before.ID,
+		BudgetID:     before.ID,
+		Before:       before,
+	})
+
 	utils.SendSuccess(w, map[string]string{
 		"message": "Budget deleted successfully",
 	})
@@ -312,7 +470,7 @@ func (h *BudgetHandler) GetBudgetCategories(w http.ResponseWriter, r *http.Reque
 	response := make([]BudgetCategoryResponse, len(categories))
 	for i, cat := range categories {
 		spent, _ := h.getCategorySpent(r.Context(), budgetID, utils.UUIDToString(cat.CategoryID))
-		limitAmount := utils.NumericToFloat64(cat.LimitAmount)
+		limitAmount := money.FromNumeric(cat.LimitAmount)
 		response[i] = BudgetCategoryResponse{
 			ID:          cat.ID,
 			BudgetID:    utils.UUIDToString(cat.BudgetID),
@@ -339,21 +497,38 @@ func (h *BudgetHandler) AddBudgetCategory(w http.ResponseWriter, r *http.Request
 
 	var req AddBudgetCategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
+		return
+	}
+	if !checkAmounts(w, r, &req.LimitAmount) {
+		return
+	}
+	if req.LimitAmount != 0 && h.inEnvelopeMode(r.Context(), budgetID) {
+		utils.BadRequest(w, "Budget is in envelope mode; move money into the envelope instead")
 		return
 	}
 
 	bc, err := h.queries.AddBudgetCategory(r.Context(), models.AddBudgetCategoryParams{
 		BudgetID:    utils.PgUUID(budgetID),
 		CategoryID:  utils.PgUUID(req.CategoryID),
-		LimitAmount: utils.PgNumeric(req.LimitAmount),
+		LimitAmount: req.LimitAmount.Numeric(),
 	})
 	if err != nil {
 		utils.InternalError(w, "Failed to add category to budget")
 		return
 	}
 
-	limitAmount := utils.NumericToFloat64(bc.LimitAmount)
+	userID, _ := auth.GetUserID(r)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "budget_category.added",
+		ResourceType: activity.ResourceBudgetCategory,
+		ResourceID:   bc.ID,
+		BudgetID:     budgetID,
+		After:        bc,
+	})
+
+	limitAmount := money.FromNumeric(bc.LimitAmount)
 
 	utils.SendCreated(w, BudgetCategoryResponse{
 		ID:          bc.ID,
@@ -375,13 +550,27 @@ func (h *BudgetHandler) UpdateBudgetCategory(w http.ResponseWriter, r *http.Requ
 
 	var req UpdateBudgetCategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
+		return
+	}
+	if !checkAmounts(w, r, req.LimitAmount) {
+		return
+	}
+
+	before, err := h.queries.GetBudgetCategoryByID(r.Context(), categoryID)
+	if err != nil {
+		utils.NotFound(w, "Budget category not found")
+		return
+	}
+	if req.LimitAmount != nil && *req.LimitAmount != money.FromNumeric(before.LimitAmount) &&
+		h.inEnvelopeMode(r.Context(), utils.UUIDToString(before.BudgetID)) {
+		utils.BadRequest(w, "Budget is in envelope mode; move money between envelopes instead")
 		return
 	}
 
 	bc, err := h.queries.UpdateBudgetCategory(r.Context(), models.UpdateBudgetCategoryParams{
 		ID:          categoryID,
-		LimitAmount: utils.PgNumericPtr(req.LimitAmount),
+		LimitAmount: money.NumericPtr(req.LimitAmount),
 	})
 	if err != nil {
 		utils.InternalError(w, "Failed to update budget category")
@@ -389,9 +578,20 @@ func (h *BudgetHandler) UpdateBudgetCategory(w http.ResponseWriter, r *http.Requ
 	}
 
 	budgetID := utils.UUIDToString(bc.BudgetID)
+	userID, _ := auth.GetUserID(r)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "budget_category.updated",
+		ResourceType: activity.ResourceBudgetCategory,
+		ResourceID:   bc.ID,
+		BudgetID:     budgetID,
+		Before:       before,
+		After:        bc,
+	})
+
 	catID := utils.UUIDToString(bc.CategoryID)
 	spent, _ := h.getCategorySpent(r.Context(), budgetID, catID)
-	limitAmount := utils.NumericToFloat64(bc.LimitAmount)
+	limitAmount := money.FromNumeric(bc.LimitAmount)
 
 	utils.SendSuccess(w, BudgetCategoryResponse{
 		ID:          bc.ID,
@@ -411,45 +611,48 @@ func (h *BudgetHandler) RemoveBudgetCategory(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	err := h.queries.RemoveBudgetCategory(r.Context(), categoryID)
+	before, err := h.queries.GetBudgetCategoryByID(r.Context(), categoryID)
+	if err != nil {
+		utils.NotFound(w, "Budget category not found")
+		return
+	}
+	if money.FromNumeric(before.LimitAmount) != 0 && h.inEnvelopeMode(r.Context(), utils.UUIDToString(before.BudgetID)) {
+		utils.BadRequest(w, "Budget is in envelope mode; move the envelope's money out before removing it")
+		return
+	}
+
+	err = h.queries.RemoveBudgetCategory(r.Context(), categoryID)
 	if err != nil {
 		utils.InternalError(w, "Failed to remove category from budget")
 		return
 	}
 
+	userID, _ := auth.GetUserID(r)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "budget_category.removed",
+		ResourceType: activity.ResourceBudgetCategory,
+		ResourceID:   before.ID,
+		BudgetID:     utils.UUIDToString(before.BudgetID),
+		Before:       before,
+	})
+
 	utils.SendSuccess(w, map[string]string{
 		"message": "Category removed from budget successfully",
 	})
 }
 
 // Helper function to get budget spent amount
-func (h *BudgetHandler) getBudgetSpent(ctx context.Context, budgetID string) (float64, error) {
+func (h *BudgetHandler) getBudgetSpent(ctx context.Context, budgetID string) (money.Amount, error) {
 	result, err := h.queries.GetBudgetSpent(ctx, utils.PgUUID(budgetID))
 	if err != nil {
 		return 0, err
 	}
-
-	// GetBudgetSpent returns interface{}, need to handle it
-	if result == nil {
-		return 0, nil
-	}
-
-	// Try to convert to float64
-	switch v := result.(type) {
-	case float64:
-		return v, nil
-	case []byte:
-		// PostgreSQL may return numeric as []byte
-		var f float64
-		_, err := fmt.Sscanf(string(v), "%f", &f)
-		return f, err
-	default:
-		return 0, nil
-	}
+	return money.FromAny(result)
 }
 
 // Helper function to get category spent amount for a budget
-func (h *BudgetHandler) getCategorySpent(ctx context.Context, budgetID, categoryID string) (float64, error) {
+func (h *BudgetHandler) getCategorySpent(ctx context.Context, budgetID, categoryID string) (money.Amount, error) {
 	result, err := h.queries.GetCategorySpent(ctx, models.GetCategorySpentParams{
 		BudgetID:   utils.PgUUID(budgetID),
 		CategoryID: utils.PgUUID(categoryID),
@@ -457,22 +660,47 @@ func (h *BudgetHandler) getCategorySpent(ctx context.Context, budgetID, category
 	if err != nil {
 		return 0, err
 	}
+	return money.FromAny(result)
+}
+
+// Helper function to check whether a budget is in envelope mode, where what its
+// categories are assigned only changes through recorded moves
+func (h *BudgetHandler) inEnvelopeMode(ctx context.Context, budgetID string) bool {
+	budget, err := h.queries.GetBudgetByID(ctx, budgetID)
+	return err == nil && budget.EnvelopeMode
+}
 
-	// GetCategorySpent returns interface{}, need to handle it
-	if result == nil {
-		return 0, nil
+// monthlyPayday returns the payday of the user's monthly budget ending on start, so
+// a budget following one from January 31 to February 28 runs to March 31. It's nil
+// if no monthly budget ends there.
+func monthlyPayday(ctx context.Context, q *models.Queries, userID pgtype.UUID, start time.Time) ([]int, error) {
+	previous, err := q.GetBudgetForDate(ctx, models.GetBudgetForDateParams{
+		UserID: userID,
+		Date:   utils.PgDate(start.AddDate(0, 0, -1)),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	period := budgetPeriod(previous)
+	if previous.PeriodType != periods.Monthly || !period.End.Equal(start) {
+		return nil, nil
 	}
+	return []int{period.Anchor()}, nil
+}
+
+// Helper function to get the period a budget covers
+func budgetPeriod(budget models.Budget) periods.Period {
+	return periods.Period{Start: utils.DateToTime(budget.Month), End: utils.DateToTime(budget.PeriodEnd)}
+}
 
-	// Try to convert to float64
-	switch v := result.(type) {
-	case float64:
-		return v, nil
-	case []byte:
-		// PostgreSQL may return numeric as []byte
-		var f float64
-		_, err := fmt.Sscanf(string(v), "%f", &f)
-		return f, err
-	default:
-		return 0, nil
+// Helper function to parse a date naming a budget: YYYY-MM-DD, or YYYY-MM for the
+// first day of the month
+func parseBudgetDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
 	}
+	return time.Parse("2006-01", s)
 }
@@ -5,8 +5,10 @@ import (
 	"net/http"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
 	"github.com/joselitophala/budget-planner-backend/internal/auth"
 	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
 	"github.com/joselitophala/budget-planner-backend/internal/utils"
 )
 
@@ -22,28 +24,28 @@ func NewCategoryHandler(queries *models.Queries) *CategoryHandler {
 
 // CategoryResponse represents a category in API responses
 type CategoryResponse struct {
-	ID           string   `json:"id"`
-	Name         string   `json:"name"`
-	Icon         *string  `json:"icon,omitempty"`
-	Color        string   `json:"color"`
-	IsSystem     bool     `json:"isSystem"`
-	DefaultLimit *float64 `json:"defaultLimit,omitempty"`
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Icon         *string       `json:"icon,omitempty"`
+	Color        string        `json:"color"`
+	IsSystem     bool          `json:"isSystem"`
+	DefaultLimit *money.Amount `json:"defaultLimit,omitempty"`
 }
 
 // CreateCategoryRequest represents the create category request
 type CreateCategoryRequest struct {
-	Name         string  `json:"name"`
-	Icon         *string `json:"icon,omitempty"`
-	Color        string  `json:"color"`
-	DefaultLimit *float64 `json:"defaultLimit,omitempty"`
+	Name         string        `json:"name"`
+	Icon         *string       `json:"icon,omitempty"`
+	Color        string        `json:"color"`
+	DefaultLimit *money.Amount `json:"defaultLimit,omitempty"`
 }
 
 // UpdateCategoryRequest represents the update category request
 type UpdateCategoryRequest struct {
-	Name         *string `json:"name,omitempty"`
-	Icon         *string `json:"icon,omitempty"`
-	Color        *string `json:"color,omitempty"`
-	DefaultLimit *float64 `json:"defaultLimit,omitempty"`
+	Name         *string       `json:"name,omitempty"`
+	Icon         *string       `json:"icon,omitempty"`
+	Color        *string       `json:"color,omitempty"`
+	DefaultLimit *money.Amount `json:"defaultLimit,omitempty"`
 }
 
 // ListCategories returns all categories for the current user
@@ -68,7 +70,7 @@ func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request)
 			Icon:         utils.TextToStringPtr(cat.Icon),
 			Color:        utils.TextToString(cat.Color),
 			IsSystem:     cat.IsSystem.Valid,
-			DefaultLimit: utils.NumericToFloat64Ptr(cat.DefaultLimit),
+			DefaultLimit: money.FromNumericPtr(cat.DefaultLimit),
 		}
 	}
 
@@ -91,7 +93,7 @@ func (h *CategoryHandler) GetSystemCategories(w http.ResponseWriter, r *http.Req
 			Icon:         utils.TextToStringPtr(cat.Icon),
 			Color:        utils.TextToString(cat.Color),
 			IsSystem:     cat.IsSystem.Valid,
-			DefaultLimit: utils.NumericToFloat64Ptr(cat.DefaultLimit),
+			DefaultLimit: money.FromNumericPtr(cat.DefaultLimit),
 		}
 	}
 
@@ -108,7 +110,10 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 
 	var req CreateCategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
+		return
+	}
+	if !checkAmounts(w, r, req.DefaultLimit) {
 		return
 	}
 
@@ -119,7 +124,7 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 
 	var defaultLimit pgtype.Numeric
 	if req.DefaultLimit != nil {
-		defaultLimit = utils.PgNumeric(*req.DefaultLimit)
+		defaultLimit = req.DefaultLimit.Numeric()
 	}
 
 	category, err := h.queries.CreateCategory(r.Context(), models.CreateCategoryParams{
@@ -135,19 +140,27 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "category.created",
+		ResourceType: activity.ResourceCategory,
+		ResourceID:   category.ID,
+		After:        category,
+	})
+
 	utils.SendCreated(w, CategoryResponse{
 		ID:           category.ID,
 		Name:         category.Name,
 		Icon:         utils.TextToStringPtr(category.Icon),
 		Color:        utils.TextToString(category.Color),
 		IsSystem:     category.IsSystem.Valid,
-		DefaultLimit: utils.NumericToFloat64Ptr(category.DefaultLimit),
+		DefaultLimit: money.FromNumericPtr(category.DefaultLimit),
 	})
 }
 
 // UpdateCategory updates an existing category
 func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
-	_, ok := auth.GetUserID(r)
+	userID, ok := auth.GetUserID(r)
 	if !ok {
 		utils.Unauthorized(w, "Not authenticated")
 		return
@@ -161,7 +174,16 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 
 	var req UpdateCategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
+		return
+	}
+	if !checkAmounts(w, r, req.DefaultLimit) {
+		return
+	}
+
+	before, err := h.queries.GetCategoryByID(r.Context(), categoryID)
+	if err != nil {
+		utils.NotFound(w, "Category not found")
 		return
 	}
 
@@ -182,7 +204,7 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 
 	var defaultLimit pgtype.Numeric
 	if req.DefaultLimit != nil {
-		defaultLimit = utils.PgNumeric(*req.DefaultLimit)
+		defaultLimit = req.DefaultLimit.Numeric()
 	}
 
 	category, err := h.queries.UpdateCategory(r.Context(), models.UpdateCategoryParams{
@@ -197,19 +219,28 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "category.updated",
+		ResourceType: activity.ResourceCategory,
+		ResourceID:   category.ID,
+		Before:       before,
+		After:        category,
+	})
+
 	utils.SendSuccess(w, CategoryResponse{
 		ID:           category.ID,
 		Name:         category.Name,
 		Icon:         utils.TextToStringPtr(category.Icon),
 		Color:        utils.TextToString(category.Color),
 		IsSystem:     category.IsSystem.Valid,
-		DefaultLimit: utils.NumericToFloat64Ptr(category.DefaultLimit),
+		DefaultLimit: money.FromNumericPtr(category.DefaultLimit),
 	})
 }
 
 // DeleteCategory soft deletes a category
 func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
-	_, ok := auth.GetUserID(r)
+	userID, ok := auth.GetUserID(r)
 	if !ok {
 		utils.Unauthorized(w, "Not authenticated")
 		return
@@ -221,12 +252,26 @@ func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := h.queries.DeleteCategory(r.Context(), categoryID)
+	before, err := h.queries.GetCategoryByID(r.Context(), categoryID)
+	if err != nil {
+		utils.NotFound(w, "Category not found")
+		return
+	}
+
+	err = h.queries.DeleteCategory(r.Context(), categoryID)
 	if err != nil {
 		utils.InternalError(w, "Failed to delete category")
 		return
 	}
 
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "category.deleted",
+		ResourceType: activity.ResourceCategory,
+		ResourceID:   before.ID,
+		Before:       before,
+	})
+
 	utils.SendSuccess(w, map[string]string{
 		"message": "Category deleted successfully",
 	})
@@ -0,0 +1,687 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/debts"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// validDebtKinds are the kinds of debt that can be tracked
+var validDebtKinds = map[string]bool{
+	"loan":        true,
+	"credit_card": true,
+	"other":       true,
+}
+
+// DebtHandler handles debt and payoff plan requests
+type DebtHandler struct {
+	queries *models.Queries
+}
+
+// NewDebtHandler creates a new debt handler
+func NewDebtHandler(queries *models.Queries) *DebtHandler {
+	return &DebtHandler{queries: queries}
+}
+
+// DebtAccountResponse represents a debt and its current balance in API responses
+type DebtAccountResponse struct {
+	ID              string       `json:"id"`
+	Name            string       `json:"name"`
+	Kind            string       `json:"kind"`
+	Principal       money.Amount `json:"principal"`
+	BalanceDate     string       `json:"balanceDate"`
+	APR             float64      `json:"apr"`
+	MinimumPayment  money.Amount `json:"minimumPayment"`
+	DueDay          int32        `json:"dueDay"`
+	PaymentMethodID *string      `json:"paymentMethodId,omitempty"`
+	Paid            money.Amount `json:"paid"`    // net payments since balanceDate
+	Balance         money.Amount `json:"balance"` // principal less payments, never negative
+	LastPaymentDate *string      `json:"lastPaymentDate,omitempty"`
+	NextDueDate     *string      `json:"nextDueDate,omitempty"`
+	CreatedAt       string       `json:"createdAt"`
+	UpdatedAt       string       `json:"updatedAt"`
+}
+
+// CreateDebtRequest represents the create debt request
+type CreateDebtRequest struct {
+	Name            string       `json:"name"`
+	Kind            string       `json:"kind"`
+	Principal       money.Amount `json:"principal"`             // in the home currency
+	BalanceDate     *string      `json:"balanceDate,omitempty"` // the date principal was owed; defaults to today
+	APR             float64      `json:"apr"`
+	MinimumPayment  money.Amount `json:"minimumPayment"`
+	DueDay          int32        `json:"dueDay"`
+	PaymentMethodID *string      `json:"paymentMethodId,omitempty"`
+}
+
+// UpdateDebtRequest represents the update debt request. Setting principal without a
+// balanceDate re-anchors the balance at today, e.g. from a new statement.
+type UpdateDebtRequest struct {
+	Name            *string       `json:"name,omitempty"`
+	Kind            *string       `json:"kind,omitempty"`
+	Principal       *money.Amount `json:"principal,omitempty"`
+	BalanceDate     *string       `json:"balanceDate,omitempty"`
+	APR             *float64      `json:"apr,omitempty"`
+	MinimumPayment  *money.Amount `json:"minimumPayment,omitempty"`
+	DueDay          *int32        `json:"dueDay,omitempty"`
+	PaymentMethodID *string       `json:"paymentMethodId,omitempty"`
+}
+
+// PayoffPlanResponse represents payoff plans for all of a user's debts
+type PayoffPlanResponse struct {
+	TotalBalance   money.Amount   `json:"totalBalance"`
+	TotalMinimums  money.Amount   `json:"totalMinimums"`
+	ExtraPayment   money.Amount   `json:"extraPayment"`
+	Plans          []PlanResponse `json:"plans"`
+	Recommendation string         `json:"recommendation,omitempty"` // the strategy with the least interest
+}
+
+// PlanResponse represents one strategy's payoff plan
+type PlanResponse struct {
+	Strategy      debts.Strategy      `json:"strategy"`
+	MonthlyBudget money.Amount        `json:"monthlyBudget"`
+	PaidOff       bool                `json:"paidOff"`
+	Months        int                 `json:"months"`
+	PayoffDate    *string             `json:"payoffDate,omitempty"`
+	TotalInterest money.Amount        `json:"totalInterest"`
+	TotalPaid     money.Amount        `json:"totalPaid"`
+	Debts         []PlanDebtResponse  `json:"debts"`
+	Schedule      []debts.Month       `json:"schedule,omitempty"`
+	Extra         *ExtraPaymentEffect `json:"extraPaymentEffect,omitempty"`
+}
+
+// PlanDebtResponse represents one debt within a payoff plan
+type PlanDebtResponse struct {
+	DebtID     string       `json:"debtId"`
+	Name       string       `json:"name"`
+	Order      int          `json:"order"`
+	PayoffDate *string      `json:"payoffDate,omitempty"`
+	Interest   money.Amount `json:"interest"`
+	TotalPaid  money.Amount `json:"totalPaid"`
+}
+
+// ExtraPaymentEffect compares a plan with the same strategy paying only the minimums
+type ExtraPaymentEffect struct {
+	MinimumOnlyMonths     int          `json:"minimumOnlyMonths"`
+	MinimumOnlyPayoffDate *string      `json:"minimumOnlyPayoffDate,omitempty"`
+	MinimumOnlyInterest   money.Amount `json:"minimumOnlyInterest"`
+	MonthsSaved           int          `json:"monthsSaved"`
+	InterestSaved         money.Amount `json:"interestSaved"`
+}
+
+// debtPayments holds a debt's payments from GetDebtPayments
+type debtPayments struct {
+	paid money.Amount
+	last *time.Time
+}
+
+// ListDebts returns the current user's debts with their balances
+func (h *DebtHandler) ListDebts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	list, payments, err := loadDebts(r.Context(), h.queries, userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch debts")
+		return
+	}
+
+	today := time.Now()
+	response := make([]DebtAccountResponse, len(list))
+	for i, d := range list {
+		response[i] = debtToResponse(d, payments[d.ID], today)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// GetDebt returns a debt with its balance
+func (h *DebtHandler) GetDebt(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	debt, ok := h.ownDebt(w, r, userID)
+	if !ok {
+		return
+	}
+
+	_, payments, err := loadDebts(r.Context(), h.queries, userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch debt")
+		return
+	}
+
+	utils.SendSuccess(w, debtToResponse(debt, payments[debt.ID], time.Now()))
+}
+
+// CreateDebt creates a debt
+func (h *DebtHandler) CreateDebt(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var req CreateDebtRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		utils.BadRequest(w, "Name is required and must be at most 100 characters")
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = "loan"
+	}
+	if req.DueDay == 0 {
+		req.DueDay = 1
+	}
+	if !validateDebt(w, &req.Kind, &req.Principal, &req.APR, &req.MinimumPayment, &req.DueDay) {
+		return
+	}
+	if !checkAmounts(w, r, &req.Principal, &req.MinimumPayment) {
+		return
+	}
+	balanceDate := time.Now()
+	if parsed, ok := parseOptionalDate(w, req.BalanceDate, "balance date"); !ok {
+		return
+	} else if parsed != nil {
+		balanceDate = *parsed
+	}
+	if !checkPaymentMethod(w, r, h.queries, req.PaymentMethodID, userID) {
+		return
+	}
+
+	debt, err := h.queries.CreateDebt(r.Context(), models.CreateDebtParams{
+		UserID:          userID,
+		Name:            req.Name,
+		Kind:            req.Kind,
+		Principal:       req.Principal.Numeric(),
+		BalanceDate:     utils.PgDate(balanceDate),
+		Apr:             utils.PgNumeric(req.APR),
+		MinimumPayment:  req.MinimumPayment.Numeric(),
+		DueDay:          req.DueDay,
+		PaymentMethodID: utils.PgUUIDPtr(req.PaymentMethodID),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to create debt")
+		return
+	}
+
+	response := debtToResponse(debt, debtPayments{}, time.Now())
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "debt.created",
+		ResourceType: activity.ResourceDebt,
+		ResourceID:   debt.ID,
+		After:        debtSettings(response),
+	})
+
+	utils.SendCreated(w, response)
+}
+
+// UpdateDebt updates a debt
+func (h *DebtHandler) UpdateDebt(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	before, ok := h.ownDebt(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req UpdateDebtRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	var name *string
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		if trimmed == "" || len(trimmed) > 100 {
+			utils.BadRequest(w, "Name must be between 1 and 100 characters")
+			return
+		}
+		name = &trimmed
+	}
+	if !validateDebt(w, req.Kind, req.Principal, req.APR, req.MinimumPayment, req.DueDay) {
+		return
+	}
+	if !checkAmounts(w, r, req.Principal, req.MinimumPayment) {
+		return
+	}
+	balanceDate, ok := parseOptionalDate(w, req.BalanceDate, "balance date")
+	if !ok {
+		return
+	}
+	if balanceDate == nil && req.Principal != nil {
+		today := time.Now()
+		balanceDate = &today
+	}
+	if !checkPaymentMethod(w, r, h.queries, req.PaymentMethodID, userID) {
+		return
+	}
+
+	var apr pgtype.Numeric
+	if req.APR != nil {
+		apr = utils.PgNumeric(*req.APR)
+	}
+
+	debt, err := h.queries.UpdateDebt(r.Context(), models.UpdateDebtParams{
+		ID:              before.ID,
+		Name:            utils.PgTextPtr(name),
+		Kind:            utils.PgTextPtr(req.Kind),
+		Principal:       money.NumericPtr(req.Principal),
+		BalanceDate:     utils.PgDatePtr(balanceDate),
+		Apr:             apr,
+		MinimumPayment:  money.NumericPtr(req.MinimumPayment),
+		DueDay:          utils.PgInt4Ptr(req.DueDay),
+		PaymentMethodID: utils.PgUUIDPtr(req.PaymentMethodID),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to update debt")
+		return
+	}
+
+	_, payments, err := loadDebts(r.Context(), h.queries, userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch debt")
+		return
+	}
+	response := debtToResponse(debt, payments[debt.ID], time.Now())
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "debt.updated",
+		ResourceType: activity.ResourceDebt,
+		ResourceID:   debt.ID,
+		Before:       debtSettings(debtToResponse(before, debtPayments{}, time.Now())),
+		After:        debtSettings(response),
+	})
+
+	utils.SendSuccess(w, response)
+}
+
+// DeleteDebt soft deletes a debt. Its payments stay as ordinary transactions.
+func (h *DebtHandler) DeleteDebt(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	before, ok := h.ownDebt(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.queries.DeleteDebt(r.Context(), before.ID); err != nil {
+		utils.InternalError(w, "Failed to delete debt")
+		return
+	}
+
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "debt.deleted",
+		ResourceType: activity.ResourceDebt,
+		ResourceID:   before.ID,
+		Before:       debtSettings(debtToResponse(before, debtPayments{}, time.Now())),
+	})
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Debt deleted successfully",
+	})
+}
+
+// ListPayments returns the transactions recorded against a debt
+func (h *DebtHandler) ListPayments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	debt, ok := h.ownDebt(w, r, userID)
+	if !ok {
+		return
+	}
+
+	transactions, err := h.queries.ListDebtPayments(r.Context(), utils.PgUUID(debt.ID))
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch payments")
+		return
+	}
+
+	response := make([]TransactionResponse, len(transactions))
+	for i, t := range transactions {
+		response[i] = transactionToResponse(t)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// AddPayment records a transfer to the debt's account as a transaction linked to the debt
+func (h *DebtHandler) AddPayment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	debt, ok := h.ownDebt(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+	if req.Amount <= 0 {
+		utils.BadRequest(w, "Amount must be positive")
+		return
+	}
+
+	params, ok := transferParams(w, r, h.queries, userID, req)
+	if !ok {
+		return
+	}
+	params.TransferToAccountID = debt.PaymentMethodID
+	params.DebtID = utils.PgUUID(debt.ID)
+	if req.Description == nil {
+		params.Description = utils.PgText("Payment to " + debt.Name)
+	}
+
+	transaction, err := h.queries.CreateTransaction(r.Context(), params)
+	if err != nil {
+		utils.InternalError(w, "Failed to record payment")
+		return
+	}
+
+	response := transactionToResponse(transaction)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "transaction.created",
+		ResourceType: activity.ResourceTransaction,
+		ResourceID:   transaction.ID,
+		BudgetID:     utils.UUIDToString(transaction.BudgetID),
+		After:        response,
+		Details:      map[string]interface{}{"debtId": debt.ID},
+	})
+
+	utils.SendCreated(w, response)
+}
+
+// GetPayoffPlan simulates paying off all of the user's debts from their current balances.
+// Supports strategy (avalanche or snowball, default both), extra (an amount paid each
+// month on top of the minimums) and schedule=false to leave out the month-by-month rows.
+func (h *DebtHandler) GetPayoffPlan(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	query := r.URL.Query()
+	strategies := []debts.Strategy{debts.Avalanche, debts.Snowball}
+	if s := query.Get("strategy"); s != "" {
+		strategy := debts.Strategy(s)
+		if !strategy.Valid() {
+			utils.BadRequest(w, "Invalid strategy. Use avalanche or snowball")
+			return
+		}
+		strategies = []debts.Strategy{strategy}
+	}
+	var extra money.Amount
+	if s := query.Get("extra"); s != "" {
+		parsed, err := money.Parse(s)
+		if err != nil || parsed < 0 {
+			utils.BadRequest(w, "Invalid extra payment amount")
+			return
+		}
+		extra = parsed
+	}
+	withSchedule := query.Get("schedule") != "false"
+
+	list, payments, err := loadDebts(r.Context(), h.queries, userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch debts")
+		return
+	}
+
+	today := time.Now()
+	response := PayoffPlanResponse{ExtraPayment: extra}
+	input := make([]debts.Debt, 0, len(list))
+	for _, d := range list {
+		resp := debtToResponse(d, payments[d.ID], today)
+		response.TotalBalance += resp.Balance
+		if resp.Balance > 0 {
+			response.TotalMinimums += resp.MinimumPayment
+		}
+		input = append(input, debts.Debt{
+			ID:             d.ID,
+			Name:           d.Name,
+			Balance:        resp.Balance,
+			APR:            resp.APR,
+			MinimumPayment: resp.MinimumPayment,
+			DueDay:         int(d.DueDay),
+		})
+	}
+
+	var best *PlanResponse
+	for _, strategy := range strategies {
+		plan := planToResponse(debts.Simulate(input, strategy, extra, today), withSchedule)
+		if extra > 0 {
+			baseline := debts.Simulate(input, strategy, 0, today)
+			effect := &ExtraPaymentEffect{
+				MinimumOnlyMonths:     baseline.Months,
+				MinimumOnlyPayoffDate: formatDatePtr(baseline.PayoffDate),
+				MinimumOnlyInterest:   baseline.TotalInterest,
+			}
+			if baseline.PaidOff && plan.PaidOff {
+				effect.MonthsSaved = baseline.Months - plan.Months
+				effect.InterestSaved = baseline.TotalInterest - plan.TotalInterest
+			}
+			plan.Extra = effect
+		}
+		response.Plans = append(response.Plans, plan)
+	}
+	for i := range response.Plans {
+		p := &response.Plans[i]
+		if p.PaidOff && (best == nil || p.TotalInterest < best.TotalInterest) {
+			best = p
+		}
+	}
+	if best != nil && len(response.Plans) > 1 {
+		response.Recommendation = string(best.Strategy)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// Helper functions
+
+// ownDebt loads the debt in the path, responding with 404 unless it belongs to userID
+func (h *DebtHandler) ownDebt(w http.ResponseWriter, r *http.Request, userID string) (models.Debt, bool) {
+	debtID := r.PathValue("id")
+	if debtID == "" {
+		utils.BadRequest(w, "Debt ID is required")
+		return models.Debt{}, false
+	}
+
+	debt, err := h.queries.GetDebtByID(r.Context(), debtID)
+	if err != nil || debt.UserID != userID {
+		utils.NotFound(w, "Debt not found")
+		return models.Debt{}, false
+	}
+	return debt, true
+}
+
+// loadDebts returns the user's debts and the payments made on each
+func loadDebts(ctx context.Context, queries *models.Queries, userID string) ([]models.Debt, map[string]debtPayments, error) {
+	list, err := queries.ListDebts(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := queries.GetDebtPayments(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payments := make(map[string]debtPayments, len(rows))
+	for _, row := range rows {
+		p := debtPayments{paid: money.FromNumeric(row.Paid)}
+		if row.LastPaymentDate.Valid {
+			last := utils.DateToTime(row.LastPaymentDate)
+			p.last = &last
+		}
+		payments[row.DebtID] = p
+	}
+	return list, payments, nil
+}
+
+// validateDebt checks the debt fields that are set, responding with 400 if one is invalid
+func validateDebt(w http.ResponseWriter, kind *string, principal *money.Amount, apr *float64, minimum *money.Amount, dueDay *int32) bool {
+	switch {
+	case kind != nil && !validDebtKinds[*kind]:
+		utils.BadRequest(w, "Invalid kind. Use loan, credit_card or other")
+	case principal != nil && *principal < 0:
+		utils.BadRequest(w, "Principal must not be negative")
+	case apr != nil && (*apr < 0 || *apr >= 1000 || math.IsNaN(*apr)):
+		utils.BadRequest(w, "APR must be a percentage between 0 and 1000")
+	case apr != nil && math.Abs(*apr*1000-math.Round(*apr*1000)) > 1e-6:
+		utils.BadRequest(w, "APR can have at most 3 decimal places")
+	case minimum != nil && *minimum < 0:
+		utils.BadRequest(w, "Minimum payment must not be negative")
+	case dueDay != nil && (*dueDay < 1 || *dueDay > 31):
+		utils.BadRequest(w, "Due day must be between 1 and 31")
+	default:
+		return true
+	}
+	return false
+}
+
+// nextDueDate returns the first due date on or after today
+func nextDueDate(dueDay int, today time.Time) time.Time {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	for month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC); ; month = month.AddDate(0, 1, 0) {
+		day := dueDay
+		if last := month.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		due := month.AddDate(0, 0, day-1)
+		if !due.Before(today) {
+			return due
+		}
+	}
+}
+
+func debtToResponse(d models.Debt, p debtPayments, today time.Time) DebtAccountResponse {
+	principal := money.FromNumeric(d.Principal)
+	var apr float64
+	if f := utils.NumericToFloat64Ptr(d.Apr); f != nil {
+		apr = *f
+	}
+
+	resp := DebtAccountResponse{
+		ID:              d.ID,
+		Name:            d.Name,
+		Kind:            d.Kind,
+		Principal:       principal,
+		BalanceDate:     utils.DateToTime(d.BalanceDate).Format("2006-01-02"),
+		APR:             apr,
+		MinimumPayment:  money.FromNumeric(d.MinimumPayment),
+		DueDay:          d.DueDay,
+		PaymentMethodID: uuidPtrToString(d.PaymentMethodID),
+		Paid:            p.paid,
+		Balance:         principal - p.paid,
+		LastPaymentDate: formatDatePtr(p.last),
+		CreatedAt:       utils.TimestamptzToTime(d.CreatedAt).Format(time.RFC3339),
+		UpdatedAt:       utils.TimestamptzToTime(d.UpdatedAt).Format(time.RFC3339),
+	}
+	if resp.Balance < 0 {
+		resp.Balance = 0
+	}
+	if resp.Balance > 0 {
+		due := nextDueDate(int(d.DueDay), today)
+		resp.NextDueDate = formatDatePtr(&due)
+	}
+	return resp
+}
+
+func planToResponse(p debts.Plan, withSchedule bool) PlanResponse {
+	resp := PlanResponse{
+		Strategy:      p.Strategy,
+		MonthlyBudget: p.MonthlyBudget,
+		PaidOff:       p.PaidOff,
+		Months:        p.Months,
+		PayoffDate:    formatDatePtr(p.PayoffDate),
+		TotalInterest: p.TotalInterest,
+		TotalPaid:     p.TotalPaid,
+		Debts:         make([]PlanDebtResponse, len(p.Debts)),
+	}
+	for i, d := range p.Debts {
+		resp.Debts[i] = PlanDebtResponse{
+			DebtID:     d.DebtID,
+			Name:       d.Name,
+			Order:      d.Order,
+			PayoffDate: formatDatePtr(d.PayoffDate),
+			Interest:   d.Interest,
+			TotalPaid:  d.TotalPaid,
+		}
+	}
+	if withSchedule {
+		resp.Schedule = p.Schedule
+	}
+	return resp
+}
+
+// debtSettings is the part of a debt that users edit, recorded in the activity log
+func debtSettings(d DebtAccountResponse) map[string]interface{} {
+	settings := map[string]interface{}{
+		"name":           d.Name,
+		"kind":           d.Kind,
+		"principal":      d.Principal,
+		"balanceDate":    d.BalanceDate,
+		"apr":            d.APR,
+		"minimumPayment": d.MinimumPayment,
+		"dueDay":         d.DueDay,
+	}
+	if d.PaymentMethodID != nil {
+		settings["paymentMethodId"] = *d.PaymentMethodID
+	}
+	return settings
+}
+
+func formatDatePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format("2006-01-02")
+	return &s
+}
@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/envelopes"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// EnvelopeHandler handles envelope budgeting requests
+type EnvelopeHandler struct {
+	queries *models.Queries
+	pool    *pgxpool.Pool
+}
+
+// NewEnvelopeHandler creates a new envelope handler
+func NewEnvelopeHandler(queries *models.Queries, pool *pgxpool.Pool) *EnvelopeHandler {
+	return &EnvelopeHandler{queries: queries, pool: pool}
+}
+
+// EnvelopeResponse represents a category envelope in API responses
+type EnvelopeResponse struct {
+	CategoryID string       `json:"categoryId"`
+	Name       string       `json:"name"`
+	Icon       *string      `json:"icon,omitempty"`
+	Color      string       `json:"color"`
+	Assigned   money.Amount `json:"assigned"`
+	Spent      money.Amount `json:"spent"`
+	Carryover  money.Amount `json:"carryover"` // left over from the owner's earlier envelope budgets
+	Available  money.Amount `json:"available"` // assigned less spent plus carryover
+}
+
+// EnvelopeBalancesResponse is an envelope budget's envelopes and unassigned pool.
+// Amounts are in the home currency.
+type EnvelopeBalancesResponse struct {
+	BudgetID   string             `json:"budgetId"`
+	Month      string             `json:"month"`
+	Strict     bool               `json:"strict"`
+	Income     money.Amount       `json:"income"`
+	Carryover  money.Amount       `json:"carryover"`  // unassigned money left from earlier budgets
+	Assigned   money.Amount       `json:"assigned"`   // across every envelope
+	Unbudgeted money.Amount       `json:"unbudgeted"` // spent in categories without an envelope
+	Unassigned money.Amount       `json:"unassigned"`
+	Envelopes  []EnvelopeResponse `json:"envelopes"`
+}
+
+// MoveEnvelopeRequest moves money between envelopes. Leaving out a category moves
+// from or to the unassigned pool.
+type MoveEnvelopeRequest struct {
+	FromCategoryID *string      `json:"fromCategoryId,omitempty"`
+	ToCategoryID   *string      `json:"toCategoryId,omitempty"`
+	Amount         money.Amount `json:"amount"`
+	Note           *string      `json:"note,omitempty"`
+}
+
+// EnvelopeMoveResponse represents a recorded move in API responses
+type EnvelopeMoveResponse struct {
+	ID             string       `json:"id"`
+	BudgetID       string       `json:"budgetId"`
+	UserID         *string      `json:"userId"`         // who moved it
+	FromCategoryID *string      `json:"fromCategoryId"` // null for the unassigned pool
+	ToCategoryID   *string      `json:"toCategoryId"`
+	Amount         money.Amount `json:"amount"`
+	Note           *string      `json:"note,omitempty"`
+	CreatedAt      string       `json:"createdAt"`
+}
+
+// GetEnvelopes returns the envelopes and unassigned pool of an envelope budget
+func (h *EnvelopeHandler) GetEnvelopes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	budget, ok := h.envelopeBudget(w, r, userID, false)
+	if !ok {
+		return
+	}
+	if !budget.EnvelopeMode {
+		utils.BadRequest(w, "Budget is not in envelope mode")
+		return
+	}
+
+	balances, err := loadEnvelopeBalances(r.Context(), h.queries, budget)
+	if err != nil {
+		utils.InternalError(w, "Failed to calculate envelope balances")
+		return
+	}
+	categories, err := h.queries.GetBudgetCategories(r.Context(), utils.PgUUID(budget.ID))
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch budget categories")
+		return
+	}
+	names := make(map[string]models.GetBudgetCategoriesRow, len(categories))
+	for _, c := range categories {
+		names[utils.FormatUUID(c.CategoryID)] = c
+	}
+
+	response := EnvelopeBalancesResponse{
+		BudgetID:   budget.ID,
+		Month:      utils.DateToTime(budget.Month).Format("2006-01"),
+		Strict:     budget.StrictEnvelopes,
+		Income:     balances.Income,
+		Carryover:  balances.Carryover,
+		Assigned:   balances.Assigned,
+		Unbudgeted: balances.Unbudgeted,
+		Unassigned: balances.Unassigned,
+		Envelopes:  make([]EnvelopeResponse, len(balances.Envelopes)),
+	}
+	for i, e := range balances.Envelopes {
+		c := names[e.CategoryID]
+		response.Envelopes[i] = EnvelopeResponse{
+			CategoryID: e.CategoryID,
+			Name:       c.Name,
+			Icon:       utils.TextToStringPtr(c.Icon),
+			Color:      utils.TextToString(c.Color),
+			Assigned:   e.Assigned,
+			Spent:      e.Spent,
+			Carryover:  e.Carryover,
+			Available:  e.Available,
+		}
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// MoveEnvelope moves money between two envelopes of a budget, or between an envelope
+// and the unassigned pool, and records the move
+func (h *EnvelopeHandler) MoveEnvelope(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var req MoveEnvelopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+	if req.Amount <= 0 {
+		utils.BadRequest(w, "amount must be greater than zero")
+		return
+	}
+	if !checkAmounts(w, r, &req.Amount) {
+		return
+	}
+
+	budget, ok := h.envelopeBudget(w, r, userID, true)
+	if !ok {
+		return
+	}
+	from, to := stringPtrOrEmpty(req.FromCategoryID), stringPtrOrEmpty(req.ToCategoryID)
+	if to != "" {
+		category, err := h.queries.GetCategoryByID(r.Context(), to)
+		if err != nil || (category.UserID.Valid && category.UserID != budget.UserID) {
+			utils.BadRequest(w, "Category not found")
+			return
+		}
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		utils.InternalError(w, "Failed to move money")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := h.queries.WithTx(tx)
+
+	// Lock the budget so expenses and other moves wait for this one, then check
+	// against its state as of the lock
+	budget, err = qtx.GetBudgetForUpdate(r.Context(), budget.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.NotFound(w, "Budget not found")
+		return
+	}
+	if err != nil {
+		utils.InternalError(w, "Failed to move money")
+		return
+	}
+	if !budget.EnvelopeMode {
+		utils.BadRequest(w, "Budget is not in envelope mode")
+		return
+	}
+
+	balances, err := loadEnvelopeBalances(r.Context(), qtx, budget)
+	if err != nil {
+		utils.InternalError(w, "Failed to calculate envelope balances")
+		return
+	}
+	if err := balances.CheckMove(from, to, req.Amount, budget.StrictEnvelopes); err != nil {
+		if errors.Is(err, envelopes.ErrOverdrawn) {
+			utils.Conflict(w, "Not enough money available to move: "+balances.Available(from).String())
+			return
+		}
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	// Taking money out of an envelope assigns it less, putting it in assigns more
+	for _, adjust := range []struct {
+		categoryID string
+		amount     money.Amount
+	}{{from, -req.Amount}, {to, req.Amount}} {
+		if adjust.categoryID == "" {
+			continue
+		}
+		if _, err := qtx.AdjustEnvelope(r.Context(), models.AdjustEnvelopeParams{
+			BudgetID:    utils.PgUUID(budget.ID),
+			CategoryID:  utils.PgUUID(adjust.categoryID),
+			LimitAmount: adjust.amount.Numeric(),
+		}); err != nil {
+			utils.InternalError(w, "Failed to move money")
+			return
+		}
+	}
+
+	move, err := qtx.CreateEnvelopeMove(r.Context(), models.CreateEnvelopeMoveParams{
+		BudgetID:       budget.ID,
+		UserID:         utils.PgUUID(userID),
+		FromCategoryID: utils.PgUUIDPtr(optionalID(from)),
+		ToCategoryID:   utils.PgUUIDPtr(optionalID(to)),
+		Amount:         req.Amount.Numeric(),
+		Note:           utils.PgTextPtr(req.Note),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to move money")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to move money")
+		return
+	}
+
+	response := envelopeMoveToResponse(move)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "envelope.moved",
+		ResourceType: activity.ResourceEnvelopeMove,
+		ResourceID:   move.ID,
+		BudgetID:     budget.ID,
+		After:        response,
+	})
+
+	utils.SendCreated(w, response)
+}
+
+// ListEnvelopeMoves returns every move recorded for a budget, newest first. Moves
+// stay listed after the budget leaves envelope mode.
+func (h *EnvelopeHandler) ListEnvelopeMoves(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	budget, ok := h.envelopeBudget(w, r, userID, false)
+	if !ok {
+		return
+	}
+
+	moves, err := h.queries.ListEnvelopeMoves(r.Context(), budget.ID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch envelope moves")
+		return
+	}
+
+	response := make([]EnvelopeMoveResponse, len(moves))
+	for i, m := range moves {
+		response[i] = envelopeMoveToResponse(m)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// Helper functions
+
+// envelopeBudget loads the budget in the path, responding with 404 unless userID has
+// access to it and with 403 if edit is set and they can only view it
+func (h *EnvelopeHandler) envelopeBudget(w http.ResponseWriter, r *http.Request, userID string, edit bool) (models.Budget, bool) {
+	budget, err := h.queries.GetBudgetByID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		utils.NotFound(w, "Budget not found")
+		return models.Budget{}, false
+	}
+	access, err := h.queries.CheckBudgetAccess(r.Context(), models.CheckBudgetAccessParams{
+		ID:     budget.ID,
+		UserID: utils.PgUUID(userID),
+	})
+	if err != nil {
+		utils.NotFound(w, "Budget not found or no access")
+		return models.Budget{}, false
+	}
+	if edit && access.Permission == "view" {
+		utils.Forbidden(w, "You need edit access to move money in this budget")
+		return models.Budget{}, false
+	}
+	return budget, true
+}
+
+// loadEnvelopeBalances works out an envelope budget's balances, carrying over what
+// its owner's earlier envelope budgets left
+func loadEnvelopeBalances(ctx context.Context, q *models.Queries, budget models.Budget) (envelopes.Balances, error) {
+	rows, err := q.ListEnvelopeTotals(ctx, models.ListEnvelopeTotalsParams{
+		UserID: budget.UserID,
+		Month:  budget.Month,
+	})
+	if err != nil {
+		return envelopes.Balances{}, err
+	}
+	totals := make([]envelopes.Total, len(rows))
+	for i, row := range rows {
+		totals[i] = envelopes.Total{
+			BudgetID:   row.BudgetID,
+			CategoryID: utils.FormatUUID(row.CategoryID),
+			Envelope:   row.IsEnvelope,
+			Assigned:   money.FromNumeric(row.Assigned),
+			Income:     money.FromNumeric(row.Income),
+			Spent:      money.FromNumeric(row.Spent),
+		}
+	}
+	return envelopes.Compute(budget.ID, totals), nil
+}
+
+// envelopeDraw is what an expense takes out of a budget's envelopes. BudgetID is
+// empty for transactions that take nothing: income, transfers and ones without a
+// budget.
+type envelopeDraw struct {
+	BudgetID   string
+	CategoryID string
+	Amount     money.Amount // in the home currency
+}
+
+func transactionDraw(t models.Transaction) envelopeDraw {
+	if !t.BudgetID.Valid || transactionType(t.Type) != "expense" || t.IsTransfer.Bool {
+		return envelopeDraw{}
+	}
+	return envelopeDraw{
+		BudgetID:   utils.FormatUUID(t.BudgetID),
+		CategoryID: utils.FormatUUID(t.CategoryID),
+		Amount:     money.FromNumeric(t.HomeAmount),
+	}
+}
+
+// checkEnvelope rejects an expense that would overdraw its envelope, or the
+// unassigned pool if its category has none, when its budget is in strict envelope
+// mode, responding with 409. previous is what the transaction drew before an edit.
+// Expenses count against the budget they're filed under, whatever their date.
+//
+// q must be the queries of the transaction that writes the expense: the budget stays
+// locked until it ends, so a concurrent expense or move can't spend the same money.
+func checkEnvelope(w http.ResponseWriter, r *http.Request, q *models.Queries, next, previous envelopeDraw) bool {
+	err := checkDraw(r.Context(), q, next, previous)
+	var conflict conflictError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &conflict):
+		utils.Conflict(w, conflict.Error())
+	default:
+		utils.InternalError(w, "Failed to calculate envelope balances")
+	}
+	return false
+}
+
+// checkDraw is checkEnvelope without the response: a conflictError if the expense
+// would overdraw its envelope
+func checkDraw(ctx context.Context, q *models.Queries, next, previous envelopeDraw) error {
+	if next.BudgetID == "" {
+		return nil
+	}
+	budget, err := q.GetBudgetForUpdate(ctx, next.BudgetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// A deleted budget has no envelopes to overdraw
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !budget.EnvelopeMode || !budget.StrictEnvelopes {
+		return nil
+	}
+	balances, err := loadEnvelopeBalances(ctx, q, budget)
+	if err != nil {
+		return err
+	}
+	source := balances.Source(next.CategoryID)
+	available := balances.Available(next.CategoryID)
+	if previous.BudgetID == next.BudgetID && balances.Source(previous.CategoryID) == source {
+		available += previous.Amount
+	}
+	if next.Amount <= available {
+		return nil
+	}
+
+	if source == "" {
+		return conflictError("Expense would overdraw the unassigned pool: " + available.String() + " available")
+	}
+	return conflictError("Expense would overdraw its envelope: " + available.String() + " available")
+}
+
+func envelopeMoveToResponse(m models.EnvelopeMove) EnvelopeMoveResponse {
+	return EnvelopeMoveResponse{
+		ID:             m.ID,
+		BudgetID:       m.BudgetID,
+		UserID:         uuidPtrToString(m.UserID),
+		FromCategoryID: uuidPtrToString(m.FromCategoryID),
+		ToCategoryID:   uuidPtrToString(m.ToCategoryID),
+		Amount:         money.FromNumeric(m.Amount),
+		Note:           utils.TextToStringPtr(m.Note),
+		CreatedAt:      utils.TimestamptzToTime(m.CreatedAt).Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/realtime"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// EventHandler streams change events to clients with Server-Sent Events
+type EventHandler struct {
+	queries *models.Queries
+	hub     *realtime.Hub
+}
+
+// NewEventHandler creates a new event stream handler
+func NewEventHandler(queries *models.Queries, hub *realtime.Hub) *EventHandler {
+	return &EventHandler{queries: queries, hub: hub}
+}
+
+const (
+	// streamMaxDuration is how long one stream stays open. Streams end on their
+	// own before the router's 60s request timeout cancels them, and clients
+	// reconnect with Last-Event-ID without missing anything.
+	streamMaxDuration = 50 * time.Second
+	// streamDeadlineMargin is left before a request deadline to end the stream cleanly
+	streamDeadlineMargin = 5 * time.Second
+	// streamHeartbeat keeps proxies from closing an idle stream
+	streamHeartbeat = 15 * time.Second
+	// streamRetry is the reconnect delay suggested to clients, in milliseconds
+	streamRetry = 1000
+	// replayPageSize is how many missed events are loaded at a time on resume
+	replayPageSize = 500
+)
+
+// Stream sends the current user's change events as text/event-stream. Each event
+// names the table, operation and record that changed; clients refetch what they
+// need. Clients resume with the Last-Event-ID header (or ?lastEventId= for
+// clients that can't set it) and get a "reset" event when the events they missed
+// have been pruned and they should run a full sync.
+//
+// Event IDs are assigned when a change is written but announced when it commits,
+// so a change committed during a reconnect can carry an ID lower than one the
+// client already saw. Events are hints to refetch, and a periodic sync pull covers
+// that gap.
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var lastID int64
+	resume := r.Header.Get("Last-Event-ID")
+	if resume == "" {
+		resume = r.URL.Query().Get("lastEventId")
+	}
+	if resume != "" {
+		id, err := strconv.ParseInt(resume, 10, 64)
+		if err != nil || id < 0 {
+			utils.BadRequest(w, "Invalid Last-Event-ID")
+			return
+		}
+		lastID = id
+	}
+
+	end := time.Now().Add(streamMaxDuration)
+	if deadline, ok := r.Context().Deadline(); ok && deadline.Add(-streamDeadlineMargin).Before(end) {
+		end = deadline.Add(-streamDeadlineMargin)
+	}
+
+	// The server's WriteTimeout is shorter than a stream; extend it for this one
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(end.Add(streamDeadlineMargin)); err != nil {
+		utils.InternalError(w, "Streaming not supported")
+		return
+	}
+
+	// Subscribe before replaying so nothing committed in between is lost; events
+	// that show up in both are sent once
+	sub := h.hub.Subscribe(userID)
+	defer h.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry); err != nil {
+		return
+	}
+
+	replayed := make(map[int64]bool)
+	if lastID > 0 {
+		oldest, err := h.queries.GetOldestChangeEventID(r.Context())
+		if err != nil {
+			return
+		}
+		if oldest > 0 && lastID < oldest-1 {
+			// Moving the client's ID past the gap stops it resetting on every reconnect
+			lastID = oldest - 1
+			if err := writeStreamEvent(w, lastID, "reset", struct{}{}); err != nil {
+				return
+			}
+		}
+
+		for {
+			events, err := h.queries.ListChangeEventsSince(r.Context(), models.ListChangeEventsSinceParams{
+				UserID:    userID,
+				AfterID:   lastID,
+				PageLimit: replayPageSize,
+			})
+			if err != nil {
+				return
+			}
+			for _, e := range events {
+				if err := writeStreamEvent(w, e.ID, "change", realtime.EventFromModel(e)); err != nil {
+					return
+				}
+				replayed[e.ID] = true
+				lastID = e.ID
+			}
+			if len(events) < replayPageSize {
+				break
+			}
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	timeout := time.NewTimer(time.Until(end))
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timeout.C:
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if replayed[e.ID] {
+				continue
+			}
+			if err := writeStreamEvent(w, e.ID, "change", e); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// Helper functions
+
+// writeStreamEvent writes one SSE frame with a JSON payload
+func writeStreamEvent(w io.Writer, id int64, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload)
+	return err
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// maxRateImportSize caps the size of an exchange rate CSV upload
+const maxRateImportSize = 10 << 20
+
+// ExchangeRateHandler handles exchange rate requests
+type ExchangeRateHandler struct {
+	queries *models.Queries
+	pool    *pgxpool.Pool
+}
+
+// NewExchangeRateHandler creates a new exchange rate handler
+func NewExchangeRateHandler(queries *models.Queries, pool *pgxpool.Pool) *ExchangeRateHandler {
+	return &ExchangeRateHandler{queries: queries, pool: pool}
+}
+
+// ExchangeRateResponse represents an exchange rate in API responses
+type ExchangeRateResponse struct {
+	Date      string     `json:"date"`
+	Base      string     `json:"base"`
+	Quote     string     `json:"quote"`
+	Rate      money.Rate `json:"rate"`
+	UpdatedAt string     `json:"updatedAt"`
+}
+
+// ImportExchangeRatesResponse summarizes an exchange rate import
+type ImportExchangeRatesResponse struct {
+	Imported int    `json:"imported"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+}
+
+// ListExchangeRates returns stored exchange rates.
+// Supports base, quote, from, to (YYYY-MM-DD), limit and offset.
+func (h *ExchangeRateHandler) ListExchangeRates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := models.ListExchangeRatesParams{
+		PageLimit:  100,
+		PageOffset: 0,
+	}
+
+	for key, dst := range map[string]*pgtype.Text{"base": &params.Base, "quote": &params.Quote} {
+		if s := query.Get(key); s != "" {
+			code, err := money.NormalizeCurrency(s)
+			if err != nil {
+				utils.BadRequest(w, "Invalid "+key+": "+err.Error())
+				return
+			}
+			*dst = utils.PgText(code)
+		}
+	}
+	for key, dst := range map[string]*pgtype.Date{"from": &params.FromDate, "to": &params.ToDate} {
+		if s := query.Get(key); s != "" {
+			t, err := time.Parse("2006-01-02", s)
+			if err != nil {
+				utils.BadRequest(w, "Invalid "+key+" date format. Use YYYY-MM-DD")
+				return
+			}
+			*dst = utils.PgDate(t)
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := parseInt(limitStr); err == nil && l > 0 && l <= 1000 {
+			params.PageLimit = int32(l)
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := parseInt(offsetStr); err == nil && o >= 0 {
+			params.PageOffset = int32(o)
+		}
+	}
+
+	rates, err := h.queries.ListExchangeRates(r.Context(), params)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch exchange rates")
+		return
+	}
+
+	response := make([]ExchangeRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		resp, err := exchangeRateToResponse(rate)
+		if err != nil {
+			utils.InternalError(w, "Failed to read exchange rates")
+			return
+		}
+		response = append(response, resp)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// ImportExchangeRates upserts exchange rates from a CSV body with the columns
+// date,base,quote,rate (header row optional). Each row means 1 base = rate quote
+// on that date. The import is all or nothing: any invalid row rejects the file.
+func (h *ExchangeRateHandler) ImportExchangeRates(w http.ResponseWriter, r *http.Request) {
+	userID, _ := auth.GetUserID(r)
+
+	reader := csv.NewReader(http.MaxBytesReader(w, r.Body, maxRateImportSize))
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		utils.InternalError(w, "Failed to import exchange rates")
+		return
+	}
+	defer tx.Rollback(r.Context())
+	qtx := h.queries.WithTx(tx)
+
+	var imported int
+	var from, to time.Time
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				utils.BadRequest(w, fmt.Sprintf("CSV must be at most %d MB", maxRateImportSize>>20))
+				return
+			}
+			utils.BadRequest(w, "Invalid CSV: "+err.Error())
+			return
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+
+		params, err := parseRateRecord(record)
+		if err != nil {
+			utils.BadRequest(w, fmt.Sprintf("Line %d: %v", line, err))
+			return
+		}
+		if err := qtx.UpsertExchangeRate(r.Context(), params); err != nil {
+			utils.InternalError(w, "Failed to import exchange rates")
+			return
+		}
+
+		date := params.RateDate.Time
+		if imported == 0 || date.Before(from) {
+			from = date
+		}
+		if imported == 0 || date.After(to) {
+			to = date
+		}
+		imported++
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to import exchange rates")
+		return
+	}
+
+	response := ImportExchangeRatesResponse{Imported: imported}
+	if imported > 0 {
+		response.From = from.Format("2006-01-02")
+		response.To = to.Format("2006-01-02")
+	}
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "exchange_rates.imported",
+		ResourceType: activity.ResourceExchangeRate,
+		Details:      map[string]interface{}{"imported": response.Imported, "from": response.From, "to": response.To},
+	})
+
+	utils.SendSuccess(w, response)
+}
+
+// Helper functions
+
+// noExchangeRateError is returned when no stored rate covers a conversion
+type noExchangeRateError struct {
+	from, to string
+	date     time.Time
+}
+
+func (e *noExchangeRateError) Error() string {
+	return fmt.Sprintf("No %s/%s exchange rate is available on or before %s", e.from, e.to, e.date.Format("2006-01-02"))
+}
+
+// convertToHome converts an amount in currency to the home currency at the latest rate
+// on or before date, returning the converted amount and the rate used
+func convertToHome(ctx context.Context, q *models.Queries, amount money.Amount, currency, home string, date time.Time) (money.Amount, money.Rate, error) {
+	if currency == home {
+		return amount, money.OneRate(), nil
+	}
+
+	stored, err := q.GetExchangeRate(ctx, models.GetExchangeRateParams{
+		FromCurrency: currency,
+		ToCurrency:   home,
+		OnDate:       utils.PgDate(date),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, money.Rate{}, &noExchangeRateError{from: currency, to: home, date: date}
+	}
+	if err != nil {
+		return 0, money.Rate{}, err
+	}
+
+	rate, err := money.RateFromNumeric(stored.Rate)
+	if err != nil {
+		return 0, money.Rate{}, err
+	}
+	if stored.Base != currency {
+		rate = rate.Inverse()
+	}
+
+	converted, err := amount.Convert(rate)
+	if err != nil {
+		return 0, money.Rate{}, err
+	}
+	// The home amount is stored too, so it has to fit the column as well
+	if !converted.Storable() {
+		return 0, money.Rate{}, money.ErrOutOfRange
+	}
+	return converted, rate, nil
+}
+
+// sendConversionError responds to a failed currency conversion
+func sendConversionError(w http.ResponseWriter, err error) {
+	var noRate *noExchangeRateError
+	switch {
+	case errors.As(err, &noRate):
+		utils.BadRequest(w, noRate.Error())
+	case errors.Is(err, money.ErrOutOfRange):
+		utils.BadRequest(w, "Converted amount is out of range")
+	default:
+		utils.InternalError(w, "Failed to convert currency")
+	}
+}
+
+// parseRateRecord validates one CSV row of date,base,quote,rate
+func parseRateRecord(record []string) (models.UpsertExchangeRateParams, error) {
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+	if err != nil {
+		return models.UpsertExchangeRateParams{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", record[0])
+	}
+	base, err := money.NormalizeCurrency(record[1])
+	if err != nil {
+		return models.UpsertExchangeRateParams{}, fmt.Errorf("invalid base %q: %w", record[1], err)
+	}
+	quote, err := money.NormalizeCurrency(record[2])
+	if err != nil {
+		return models.UpsertExchangeRateParams{}, fmt.Errorf("invalid quote %q: %w", record[2], err)
+	}
+	if base == quote {
+		return models.UpsertExchangeRateParams{}, fmt.Errorf("base and quote are both %s", base)
+	}
+	rate, err := money.ParseRate(record[3])
+	if err != nil {
+		return models.UpsertExchangeRateParams{}, fmt.Errorf("invalid rate %q: %w", record[3], err)
+	}
+
+	return models.UpsertExchangeRateParams{
+		RateDate: utils.PgDate(date),
+		Base:     base,
+		Quote:    quote,
+		Rate:     rate.Numeric(),
+	}, nil
+}
+
+func exchangeRateToResponse(e models.ExchangeRate) (ExchangeRateResponse, error) {
+	rate, err := money.RateFromNumeric(e.Rate)
+	if err != nil {
+		return ExchangeRateResponse{}, err
+	}
+	return ExchangeRateResponse{
+		Date:      utils.DateToTime(e.RateDate).Format("2006-01-02"),
+		Base:      e.Base,
+		Quote:     e.Quote,
+		Rate:      rate,
+		UpdatedAt: utils.TimestamptzToTime(e.UpdatedAt).Format(time.RFC3339),
+	}, nil
+}
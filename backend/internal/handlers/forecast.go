@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/joselitophala/budget-planner-backend/internal/alerts"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/forecast"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// ForecastHandler handles cash flow forecast requests
+type ForecastHandler struct {
+	queries *models.Queries
+}
+
+// NewForecastHandler creates a new forecast handler
+func NewForecastHandler(queries *models.Queries) *ForecastHandler {
+	return &ForecastHandler{queries: queries}
+}
+
+// ForecastResponse is a cash flow forecast. Amounts are in the home currency.
+type ForecastResponse struct {
+	AsOf           string                  `json:"asOf"`
+	MonthEnd       string                  `json:"monthEnd"`
+	RestOfMonth    ForecastTotals          `json:"restOfMonth"` // projected from tomorrow through monthEnd
+	Budget         *ForecastBudget         `json:"budget,omitempty"`
+	Categories     []ForecastCategory      `json:"categories"`
+	PaymentMethods []ForecastPaymentMethod `json:"paymentMethods"`
+	Upcoming       []ForecastScheduledItem `json:"upcoming"`
+	Daily          []ForecastDay           `json:"daily"`
+	Assumptions    ForecastAssumptions     `json:"assumptions"`
+}
+
+// ForecastTotals are projected expenses and income
+type ForecastTotals struct {
+	Expenses money.Amount `json:"expenses"`
+	Income   money.Amount `json:"income"`
+}
+
+// ForecastBudget compares the current month's budget with projected spending
+type ForecastBudget struct {
+	ID             string       `json:"id"`
+	TotalLimit     money.Amount `json:"totalLimit"`
+	Spent          money.Amount `json:"spent"`
+	ProjectedSpent money.Amount `json:"projectedSpent"`
+	Difference     money.Amount `json:"difference"` // totalLimit less projectedSpent; negative means over
+}
+
+// ForecastCategory is a budget category's expected month-end position
+type ForecastCategory struct {
+	BudgetCategoryID string       `json:"budgetCategoryId"`
+	CategoryID       string       `json:"categoryId"`
+	Name             string       `json:"name"`
+	Limit            money.Amount `json:"limit"`
+	Spent            money.Amount `json:"spent"`
+	ProjectedSpent   money.Amount `json:"projectedSpent"`
+	Difference       money.Amount `json:"difference"` // limit less projectedSpent; negative means over
+	OverLimit        bool         `json:"overLimit"`
+}
+
+// ForecastPaymentMethod is a payment method's expected month-end balance.
+// Methods with a credit limit are credit lines whose balance is what's owed, so
+// spending raises it. Balances are only projected for methods in the home currency.
+type ForecastPaymentMethod struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	Currency         string        `json:"currency"`
+	CurrentBalance   *money.Amount `json:"currentBalance"`
+	ProjectedSpent   money.Amount  `json:"projectedSpent"`
+	ProjectedIncome  money.Amount  `json:"projectedIncome"`
+	ProjectedBalance *money.Amount `json:"projectedBalance"`
+}
+
+// ForecastScheduledItem is a known upcoming transaction
+type ForecastScheduledItem struct {
+	Date            string       `json:"date"`
+	Kind            string       `json:"kind"` // recurring or bill
+	SourceID        string       `json:"sourceId"`
+	Name            string       `json:"name"`
+	Type            string       `json:"type"`
+	Amount          money.Amount `json:"amount"`
+	CategoryID      *string      `json:"categoryId,omitempty"`
+	PaymentMethodID *string      `json:"paymentMethodId,omitempty"`
+}
+
+// ForecastDay is one day of the projected curve
+type ForecastDay struct {
+	Date     string       `json:"date"`
+	Expenses money.Amount `json:"expenses"`
+	Income   money.Amount `json:"income"`
+	Balance  money.Amount `json:"balance"`
+}
+
+// ForecastAssumptions describes what the projection was based on
+type ForecastAssumptions struct {
+	HistoryStart    string       `json:"historyStart"`
+	StartingBalance money.Amount `json:"startingBalance"` // current balances of home currency, non-credit payment methods
+}
+
+// GetForecast projects spending and income for the rest of the month and the next
+// days (30 by default, at most 90). Spending is projected from the current month's
+// pace and the day-of-week pattern of the last 90 days, plus upcoming occurrences
+// of recurring transactions and minimum payments on debts' due dates.
+func (h *ForecastHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	days := 30
+	if s := r.URL.Query().Get("days"); s != "" {
+		d, err := strconv.Atoi(s)
+		if err != nil || d < 1 || d > forecast.MaxDays {
+			utils.BadRequest(w, "days must be between 1 and 90")
+			return
+		}
+		days = d
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	horizon := forecast.Horizon(today, days)
+	home := auth.GetCurrency(r)
+
+	totals, err := h.queries.ListDailyTotals(r.Context(), models.ListDailyTotalsParams{
+		UserID:    utils.PgUUID(userID),
+		StartDate: utils.PgDate(forecast.HistoryStart(today)),
+		EndDate:   utils.PgDate(today),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch spending history")
+		return
+	}
+	flows := make([]forecast.Flow, len(totals))
+	for i, t := range totals {
+		flows[i] = forecast.Flow{
+			Date:            utils.DateToTime(t.TransactionDate),
+			Type:            transactionType(t.Type),
+			CategoryID:      utils.FormatUUID(t.CategoryID),
+			PaymentMethodID: utils.FormatUUID(t.PaymentMethodID),
+			Amount:          money.FromNumeric(t.Total),
+		}
+	}
+
+	scheduled, err := h.scheduled(r.Context(), userID, today, horizon)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch scheduled transactions")
+		return
+	}
+
+	methods, err := h.queries.ListPaymentMethods(r.Context(), utils.PgUUID(userID))
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch payment methods")
+		return
+	}
+	var startingBalance money.Amount
+	for _, m := range methods {
+		if m.IsActive.Bool && m.CurrentBalance.Valid && !m.CreditLimit.Valid && methodCurrency(r, m.Currency) == home {
+			startingBalance += money.FromNumeric(m.CurrentBalance)
+		}
+	}
+
+	result := forecast.Project(forecast.Input{
+		Today:           today,
+		Days:            days,
+		Flows:           flows,
+		Scheduled:       scheduled,
+		StartingBalance: startingBalance,
+	})
+
+	response := ForecastResponse{
+		AsOf:           today.Format("2006-01-02"),
+		MonthEnd:       result.MonthEnd.Format("2006-01-02"),
+		RestOfMonth:    ForecastTotals{Expenses: result.Month.Expenses, Income: result.Month.Income},
+		Categories:     []ForecastCategory{},
+		PaymentMethods: []ForecastPaymentMethod{},
+		Upcoming:       make([]ForecastScheduledItem, len(scheduled)),
+		Daily:          make([]ForecastDay, len(result.Daily)),
+		Assumptions: ForecastAssumptions{
+			HistoryStart:    forecast.HistoryStart(today).Format("2006-01-02"),
+			StartingBalance: startingBalance,
+		},
+	}
+
+	// Budget limits for the current month, when there is a budget for it. Projections
+	// run to the end of the month, so budgets with other periods are left out.
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	budget, err := h.queries.GetBudgetForDate(r.Context(), models.GetBudgetForDateParams{
+		UserID: utils.PgUUID(userID),
+		Date:   utils.PgDate(today),
+	})
+	switch {
+	case err == nil:
+		if period := budgetPeriod(budget); !period.Start.Equal(month) || !period.End.Equal(month.AddDate(0, 1, 0)) {
+			break
+		}
+		if response.Budget, response.Categories, err = h.budgetForecast(r.Context(), budget, result); err != nil {
+			utils.InternalError(w, "Failed to fetch budget spending")
+			return
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		utils.InternalError(w, "Failed to fetch budget")
+		return
+	}
+
+	for _, m := range methods {
+		if !m.IsActive.Bool {
+			continue
+		}
+		projected := result.PaymentMethods[m.ID]
+		method := ForecastPaymentMethod{
+			ID:              m.ID,
+			Name:            m.Name,
+			Currency:        methodCurrency(r, m.Currency),
+			CurrentBalance:  money.FromNumericPtr(m.CurrentBalance),
+			ProjectedSpent:  projected.Expenses,
+			ProjectedIncome: projected.Income,
+		}
+		if method.CurrentBalance != nil && method.Currency == home {
+			balance := *method.CurrentBalance - projected.Expenses + projected.Income
+			if m.CreditLimit.Valid {
+				balance = *method.CurrentBalance + projected.Expenses - projected.Income
+			}
+			method.ProjectedBalance = &balance
+		}
+		response.PaymentMethods = append(response.PaymentMethods, method)
+	}
+
+	for i, s := range scheduled {
+		response.Upcoming[i] = ForecastScheduledItem{
+			Date:            s.Date.Format("2006-01-02"),
+			Kind:            s.Kind,
+			SourceID:        s.SourceID,
+			Name:            s.Name,
+			Type:            s.Type,
+			Amount:          s.Amount,
+			CategoryID:      optionalID(s.CategoryID),
+			PaymentMethodID: optionalID(s.PaymentMethodID),
+		}
+	}
+	for i, d := range result.Daily {
+		response.Daily[i] = ForecastDay{
+			Date:     d.Date.Format("2006-01-02"),
+			Expenses: d.Expenses,
+			Income:   d.Income,
+			Balance:  d.Balance,
+		}
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// Helper functions
+
+// scheduled lists the occurrences of recurring transactions and the debt payments
+// due after today through until, in date order
+func (h *ForecastHandler) scheduled(ctx context.Context, userID string, today, until time.Time) ([]forecast.Scheduled, error) {
+	recurring, err := h.queries.ListRecurringTransactions(ctx, utils.PgUUID(userID))
+	if err != nil {
+		return nil, err
+	}
+	debtList, payments, err := loadDebts(ctx, h.queries, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []forecast.Scheduled
+	for _, t := range recurring {
+		name := utils.TextToString(t.Description)
+		if name == "" {
+			name = "Recurring transaction"
+		}
+		start := utils.DateToTime(t.TransactionDate)
+		for day := today.AddDate(0, 0, 1); ; {
+			due, ok := alerts.NextOccurrence(start, t.RecurrencePattern, day)
+			if !ok || due.After(until) {
+				break
+			}
+			list = append(list, forecast.Scheduled{
+				Date:            due,
+				Kind:            "recurring",
+				SourceID:        t.ID,
+				Name:            name,
+				Type:            transactionType(t.Type),
+				CategoryID:      utils.FormatUUID(t.CategoryID),
+				PaymentMethodID: utils.FormatUUID(t.PaymentMethodID),
+				Amount:          money.FromNumeric(t.HomeAmount),
+			})
+			day = due.AddDate(0, 0, 1)
+		}
+	}
+
+	for _, d := range debtList {
+		p := payments[d.ID]
+		balance := money.FromNumeric(d.Principal) - p.paid
+		minimum := money.FromNumeric(d.MinimumPayment)
+		if balance <= 0 || minimum <= 0 {
+			continue
+		}
+		for day := today.AddDate(0, 0, 1); balance > 0; {
+			due := nextDueDate(int(d.DueDay), day)
+			if due.After(until) {
+				break
+			}
+			day = due.AddDate(0, 0, 1)
+			// This month's payment may already have been made
+			if p.last != nil && p.last.Year() == due.Year() && p.last.Month() == due.Month() {
+				continue
+			}
+			amount := min(minimum, balance)
+			balance -= amount
+			list = append(list, forecast.Scheduled{
+				Date:     due,
+				Kind:     "bill",
+				SourceID: d.ID,
+				Name:     d.Name,
+				Type:     "expense",
+				Amount:   amount,
+			})
+		}
+	}
+
+	forecast.SortScheduled(list)
+	return list, nil
+}
+
+// budgetForecast compares budget's limits with what's spent so far plus the
+// projection for the rest of the month
+func (h *ForecastHandler) budgetForecast(ctx context.Context, budget models.Budget, result forecast.Result) (*ForecastBudget, []ForecastCategory, error) {
+	summary, err := h.queries.GetDashboardSummary(ctx, budget.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	spent, err := money.FromAny(summary.TotalSpent)
+	if err != nil {
+		return nil, nil, err
+	}
+	limits, err := h.queries.GetBudgetCategories(ctx, utils.PgUUID(budget.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+	spending, err := h.queries.GetSpendingByCategory(ctx, utils.PgUUID(budget.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+	spentByCategory := make(map[string]money.Amount, len(spending))
+	for _, s := range spending {
+		amount, err := money.FromAny(s.TotalSpent)
+		if err != nil {
+			return nil, nil, err
+		}
+		spentByCategory[s.ID] = amount
+	}
+
+	totalLimit := money.FromNumeric(budget.TotalLimit)
+	projected := spent + result.Month.Expenses
+	b := &ForecastBudget{
+		ID:             budget.ID,
+		TotalLimit:     totalLimit,
+		Spent:          spent,
+		ProjectedSpent: projected,
+		Difference:     totalLimit - projected,
+	}
+
+	categories := make([]ForecastCategory, len(limits))
+	for i, l := range limits {
+		categoryID := utils.FormatUUID(l.CategoryID)
+		limit := money.FromNumeric(l.LimitAmount)
+		categorySpent := spentByCategory[categoryID]
+		categoryProjected := categorySpent + result.Categories[categoryID].Expenses
+		categories[i] = ForecastCategory{
+			BudgetCategoryID: l.ID,
+			CategoryID:       categoryID,
+			Name:             l.Name,
+			Limit:            limit,
+			Spent:            categorySpent,
+			ProjectedSpent:   categoryProjected,
+			Difference:       limit - categoryProjected,
+			OverLimit:        categoryProjected > limit,
+		}
+	}
+	return b, categories, nil
+}
+
+// transactionType returns a transaction's type, which defaults to expense
+func transactionType(t pgtype.Text) string {
+	if t.Valid && t.String != "" {
+		return t.String
+	}
+	return "expense"
+}
+
+// optionalID returns nil for an empty ID
+func optionalID(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
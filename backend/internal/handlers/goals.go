@@ -0,0 +1,548 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/goals"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// GoalHandler handles savings goal requests
+type GoalHandler struct {
+	queries *models.Queries
+}
+
+// NewGoalHandler creates a new savings goal handler
+func NewGoalHandler(queries *models.Queries) *GoalHandler {
+	return &GoalHandler{queries: queries}
+}
+
+// GoalResponse represents a savings goal and its progress in API responses
+type GoalResponse struct {
+	ID                   string        `json:"id"`
+	Name                 string        `json:"name"`
+	TargetAmount         money.Amount  `json:"targetAmount"`
+	TargetDate           *string       `json:"targetDate,omitempty"`
+	PaymentMethodID      *string       `json:"paymentMethodId,omitempty"`
+	Saved                money.Amount  `json:"saved"`
+	Remaining            money.Amount  `json:"remaining"`
+	Percent              float64       `json:"percent"`
+	ContributedThisMonth money.Amount  `json:"contributedThisMonth"`
+	MonthsLeft           int           `json:"monthsLeft,omitempty"`
+	RequiredMonthly      *money.Amount `json:"requiredMonthly,omitempty"`
+	MonthlyPace          money.Amount  `json:"monthlyPace"`
+	ProjectedCompletion  *string       `json:"projectedCompletion,omitempty"`
+	Status               goals.Status  `json:"status"`
+	CreatedAt            string        `json:"createdAt"`
+	UpdatedAt            string        `json:"updatedAt"`
+}
+
+// BudgetGoalLine is a savings goal shown as a line of a monthly budget
+type BudgetGoalLine struct {
+	GoalID      string        `json:"goalId"`
+	Name        string        `json:"name"`
+	Planned     *money.Amount `json:"planned,omitempty"` // the required monthly contribution, if the goal has a target date
+	Contributed money.Amount  `json:"contributed"`       // net contributions during the budget month
+	Status      goals.Status  `json:"status"`
+}
+
+// CreateGoalRequest represents the create savings goal request
+type CreateGoalRequest struct {
+	Name            string       `json:"name"`
+	TargetAmount    money.Amount `json:"targetAmount"` // in the home currency
+	TargetDate      *string      `json:"targetDate,omitempty"`
+	PaymentMethodID *string      `json:"paymentMethodId,omitempty"`
+}
+
+// UpdateGoalRequest represents the update savings goal request
+type UpdateGoalRequest struct {
+	Name            *string       `json:"name,omitempty"`
+	TargetAmount    *money.Amount `json:"targetAmount,omitempty"`
+	TargetDate      *string       `json:"targetDate,omitempty"`
+	PaymentMethodID *string       `json:"paymentMethodId,omitempty"`
+}
+
+// TransferRequest represents a contribution to a savings goal (a negative amount withdraws
+// from it) or a payment toward a debt
+type TransferRequest struct {
+	Amount              money.Amount `json:"amount"`
+	Currency            *string      `json:"currency,omitempty"`
+	FromPaymentMethodID *string      `json:"fromPaymentMethodId,omitempty"`
+	BudgetID            *string      `json:"budgetId,omitempty"`
+	Description         *string      `json:"description,omitempty"`
+	Date                string       `json:"date"`
+}
+
+// goalTotals holds a goal's net contributions from GetSavingsGoalTotals
+type goalTotals struct {
+	saved, recent, month money.Amount
+}
+
+// ListGoals returns the current user's savings goals with their progress
+func (h *GoalHandler) ListGoals(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	list, err := h.queries.ListSavingsGoals(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch savings goals")
+		return
+	}
+
+	today := time.Now()
+	totals, err := loadGoalTotals(r.Context(), h.queries, userID, today)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch savings goals")
+		return
+	}
+
+	response := make([]GoalResponse, len(list))
+	for i, g := range list {
+		response[i] = goalToResponse(g, totals[g.ID], today)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// GetGoal returns a savings goal with its progress
+func (h *GoalHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	goal, ok := h.ownGoal(w, r, userID)
+	if !ok {
+		return
+	}
+
+	response, err := h.goalResponse(r.Context(), goal)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch savings goal")
+		return
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// CreateGoal creates a savings goal
+func (h *GoalHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var req CreateGoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		utils.BadRequest(w, "Name is required and must be at most 100 characters")
+		return
+	}
+	if req.TargetAmount <= 0 {
+		utils.BadRequest(w, "Target amount must be positive")
+		return
+	}
+	if !checkAmounts(w, r, &req.TargetAmount) {
+		return
+	}
+	targetDate, ok := parseOptionalDate(w, req.TargetDate, "target date")
+	if !ok {
+		return
+	}
+	if !checkPaymentMethod(w, r, h.queries, req.PaymentMethodID, userID) {
+		return
+	}
+
+	goal, err := h.queries.CreateSavingsGoal(r.Context(), models.CreateSavingsGoalParams{
+		UserID:          userID,
+		Name:            req.Name,
+		TargetAmount:    req.TargetAmount.Numeric(),
+		TargetDate:      utils.PgDatePtr(targetDate),
+		PaymentMethodID: utils.PgUUIDPtr(req.PaymentMethodID),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to create savings goal")
+		return
+	}
+
+	response := goalToResponse(goal, goalTotals{}, time.Now())
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "savings_goal.created",
+		ResourceType: activity.ResourceSavingsGoal,
+		ResourceID:   goal.ID,
+		After:        goalSettings(response),
+	})
+
+	utils.SendCreated(w, response)
+}
+
+// UpdateGoal updates a savings goal
+func (h *GoalHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	before, ok := h.ownGoal(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req UpdateGoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	var name *string
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		if trimmed == "" || len(trimmed) > 100 {
+			utils.BadRequest(w, "Name must be between 1 and 100 characters")
+			return
+		}
+		name = &trimmed
+	}
+	if req.TargetAmount != nil && *req.TargetAmount <= 0 {
+		utils.BadRequest(w, "Target amount must be positive")
+		return
+	}
+	if !checkAmounts(w, r, req.TargetAmount) {
+		return
+	}
+	targetDate, ok := parseOptionalDate(w, req.TargetDate, "target date")
+	if !ok {
+		return
+	}
+	if !checkPaymentMethod(w, r, h.queries, req.PaymentMethodID, userID) {
+		return
+	}
+
+	goal, err := h.queries.UpdateSavingsGoal(r.Context(), models.UpdateSavingsGoalParams{
+		ID:              before.ID,
+		Name:            utils.PgTextPtr(name),
+		TargetAmount:    money.NumericPtr(req.TargetAmount),
+		TargetDate:      utils.PgDatePtr(targetDate),
+		PaymentMethodID: utils.PgUUIDPtr(req.PaymentMethodID),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to update savings goal")
+		return
+	}
+
+	response, err := h.goalResponse(r.Context(), goal)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch savings goal")
+		return
+	}
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "savings_goal.updated",
+		ResourceType: activity.ResourceSavingsGoal,
+		ResourceID:   goal.ID,
+		Before:       goalSettings(goalToResponse(before, goalTotals{}, time.Now())),
+		After:        goalSettings(response),
+	})
+
+	utils.SendSuccess(w, response)
+}
+
+// DeleteGoal soft deletes a savings goal. Its contributions stay as ordinary transactions.
+func (h *GoalHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	before, ok := h.ownGoal(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.queries.DeleteSavingsGoal(r.Context(), before.ID); err != nil {
+		utils.InternalError(w, "Failed to delete savings goal")
+		return
+	}
+
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "savings_goal.deleted",
+		ResourceType: activity.ResourceSavingsGoal,
+		ResourceID:   before.ID,
+		Before:       goalSettings(goalToResponse(before, goalTotals{}, time.Now())),
+	})
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Savings goal deleted successfully",
+	})
+}
+
+// ListContributions returns the transactions recorded against a goal
+func (h *GoalHandler) ListContributions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	goal, ok := h.ownGoal(w, r, userID)
+	if !ok {
+		return
+	}
+
+	transactions, err := h.queries.ListGoalContributions(r.Context(), utils.PgUUID(goal.ID))
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch contributions")
+		return
+	}
+
+	response := make([]TransactionResponse, len(transactions))
+	for i, t := range transactions {
+		response[i] = transactionToResponse(t)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// AddContribution records a transfer into the goal's account (or out of it, for a
+// negative amount) as a transaction linked to the goal
+func (h *GoalHandler) AddContribution(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	goal, ok := h.ownGoal(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+	if req.Amount == 0 {
+		utils.BadRequest(w, "Amount must not be zero")
+		return
+	}
+
+	params, ok := transferParams(w, r, h.queries, userID, req)
+	if !ok {
+		return
+	}
+	params.TransferToAccountID = goal.PaymentMethodID
+	params.GoalID = utils.PgUUID(goal.ID)
+	if req.Description == nil {
+		text := "Contribution to " + goal.Name
+		if req.Amount < 0 {
+			text = "Withdrawal from " + goal.Name
+		}
+		params.Description = utils.PgText(text)
+	}
+
+	transaction, err := h.queries.CreateTransaction(r.Context(), params)
+	if err != nil {
+		utils.InternalError(w, "Failed to record contribution")
+		return
+	}
+
+	response := transactionToResponse(transaction)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "transaction.created",
+		ResourceType: activity.ResourceTransaction,
+		ResourceID:   transaction.ID,
+		BudgetID:     utils.UUIDToString(transaction.BudgetID),
+		After:        response,
+		Details:      map[string]interface{}{"goalId": goal.ID},
+	})
+
+	utils.SendCreated(w, response)
+}
+
+// Helper functions
+
+// ownGoal loads the goal in the path, responding with 404 unless it belongs to userID
+func (h *GoalHandler) ownGoal(w http.ResponseWriter, r *http.Request, userID string) (models.SavingsGoal, bool) {
+	goalID := r.PathValue("id")
+	if goalID == "" {
+		utils.BadRequest(w, "Goal ID is required")
+		return models.SavingsGoal{}, false
+	}
+
+	goal, err := h.queries.GetSavingsGoalByID(r.Context(), goalID)
+	if err != nil || goal.UserID != userID {
+		utils.NotFound(w, "Savings goal not found")
+		return models.SavingsGoal{}, false
+	}
+	return goal, true
+}
+
+// checkPaymentMethod verifies that a linked payment method belongs to the user
+func checkPaymentMethod(w http.ResponseWriter, r *http.Request, q *models.Queries, methodID *string, userID string) bool {
+	if methodID == nil {
+		return true
+	}
+	method, err := q.GetPaymentMethodByID(r.Context(), *methodID)
+	if err != nil || !utils.UUIDEquals(method.UserID, userID) {
+		utils.BadRequest(w, "Payment method not found")
+		return false
+	}
+	return true
+}
+
+// goalResponse computes a single goal's progress as of today
+func (h *GoalHandler) goalResponse(ctx context.Context, goal models.SavingsGoal) (GoalResponse, error) {
+	today := time.Now()
+	totals, err := loadGoalTotals(ctx, h.queries, goal.UserID, today)
+	if err != nil {
+		return GoalResponse{}, err
+	}
+	return goalToResponse(goal, totals[goal.ID], today), nil
+}
+
+// loadGoalTotals returns the net contributions of each of the user's goals, with the
+// month totals for the month containing day
+func loadGoalTotals(ctx context.Context, q *models.Queries, userID string, day time.Time) (map[string]goalTotals, error) {
+	rows, err := q.GetSavingsGoalTotals(ctx, models.GetSavingsGoalTotalsParams{
+		RecentSince: utils.PgDate(day.AddDate(0, 0, -goals.PaceWindowDays)),
+		MonthStart:  utils.PgDate(time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)),
+		UserID:      userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]goalTotals, len(rows))
+	for _, row := range rows {
+		totals[row.GoalID] = goalTotals{
+			saved:  money.FromNumeric(row.Saved),
+			recent: money.FromNumeric(row.Recent),
+			month:  money.FromNumeric(row.MonthTotal),
+		}
+	}
+	return totals, nil
+}
+
+// budgetGoalLines returns the user's goals as lines of the budget for month
+func budgetGoalLines(ctx context.Context, q *models.Queries, userID string, month time.Time) ([]BudgetGoalLine, error) {
+	list, err := q.ListSavingsGoals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := loadGoalTotals(ctx, q, userID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	// Progress is as of today, but no earlier than the start of the budget month
+	today := time.Now()
+	if today.Before(month) {
+		today = month
+	}
+
+	lines := make([]BudgetGoalLine, len(list))
+	for i, g := range list {
+		t := totals[g.ID]
+		progress := goals.Compute(goalTarget(g), t.saved, t.recent, today)
+		lines[i] = BudgetGoalLine{
+			GoalID:      g.ID,
+			Name:        g.Name,
+			Planned:     progress.RequiredMonthly,
+			Contributed: t.month,
+			Status:      progress.Status,
+		}
+	}
+	return lines, nil
+}
+
+func goalTarget(g models.SavingsGoal) goals.Goal {
+	goal := goals.Goal{Target: money.FromNumeric(g.TargetAmount)}
+	if g.TargetDate.Valid {
+		date := utils.DateToTime(g.TargetDate)
+		goal.TargetDate = &date
+	}
+	return goal
+}
+
+func goalToResponse(g models.SavingsGoal, t goalTotals, today time.Time) GoalResponse {
+	progress := goals.Compute(goalTarget(g), t.saved, t.recent, today)
+
+	resp := GoalResponse{
+		ID:                   g.ID,
+		Name:                 g.Name,
+		TargetAmount:         money.FromNumeric(g.TargetAmount),
+		PaymentMethodID:      uuidPtrToString(g.PaymentMethodID),
+		Saved:                progress.Saved,
+		Remaining:            progress.Remaining,
+		Percent:              progress.Percent,
+		ContributedThisMonth: t.month,
+		MonthsLeft:           progress.MonthsLeft,
+		RequiredMonthly:      progress.RequiredMonthly,
+		MonthlyPace:          progress.MonthlyPace,
+		Status:               progress.Status,
+		CreatedAt:            utils.TimestamptzToTime(g.CreatedAt).Format(time.RFC3339),
+		UpdatedAt:            utils.TimestamptzToTime(g.UpdatedAt).Format(time.RFC3339),
+	}
+	if g.TargetDate.Valid {
+		s := utils.DateToTime(g.TargetDate).Format("2006-01-02")
+		resp.TargetDate = &s
+	}
+	if progress.ProjectedCompletion != nil {
+		s := progress.ProjectedCompletion.Format("2006-01-02")
+		resp.ProjectedCompletion = &s
+	}
+	return resp
+}
+
+// goalSettings is the part of a goal that users edit, recorded in the activity log
+// without the progress figures that change with every contribution
+func goalSettings(g GoalResponse) map[string]interface{} {
+	settings := map[string]interface{}{
+		"name":         g.Name,
+		"targetAmount": g.TargetAmount,
+	}
+	if g.TargetDate != nil {
+		settings["targetDate"] = *g.TargetDate
+	}
+	if g.PaymentMethodID != nil {
+		settings["paymentMethodId"] = *g.PaymentMethodID
+	}
+	return settings
+}
+
+// parseOptionalDate parses an optional YYYY-MM-DD field, responding with 400 if it's invalid
+func parseOptionalDate(w http.ResponseWriter, s *string, field string) (*time.Time, bool) {
+	if s == nil {
+		return nil, true
+	}
+	t, err := time.Parse("2006-01-02", *s)
+	if err != nil {
+		utils.BadRequest(w, "Invalid "+field+" format. Use YYYY-MM-DD")
+		return nil, false
+	}
+	return &t, true
+}
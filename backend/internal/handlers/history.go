@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// Tables with revision history (record_revisions.table_name)
+const (
+	historyTransactions     = "transactions"
+	historyBudgets          = "budgets"
+	historyBudgetCategories = "budget_categories"
+)
+
+// historyResource describes a versioned table for messages and the activity log
+type historyResource struct {
+	table        string
+	label        string // e.g. "Transaction"
+	resourceType string
+}
+
+var (
+	transactionHistory    = historyResource{historyTransactions, "Transaction", activity.ResourceTransaction}
+	budgetHistory         = historyResource{historyBudgets, "Budget", activity.ResourceBudget}
+	budgetCategoryHistory = historyResource{historyBudgetCategories, "Budget category", activity.ResourceBudgetCategory}
+)
+
+// HistoryHandler handles revision history and restore requests
+type HistoryHandler struct {
+	queries *models.Queries
+	pool    *pgxpool.Pool
+}
+
+// NewHistoryHandler creates a new history handler
+func NewHistoryHandler(queries *models.Queries, pool *pgxpool.Pool) *HistoryHandler {
+	return &HistoryHandler{
+		queries: queries,
+		pool:    pool,
+	}
+}
+
+// RevisionResponse represents a single revision of a record in API responses
+type RevisionResponse struct {
+	Revision  int32                      `json:"revision"`
+	Operation string                     `json:"operation"` // create, update, delete, restore
+	Data      map[string]interface{}     `json:"data"`
+	Changes   map[string]activity.Change `json:"changes,omitempty"` // compared with the previous revision
+	CreatedAt string                     `json:"createdAt"`
+}
+
+// RestoreRequest represents a restore request. Revision may be omitted to undelete
+// a deleted record as it was when it was deleted.
+type RestoreRequest struct {
+	Revision *int32 `json:"revision,omitempty"`
+}
+
+// ListTransactionRevisions returns the revisions of a transaction, newest first
+func (h *HistoryHandler) ListTransactionRevisions(w http.ResponseWriter, r *http.Request) {
+	h.listRevisions(w, r, transactionHistory, r.PathValue("id"))
+}
+
+// RestoreTransaction reverts a transaction to an earlier revision or undeletes it
+func (h *HistoryHandler) RestoreTransaction(w http.ResponseWriter, r *http.Request) {
+	h.restore(w, r, transactionHistory, r.PathValue("id"))
+}
+
+// ListBudgetRevisions returns the revisions of a budget, newest first
+func (h *HistoryHandler) ListBudgetRevisions(w http.ResponseWriter, r *http.Request) {
+	h.listRevisions(w, r, budgetHistory, r.PathValue("id"))
+}
+
+// RestoreBudget reverts a budget to an earlier revision or undeletes it
+func (h *HistoryHandler) RestoreBudget(w http.ResponseWriter, r *http.Request) {
+	h.restore(w, r, budgetHistory, r.PathValue("id"))
+}
+
+// ListBudgetCategoryRevisions returns the revisions of a budget category, newest first
+func (h *HistoryHandler) ListBudgetCategoryRevisions(w http.ResponseWriter, r *http.Request) {
+	h.listRevisions(w, r, budgetCategoryHistory, r.PathValue("categoryId"))
+}
+
+// RestoreBudgetCategory reverts a budget category to an earlier revision or re-adds a removed one
+func (h *HistoryHandler) RestoreBudgetCategory(w http.ResponseWriter, r *http.Request) {
+	h.restore(w, r, budgetCategoryHistory, r.PathValue("categoryId"))
+}
+
+func (h *HistoryHandler) listRevisions(w http.ResponseWriter, r *http.Request, res historyResource, recordID string) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	if recordID == "" {
+		utils.BadRequest(w, res.label+" ID is required")
+		return
+	}
+
+	revisions, err := h.queries.ListRecordRevisions(r.Context(), models.ListRecordRevisionsParams{
+		TableName: res.table,
+		RecordID:  recordID,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch revisions")
+		return
+	}
+	if len(revisions) == 0 {
+		utils.NotFound(w, res.label+" not found")
+		return
+	}
+
+	// Access follows the record's current owner and budget
+	permission, err := h.revisionPermission(r.Context(), h.queries, userID, revisions[0])
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch revisions")
+		return
+	}
+	if permission == "" {
+		utils.NotFound(w, res.label+" not found")
+		return
+	}
+
+	response := make([]RevisionResponse, len(revisions))
+	for i, rev := range revisions {
+		var previous *models.RecordRevision
+		if i+1 < len(revisions) {
+			previous = &revisions[i+1]
+		}
+		response[i] = revisionToResponse(rev, previous)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+func (h *HistoryHandler) restore(w http.ResponseWriter, r *http.Request, res historyResource, recordID string) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	if recordID == "" {
+		utils.BadRequest(w, res.label+" ID is required")
+		return
+	}
+
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := h.queries.WithTx(tx)
+
+	latest, err := qtx.GetLatestRecordRevision(r.Context(), models.GetLatestRecordRevisionParams{
+		TableName: res.table,
+		RecordID:  recordID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			utils.NotFound(w, res.label+" not found")
+			return
+		}
+		utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+		return
+	}
+
+	permission, err := h.revisionPermission(r.Context(), qtx, userID, latest)
+	if err != nil {
+		utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+		return
+	}
+	if permission == "" {
+		utils.NotFound(w, res.label+" not found")
+		return
+	}
+	if permission == "view" {
+		utils.Forbidden(w, "You don't have permission to restore this "+strings.ToLower(res.label))
+		return
+	}
+
+	target := latest
+	if req.Revision == nil {
+		if latest.Operation != "delete" {
+			utils.BadRequest(w, "Revision is required unless the "+strings.ToLower(res.label)+" is deleted")
+			return
+		}
+	} else if *req.Revision != latest.Revision {
+		target, err = qtx.GetRecordRevision(r.Context(), models.GetRecordRevisionParams{
+			TableName: res.table,
+			RecordID:  recordID,
+			Revision:  *req.Revision,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				utils.NotFound(w, "Revision not found")
+				return
+			}
+			utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+			return
+		}
+
+		// The old revision may belong to a different budget
+		permission, err = h.revisionPermission(r.Context(), qtx, userID, target)
+		if err != nil {
+			utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+			return
+		}
+		if permission == "" || permission == "view" {
+			utils.Forbidden(w, "You don't have permission to restore this revision")
+			return
+		}
+	} else if latest.Operation != "delete" {
+		utils.BadRequest(w, res.label+" is already at this revision")
+		return
+	}
+
+	// Labels the revision written by the trigger as a restore
+	if err := qtx.MarkRestoring(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+		return
+	}
+
+	// Restores bump updated_at so offline clients pick them up on their next pull
+	var budgetID string
+	var restored *models.Transaction
+	switch res.table {
+	case historyTransactions:
+		t, err := qtx.RestoreTransaction(r.Context(), models.RestoreTransactionParams{
+			Data: target.Data,
+			ID:   recordID,
+		})
+		if err != nil {
+			h.restoreError(w, res, err)
+			return
+		}
+		// Strict envelopes hold restored expenses to their balance too. The restored
+		// row already counts against it, so it's checked as an edit of itself.
+		draw := transactionDraw(t)
+		if !checkEnvelope(w, r, qtx, draw, draw) {
+			return
+		}
+		budgetID = utils.UUIDToString(t.BudgetID)
+		restored = &t
+	case historyBudgets:
+		b, err := qtx.RestoreBudget(r.Context(), models.RestoreBudgetParams{
+			Data: target.Data,
+			ID:   recordID,
+		})
+		if err != nil {
+			h.restoreError(w, res, err)
+			return
+		}
+		budgetID = b.ID
+	case historyBudgetCategories:
+		bc, err := qtx.RestoreBudgetCategory(r.Context(), target.Data)
+		if err != nil {
+			h.restoreError(w, res, err)
+			return
+		}
+		budgetID = utils.UUIDToString(bc.BudgetID)
+	}
+
+	current, err := qtx.GetLatestRecordRevision(r.Context(), models.GetLatestRecordRevisionParams{
+		TableName: res.table,
+		RecordID:  recordID,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+		return
+	}
+
+	if err := activity.Record(r.Context(), qtx, r, activity.Entry{
+		UserID:       userID,
+		Action:       res.resourceType + ".restored",
+		ResourceType: res.resourceType,
+		ResourceID:   recordID,
+		BudgetID:     budgetID,
+		Before:       revisionData(latest.Data),
+		After:        revisionData(current.Data),
+		Details: map[string]interface{}{
+			"revision": target.Revision,
+		},
+	}); err != nil {
+		utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+		return
+	}
+	if restored != nil {
+		checkAlerts(r, h.pool, h.queries, *restored)
+	}
+
+	if current.Revision == latest.Revision {
+		// The chosen revision matched the current state, so no new revision was written
+		utils.SendSuccess(w, revisionToResponse(current, nil))
+		return
+	}
+	utils.SendSuccess(w, revisionToResponse(current, &latest))
+}
+
+// restoreError maps a failed restore to a response
+func (h *HistoryHandler) restoreError(w http.ResponseWriter, res historyResource, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.NotFound(w, res.label+" not found")
+		return
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505", "23P01":
+			utils.Conflict(w, "Restoring this revision would conflict with an existing record")
+			return
+		case "23503":
+			utils.Conflict(w, "Something this revision refers to no longer exists")
+			return
+		}
+	}
+	utils.InternalError(w, "Failed to restore "+strings.ToLower(res.label))
+}
+
+// revisionPermission returns the user's permission on the record as of rev:
+// "owner" for their own records, otherwise their access to the record's budget.
+// An empty permission means the user has no access.
+func (h *HistoryHandler) revisionPermission(ctx context.Context, q *models.Queries, userID string, rev models.RecordRevision) (string, error) {
+	var ref struct {
+		UserID   string `json:"user_id"`
+		BudgetID string `json:"budget_id"`
+	}
+	if err := json.Unmarshal(rev.Data, &ref); err != nil {
+		return "", err
+	}
+	if rev.TableName == historyBudgets {
+		ref.BudgetID = rev.RecordID
+	}
+
+	if ref.UserID != "" && ref.UserID == userID {
+		return "owner", nil
+	}
+	if ref.BudgetID == "" {
+		return "", nil
+	}
+
+	access, err := q.CheckBudgetAccess(ctx, models.CheckBudgetAccessParams{
+		ID:     ref.BudgetID,
+		UserID: utils.PgUUID(userID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return access.Permission, nil
+}
+
+// Helper functions
+
+func revisionToResponse(rev models.RecordRevision, previous *models.RecordRevision) RevisionResponse {
+	resp := RevisionResponse{
+		Revision:  rev.Revision,
+		Operation: rev.Operation,
+		Data:      revisionData(rev.Data),
+		CreatedAt: utils.TimestamptzToTime(rev.CreatedAt).Format(time.RFC3339),
+	}
+	if previous != nil {
+		if changes := activity.Diff(revisionData(previous.Data), resp.Data); len(changes) > 0 {
+			resp.Changes = changes
+		}
+	}
+	return resp
+}
+
+// revisionData decodes a stored row snapshot, renaming its columns to the
+// camelCase field names used in API responses
+func revisionData(data []byte) map[string]interface{} {
+	// Numbers keep their literal text so amounts aren't rounded through float64
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var row map[string]interface{}
+	if err := decoder.Decode(&row); err != nil {
+		return nil
+	}
+
+	out := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		parts := strings.Split(column, "_")
+		for i := 1; i < len(parts); i++ {
+			if parts[i] != "" {
+				parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+			}
+		}
+		out[strings.Join(parts, "")] = value
+	}
+	return out
+}
@@ -0,0 +1,724 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/income"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/periods"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// IncomeHandler handles income source and income plan requests
+type IncomeHandler struct {
+	queries *models.Queries
+}
+
+// NewIncomeHandler creates a new income handler
+func NewIncomeHandler(queries *models.Queries) *IncomeHandler {
+	return &IncomeHandler{queries: queries}
+}
+
+// IncomeSourceResponse represents an income source in API responses
+type IncomeSourceResponse struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	Kind       string       `json:"kind"`
+	CategoryID *string      `json:"categoryId"`
+	Amount     money.Amount `json:"amount"` // expected each month it pays in, in the home currency
+	Months     []int        `json:"months"` // months of the year it pays in, empty for every month
+	StartMonth string       `json:"startMonth"`
+	EndMonth   *string      `json:"endMonth"`
+	CreatedAt  string       `json:"createdAt"`
+	UpdatedAt  string       `json:"updatedAt"`
+}
+
+// CreateIncomeSourceRequest represents the create income source request
+type CreateIncomeSourceRequest struct {
+	Name       string       `json:"name"`
+	Kind       string       `json:"kind"`
+	CategoryID *string      `json:"categoryId,omitempty"` // an income category whose transactions count as received from it
+	Amount     money.Amount `json:"amount"`
+	Months     []int        `json:"months,omitempty"`
+	StartMonth *string      `json:"startMonth,omitempty"` // YYYY-MM, defaults to this month
+	EndMonth   *string      `json:"endMonth,omitempty"`
+}
+
+// UpdateIncomeSourceRequest represents the update income source request. An empty
+// categoryId or endMonth clears it, and empty months means every month.
+type UpdateIncomeSourceRequest struct {
+	Name       *string       `json:"name,omitempty"`
+	Kind       *string       `json:"kind,omitempty"`
+	CategoryID *string       `json:"categoryId,omitempty"`
+	Amount     *money.Amount `json:"amount,omitempty"`
+	Months     *[]int        `json:"months,omitempty"`
+	StartMonth *string       `json:"startMonth,omitempty"`
+	EndMonth   *string       `json:"endMonth,omitempty"`
+}
+
+// ExpectedIncomeRequest sets what a source is expected to pay in one month
+type ExpectedIncomeRequest struct {
+	Amount money.Amount `json:"amount"`
+}
+
+// IncomePlanResponse is a month's expected income against what arrived, from the
+// user's own transactions. Amounts are in the home currency.
+type IncomePlanResponse struct {
+	Month       string            `json:"month"`
+	Expected    money.Amount      `json:"expected"`
+	Actual      money.Amount      `json:"actual"`
+	Unplanned   money.Amount      `json:"unplanned"` // income in categories no source claims
+	Variance    money.Amount      `json:"variance"`  // actual less expected
+	Expenses    money.Amount      `json:"expenses"`
+	SavingsRate *float64          `json:"savingsRate"` // share of income not spent, null without income
+	Sources     []IncomePlanLine  `json:"sources"`
+	Budget      *BudgetAllocation `json:"budget"` // null without a budget for the month
+}
+
+// IncomePlanLine is a source's expected and received income in a month
+type IncomePlanLine struct {
+	SourceID   string       `json:"sourceId"`
+	Name       string       `json:"name"`
+	Kind       string       `json:"kind"`
+	Expected   money.Amount `json:"expected"`
+	Actual     money.Amount `json:"actual"`
+	Variance   money.Amount `json:"variance"`
+	Overridden bool         `json:"overridden"` // expected was set for this month
+}
+
+// BudgetAllocation is how much of a month's income is allocated to its budget's
+// categories, for zero-based budgeting
+type BudgetAllocation struct {
+	BudgetID     string       `json:"budgetId"`
+	Basis        string       `json:"basis"` // expected, or actual when no source pays in the month
+	Income       money.Amount `json:"income"`
+	Allocated    money.Amount `json:"allocated"`
+	LeftToBudget money.Amount `json:"leftToBudget"`
+	Status       string       `json:"status"` // under, balanced or over
+}
+
+// ListIncomeSources returns the current user's income sources
+func (h *IncomeHandler) ListIncomeSources(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	sources, err := h.queries.ListIncomeSources(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch income sources")
+		return
+	}
+
+	response := make([]IncomeSourceResponse, len(sources))
+	for i, s := range sources {
+		response[i] = incomeSourceToResponse(s)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// CreateIncomeSource creates an income source
+func (h *IncomeHandler) CreateIncomeSource(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var req CreateIncomeSourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	if req.Kind == "" {
+		req.Kind = income.KindOther
+	}
+	if !validCategoryID(w, req.CategoryID) {
+		return
+	}
+	params := models.CreateIncomeSourceParams{
+		UserID:     userID,
+		Name:       strings.TrimSpace(req.Name),
+		Kind:       req.Kind,
+		CategoryID: utils.PgUUIDPtr(req.CategoryID),
+		Amount:     req.Amount.Numeric(),
+		Months:     incomeMonths(req.Months),
+		StartMonth: utils.PgDate(monthOf(time.Now())),
+	}
+	if req.StartMonth != nil {
+		start, ok := parseMonthParam(w, *req.StartMonth, "startMonth")
+		if !ok {
+			return
+		}
+		params.StartMonth = utils.PgDate(start)
+	}
+	if req.EndMonth != nil && *req.EndMonth != "" {
+		end, ok := parseMonthParam(w, *req.EndMonth, "endMonth")
+		if !ok {
+			return
+		}
+		params.EndMonth = utils.PgDate(end)
+	}
+	if !h.validateIncomeSource(w, r, userID, models.IncomeSource{
+		Name:       params.Name,
+		Kind:       params.Kind,
+		CategoryID: params.CategoryID,
+		Amount:     params.Amount,
+		Months:     params.Months,
+		StartMonth: params.StartMonth,
+		EndMonth:   params.EndMonth,
+	}) {
+		return
+	}
+
+	source, err := h.queries.CreateIncomeSource(r.Context(), params)
+	if isCategoryTaken(err) {
+		utils.Conflict(w, "Another income source already uses this category")
+		return
+	}
+	if err != nil {
+		utils.InternalError(w, "Failed to create income source")
+		return
+	}
+
+	response := incomeSourceToResponse(source)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "income_source.created",
+		ResourceType: activity.ResourceIncomeSource,
+		ResourceID:   source.ID,
+		After:        response,
+	})
+
+	utils.SendCreated(w, response)
+}
+
+// UpdateIncomeSource updates an income source
+func (h *IncomeHandler) UpdateIncomeSource(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	before, ok := h.ownIncomeSource(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req UpdateIncomeSourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	updated := before
+	if req.Name != nil {
+		updated.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Kind != nil {
+		updated.Kind = *req.Kind
+	}
+	if req.CategoryID != nil {
+		if !validCategoryID(w, req.CategoryID) {
+			return
+		}
+		updated.CategoryID = utils.PgUUIDPtr(req.CategoryID)
+	}
+	if req.Amount != nil {
+		updated.Amount = req.Amount.Numeric()
+	}
+	if req.Months != nil {
+		updated.Months = incomeMonths(*req.Months)
+	}
+	if req.StartMonth != nil {
+		start, ok := parseMonthParam(w, *req.StartMonth, "startMonth")
+		if !ok {
+			return
+		}
+		updated.StartMonth = utils.PgDate(start)
+	}
+	if req.EndMonth != nil {
+		updated.EndMonth = pgtype.Date{}
+		if *req.EndMonth != "" {
+			end, ok := parseMonthParam(w, *req.EndMonth, "endMonth")
+			if !ok {
+				return
+			}
+			updated.EndMonth = utils.PgDate(end)
+		}
+	}
+	if !h.validateIncomeSource(w, r, userID, updated) {
+		return
+	}
+
+	source, err := h.queries.UpdateIncomeSource(r.Context(), models.UpdateIncomeSourceParams{
+		ID:         before.ID,
+		Name:       updated.Name,
+		Kind:       updated.Kind,
+		CategoryID: updated.CategoryID,
+		Amount:     updated.Amount,
+		Months:     updated.Months,
+		StartMonth: updated.StartMonth,
+		EndMonth:   updated.EndMonth,
+	})
+	if isCategoryTaken(err) {
+		utils.Conflict(w, "Another income source already uses this category")
+		return
+	}
+	if err != nil {
+		utils.InternalError(w, "Failed to update income source")
+		return
+	}
+
+	response := incomeSourceToResponse(source)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "income_source.updated",
+		ResourceType: activity.ResourceIncomeSource,
+		ResourceID:   source.ID,
+		Before:       incomeSourceToResponse(before),
+		After:        response,
+	})
+
+	utils.SendSuccess(w, response)
+}
+
+// DeleteIncomeSource soft deletes an income source
+func (h *IncomeHandler) DeleteIncomeSource(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	before, ok := h.ownIncomeSource(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.queries.DeleteIncomeSource(r.Context(), before.ID); err != nil {
+		utils.InternalError(w, "Failed to delete income source")
+		return
+	}
+
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "income_source.deleted",
+		ResourceType: activity.ResourceIncomeSource,
+		ResourceID:   before.ID,
+		Before:       incomeSourceToResponse(before),
+	})
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Income source deleted successfully",
+	})
+}
+
+// SetExpectedIncome sets what a source is expected to pay in the month in the path,
+// in place of its usual amount
+func (h *IncomeHandler) SetExpectedIncome(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	source, ok := h.ownIncomeSource(w, r, userID)
+	if !ok {
+		return
+	}
+	month, ok := parseMonthParam(w, r.PathValue("month"), "month")
+	if !ok {
+		return
+	}
+
+	var req ExpectedIncomeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+	if req.Amount < 0 {
+		utils.BadRequest(w, "Amount must not be negative")
+		return
+	}
+	if !checkAmounts(w, r, &req.Amount) {
+		return
+	}
+
+	expectation, err := h.queries.UpsertIncomeExpectation(r.Context(), models.UpsertIncomeExpectationParams{
+		SourceID: source.ID,
+		Month:    utils.PgDate(month),
+		Amount:   req.Amount.Numeric(),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to set expected income")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]interface{}{
+		"sourceId": expectation.SourceID,
+		"month":    utils.DateToTime(expectation.Month).Format("2006-01"),
+		"amount":   money.FromNumeric(expectation.Amount),
+	})
+}
+
+// ClearExpectedIncome goes back to a source's usual amount for the month in the path
+func (h *IncomeHandler) ClearExpectedIncome(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	source, ok := h.ownIncomeSource(w, r, userID)
+	if !ok {
+		return
+	}
+	month, ok := parseMonthParam(w, r.PathValue("month"), "month")
+	if !ok {
+		return
+	}
+
+	rows, err := h.queries.DeleteIncomeExpectation(r.Context(), models.DeleteIncomeExpectationParams{
+		SourceID: source.ID,
+		Month:    utils.PgDate(month),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to clear expected income")
+		return
+	}
+	if rows == 0 {
+		utils.NotFound(w, "No expected income set for this month")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Expected income cleared successfully",
+	})
+}
+
+// GetIncomePlan compares the income the user's sources expect in a month (YYYY-MM)
+// with their own income transactions, with the savings rate and, when there's a
+// budget for the month, how much income is left to allocate to its categories
+func (h *IncomeHandler) GetIncomePlan(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	month, ok := parseMonthParam(w, r.PathValue("month"), "month")
+	if !ok {
+		return
+	}
+
+	totals, err := h.queries.ListMonthTotalsByCategory(r.Context(), models.ListMonthTotalsByCategoryParams{
+		UserID: utils.PgUUID(userID),
+		Month:  utils.PgDate(month),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch income")
+		return
+	}
+	actual := make(map[string]money.Amount)
+	var expenses money.Amount
+	for _, t := range totals {
+		if transactionType(t.Type) == "income" {
+			actual[utils.UUIDToString(t.CategoryID)] += money.FromNumeric(t.Total)
+		} else {
+			expenses += money.FromNumeric(t.Total)
+		}
+	}
+
+	plan, err := loadIncomePlan(r.Context(), h.queries, userID, month, actual)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch income sources")
+		return
+	}
+
+	response := IncomePlanResponse{
+		Month:       month.Format("2006-01"),
+		Expected:    plan.Expected,
+		Actual:      plan.Actual,
+		Unplanned:   plan.Unplanned,
+		Variance:    plan.Variance,
+		Expenses:    expenses,
+		SavingsRate: income.SavingsRate(plan.Actual, expenses),
+		Sources:     make([]IncomePlanLine, len(plan.Lines)),
+	}
+	for i, l := range plan.Lines {
+		response.Sources[i] = IncomePlanLine{
+			SourceID:   l.Source.ID,
+			Name:       l.Source.Name,
+			Kind:       l.Source.Kind,
+			Expected:   l.Expected,
+			Actual:     l.Actual,
+			Variance:   l.Variance,
+			Overridden: l.Overridden,
+		}
+	}
+
+	budget, err := h.queries.GetBudgetForDate(r.Context(), models.GetBudgetForDateParams{
+		UserID: utils.PgUUID(userID),
+		Date:   utils.PgDate(month),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		utils.InternalError(w, "Failed to fetch budget")
+		return
+	}
+	if err == nil {
+		allocated, err := budgetAllocated(r.Context(), h.queries, budget.ID)
+		if err != nil {
+			utils.InternalError(w, "Failed to fetch budget categories")
+			return
+		}
+		a := income.Allocate(plan, allocated)
+		response.Budget = &BudgetAllocation{
+			BudgetID:     budget.ID,
+			Basis:        a.Basis,
+			Income:       a.Income,
+			Allocated:    a.Allocated,
+			LeftToBudget: a.LeftToBudget,
+			Status:       a.Status,
+		}
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// Helper functions
+
+// ownIncomeSource loads the income source in the path, responding with 404 unless
+// it belongs to userID
+func (h *IncomeHandler) ownIncomeSource(w http.ResponseWriter, r *http.Request, userID string) (models.IncomeSource, bool) {
+	sourceID := r.PathValue("id")
+	if sourceID == "" {
+		utils.BadRequest(w, "Income source ID is required")
+		return models.IncomeSource{}, false
+	}
+
+	source, err := h.queries.GetIncomeSourceByID(r.Context(), sourceID)
+	if err != nil || source.UserID != userID {
+		utils.NotFound(w, "Income source not found")
+		return models.IncomeSource{}, false
+	}
+	return source, true
+}
+
+// validateIncomeSource checks a source about to be saved, responding with 400 if
+// anything is invalid
+func (h *IncomeHandler) validateIncomeSource(w http.ResponseWriter, r *http.Request, userID string, s models.IncomeSource) bool {
+	if s.Name == "" || len(s.Name) > 100 {
+		utils.BadRequest(w, "Name is required and must be at most 100 characters")
+		return false
+	}
+	validKind := false
+	for _, k := range income.Kinds {
+		if s.Kind == k {
+			validKind = true
+		}
+	}
+	if !validKind {
+		utils.BadRequest(w, "Invalid kind. Use salary, freelance, bonus, thirteenth_month, investment or other")
+		return false
+	}
+	amount := money.FromNumeric(s.Amount)
+	if amount < 0 {
+		utils.BadRequest(w, "Amount must not be negative")
+		return false
+	}
+	if !checkAmounts(w, r, &amount) {
+		return false
+	}
+	for _, m := range s.Months {
+		if m < 1 || m > 12 {
+			utils.BadRequest(w, "Months must be between 1 and 12")
+			return false
+		}
+	}
+	if s.EndMonth.Valid && utils.DateToTime(s.EndMonth).Before(utils.DateToTime(s.StartMonth)) {
+		utils.BadRequest(w, "endMonth must not be before startMonth")
+		return false
+	}
+	if s.CategoryID.Valid {
+		category, err := h.queries.GetCategoryByID(r.Context(), utils.UUIDToString(s.CategoryID))
+		if err != nil || (category.UserID.Valid && !utils.UUIDEquals(category.UserID, userID)) {
+			utils.BadRequest(w, "Category not found")
+			return false
+		}
+	}
+	return true
+}
+
+// validCategoryID responds with 400 if id is set to something other than a UUID
+func validCategoryID(w http.ResponseWriter, id *string) bool {
+	if id != nil && *id != "" && !utils.PgUUID(*id).Valid {
+		utils.BadRequest(w, "Category not found")
+		return false
+	}
+	return true
+}
+
+// loadIncomePlan builds userID's income plan for month from their sources and
+// overrides, against actual income per category
+func loadIncomePlan(ctx context.Context, q *models.Queries, userID string, month time.Time, actual map[string]money.Amount) (income.Plan, error) {
+	sources, err := loadIncomeSources(ctx, q, userID)
+	if err != nil {
+		return income.Plan{}, err
+	}
+	overrides, err := loadIncomeOverrides(ctx, q, userID, month)
+	if err != nil {
+		return income.Plan{}, err
+	}
+	return income.BuildPlan(month, sources, overrides, actual), nil
+}
+
+// loadPeriodIncomePlan is loadIncomePlan for a budget period, which may be shorter
+// than a month or span two
+func loadPeriodIncomePlan(ctx context.Context, q *models.Queries, userID string, period periods.Period, actual map[string]money.Amount) (income.Plan, error) {
+	sources, err := loadIncomeSources(ctx, q, userID)
+	if err != nil {
+		return income.Plan{}, err
+	}
+	overrides := make(map[time.Time]map[string]money.Amount)
+	for month := monthOf(period.Start); month.Before(period.End); month = month.AddDate(0, 1, 0) {
+		if overrides[month], err = loadIncomeOverrides(ctx, q, userID, month); err != nil {
+			return income.Plan{}, err
+		}
+	}
+	return income.BuildPeriodPlan(period, sources, overrides, actual), nil
+}
+
+// loadIncomeSources reads userID's income sources
+func loadIncomeSources(ctx context.Context, q *models.Queries, userID string) ([]income.Source, error) {
+	rows, err := q.ListIncomeSources(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]income.Source, len(rows))
+	for i, s := range rows {
+		sources[i] = income.Source{
+			ID:         s.ID,
+			Name:       s.Name,
+			Kind:       s.Kind,
+			CategoryID: utils.UUIDToString(s.CategoryID),
+			Amount:     money.FromNumeric(s.Amount),
+			Start:      utils.DateToTime(s.StartMonth),
+		}
+		for _, m := range s.Months {
+			sources[i].Months = append(sources[i].Months, int(m))
+		}
+		if s.EndMonth.Valid {
+			end := utils.DateToTime(s.EndMonth)
+			sources[i].End = &end
+		}
+	}
+	return sources, nil
+}
+
+// loadIncomeOverrides reads the amounts userID expects from their sources in month,
+// by source ID
+func loadIncomeOverrides(ctx context.Context, q *models.Queries, userID string, month time.Time) (map[string]money.Amount, error) {
+	expectations, err := q.ListIncomeExpectations(ctx, models.ListIncomeExpectationsParams{
+		UserID: userID,
+		Month:  utils.PgDate(month),
+	})
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]money.Amount, len(expectations))
+	for _, e := range expectations {
+		overrides[e.SourceID] = money.FromNumeric(e.Amount)
+	}
+	return overrides, nil
+}
+
+// budgetAllocated totals the limits of a budget's categories
+func budgetAllocated(ctx context.Context, q *models.Queries, budgetID string) (money.Amount, error) {
+	categories, err := q.GetBudgetCategories(ctx, utils.PgUUID(budgetID))
+	if err != nil {
+		return 0, err
+	}
+	var allocated money.Amount
+	for _, c := range categories {
+		allocated += money.FromNumeric(c.LimitAmount)
+	}
+	return allocated, nil
+}
+
+// parseMonthParam parses a YYYY-MM month, responding with 400 if it's invalid
+func parseMonthParam(w http.ResponseWriter, s, field string) (time.Time, bool) {
+	month, err := time.Parse("2006-01", s)
+	if err != nil {
+		utils.BadRequest(w, "Invalid "+field+" format. Use YYYY-MM")
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+// isCategoryTaken reports whether err is another source already using the category
+func isCategoryTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_income_sources_category"
+}
+
+// incomeMonths sorts and dedupes months of the year, nil for every month
+func incomeMonths(months []int) []int16 {
+	if len(months) == 0 {
+		return nil
+	}
+	seen := make(map[int]bool)
+	var result []int16
+	for _, m := range months {
+		if !seen[m] {
+			seen[m] = true
+			result = append(result, int16(m))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func incomeSourceToResponse(s models.IncomeSource) IncomeSourceResponse {
+	resp := IncomeSourceResponse{
+		ID:         s.ID,
+		Name:       s.Name,
+		Kind:       s.Kind,
+		CategoryID: uuidPtrToString(s.CategoryID),
+		Amount:     money.FromNumeric(s.Amount),
+		Months:     make([]int, len(s.Months)),
+		StartMonth: utils.DateToTime(s.StartMonth).Format("2006-01"),
+		CreatedAt:  utils.TimestamptzToTime(s.CreatedAt).Format(time.RFC3339),
+		UpdatedAt:  utils.TimestamptzToTime(s.UpdatedAt).Format(time.RFC3339),
+	}
+	for i, m := range s.Months {
+		resp.Months[i] = int(m)
+	}
+	if s.EndMonth.Valid {
+		end := utils.DateToTime(s.EndMonth).Format("2006-01")
+		resp.EndMonth = &end
+	}
+	return resp
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/insights"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// InsightHandler handles spending insight requests
+type InsightHandler struct {
+	queries *models.Queries
+}
+
+// NewInsightHandler creates a new insight handler
+func NewInsightHandler(queries *models.Queries) *InsightHandler {
+	return &InsightHandler{queries: queries}
+}
+
+// InsightResponse represents an insight in API responses. Amounts are in the home currency.
+type InsightResponse struct {
+	ID             string       `json:"id"`
+	Kind           string       `json:"kind"`
+	Date           string       `json:"date"`
+	Title          string       `json:"title"`
+	Body           string       `json:"body"`
+	Amount         money.Amount `json:"amount"`
+	Typical        money.Amount `json:"typical"`
+	CategoryID     *string      `json:"categoryId,omitempty"`
+	TransactionIDs []string     `json:"transactionIds"`
+	Dismissed      bool         `json:"dismissed"`
+	DismissedAt    *string      `json:"dismissedAt,omitempty"`
+}
+
+// insightKinds are the kinds an insight ID can start with
+var insightKinds = map[string]bool{
+	insights.KindLargeTransaction: true,
+	insights.KindCategorySpike:    true,
+	insights.KindDuplicateCharge:  true,
+	insights.KindPriceChange:      true,
+}
+
+// ListInsights returns unusual activity in the last 30 days, newest first. Dismissed
+// insights are left out unless includeDismissed=true; kind filters by insight kind.
+func (h *InsightHandler) ListInsights(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	query := r.URL.Query()
+	kind := query.Get("kind")
+	if kind != "" && !insightKinds[kind] {
+		utils.BadRequest(w, "Invalid kind. Use large_transaction, category_spike, duplicate_charge or price_change")
+		return
+	}
+	includeDismissed := query.Get("includeDismissed") == "true"
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	rows, err := h.queries.ListInsightTransactions(r.Context(), models.ListInsightTransactionsParams{
+		UserID:    utils.PgUUID(userID),
+		StartDate: utils.PgDate(insights.HistoryStart(today)),
+		EndDate:   utils.PgDate(today),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch transactions")
+		return
+	}
+	dismissals, err := h.queries.ListInsightDismissals(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch dismissed insights")
+		return
+	}
+	dismissed := make(map[string]time.Time, len(dismissals))
+	for _, d := range dismissals {
+		dismissed[d.InsightID] = utils.TimestamptzToTime(d.DismissedAt)
+	}
+
+	transactions := make([]insights.Transaction, len(rows))
+	for i, row := range rows {
+		transactions[i] = insights.Transaction{
+			ID:              row.ID,
+			Date:            utils.DateToTime(row.TransactionDate),
+			CategoryID:      utils.UUIDToString(row.CategoryID),
+			CategoryName:    utils.TextToString(row.CategoryName),
+			Description:     utils.TextToString(row.Description),
+			Amount:          money.FromNumeric(row.HomeAmount),
+			PaymentMethodID: utils.UUIDToString(row.PaymentMethodID),
+			Recurring:       row.IsRecurring.Bool,
+		}
+	}
+
+	response := []InsightResponse{}
+	for _, insight := range insights.Detect(transactions, today) {
+		if kind != "" && insight.Kind != kind {
+			continue
+		}
+		resp := insightToResponse(insight)
+		if at, ok := dismissed[insight.ID]; ok {
+			if !includeDismissed {
+				continue
+			}
+			formatted := at.Format(time.RFC3339)
+			resp.Dismissed = true
+			resp.DismissedAt = &formatted
+		}
+		response = append(response, resp)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// DismissInsight hides an insight from the list. Dismissing is remembered by ID, so
+// an insight that applies again later, e.g. a spike in a new month, shows again.
+func (h *InsightHandler) DismissInsight(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	insightID, ok := insightIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.queries.DismissInsight(r.Context(), models.DismissInsightParams{
+		UserID:    userID,
+		InsightID: insightID,
+	}); err != nil {
+		utils.InternalError(w, "Failed to dismiss insight")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Insight dismissed",
+	})
+}
+
+// RestoreInsight undoes a dismissal
+func (h *InsightHandler) RestoreInsight(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	insightID, ok := insightIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.queries.RestoreInsight(r.Context(), models.RestoreInsightParams{
+		UserID:    userID,
+		InsightID: insightID,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to restore insight")
+		return
+	}
+	if rows == 0 {
+		utils.NotFound(w, "Insight is not dismissed")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Insight restored",
+	})
+}
+
+// Helper functions
+
+// insightIDParam reads the {id} path value, responding with 400 if it isn't a
+// well-formed insight ID
+func insightIDParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	kind, _, found := strings.Cut(id, ".")
+	if !found || !insightKinds[kind] || len(id) > 200 {
+		utils.BadRequest(w, "Invalid insight ID")
+		return "", false
+	}
+	return id, true
+}
+
+func insightToResponse(i insights.Insight) InsightResponse {
+	resp := InsightResponse{
+		ID:             i.ID,
+		Kind:           i.Kind,
+		Date:           i.Date.Format("2006-01-02"),
+		Title:          i.Title,
+		Body:           i.Body,
+		Amount:         i.Amount,
+		Typical:        i.Typical,
+		TransactionIDs: i.TransactionIDs,
+	}
+	if resp.TransactionIDs == nil {
+		resp.TransactionIDs = []string{}
+	}
+	if i.CategoryID != "" {
+		resp.CategoryID = &i.CategoryID
+	}
+	return resp
+}
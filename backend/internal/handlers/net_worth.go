@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/networth"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// maxNetWorthMonths caps the net worth history
+const maxNetWorthMonths = 120
+
+// NetWorthHandler handles net worth requests
+type NetWorthHandler struct {
+	queries *models.Queries
+}
+
+// NewNetWorthHandler creates a new net worth handler
+func NewNetWorthHandler(queries *models.Queries) *NetWorthHandler {
+	return &NetWorthHandler{queries: queries}
+}
+
+// NetWorthResponse is the current net worth. Amounts are in the home currency.
+type NetWorthResponse struct {
+	Date             string                  `json:"date"`
+	Assets           money.Amount            `json:"assets"`
+	Liabilities      money.Amount            `json:"liabilities"`
+	NetWorth         money.Amount            `json:"netWorth"`
+	AssetClasses     map[string]money.Amount `json:"assetClasses"`
+	LiabilityClasses map[string]money.Amount `json:"liabilityClasses"`
+	Items            []NetWorthItem          `json:"items"`
+	Unconverted      []NetWorthItem          `json:"unconverted"` // accounts without an exchange rate, not counted
+}
+
+// NetWorthItem is an account, asset or debt counted in net worth
+type NetWorthItem struct {
+	Source         string        `json:"source"` // account, asset or debt
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	Class          string        `json:"class"`
+	Liability      bool          `json:"liability"`
+	Amount         money.Amount  `json:"amount"`
+	OriginalAmount *money.Amount `json:"originalAmount,omitempty"`
+	Currency       string        `json:"currency,omitempty"` // set with originalAmount for accounts in another currency
+	ValuedOn       *string       `json:"valuedOn,omitempty"`
+}
+
+// NetWorthPoint is a month of the net worth history
+type NetWorthPoint struct {
+	Month            string                  `json:"month"`
+	Assets           money.Amount            `json:"assets"`
+	Liabilities      money.Amount            `json:"liabilities"`
+	NetWorth         money.Amount            `json:"netWorth"`
+	Change           *money.Amount           `json:"change"` // from the month before, null without one
+	AssetClasses     map[string]money.Amount `json:"assetClasses"`
+	LiabilityClasses map[string]money.Amount `json:"liabilityClasses"`
+	Live             bool                    `json:"live"` // computed now rather than read from a snapshot
+}
+
+// GetNetWorth returns the current user's net worth with every account, asset and
+// debt counted in it
+func (h *NetWorthHandler) GetNetWorth(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	s, err := networth.Load(r.Context(), h.queries, userID, auth.GetCurrency(r), time.Now())
+	if err != nil {
+		utils.InternalError(w, "Failed to calculate net worth")
+		return
+	}
+
+	response := NetWorthResponse{
+		Date:             s.Date.Format("2006-01-02"),
+		Assets:           s.Assets,
+		Liabilities:      s.Liabilities,
+		NetWorth:         s.NetWorth,
+		AssetClasses:     s.AssetClasses,
+		LiabilityClasses: s.LiabilityClasses,
+		Items:            make([]NetWorthItem, len(s.Items)),
+		Unconverted:      make([]NetWorthItem, len(s.Unconverted)),
+	}
+	for i, item := range s.Items {
+		response.Items[i] = netWorthItemToResponse(item)
+	}
+	for i, item := range s.Unconverted {
+		response.Unconverted[i] = netWorthItemToResponse(item)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// GetNetWorthHistory returns monthly net worth for the last months months (default
+// 12, at most 120), oldest first, from the snapshots recorded each month. The
+// current month is always computed live. Months before tracking started are left out.
+func (h *NetWorthHandler) GetNetWorthHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	months := 12
+	if monthsStr := r.URL.Query().Get("months"); monthsStr != "" {
+		m, err := strconv.Atoi(monthsStr)
+		if err != nil || m < 1 || m > maxNetWorthMonths {
+			utils.BadRequest(w, "months must be between 1 and 120")
+			return
+		}
+		months = m
+	}
+
+	now := time.Now()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	snapshots, err := h.queries.ListNetWorthSnapshots(r.Context(), models.ListNetWorthSnapshotsParams{
+		UserID: userID,
+		Month:  utils.PgDate(current.AddDate(0, -(months - 1), 0)),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch net worth history")
+		return
+	}
+	live, err := networth.Load(r.Context(), h.queries, userID, auth.GetCurrency(r), now)
+	if err != nil {
+		utils.InternalError(w, "Failed to calculate net worth")
+		return
+	}
+
+	points := make([]NetWorthPoint, 0, len(snapshots)+1)
+	for _, s := range snapshots {
+		month := utils.DateToTime(s.Month)
+		if !month.Before(current) {
+			continue
+		}
+		breakdown := networth.DecodeBreakdown(s.Breakdown)
+		points = append(points, NetWorthPoint{
+			Month:            month.Format("2006-01"),
+			Assets:           money.FromNumeric(s.Assets),
+			Liabilities:      money.FromNumeric(s.Liabilities),
+			NetWorth:         money.FromNumeric(s.NetWorth),
+			AssetClasses:     breakdown.Assets,
+			LiabilityClasses: breakdown.Liabilities,
+		})
+	}
+	points = append(points, NetWorthPoint{
+		Month:            current.Format("2006-01"),
+		Assets:           live.Assets,
+		Liabilities:      live.Liabilities,
+		NetWorth:         live.NetWorth,
+		AssetClasses:     live.AssetClasses,
+		LiabilityClasses: live.LiabilityClasses,
+		Live:             true,
+	})
+	// Changes are only between consecutive months, so a gap in the snapshots shows
+	for i := 1; i < len(points); i++ {
+		prev, _ := time.Parse("2006-01", points[i-1].Month)
+		month, _ := time.Parse("2006-01", points[i].Month)
+		if prev.AddDate(0, 1, 0).Equal(month) {
+			change := points[i].NetWorth - points[i-1].NetWorth
+			points[i].Change = &change
+		}
+	}
+
+	utils.SendSuccess(w, points)
+}
+
+// Helper functions
+
+func netWorthItemToResponse(item networth.Item) NetWorthItem {
+	resp := NetWorthItem{
+		Source:         item.Source,
+		ID:             item.ID,
+		Name:           item.Name,
+		Class:          item.Class,
+		Liability:      item.Liability,
+		Amount:         item.Amount,
+		OriginalAmount: item.Original,
+		Currency:       item.Currency,
+	}
+	if item.ValuedOn != nil {
+		valuedOn := item.ValuedOn.Format("2006-01-02")
+		resp.ValuedOn = &valuedOn
+	}
+	return resp
+}
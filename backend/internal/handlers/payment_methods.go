@@ -5,8 +5,12 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
 	"github.com/joselitophala/budget-planner-backend/internal/auth"
 	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
 	"github.com/joselitophala/budget-planner-backend/internal/utils"
 )
 
@@ -22,40 +26,43 @@ func NewPaymentMethodHandler(queries *models.Queries) *PaymentMethodHandler {
 
 // PaymentMethodResponse represents a payment method in API responses
 type PaymentMethodResponse struct {
-	ID             string  `json:"id"`
-	Name           string  `json:"name"`
-	Type           string  `json:"type"`
-	LastFour       *string `json:"lastFour,omitempty"`
-	Brand          *string `json:"brand,omitempty"`
-	IsDefault      bool    `json:"isDefault"`
-	IsActive       bool    `json:"isActive"`
-	CreditLimit    *float64 `json:"creditLimit,omitempty"`
-	CurrentBalance *float64 `json:"currentBalance,omitempty"`
-	CreatedAt      string  `json:"createdAt"`
-	UpdatedAt      string  `json:"updatedAt"`
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	Type           string        `json:"type"`
+	LastFour       *string       `json:"lastFour,omitempty"`
+	Brand          *string       `json:"brand,omitempty"`
+	IsDefault      bool          `json:"isDefault"`
+	IsActive       bool          `json:"isActive"`
+	CreditLimit    *money.Amount `json:"creditLimit,omitempty"`
+	CurrentBalance *money.Amount `json:"currentBalance,omitempty"`
+	Currency       *string       `json:"currency,omitempty"` // unset: the home currency
+	CreatedAt      string        `json:"createdAt"`
+	UpdatedAt      string        `json:"updatedAt"`
 }
 
 // CreatePaymentMethodRequest represents the create payment method request
 type CreatePaymentMethodRequest struct {
-	Name           string  `json:"name"`
-	Type           string  `json:"type"`
-	LastFour       *string `json:"lastFour,omitempty"`
-	Brand          *string `json:"brand,omitempty"`
-	IsDefault      bool    `json:"isDefault"`
-	CreditLimit    *float64 `json:"creditLimit,omitempty"`
-	CurrentBalance *float64 `json:"currentBalance,omitempty"`
+	Name           string        `json:"name"`
+	Type           string        `json:"type"`
+	LastFour       *string       `json:"lastFour,omitempty"`
+	Brand          *string       `json:"brand,omitempty"`
+	IsDefault      bool          `json:"isDefault"`
+	CreditLimit    *money.Amount `json:"creditLimit,omitempty"`
+	CurrentBalance *money.Amount `json:"currentBalance,omitempty"`
+	Currency       *string       `json:"currency,omitempty"` // defaults to the home currency
 }
 
 // UpdatePaymentMethodRequest represents the update payment method request
 type UpdatePaymentMethodRequest struct {
-	Name           *string `json:"name,omitempty"`
-	Type           *string `json:"type,omitempty"`
-	LastFour       *string `json:"lastFour,omitempty"`
-	Brand          *string `json:"brand,omitempty"`
-	IsDefault      *bool   `json:"isDefault,omitempty"`
-	IsActive       *bool   `json:"isActive,omitempty"`
-	CreditLimit    *float64 `json:"creditLimit,omitempty"`
-	CurrentBalance *float64 `json:"currentBalance,omitempty"`
+	Name           *string       `json:"name,omitempty"`
+	Type           *string       `json:"type,omitempty"`
+	LastFour       *string       `json:"lastFour,omitempty"`
+	Brand          *string       `json:"brand,omitempty"`
+	IsDefault      *bool         `json:"isDefault,omitempty"`
+	IsActive       *bool         `json:"isActive,omitempty"`
+	CreditLimit    *money.Amount `json:"creditLimit,omitempty"`
+	CurrentBalance *money.Amount `json:"currentBalance,omitempty"`
+	Currency       *string       `json:"currency,omitempty"`
 }
 
 // ListPaymentMethods returns all payment methods for the current user
@@ -113,7 +120,20 @@ func (h *PaymentMethodHandler) CreatePaymentMethod(w http.ResponseWriter, r *htt
 
 	var req CreatePaymentMethodRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
+		return
+	}
+
+	var currency pgtype.Text
+	if req.Currency != nil {
+		code, err := money.NormalizeCurrency(*req.Currency)
+		if err != nil {
+			utils.BadRequest(w, err.Error())
+			return
+		}
+		currency = utils.PgText(code)
+	}
+	if !checkAmountsIn(w, methodCurrency(r, currency), req.CreditLimit, req.CurrentBalance) {
 		return
 	}
 
@@ -125,20 +145,30 @@ func (h *PaymentMethodHandler) CreatePaymentMethod(w http.ResponseWriter, r *htt
 		Brand:          utils.PgTextPtr(req.Brand),
 		IsDefault:      utils.PgBool(req.IsDefault),
 		IsActive:       utils.PgBool(true),
-		CreditLimit:    utils.PgNumericPtr(req.CreditLimit),
-		CurrentBalance: utils.PgNumericPtr(req.CurrentBalance),
+		CreditLimit:    money.NumericPtr(req.CreditLimit),
+		CurrentBalance: money.NumericPtr(req.CurrentBalance),
+		Currency:       currency,
 	})
 	if err != nil {
 		utils.InternalError(w, "Failed to create payment method")
 		return
 	}
 
-	utils.SendCreated(w, paymentMethodToResponse(method))
+	response := paymentMethodToResponse(method)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "payment_method.created",
+		ResourceType: activity.ResourcePaymentMethod,
+		ResourceID:   method.ID,
+		After:        response,
+	})
+
+	utils.SendCreated(w, response)
 }
 
 // UpdatePaymentMethod updates an existing payment method
 func (h *PaymentMethodHandler) UpdatePaymentMethod(w http.ResponseWriter, r *http.Request) {
-	_, ok := auth.GetUserID(r)
+	userID, ok := auth.GetUserID(r)
 	if !ok {
 		utils.Unauthorized(w, "Not authenticated")
 		return
@@ -152,7 +182,26 @@ func (h *PaymentMethodHandler) UpdatePaymentMethod(w http.ResponseWriter, r *htt
 
 	var req UpdatePaymentMethodRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
+		return
+	}
+
+	before, err := h.queries.GetPaymentMethodByID(r.Context(), methodID)
+	if err != nil {
+		utils.NotFound(w, "Payment method not found")
+		return
+	}
+
+	currency := before.Currency
+	if req.Currency != nil {
+		code, err := money.NormalizeCurrency(*req.Currency)
+		if err != nil {
+			utils.BadRequest(w, err.Error())
+			return
+		}
+		currency = utils.PgText(code)
+	}
+	if !checkAmountsIn(w, methodCurrency(r, currency), req.CreditLimit, req.CurrentBalance) {
 		return
 	}
 
@@ -164,20 +213,31 @@ func (h *PaymentMethodHandler) UpdatePaymentMethod(w http.ResponseWriter, r *htt
 		Brand:          utils.PgTextPtr(req.Brand),
 		IsDefault:      utils.PgBoolPtr(req.IsDefault),
 		IsActive:       utils.PgBoolPtr(req.IsActive),
-		CreditLimit:    utils.PgNumericPtr(req.CreditLimit),
-		CurrentBalance: utils.PgNumericPtr(req.CurrentBalance),
+		CreditLimit:    money.NumericPtr(req.CreditLimit),
+		CurrentBalance: money.NumericPtr(req.CurrentBalance),
+		Currency:       currency,
 	})
 	if err != nil {
 		utils.InternalError(w, "Failed to update payment method")
 		return
 	}
 
-	utils.SendSuccess(w, paymentMethodToResponse(method))
+	response := paymentMethodToResponse(method)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "payment_method.updated",
+		ResourceType: activity.ResourcePaymentMethod,
+		ResourceID:   method.ID,
+		Before:       paymentMethodToResponse(before),
+		After:        response,
+	})
+
+	utils.SendSuccess(w, response)
 }
 
 // DeletePaymentMethod soft deletes a payment method
 func (h *PaymentMethodHandler) DeletePaymentMethod(w http.ResponseWriter, r *http.Request) {
-	_, ok := auth.GetUserID(r)
+	userID, ok := auth.GetUserID(r)
 	if !ok {
 		utils.Unauthorized(w, "Not authenticated")
 		return
@@ -189,17 +249,39 @@ func (h *PaymentMethodHandler) DeletePaymentMethod(w http.ResponseWriter, r *htt
 		return
 	}
 
-	err := h.queries.DeletePaymentMethod(r.Context(), methodID)
+	before, err := h.queries.GetPaymentMethodByID(r.Context(), methodID)
+	if err != nil {
+		utils.NotFound(w, "Payment method not found")
+		return
+	}
+
+	err = h.queries.DeletePaymentMethod(r.Context(), methodID)
 	if err != nil {
 		utils.InternalError(w, "Failed to delete payment method")
 		return
 	}
 
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "payment_method.deleted",
+		ResourceType: activity.ResourcePaymentMethod,
+		ResourceID:   before.ID,
+		Before:       paymentMethodToResponse(before),
+	})
+
 	utils.SendSuccess(w, map[string]string{
 		"message": "Payment method deleted successfully",
 	})
 }
 
+// methodCurrency returns a payment method's currency, or the user's home currency if it has none
+func methodCurrency(r *http.Request, currency pgtype.Text) string {
+	if currency.Valid {
+		return currency.String
+	}
+	return auth.GetCurrency(r)
+}
+
 func paymentMethodToResponse(m models.PaymentMethod) PaymentMethodResponse {
 	return PaymentMethodResponse{
 		ID:             m.ID,
@@ -209,8 +291,9 @@ func paymentMethodToResponse(m models.PaymentMethod) PaymentMethodResponse {
 		Brand:          utils.TextToStringPtr(m.Brand),
 		IsDefault:      m.IsDefault.Bool,
 		IsActive:       m.IsActive.Bool,
-		CreditLimit:    utils.NumericToFloat64Ptr(m.CreditLimit),
-		CurrentBalance: utils.NumericToFloat64Ptr(m.CurrentBalance),
+		CreditLimit:    money.FromNumericPtr(m.CreditLimit),
+		CurrentBalance: money.FromNumericPtr(m.CurrentBalance),
+		Currency:       utils.TextToStringPtr(m.Currency),
 		CreatedAt:      utils.TimestamptzToTime(m.CreatedAt).Format(time.RFC3339),
 		UpdatedAt:      utils.TimestamptzToTime(m.UpdatedAt).Format(time.RFC3339),
 	}
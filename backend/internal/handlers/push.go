@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/push"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// PushHandler handles Web Push subscription requests
+type PushHandler struct {
+	queries    *models.Queries
+	sender     *push.Sender // nil when VAPID keys aren't configured
+	allowLocal bool         // accept http and private endpoints, for a local push service in development
+}
+
+// NewPushHandler creates a new push handler
+func NewPushHandler(queries *models.Queries, sender *push.Sender, allowLocal bool) *PushHandler {
+	return &PushHandler{queries: queries, sender: sender, allowLocal: allowLocal}
+}
+
+// PushSubscriptionResponse represents a registered device in API responses
+type PushSubscriptionResponse struct {
+	ID        string  `json:"id"`
+	Endpoint  string  `json:"endpoint"`
+	UserAgent *string `json:"userAgent,omitempty"`
+	CreatedAt string  `json:"createdAt"`
+	UpdatedAt string  `json:"updatedAt"`
+}
+
+// PushSubscriptionRequest is the browser's PushSubscription.toJSON()
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// GetVAPIDPublicKey returns the applicationServerKey browsers subscribe with
+func (h *PushHandler) GetVAPIDPublicKey(w http.ResponseWriter, r *http.Request) {
+	if h.sender == nil {
+		utils.ServiceUnavailable(w, "Push notifications are not configured")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"publicKey": h.sender.PublicKey(),
+	})
+}
+
+// ListSubscriptions returns the current user's registered devices
+func (h *PushHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	subscriptions, err := h.queries.ListPushSubscriptions(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch push subscriptions")
+		return
+	}
+
+	response := make([]PushSubscriptionResponse, len(subscriptions))
+	for i, s := range subscriptions {
+		response[i] = pushSubscriptionToResponse(s)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// Subscribe registers this device for push notifications. Subscribing an endpoint
+// again updates its keys.
+func (h *PushHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+	if h.sender == nil {
+		utils.ServiceUnavailable(w, "Push notifications are not configured")
+		return
+	}
+
+	var req PushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+	sub := push.Subscription{Endpoint: req.Endpoint, P256dh: req.Keys.P256dh, Auth: req.Keys.Auth}
+	if err := push.ValidateSubscription(sub, h.allowLocal); err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+	subscription, err := h.queries.UpsertPushSubscription(r.Context(), models.UpsertPushSubscriptionParams{
+		UserID:    userID,
+		Endpoint:  sub.Endpoint,
+		P256dh:    sub.P256dh,
+		Auth:      sub.Auth,
+		UserAgent: utils.PgText(userAgent),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.Conflict(w, "This endpoint is registered to another account")
+		return
+	}
+	if err != nil {
+		utils.InternalError(w, "Failed to save push subscription")
+		return
+	}
+
+	utils.SendCreated(w, pushSubscriptionToResponse(subscription))
+}
+
+// Unsubscribe removes a device, by id or, for a browser that only knows its
+// endpoint, with ?endpoint=
+func (h *PushHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	var rows int64
+	var err error
+	if id := r.PathValue("id"); id != "" {
+		rows, err = h.queries.DeletePushSubscription(r.Context(), models.DeletePushSubscriptionParams{
+			ID:     id,
+			UserID: userID,
+		})
+	} else if endpoint := r.URL.Query().Get("endpoint"); endpoint != "" {
+		rows, err = h.queries.DeletePushSubscriptionByEndpoint(r.Context(), models.DeletePushSubscriptionByEndpointParams{
+			Endpoint: endpoint,
+			UserID:   userID,
+		})
+	} else {
+		utils.BadRequest(w, "Subscription ID or endpoint is required")
+		return
+	}
+	if err != nil {
+		utils.InternalError(w, "Failed to delete push subscription")
+		return
+	}
+	if rows == 0 {
+		utils.NotFound(w, "Push subscription not found")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Push subscription deleted successfully",
+	})
+}
+
+// SendTest queues a test notification to all of the current user's devices
+func (h *PushHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+	if h.sender == nil {
+		utils.ServiceUnavailable(w, "Push notifications are not configured")
+		return
+	}
+
+	queued, err := push.Enqueue(r.Context(), h.queries, userID, push.Message{
+		Title: "Push notifications are working",
+		Body:  "You'll get budget alerts and invitations on this device.",
+		URL:   "/notifications",
+		Tag:   "test",
+		Kind:  "test",
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to queue test notification")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]int64{
+		"queued": queued,
+	})
+}
+
+// Helper functions
+
+func pushSubscriptionToResponse(s models.PushSubscription) PushSubscriptionResponse {
+	return PushSubscriptionResponse{
+		ID:        s.ID,
+		Endpoint:  s.Endpoint,
+		UserAgent: utils.TextToStringPtr(s.UserAgent),
+		CreatedAt: s.CreatedAt.Time.Format(time.RFC3339),
+		UpdatedAt: s.UpdatedAt.Time.Format(time.RFC3339),
+	}
+}
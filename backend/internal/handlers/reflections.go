@@ -38,34 +38,34 @@ type CreateReflectionRequest struct {
 	IsPrivate     bool               `json:"isPrivate"`
 }
 
-// GetReflectionByMonth returns a reflection for a specific month
-func (h *ReflectionHandler) GetReflectionByMonth(w http.ResponseWriter, r *http.Request) {
+// GetReflectionByDate returns the reflection on the budget period including a date
+func (h *ReflectionHandler) GetReflectionByDate(w http.ResponseWriter, r *http.Request) {
 	userID, ok := auth.GetUserID(r)
 	if !ok {
 		utils.Unauthorized(w, "Not authenticated")
 		return
 	}
 
-	monthStr := r.PathValue("month")
-	if monthStr == "" {
-		utils.BadRequest(w, "Month is required (format: YYYY-MM)")
+	dateStr := r.PathValue("date")
+	if dateStr == "" {
+		utils.BadRequest(w, "Date is required (format: YYYY-MM-DD or YYYY-MM)")
 		return
 	}
 
-	// Parse month
-	month, err := time.Parse("2006-01", monthStr)
+	// Parse date, or month for its first day
+	date, err := parseBudgetDate(dateStr)
 	if err != nil {
-		utils.BadRequest(w, "Invalid month format. Use YYYY-MM")
+		utils.BadRequest(w, "Invalid date format. Use YYYY-MM-DD or YYYY-MM")
 		return
 	}
 
-	// Get budget for this month
-	budget, err := h.queries.GetBudgetByMonth(r.Context(), models.GetBudgetByMonthParams{
+	// Get the budget whose period includes the date
+	budget, err := h.queries.GetBudgetForDate(r.Context(), models.GetBudgetForDateParams{
 		UserID: utils.PgUUID(userID),
-		Month:  utils.PgDate(month),
+		Date:   utils.PgDate(date),
 	})
 	if err != nil {
-		utils.NotFound(w, "Budget not found for this month")
+		utils.NotFound(w, "Budget not found for this date")
 		return
 	}
 
@@ -95,7 +95,7 @@ func (h *ReflectionHandler) CreateReflection(w http.ResponseWriter, r *http.Requ
 
 	// Verify the budget belongs to the user
 	budget, err := h.queries.GetBudgetByID(r.Context(), req.BudgetID)
-	if err != nil || !budget.UserID.Valid || utils.UUIDToString(budget.UserID) != userID {
+	if err != nil || !utils.UUIDEquals(budget.UserID, userID) {
 		utils.Forbidden(w, "You can only create reflections for your own budgets")
 		return
 	}
@@ -142,7 +142,7 @@ func (h *ReflectionHandler) UpdateReflection(w http.ResponseWriter, r *http.Requ
 
 	// Verify ownership
 	existing, err := h.queries.GetReflectionByID(r.Context(), reflectionID)
-	if err != nil || !existing.UserID.Valid || utils.UUIDToString(existing.UserID) != userID {
+	if err != nil || !utils.UUIDEquals(existing.UserID, userID) {
 		utils.Forbidden(w, "You can only update your own reflections")
 		return
 	}
@@ -176,7 +176,7 @@ func (h *ReflectionHandler) DeleteReflection(w http.ResponseWriter, r *http.Requ
 
 	// Verify ownership
 	existing, err := h.queries.GetReflectionByID(r.Context(), reflectionID)
-	if err != nil || !existing.UserID.Valid || utils.UUIDToString(existing.UserID) != userID {
+	if err != nil || !utils.UUIDEquals(existing.UserID, userID) {
 		utils.Forbidden(w, "You can only delete your own reflections")
 		return
 	}
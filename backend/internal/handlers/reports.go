@@ -0,0 +1,604 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/reports"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// ReportHandler handles comparison, rolling average and custom reports
+type ReportHandler struct {
+	queries *models.Queries
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(queries *models.Queries) *ReportHandler {
+	return &ReportHandler{queries: queries}
+}
+
+// ComparisonResponse compares a period against a baseline. Amounts are in the home currency.
+type ComparisonResponse struct {
+	Current        ComparisonPeriod `json:"current"`
+	Baseline       ComparisonPeriod `json:"baseline"`
+	Expenses       ComparisonDelta  `json:"expenses"`
+	Income         ComparisonDelta  `json:"income"`
+	Net            ComparisonDelta  `json:"net"`
+	Categories     []ComparisonLine `json:"categories"`
+	PaymentMethods []ComparisonLine `json:"paymentMethods"`
+}
+
+// ComparisonPeriod is a compared period's totals
+type ComparisonPeriod struct {
+	StartDate        string       `json:"startDate"`
+	EndDate          string       `json:"endDate"`
+	BudgetID         *string      `json:"budgetId,omitempty"`
+	Expenses         money.Amount `json:"expenses"`
+	Income           money.Amount `json:"income"`
+	Net              money.Amount `json:"net"`
+	TransactionCount int64        `json:"transactionCount"`
+}
+
+// ComparisonDelta is how much a value changed from the baseline
+type ComparisonDelta struct {
+	Amount  money.Amount `json:"amount"`
+	Percent *float64     `json:"percent"` // null when the baseline was zero
+}
+
+// ComparisonLine compares a category's or payment method's income or expenses.
+// A null id groups uncategorized transactions or ones without a payment method.
+type ComparisonLine struct {
+	ID       *string         `json:"id"`
+	Name     string          `json:"name"`
+	Type     string          `json:"type"`
+	Current  money.Amount    `json:"current"`
+	Baseline money.Amount    `json:"baseline"`
+	Change   ComparisonDelta `json:"change"`
+}
+
+// RollingAveragesResponse is category spending with rolling averages. Amounts are
+// in the home currency.
+type RollingAveragesResponse struct {
+	StartMonth string                  `json:"startMonth"`
+	EndMonth   string                  `json:"endMonth"`
+	BudgetID   *string                 `json:"budgetId,omitempty"`
+	Categories []RollingCategorySeries `json:"categories"`
+}
+
+// RollingCategorySeries is a category's monthly spending and rolling averages
+type RollingCategorySeries struct {
+	CategoryID *string        `json:"categoryId"`
+	Name       string         `json:"name"`
+	Months     []RollingMonth `json:"months"`
+}
+
+// RollingMonth is a month's spending with its averages over the 3, 6 and 12 months
+// ending with it, null before there was any spending
+type RollingMonth struct {
+	Month string        `json:"month"`
+	Total money.Amount  `json:"total"`
+	Avg3  *money.Amount `json:"avg3"`
+	Avg6  *money.Amount `json:"avg6"`
+	Avg12 *money.Amount `json:"avg12"`
+}
+
+// CustomReportResponse is a custom report's groups. Amounts are in the home currency.
+type CustomReportResponse struct {
+	StartDate        string              `json:"startDate"`
+	EndDate          string              `json:"endDate"`
+	GroupBy          []string            `json:"groupBy"`
+	Total            money.Amount        `json:"total"`
+	TransactionCount int64               `json:"transactionCount"`
+	Average          money.Amount        `json:"average"` // per transaction
+	Groups           []CustomReportGroup `json:"groups"`
+}
+
+// CustomReportGroup is the transactions sharing a value for every groupBy
+// dimension. A null key groups uncategorized transactions or ones without a
+// payment method.
+type CustomReportGroup struct {
+	Keys             map[string]*string `json:"keys"`
+	Labels           map[string]string  `json:"labels"` // names for category, paymentMethod and weekday keys
+	Total            money.Amount       `json:"total"`
+	TransactionCount int64              `json:"transactionCount"`
+	Average          money.Amount       `json:"average"` // per transaction
+}
+
+// GetComparison compares income and spending between two periods, by category and
+// payment method.
+//
+// Periods are either a month, quarter or year (period, default month) containing
+// date (default today) against the one before or the same one last year (against:
+// previous or last_year), or two custom ranges given by startDate, endDate,
+// compareStartDate and compareEndDate. toDate=true compares a period in progress
+// with the same number of days of the baseline. budgetId reports on a budget's
+// transactions, including collaborators' on a shared budget, instead of the user's
+// own; compareBudgetId uses a different budget for the baseline.
+func (h *ReportHandler) GetComparison(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	query := r.URL.Query()
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var current, baseline reports.Period
+	if query.Get("startDate") != "" {
+		var ok bool
+		if current, ok = periodParams(w, r, "startDate", "endDate"); !ok {
+			return
+		}
+		if baseline, ok = periodParams(w, r, "compareStartDate", "compareEndDate"); !ok {
+			return
+		}
+	} else {
+		unit := query.Get("period")
+		if unit == "" {
+			unit = reports.UnitMonth
+		}
+		against := query.Get("against")
+		if against == "" {
+			against = reports.AgainstPrevious
+		}
+		if against != reports.AgainstPrevious && against != reports.AgainstLastYear {
+			utils.BadRequest(w, "Invalid against. Use previous or last_year")
+			return
+		}
+		day := today
+		if dateStr := query.Get("date"); dateStr != "" {
+			t, err := time.Parse("2006-01-02", dateStr)
+			if err != nil {
+				utils.BadRequest(w, "Invalid date format. Use YYYY-MM-DD")
+				return
+			}
+			day = t
+		}
+		var err error
+		if current, err = reports.PeriodContaining(unit, day); err != nil {
+			utils.BadRequest(w, "Invalid period. Use month, quarter or year")
+			return
+		}
+		baseline, _ = reports.Baseline(current, unit, against)
+	}
+	if query.Get("toDate") == "true" {
+		current, baseline = reports.ToDate(current, baseline, today)
+	}
+
+	userScope, budgetScope, ok := h.reportScope(w, r, userID, "budgetId")
+	if !ok {
+		return
+	}
+	compareUserScope, compareBudgetScope := userScope, budgetScope
+	if query.Get("compareBudgetId") != "" {
+		if compareUserScope, compareBudgetScope, ok = h.reportScope(w, r, userID, "compareBudgetId"); !ok {
+			return
+		}
+	}
+
+	currentTotals, err := h.periodTotals(r, userScope, budgetScope, current)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch comparison")
+		return
+	}
+	baselineTotals, err := h.periodTotals(r, compareUserScope, compareBudgetScope, baseline)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch comparison")
+		return
+	}
+
+	c := reports.Compare(current, baseline, currentTotals, baselineTotals)
+	response := ComparisonResponse{
+		Current:        comparisonPeriodToResponse(c.Current, budgetScope),
+		Baseline:       comparisonPeriodToResponse(c.Baseline, compareBudgetScope),
+		Expenses:       comparisonDeltaToResponse(c.Expenses),
+		Income:         comparisonDeltaToResponse(c.Income),
+		Net:            comparisonDeltaToResponse(c.Net),
+		Categories:     comparisonLinesToResponse(c.Categories, "Uncategorized"),
+		PaymentMethods: comparisonLinesToResponse(c.PaymentMethods, "No payment method"),
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// GetRollingAverages returns monthly spending per category for the months months
+// (default 12, at most 36) ending with month (YYYY-MM, default this month), each with
+// its average over the 3, 6 and 12 months ending with it. budgetId reports on a
+// budget's transactions like GetComparison.
+func (h *ReportHandler) GetRollingAverages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	query := r.URL.Query()
+	now := time.Now()
+	last := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if monthStr := query.Get("month"); monthStr != "" {
+		t, err := time.Parse("2006-01", monthStr)
+		if err != nil {
+			utils.BadRequest(w, "Invalid month format. Use YYYY-MM")
+			return
+		}
+		last = t
+	}
+	months := 12
+	if monthsStr := query.Get("months"); monthsStr != "" {
+		m, err := strconv.Atoi(monthsStr)
+		if err != nil || m < 1 || m > reports.MaxRollingMonths {
+			utils.BadRequest(w, "months must be between 1 and 36")
+			return
+		}
+		months = m
+	}
+
+	userScope, budgetScope, ok := h.reportScope(w, r, userID, "budgetId")
+	if !ok {
+		return
+	}
+
+	rows, err := h.queries.ListMonthlyCategoryTotals(r.Context(), models.ListMonthlyCategoryTotalsParams{
+		UserID:    userScope,
+		BudgetID:  budgetScope,
+		StartDate: utils.PgDate(reports.RollingStart(last, months)),
+		EndDate:   utils.PgDate(last.AddDate(0, 1, -1)),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch rolling averages")
+		return
+	}
+	totals := make([]reports.MonthlyTotal, len(rows))
+	for i, row := range rows {
+		totals[i] = reports.MonthlyTotal{
+			Month:        utils.DateToTime(row.Month),
+			CategoryID:   utils.UUIDToString(row.CategoryID),
+			CategoryName: utils.TextToString(row.CategoryName),
+			Amount:       money.FromNumeric(row.Total),
+		}
+	}
+
+	response := RollingAveragesResponse{
+		StartMonth: last.AddDate(0, -(months - 1), 0).Format("2006-01"),
+		EndMonth:   last.Format("2006-01"),
+		BudgetID:   uuidPtrToString(budgetScope),
+		Categories: []RollingCategorySeries{},
+	}
+	for _, s := range reports.RollingAverages(totals, last, months) {
+		series := RollingCategorySeries{
+			Name:   s.CategoryName,
+			Months: make([]RollingMonth, len(s.Points)),
+		}
+		if s.CategoryID != "" {
+			series.CategoryID = &s.CategoryID
+		} else {
+			series.Name = "Uncategorized"
+		}
+		for i, p := range s.Points {
+			series.Months[i] = RollingMonth{
+				Month: p.Month.Format("2006-01"),
+				Total: p.Total,
+				Avg3:  p.Averages[3],
+				Avg6:  p.Averages[6],
+				Avg12: p.Averages[12],
+			}
+		}
+		response.Categories = append(response.Categories, series)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// GetCustomReport sums, counts and averages transactions between startDate and
+// endDate (default this month to date, at most five years), grouped by up to three
+// groupBy dimensions: one of day, week, month, quarter or year, plus weekday,
+// category, paymentMethod or type. Without groupBy there's one group.
+//
+// Filters: categoryId, paymentMethodId and budgetId (repeated or comma-separated),
+// type (expense, income or all; default expense), minAmount and maxAmount on the
+// home amount, search in descriptions, and shared=true|false for transactions in
+// shared budgets or not. Reports cover the user's own transactions unless budgetId
+// is given, in which case they cover those budgets', collaborators' included, after
+// checking the user can see each.
+func (h *ReportHandler) GetCustomReport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	query := r.URL.Query()
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	period := reports.Period{Start: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), End: today}
+	if query.Get("startDate") != "" || query.Get("endDate") != "" {
+		if period, ok = periodParams(w, r, "startDate", "endDate"); !ok {
+			return
+		}
+	}
+	if period.Days() > reports.MaxQueryDays {
+		utils.BadRequest(w, "Date range must be at most five years")
+		return
+	}
+
+	dims, err := reports.ParseDimensions(query.Get("groupBy"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid groupBy: "+err.Error())
+		return
+	}
+
+	params := models.ListReportTotalsParams{
+		UserID:    utils.PgUUID(userID),
+		StartDate: utils.PgDate(period.Start),
+		EndDate:   utils.PgDate(period.End),
+	}
+	if params.CategoryIds, ok = uuidListParam(w, r, "categoryId"); !ok {
+		return
+	}
+	if params.PaymentMethodIds, ok = uuidListParam(w, r, "paymentMethodId"); !ok {
+		return
+	}
+	if params.BudgetIds, ok = uuidListParam(w, r, "budgetId"); !ok {
+		return
+	}
+	for _, budgetID := range params.BudgetIds {
+		if !h.checkBudget(w, r, userID, budgetID) {
+			return
+		}
+	}
+
+	switch t := query.Get("type"); t {
+	case "", "expense":
+		params.Type = pgtype.Text{String: "expense", Valid: true}
+	case "income":
+		params.Type = pgtype.Text{String: t, Valid: true}
+	case "all":
+	default:
+		utils.BadRequest(w, "Invalid type. Use expense, income or all")
+		return
+	}
+	for _, bound := range []struct {
+		param string
+		dest  *pgtype.Numeric
+	}{{"minAmount", &params.MinAmount}, {"maxAmount", &params.MaxAmount}} {
+		if str := query.Get(bound.param); str != "" {
+			amount, err := money.Parse(str)
+			if err != nil || amount < 0 {
+				utils.BadRequest(w, "Invalid "+bound.param)
+				return
+			}
+			*bound.dest = amount.Numeric()
+		}
+	}
+	if search := strings.TrimSpace(query.Get("search")); search != "" {
+		if len(search) > 100 {
+			utils.BadRequest(w, "search must be at most 100 characters")
+			return
+		}
+		params.Search = pgtype.Text{String: search, Valid: true}
+	}
+	switch query.Get("shared") {
+	case "":
+	case "true", "false":
+		params.Shared = pgtype.Bool{Bool: query.Get("shared") == "true", Valid: true}
+	default:
+		utils.BadRequest(w, "Invalid shared. Use true or false")
+		return
+	}
+
+	rows, err := h.queries.ListReportTotals(r.Context(), params)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch report")
+		return
+	}
+	totals := make([]reports.DailyTotal, len(rows))
+	for i, row := range rows {
+		totals[i] = reports.DailyTotal{
+			Date: utils.DateToTime(row.TransactionDate),
+			Total: reports.Total{
+				Type:              transactionType(row.Type),
+				CategoryID:        utils.UUIDToString(row.CategoryID),
+				CategoryName:      utils.TextToString(row.CategoryName),
+				PaymentMethodID:   utils.UUIDToString(row.PaymentMethodID),
+				PaymentMethodName: utils.TextToString(row.PaymentMethodName),
+				Amount:            money.FromNumeric(row.Total),
+				Count:             row.TransactionCount,
+			},
+		}
+	}
+
+	result := reports.Aggregate(totals, dims)
+	response := CustomReportResponse{
+		StartDate:        period.Start.Format("2006-01-02"),
+		EndDate:          period.End.Format("2006-01-02"),
+		GroupBy:          dims,
+		Total:            result.Amount,
+		TransactionCount: result.Count,
+		Average:          result.Average,
+		Groups:           make([]CustomReportGroup, len(result.Groups)),
+	}
+	if response.GroupBy == nil {
+		response.GroupBy = []string{}
+	}
+	for i, g := range result.Groups {
+		group := CustomReportGroup{
+			Keys:             make(map[string]*string, len(g.Keys)),
+			Labels:           make(map[string]string),
+			Total:            g.Amount,
+			TransactionCount: g.Count,
+			Average:          g.Average,
+		}
+		for _, k := range g.Keys {
+			if k.Value != "" {
+				value := k.Value
+				group.Keys[k.Dimension] = &value
+			} else {
+				group.Keys[k.Dimension] = nil
+			}
+			switch {
+			case k.Label != "":
+				group.Labels[k.Dimension] = k.Label
+			case k.Dimension == reports.DimCategory:
+				group.Labels[k.Dimension] = "Uncategorized"
+			case k.Dimension == reports.DimPaymentMethod:
+				group.Labels[k.Dimension] = "No payment method"
+			}
+		}
+		response.Groups[i] = group
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// Helper functions
+
+// reportScope reads the budget ID in the query parameter param. Without one, reports
+// cover the user's own transactions; with one, the budget's, after checking the user
+// can see it. Exactly one of the returned scopes is set.
+func (h *ReportHandler) reportScope(w http.ResponseWriter, r *http.Request, userID, param string) (pgtype.UUID, pgtype.UUID, bool) {
+	budgetID := r.URL.Query().Get(param)
+	if budgetID == "" {
+		return utils.PgUUID(userID), pgtype.UUID{}, true
+	}
+	if !utils.PgUUID(budgetID).Valid {
+		utils.BadRequest(w, "Invalid "+param)
+		return pgtype.UUID{}, pgtype.UUID{}, false
+	}
+	if !h.checkBudget(w, r, userID, budgetID) {
+		return pgtype.UUID{}, pgtype.UUID{}, false
+	}
+	return pgtype.UUID{}, utils.PgUUID(budgetID), true
+}
+
+// checkBudget responds with 404 unless the user can see the budget
+func (h *ReportHandler) checkBudget(w http.ResponseWriter, r *http.Request, userID, budgetID string) bool {
+	_, err := h.queries.CheckBudgetAccess(r.Context(), models.CheckBudgetAccessParams{
+		ID:     budgetID,
+		UserID: utils.PgUUID(userID),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		utils.NotFound(w, "Budget not found")
+		return false
+	}
+	if err != nil {
+		utils.InternalError(w, "Failed to check budget access")
+		return false
+	}
+	return true
+}
+
+func (h *ReportHandler) periodTotals(r *http.Request, userScope, budgetScope pgtype.UUID, p reports.Period) ([]reports.Total, error) {
+	rows, err := h.queries.ListPeriodTotals(r.Context(), models.ListPeriodTotalsParams{
+		UserID:    userScope,
+		BudgetID:  budgetScope,
+		StartDate: utils.PgDate(p.Start),
+		EndDate:   utils.PgDate(p.End),
+	})
+	if err != nil {
+		return nil, err
+	}
+	totals := make([]reports.Total, len(rows))
+	for i, row := range rows {
+		totals[i] = reports.Total{
+			Type:              transactionType(row.Type),
+			CategoryID:        utils.UUIDToString(row.CategoryID),
+			CategoryName:      utils.TextToString(row.CategoryName),
+			PaymentMethodID:   utils.UUIDToString(row.PaymentMethodID),
+			PaymentMethodName: utils.TextToString(row.PaymentMethodName),
+			Amount:            money.FromNumeric(row.Total),
+			Count:             row.TransactionCount,
+		}
+	}
+	return totals, nil
+}
+
+// periodParams reads a custom period from the start and end query parameters,
+// responding with 400 if either is missing or invalid
+func periodParams(w http.ResponseWriter, r *http.Request, startParam, endParam string) (reports.Period, bool) {
+	query := r.URL.Query()
+	start, err := time.Parse("2006-01-02", query.Get(startParam))
+	if err != nil {
+		utils.BadRequest(w, startParam+" is required. Use YYYY-MM-DD")
+		return reports.Period{}, false
+	}
+	end, err := time.Parse("2006-01-02", query.Get(endParam))
+	if err != nil {
+		utils.BadRequest(w, endParam+" is required. Use YYYY-MM-DD")
+		return reports.Period{}, false
+	}
+	if end.Before(start) {
+		utils.BadRequest(w, endParam+" must not be before "+startParam)
+		return reports.Period{}, false
+	}
+	return reports.Period{Start: start, End: end}, true
+}
+
+// uuidListParam reads IDs from the query parameter param, repeated or
+// comma-separated, responding with 400 if any isn't a UUID. It returns nil if
+// there are none.
+func uuidListParam(w http.ResponseWriter, r *http.Request, param string) ([]string, bool) {
+	var ids []string
+	for _, value := range r.URL.Query()[param] {
+		for _, id := range strings.Split(value, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			if !utils.PgUUID(id).Valid {
+				utils.BadRequest(w, "Invalid "+param)
+				return nil, false
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, true
+}
+
+func comparisonPeriodToResponse(s reports.Summary, budgetScope pgtype.UUID) ComparisonPeriod {
+	return ComparisonPeriod{
+		StartDate:        s.Period.Start.Format("2006-01-02"),
+		EndDate:          s.Period.End.Format("2006-01-02"),
+		BudgetID:         uuidPtrToString(budgetScope),
+		Expenses:         s.Expenses,
+		Income:           s.Income,
+		Net:              s.Net,
+		TransactionCount: s.TransactionCount,
+	}
+}
+
+func comparisonDeltaToResponse(d reports.Delta) ComparisonDelta {
+	return ComparisonDelta{Amount: d.Amount, Percent: d.Percent}
+}
+
+// comparisonLinesToResponse converts lines, naming the one without an ID unnamed
+func comparisonLinesToResponse(lines []reports.Line, unnamed string) []ComparisonLine {
+	response := make([]ComparisonLine, len(lines))
+	for i, l := range lines {
+		response[i] = ComparisonLine{
+			Name:     l.Name,
+			Type:     l.Type,
+			Current:  l.Current,
+			Baseline: l.Baseline,
+			Change:   comparisonDeltaToResponse(l.Change),
+		}
+		if l.ID != "" {
+			id := l.ID
+			response[i].ID = &id
+		} else {
+			response[i].Name = unnamed
+		}
+	}
+	return response
+}
@@ -2,22 +2,31 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/mail"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
 	"github.com/joselitophala/budget-planner-backend/internal/auth"
 	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/notify"
+	"github.com/joselitophala/budget-planner-backend/internal/push"
 	"github.com/joselitophala/budget-planner-backend/internal/utils"
 )
 
 // SharingHandler handles budget sharing-related requests
 type SharingHandler struct {
 	queries *models.Queries
+	pool    *pgxpool.Pool
 }
 
 // NewSharingHandler creates a new sharing handler
-func NewSharingHandler(queries *models.Queries) *SharingHandler {
-	return &SharingHandler{queries: queries}
+func NewSharingHandler(queries *models.Queries, pool *pgxpool.Pool) *SharingHandler {
+	return &SharingHandler{queries: queries, pool: pool}
 }
 
 // ShareInvitationRequest represents the create share invitation request
@@ -32,6 +41,16 @@ type UpdateInvitationRequest struct {
 	Status string `json:"status"` // "accepted" or "declined"
 }
 
+// UpdateAccessRequest represents the change collaborator permission request
+type UpdateAccessRequest struct {
+	Permission string `json:"permission"` // "view" or "edit"
+}
+
+// TransferOwnershipRequest represents the transfer budget ownership request
+type TransferOwnershipRequest struct {
+	NewOwnerID string `json:"newOwnerId"`
+}
+
 // CreateShareInvitation creates a new share invitation
 func (h *SharingHandler) CreateShareInvitation(w http.ResponseWriter, r *http.Request) {
 	userID, ok := auth.GetUserID(r)
@@ -48,7 +67,7 @@ func (h *SharingHandler) CreateShareInvitation(w http.ResponseWriter, r *http.Re
 
 	// Verify ownership of the budget
 	budget, err := h.queries.GetBudgetByID(r.Context(), req.BudgetID)
-	if err != nil || !budget.UserID.Valid || utils.UUIDToString(budget.UserID) != userID {
+	if err != nil || budget.UserID != utils.PgUUID(userID) {
 		utils.Forbidden(w, "You can only share your own budgets")
 		return
 	}
@@ -58,8 +77,28 @@ func (h *SharingHandler) CreateShareInvitation(w http.ResponseWriter, r *http.Re
 		utils.BadRequest(w, "Permission must be 'view' or 'edit'")
 		return
 	}
+	if _, err := mail.ParseAddress(req.RecipientEmail); err != nil {
+		utils.BadRequest(w, "Invalid recipient email")
+		return
+	}
+
+	inviter, err := h.queries.GetCurrentUser(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch user")
+		return
+	}
+
+	// The invitation email is queued with the invitation, so neither exists without the other
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		utils.InternalError(w, "Failed to create invitation")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := h.queries.WithTx(tx)
 
-	invitation, err := h.queries.CreateShareInvitation(r.Context(), models.CreateShareInvitationParams{
+	invitation, err := qtx.CreateShareInvitation(r.Context(), models.CreateShareInvitationParams{
 		BudgetID:       utils.PgUUID(req.BudgetID),
 		OwnerID:        utils.PgUUID(userID),
 		RecipientEmail: req.RecipientEmail,
@@ -71,6 +110,72 @@ func (h *SharingHandler) CreateShareInvitation(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	inviterName := utils.TextToString(inviter.Name)
+	if inviterName == "" {
+		inviterName = inviter.Email
+	}
+	budgetName := utils.TextToString(budget.Name)
+	if budgetName == "" {
+		budgetName = budgetPeriod(budget).Label()
+	}
+	// Recipients who already have an account also get it on their devices
+	var recipientID string
+	if recipient, err := qtx.GetUserByEmail(r.Context(), invitation.RecipientEmail); err == nil {
+		recipientID = recipient.ID
+		_, err = push.Enqueue(r.Context(), qtx, recipientID, push.Message{
+			Title: inviterName + " shared a budget with you",
+			Body:  budgetName,
+			URL:   "/sharing",
+			Tag:   "invitation:" + invitation.ID,
+			Kind:  "share_invitation",
+			Data: map[string]interface{}{
+				"invitationId": invitation.ID,
+			},
+		})
+		if err != nil {
+			utils.InternalError(w, "Failed to create invitation")
+			return
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		utils.InternalError(w, "Failed to create invitation")
+		return
+	}
+
+	_, err = notify.Enqueue(r.Context(), qtx, notify.Email{
+		UserID:   recipientID,
+		To:       invitation.RecipientEmail,
+		Template: notify.TemplateShareInvitation,
+		Data: map[string]interface{}{
+			"invitationId":   invitation.ID,
+			"inviterName":    inviterName,
+			"budgetName":     budgetName,
+			"permission":     invitation.Permission,
+			"recipientEmail": invitation.RecipientEmail,
+			"expiresAt":      utils.TimestamptzToTime(invitation.ExpiresAt).Format("January 2, 2006"),
+		},
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to create invitation")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to create invitation")
+		return
+	}
+
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "share.invited",
+		ResourceType: activity.ResourceShare,
+		ResourceID:   invitation.ID,
+		BudgetID:     req.BudgetID,
+		Details: map[string]interface{}{
+			"recipientEmail": invitation.RecipientEmail,
+			"permission":     invitation.Permission,
+		},
+	})
+
 	utils.SendCreated(w, invitation)
 }
 
@@ -156,6 +261,17 @@ func (h *SharingHandler) RespondToInvitation(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "share.invitation_" + req.Status,
+		ResourceType: activity.ResourceShare,
+		ResourceID:   updated.ID,
+		BudgetID:     utils.UUIDToString(updated.BudgetID),
+		Details: map[string]interface{}{
+			"permission": updated.Permission,
+		},
+	})
+
 	utils.SendSuccess(w, updated)
 }
 
@@ -180,7 +296,7 @@ func (h *SharingHandler) CancelInvitation(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if !invitation.OwnerID.Valid || utils.UUIDToString(invitation.OwnerID) != userID {
+	if invitation.OwnerID != utils.PgUUID(userID) {
 		utils.Forbidden(w, "You can only cancel your own invitations")
 		return
 	}
@@ -191,6 +307,17 @@ func (h *SharingHandler) CancelInvitation(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "share.invitation_cancelled",
+		ResourceType: activity.ResourceShare,
+		ResourceID:   invitation.ID,
+		BudgetID:     utils.UUIDToString(invitation.BudgetID),
+		Details: map[string]interface{}{
+			"recipientEmail": invitation.RecipientEmail,
+		},
+	})
+
 	utils.SendSuccess(w, map[string]string{
 		"message": "Invitation cancelled successfully",
 	})
@@ -234,7 +361,7 @@ func (h *SharingHandler) RemoveAccess(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !access.OwnerID.Valid || utils.UUIDToString(access.OwnerID) != userID {
+	if access.OwnerID != utils.PgUUID(userID) {
 		utils.Forbidden(w, "You can only remove access for your own budgets")
 		return
 	}
@@ -245,6 +372,18 @@ func (h *SharingHandler) RemoveAccess(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "share.access_removed",
+		ResourceType: activity.ResourceShare,
+		ResourceID:   access.ID,
+		BudgetID:     utils.UUIDToString(access.BudgetID),
+		Details: map[string]interface{}{
+			"sharedWithId": utils.UUIDToString(access.SharedWithID),
+			"permission":   access.Permission,
+		},
+	})
+
 	utils.SendSuccess(w, map[string]string{
 		"message": "Access removed successfully",
 	})
@@ -266,3 +405,248 @@ func (h *SharingHandler) GetSharedBudgets(w http.ResponseWriter, r *http.Request
 
 	utils.SendSuccess(w, sharedBudgets)
 }
+
+// UpdateAccess changes a collaborator's permission between view and edit (owner only)
+func (h *SharingHandler) UpdateAccess(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	accessID := r.PathValue("id")
+	if accessID == "" {
+		utils.BadRequest(w, "Access ID is required")
+		return
+	}
+
+	var req UpdateAccessRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	if req.Permission != "view" && req.Permission != "edit" {
+		utils.BadRequest(w, "Permission must be 'view' or 'edit'")
+		return
+	}
+
+	access, err := h.queries.GetShareAccessByID(r.Context(), accessID)
+	if err != nil {
+		utils.NotFound(w, "Access record not found")
+		return
+	}
+
+	if access.OwnerID != utils.PgUUID(userID) {
+		utils.Forbidden(w, "You can only change access for your own budgets")
+		return
+	}
+
+	updated, err := h.queries.UpdateShareAccess(r.Context(), models.UpdateShareAccessParams{
+		Permission: req.Permission,
+		ID:         accessID,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to update access")
+		return
+	}
+
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "share.permission_changed",
+		ResourceType: activity.ResourceShare,
+		ResourceID:   updated.ID,
+		BudgetID:     utils.UUIDToString(updated.BudgetID),
+		Before:       map[string]interface{}{"permission": access.Permission},
+		After:        map[string]interface{}{"permission": updated.Permission},
+		Details: map[string]interface{}{
+			"sharedWithId": utils.UUIDToString(updated.SharedWithID),
+		},
+	})
+
+	utils.SendSuccess(w, updated)
+}
+
+// LeaveBudget removes the current user's own access to a budget shared with them
+func (h *SharingHandler) LeaveBudget(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	budgetID := r.PathValue("budgetId")
+	if budgetID == "" {
+		utils.BadRequest(w, "Budget ID is required")
+		return
+	}
+
+	access, err := h.queries.GetShareAccessForBudgetAndUser(r.Context(), models.GetShareAccessForBudgetAndUserParams{
+		BudgetID:     utils.PgUUID(budgetID),
+		SharedWithID: utils.PgUUID(userID),
+	})
+	if err != nil {
+		utils.NotFound(w, "You are not a collaborator on this budget")
+		return
+	}
+
+	err = h.queries.DeleteShareAccess(r.Context(), access.ID)
+	if err != nil {
+		utils.InternalError(w, "Failed to leave budget")
+		return
+	}
+
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "share.left",
+		ResourceType: activity.ResourceShare,
+		ResourceID:   access.ID,
+		BudgetID:     utils.UUIDToString(access.BudgetID),
+		Details: map[string]interface{}{
+			"ownerId":    utils.UUIDToString(access.OwnerID),
+			"permission": access.Permission,
+		},
+	})
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "You have left the shared budget",
+	})
+}
+
+// TransferOwnership hands a budget over to an existing editor (owner only).
+// The previous owner keeps edit access to the budget.
+func (h *SharingHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	budgetID := r.PathValue("budgetId")
+	if budgetID == "" {
+		utils.BadRequest(w, "Budget ID is required")
+		return
+	}
+
+	var req TransferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	if req.NewOwnerID == "" || req.NewOwnerID == userID {
+		utils.BadRequest(w, "A different new owner is required")
+		return
+	}
+
+	budget, err := h.queries.GetBudgetByID(r.Context(), budgetID)
+	if err != nil {
+		utils.NotFound(w, "Budget not found")
+		return
+	}
+
+	if budget.UserID != utils.PgUUID(userID) {
+		utils.Forbidden(w, "You can only transfer your own budgets")
+		return
+	}
+
+	// The new owner must already be an editor of the budget
+	newOwnerAccess, err := h.queries.GetShareAccessForBudgetAndUser(r.Context(), models.GetShareAccessForBudgetAndUserParams{
+		BudgetID:     utils.PgUUID(budgetID),
+		SharedWithID: utils.PgUUID(req.NewOwnerID),
+	})
+	if err != nil || newOwnerAccess.Permission != "edit" {
+		utils.BadRequest(w, "Ownership can only be transferred to an existing editor")
+		return
+	}
+
+	// The budget's limits and the amounts filed under it are kept in its owner's home
+	// currency, so they'd be misread by an owner with a different one
+	currency, err := homeCurrency(r.Context(), r, h.queries, utils.PgUUID(req.NewOwnerID))
+	if err != nil {
+		utils.InternalError(w, "Failed to transfer ownership")
+		return
+	}
+	if currency != auth.GetCurrency(r) {
+		utils.Conflict(w, "Ownership can only be transferred to an editor with the same home currency")
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		utils.InternalError(w, "Failed to transfer ownership")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := h.queries.WithTx(tx)
+
+	// The new owner no longer needs a share record of their own
+	if err := qtx.DeleteShareAccess(r.Context(), newOwnerAccess.ID); err != nil {
+		utils.InternalError(w, "Failed to transfer ownership")
+		return
+	}
+
+	updated, err := qtx.TransferBudgetOwnership(r.Context(), models.TransferBudgetOwnershipParams{
+		ID:     budgetID,
+		UserID: utils.PgUUID(req.NewOwnerID),
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			utils.Conflict(w, "The new owner already has a budget for this month")
+			return
+		}
+		utils.InternalError(w, "Failed to transfer ownership")
+		return
+	}
+
+	// Remaining collaborators and pending invitations now belong to the new owner
+	if err := qtx.TransferShareAccessOwner(r.Context(), models.TransferShareAccessOwnerParams{
+		BudgetID: utils.PgUUID(budgetID),
+		OwnerID:  utils.PgUUID(req.NewOwnerID),
+	}); err != nil {
+		utils.InternalError(w, "Failed to transfer ownership")
+		return
+	}
+
+	if err := qtx.TransferPendingInvitationsOwner(r.Context(), models.TransferPendingInvitationsOwnerParams{
+		BudgetID: utils.PgUUID(budgetID),
+		OwnerID:  utils.PgUUID(req.NewOwnerID),
+	}); err != nil {
+		utils.InternalError(w, "Failed to transfer ownership")
+		return
+	}
+
+	// Keep the previous owner on the budget as an editor
+	_, err = qtx.CreateShareAccess(r.Context(), models.CreateShareAccessParams{
+		BudgetID:     utils.PgUUID(budgetID),
+		OwnerID:      utils.PgUUID(req.NewOwnerID),
+		SharedWithID: utils.PgUUID(userID),
+		Permission:   "edit",
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to transfer ownership")
+		return
+	}
+
+	if err := activity.Record(r.Context(), qtx, r, activity.Entry{
+		UserID:       userID,
+		Action:       "budget.ownership_transferred",
+		ResourceType: activity.ResourceBudget,
+		ResourceID:   budgetID,
+		BudgetID:     budgetID,
+		Before:       map[string]interface{}{"ownerId": userID},
+		After:        map[string]interface{}{"ownerId": req.NewOwnerID},
+	}); err != nil {
+		utils.InternalError(w, "Failed to transfer ownership")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to transfer ownership")
+		return
+	}
+
+	utils.SendSuccess(w, updated)
+}
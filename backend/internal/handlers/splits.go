@@ -0,0 +1,724 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/splits"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// SplitHandler handles expense splitting and settle-up between shared budget members
+type SplitHandler struct {
+	queries *models.Queries
+	pool    *pgxpool.Pool
+}
+
+// NewSplitHandler creates a new split handler
+func NewSplitHandler(queries *models.Queries, pool *pgxpool.Pool) *SplitHandler {
+	return &SplitHandler{queries: queries, pool: pool}
+}
+
+// SplitParticipant represents one member's part of a split request
+type SplitParticipant struct {
+	UserID string       `json:"userId"`
+	Value  splits.Value `json:"value,omitempty"` // amount, percentage or share count depending on method
+}
+
+// SplitRequest represents the set transaction split request
+type SplitRequest struct {
+	PaidByID     string             `json:"paidById"`
+	Method       string             `json:"method"` // "equal", "exact", "percentage" or "shares"
+	Participants []SplitParticipant `json:"participants"`
+}
+
+// SplitShareResponse represents a member's share of a split transaction
+type SplitShareResponse struct {
+	UserID string        `json:"userId"`
+	Value  *splits.Value `json:"value,omitempty"`
+	Amount money.Amount  `json:"amount"`
+}
+
+// SplitResponse represents a transaction split in API responses
+type SplitResponse struct {
+	TransactionID string               `json:"transactionId"`
+	BudgetID      string               `json:"budgetId"`
+	PaidByID      string               `json:"paidById"`
+	Method        string               `json:"method"`
+	Shares        []SplitShareResponse `json:"shares"`
+	UpdatedAt     string               `json:"updatedAt"`
+}
+
+// MemberBalance represents a member's position within a shared budget
+type MemberBalance struct {
+	UserID     string       `json:"userId"`
+	Name       string       `json:"name"`
+	Email      string       `json:"email"`
+	TotalPaid  money.Amount `json:"totalPaid"`  // total of split expenses this member paid for
+	TotalShare money.Amount `json:"totalShare"` // total of this member's shares of split expenses
+	Net        money.Amount `json:"net"`        // positive: owed money, negative: owes money
+}
+
+// DebtResponse represents an amount one member owes another
+type DebtResponse struct {
+	FromUserID string       `json:"fromUserId"`
+	ToUserID   string       `json:"toUserId"`
+	Amount     money.Amount `json:"amount"`
+}
+
+// BudgetBalancesResponse represents who owes whom within a shared budget
+type BudgetBalancesResponse struct {
+	BudgetID    string          `json:"budgetId"`
+	Members     []MemberBalance `json:"members"`
+	Debts       []DebtResponse  `json:"debts"`       // outstanding debts between each pair of members
+	Suggestions []DebtResponse  `json:"suggestions"` // simplified payments that settle everyone up
+}
+
+// CreateSettlementRequest represents the record settlement request
+type CreateSettlementRequest struct {
+	FromUserID string        `json:"fromUserId"`
+	ToUserID   string        `json:"toUserId"`
+	Amount     *money.Amount `json:"amount,omitempty"` // defaults to the full balance between the two members
+	Note       *string       `json:"note,omitempty"`
+}
+
+// SettlementResponse represents a settlement in API responses
+type SettlementResponse struct {
+	ID         string       `json:"id"`
+	BudgetID   string       `json:"budgetId"`
+	FromUserID string       `json:"fromUserId"`
+	ToUserID   string       `json:"toUserId"`
+	Amount     money.Amount `json:"amount"`
+	Note       *string      `json:"note,omitempty"`
+	CreatedAt  string       `json:"createdAt"`
+}
+
+// GetSplit returns how a transaction is split among budget members
+func (h *SplitHandler) GetSplit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	transactionID := r.PathValue("id")
+	if transactionID == "" {
+		utils.BadRequest(w, "Transaction ID is required")
+		return
+	}
+
+	transaction, err := h.queries.GetTransactionByID(r.Context(), transactionID)
+	if err != nil || !transaction.BudgetID.Valid {
+		utils.NotFound(w, "Transaction not found")
+		return
+	}
+
+	if _, err := h.budgetPermission(r.Context(), utils.UUIDToString(transaction.BudgetID), userID); err != nil {
+		utils.NotFound(w, "Transaction not found")
+		return
+	}
+
+	split, err := h.queries.GetExpenseSplitByTransaction(r.Context(), transactionID)
+	if err != nil {
+		utils.NotFound(w, "Transaction is not split")
+		return
+	}
+
+	shares, err := h.queries.GetExpenseSplitShares(r.Context(), split.ID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch split")
+		return
+	}
+
+	utils.SendSuccess(w, splitToResponse(split, shares))
+}
+
+// SetSplit records who paid a shared budget transaction and how it splits across members
+func (h *SplitHandler) SetSplit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	transactionID := r.PathValue("id")
+	if transactionID == "" {
+		utils.BadRequest(w, "Transaction ID is required")
+		return
+	}
+
+	var req SplitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+
+	method := splits.Method(req.Method)
+	if !splits.IsValidMethod(method) {
+		utils.BadRequest(w, "Method must be 'equal', 'exact', 'percentage' or 'shares'")
+		return
+	}
+
+	transaction, err := h.queries.GetTransactionByID(r.Context(), transactionID)
+	if err != nil {
+		utils.NotFound(w, "Transaction not found")
+		return
+	}
+
+	if !transaction.BudgetID.Valid {
+		utils.BadRequest(w, "Only transactions in a shared budget can be split")
+		return
+	}
+	budgetID := utils.UUIDToString(transaction.BudgetID)
+
+	permission, err := h.budgetPermission(r.Context(), budgetID, userID)
+	if err != nil {
+		utils.NotFound(w, "Transaction not found")
+		return
+	}
+	if permission == "view" {
+		utils.Forbidden(w, "You need edit access to split transactions in this budget")
+		return
+	}
+
+	members, err := h.budgetMembers(r.Context(), budgetID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch budget members")
+		return
+	}
+
+	if _, ok := members[req.PaidByID]; !ok {
+		utils.BadRequest(w, "The member who paid must have access to the budget")
+		return
+	}
+
+	parts := make([]splits.Part, len(req.Participants))
+	for i, p := range req.Participants {
+		if _, ok := members[p.UserID]; !ok {
+			utils.BadRequest(w, "All participants must have access to the budget")
+			return
+		}
+		parts[i] = splits.Part{UserID: p.UserID, Value: p.Value}
+	}
+
+	// Split the home currency amount so balances across transactions in different currencies add up
+	total := money.FromNumeric(transaction.HomeAmount).Cents()
+	if total <= 0 {
+		utils.BadRequest(w, "Only transactions with a positive amount can be split")
+		return
+	}
+
+	amounts, err := splits.Allocate(total, method, parts)
+	if err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		utils.InternalError(w, "Failed to save split")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := h.queries.WithTx(tx)
+
+	split, err := qtx.UpsertExpenseSplit(r.Context(), models.UpsertExpenseSplitParams{
+		TransactionID: transactionID,
+		BudgetID:      budgetID,
+		PaidByID:      req.PaidByID,
+		SplitMethod:   string(method),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to save split")
+		return
+	}
+
+	if err := qtx.DeleteExpenseSplitShares(r.Context(), split.ID); err != nil {
+		utils.InternalError(w, "Failed to save split")
+		return
+	}
+
+	shares := make([]models.ExpenseSplitShare, len(parts))
+	for i, p := range parts {
+		value := p.Value.Numeric()
+		if method == splits.MethodEqual {
+			value.Valid = false
+		}
+		shares[i], err = qtx.CreateExpenseSplitShare(r.Context(), models.CreateExpenseSplitShareParams{
+			SplitID: split.ID,
+			UserID:  p.UserID,
+			Value:   value,
+			Amount:  money.FromCents(amounts[i]).Numeric(),
+		})
+		if err != nil {
+			utils.InternalError(w, "Failed to save split")
+			return
+		}
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to save split")
+		return
+	}
+
+	response := splitToResponse(split, shares)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "transaction.split_set",
+		ResourceType: activity.ResourceTransaction,
+		ResourceID:   transactionID,
+		BudgetID:     budgetID,
+		Details: map[string]interface{}{
+			"split": response,
+		},
+	})
+
+	utils.SendSuccess(w, response)
+}
+
+// RemoveSplit removes the split from a transaction
+func (h *SplitHandler) RemoveSplit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	transactionID := r.PathValue("id")
+	if transactionID == "" {
+		utils.BadRequest(w, "Transaction ID is required")
+		return
+	}
+
+	split, err := h.queries.GetExpenseSplitByTransaction(r.Context(), transactionID)
+	if err != nil {
+		utils.NotFound(w, "Transaction is not split")
+		return
+	}
+
+	permission, err := h.budgetPermission(r.Context(), split.BudgetID, userID)
+	if err != nil {
+		utils.NotFound(w, "Transaction is not split")
+		return
+	}
+	if permission == "view" {
+		utils.Forbidden(w, "You need edit access to change splits in this budget")
+		return
+	}
+
+	if err := h.queries.DeleteExpenseSplit(r.Context(), transactionID); err != nil {
+		utils.InternalError(w, "Failed to remove split")
+		return
+	}
+
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "transaction.split_removed",
+		ResourceType: activity.ResourceTransaction,
+		ResourceID:   transactionID,
+		BudgetID:     split.BudgetID,
+		Details: map[string]interface{}{
+			"paidById":    split.PaidByID,
+			"splitMethod": split.SplitMethod,
+		},
+	})
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Split removed successfully",
+	})
+}
+
+// GetBalances returns net balances per member and simplified settle-up suggestions for a budget
+func (h *SplitHandler) GetBalances(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	budgetID := r.PathValue("id")
+	if budgetID == "" {
+		utils.BadRequest(w, "Budget ID is required")
+		return
+	}
+
+	if _, err := h.budgetPermission(r.Context(), budgetID, userID); err != nil {
+		utils.NotFound(w, "Budget not found or no access")
+		return
+	}
+
+	members, err := h.queries.GetBudgetMembers(r.Context(), budgetID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch budget members")
+		return
+	}
+
+	ledger, paid, share, err := h.buildLedger(r.Context(), budgetID)
+	if err != nil {
+		utils.InternalError(w, "Failed to calculate balances")
+		return
+	}
+
+	net := ledger.Net()
+
+	response := BudgetBalancesResponse{
+		BudgetID:    budgetID,
+		Members:     make([]MemberBalance, len(members)),
+		Debts:       transfersToResponse(ledger.Pairwise()),
+		Suggestions: transfersToResponse(splits.Simplify(net)),
+	}
+	for i, m := range members {
+		response.Members[i] = MemberBalance{
+			UserID:     m.ID,
+			Name:       utils.TextToString(m.Name),
+			Email:      m.Email,
+			TotalPaid:  money.FromCents(paid[m.ID]),
+			TotalShare: money.FromCents(share[m.ID]),
+			Net:        money.FromCents(net[m.ID]),
+		}
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// ListSettlements returns the settlements recorded for a budget
+func (h *SplitHandler) ListSettlements(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	budgetID := r.PathValue("id")
+	if budgetID == "" {
+		utils.BadRequest(w, "Budget ID is required")
+		return
+	}
+
+	if _, err := h.budgetPermission(r.Context(), budgetID, userID); err != nil {
+		utils.NotFound(w, "Budget not found or no access")
+		return
+	}
+
+	settlements, err := h.queries.ListSettlementsByBudget(r.Context(), budgetID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch settlements")
+		return
+	}
+
+	response := make([]SettlementResponse, len(settlements))
+	for i, s := range settlements {
+		response[i] = settlementToResponse(s)
+	}
+
+	utils.SendSuccess(w, response)
+}
+
+// CreateSettlement records a payment between two members. Without an amount it
+// settles the full outstanding balance, zeroing out what they owe each other.
+func (h *SplitHandler) CreateSettlement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	budgetID := r.PathValue("id")
+	if budgetID == "" {
+		utils.BadRequest(w, "Budget ID is required")
+		return
+	}
+
+	var req CreateSettlementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequestBody(w, err)
+		return
+	}
+	if !checkAmounts(w, r, req.Amount) {
+		return
+	}
+
+	if req.FromUserID == "" || req.ToUserID == "" || req.FromUserID == req.ToUserID {
+		utils.BadRequest(w, "Two different members are required")
+		return
+	}
+
+	permission, err := h.budgetPermission(r.Context(), budgetID, userID)
+	if err != nil {
+		utils.NotFound(w, "Budget not found or no access")
+		return
+	}
+
+	// Only the two members involved or the budget owner can record a settlement
+	if permission != "owner" && userID != req.FromUserID && userID != req.ToUserID {
+		utils.Forbidden(w, "You can only record settlements you are part of")
+		return
+	}
+
+	members, err := h.budgetMembers(r.Context(), budgetID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch budget members")
+		return
+	}
+	if _, ok := members[req.FromUserID]; !ok {
+		utils.BadRequest(w, "Both members must have access to the budget")
+		return
+	}
+	if _, ok := members[req.ToUserID]; !ok {
+		utils.BadRequest(w, "Both members must have access to the budget")
+		return
+	}
+
+	var amount int64
+	if req.Amount != nil {
+		amount = req.Amount.Cents()
+	} else {
+		ledger, _, _, err := h.buildLedger(r.Context(), budgetID)
+		if err != nil {
+			utils.InternalError(w, "Failed to calculate balances")
+			return
+		}
+		amount = ledger.Owed(req.FromUserID, req.ToUserID)
+	}
+
+	if amount <= 0 {
+		utils.BadRequest(w, "There is nothing to settle between these members")
+		return
+	}
+
+	settlement, err := h.queries.CreateSettlement(r.Context(), models.CreateSettlementParams{
+		BudgetID:   budgetID,
+		FromUserID: req.FromUserID,
+		ToUserID:   req.ToUserID,
+		Amount:     money.FromCents(amount).Numeric(),
+		Note:       utils.PgTextPtr(req.Note),
+		CreatedBy:  utils.PgUUID(userID),
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to record settlement")
+		return
+	}
+
+	response := settlementToResponse(settlement)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "settlement.created",
+		ResourceType: activity.ResourceSettlement,
+		ResourceID:   settlement.ID,
+		BudgetID:     budgetID,
+		After:        response,
+	})
+
+	utils.SendCreated(w, response)
+}
+
+// buildLedger loads split shares and settlements for a budget into a ledger,
+// along with each member's total paid and total share in centavos
+func (h *SplitHandler) buildLedger(ctx context.Context, budgetID string) (*splits.Ledger, map[string]int64, map[string]int64, error) {
+	shares, err := h.queries.GetBudgetSplitShares(ctx, budgetID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	settlements, err := h.queries.ListSettlementsByBudget(ctx, budgetID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ledger := splits.NewLedger()
+	paid := make(map[string]int64)
+	share := make(map[string]int64)
+	for _, s := range shares {
+		amount := money.FromNumeric(s.Amount).Cents()
+		ledger.AddShare(s.PaidByID, s.UserID, amount)
+		paid[s.PaidByID] += amount
+		share[s.UserID] += amount
+	}
+	for _, s := range settlements {
+		ledger.AddSettlement(s.FromUserID, s.ToUserID, money.FromNumeric(s.Amount).Cents())
+	}
+
+	return ledger, paid, share, nil
+}
+
+// budgetPermission returns the user's permission on a budget ("owner", "edit" or "view")
+func (h *SplitHandler) budgetPermission(ctx context.Context, budgetID, userID string) (string, error) {
+	access, err := h.queries.CheckBudgetAccess(ctx, models.CheckBudgetAccessParams{
+		ID:     budgetID,
+		UserID: utils.PgUUID(userID),
+	})
+	if err != nil {
+		return "", err
+	}
+	return access.Permission, nil
+}
+
+// budgetMembers returns the owner and collaborators of a budget keyed by user ID
+func (h *SplitHandler) budgetMembers(ctx context.Context, budgetID string) (map[string]models.GetBudgetMembersRow, error) {
+	rows, err := h.queries.GetBudgetMembers(ctx, budgetID)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]models.GetBudgetMembersRow, len(rows))
+	for _, m := range rows {
+		members[m.ID] = m
+	}
+	return members, nil
+}
+
+// syncSplit keeps a split transaction's split in step with an edit, responding with
+// 409 if the split can't follow it. A split moves with its transaction to another
+// budget whose members include everyone in it, and its shares are reallocated when
+// the amount changes.
+func syncSplit(w http.ResponseWriter, r *http.Request, q *models.Queries, before, after models.Transaction) bool {
+	err := followSplit(r.Context(), q, before, after)
+	var conflict conflictError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &conflict):
+		utils.Conflict(w, conflict.Error())
+	default:
+		utils.InternalError(w, "Failed to update split")
+	}
+	return false
+}
+
+// followSplit is syncSplit without the response: a conflictError if the split can't
+// follow the edit
+func followSplit(ctx context.Context, q *models.Queries, before, after models.Transaction) error {
+	amountChanged := money.FromNumeric(after.HomeAmount) != money.FromNumeric(before.HomeAmount)
+	moved := after.BudgetID != before.BudgetID
+	if !amountChanged && !moved {
+		return nil
+	}
+
+	split, err := q.GetExpenseSplitByTransaction(ctx, after.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	shares, err := q.GetExpenseSplitShares(ctx, split.ID)
+	if err != nil {
+		return err
+	}
+
+	budgetID := utils.FormatUUID(after.BudgetID)
+	if moved {
+		rows, err := q.GetBudgetMembers(ctx, budgetID)
+		if err != nil {
+			return err
+		}
+		members := make(map[string]bool, len(rows))
+		for _, m := range rows {
+			members[m.ID] = true
+		}
+		if !members[split.PaidByID] {
+			return conflictError("The member who paid for this split transaction needs access to the new budget")
+		}
+		for _, share := range shares {
+			if !members[share.UserID] {
+				return conflictError("Everyone in this split transaction needs access to the new budget")
+			}
+		}
+	}
+
+	if _, err := q.UpsertExpenseSplit(ctx, models.UpsertExpenseSplitParams{
+		TransactionID: split.TransactionID,
+		BudgetID:      budgetID,
+		PaidByID:      split.PaidByID,
+		SplitMethod:   split.SplitMethod,
+	}); err != nil {
+		return err
+	}
+	if !amountChanged {
+		return nil
+	}
+
+	method := splits.Method(split.SplitMethod)
+	total := money.FromNumeric(after.HomeAmount).Cents()
+	if total <= 0 {
+		return conflictError("Only transactions with a positive amount can be split; remove the split first")
+	}
+	parts := make([]splits.Part, len(shares))
+	for i, share := range shares {
+		value, err := splits.ValueFromNumeric(share.Value)
+		if err != nil {
+			return err
+		}
+		parts[i] = splits.Part{UserID: share.UserID}
+		if value != nil {
+			parts[i].Value = *value
+		}
+	}
+	amounts, err := splits.Allocate(total, method, parts)
+	if err != nil {
+		return conflictError("The split no longer fits the new amount, update it first: " + err.Error())
+	}
+	for i, share := range shares {
+		if err := q.UpdateExpenseSplitShareAmount(ctx, models.UpdateExpenseSplitShareAmountParams{
+			ID:     share.ID,
+			Amount: money.FromCents(amounts[i]).Numeric(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitToResponse(split models.ExpenseSplit, shares []models.ExpenseSplitShare) SplitResponse {
+	response := SplitResponse{
+		TransactionID: split.TransactionID,
+		BudgetID:      split.BudgetID,
+		PaidByID:      split.PaidByID,
+		Method:        split.SplitMethod,
+		Shares:        make([]SplitShareResponse, len(shares)),
+		UpdatedAt:     utils.TimestamptzToTime(split.UpdatedAt).Format(time.RFC3339),
+	}
+	for i, s := range shares {
+		// Values are DECIMAL(12, 4), which always fits
+		value, _ := splits.ValueFromNumeric(s.Value)
+		response.Shares[i] = SplitShareResponse{
+			UserID: s.UserID,
+			Value:  value,
+			Amount: money.FromNumeric(s.Amount),
+		}
+	}
+	return response
+}
+
+func settlementToResponse(s models.Settlement) SettlementResponse {
+	return SettlementResponse{
+		ID:         s.ID,
+		BudgetID:   s.BudgetID,
+		FromUserID: s.FromUserID,
+		ToUserID:   s.ToUserID,
+		Amount:     money.FromNumeric(s.Amount),
+		Note:       utils.TextToStringPtr(s.Note),
+		CreatedAt:  utils.TimestamptzToTime(s.CreatedAt).Format(time.RFC3339),
+	}
+}
+
+func transfersToResponse(transfers []splits.Transfer) []DebtResponse {
+	response := make([]DebtResponse, len(transfers))
+	for i, t := range transfers {
+		response[i] = DebtResponse{
+			FromUserID: t.From,
+			ToUserID:   t.To,
+			Amount:     money.FromCents(t.Amount),
+		}
+	}
+	return response
+}
@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/insights"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// SubscriptionHandler handles detected subscription requests
+type SubscriptionHandler struct {
+	queries *models.Queries
+	pool    *pgxpool.Pool
+}
+
+// NewSubscriptionHandler creates a new subscription handler
+func NewSubscriptionHandler(queries *models.Queries, pool *pgxpool.Pool) *SubscriptionHandler {
+	return &SubscriptionHandler{queries: queries, pool: pool}
+}
+
+// Subscription statuses
+const (
+	subscriptionActive    = "active"
+	subscriptionLapsed    = "lapsed" // the expected charge is well overdue
+	subscriptionCancelled = "cancelled"
+)
+
+// SubscriptionResponse represents a detected subscription in API responses. Amounts
+// are in the home currency.
+type SubscriptionResponse struct {
+	ID                     string                   `json:"id"`
+	Name                   string                   `json:"name"`
+	Frequency              string                   `json:"frequency"`
+	Amount                 money.Amount             `json:"amount"`
+	AnnualCost             money.Amount             `json:"annualCost"`
+	CategoryID             *string                  `json:"categoryId,omitempty"`
+	PaymentMethodID        *string                  `json:"paymentMethodId,omitempty"`
+	Status                 string                   `json:"status"`
+	ChargeCount            int                      `json:"chargeCount"`
+	FirstCharge            string                   `json:"firstCharge"`
+	LastCharge             string                   `json:"lastCharge"`
+	NextCharge             *string                  `json:"nextCharge"` // null once cancelled
+	LastPriceChange        *SubscriptionPriceChange `json:"lastPriceChange"`
+	RecurringTransactionID *string                  `json:"recurringTransactionId"`
+	CancelledAt            *string                  `json:"cancelledAt,omitempty"`
+	ChargedSinceCancelled  bool                     `json:"chargedSinceCancelled"`
+	TransactionIDs         []string                 `json:"transactionIds"`
+}
+
+// SubscriptionPriceChange is when a subscription's price last changed
+type SubscriptionPriceChange struct {
+	Date string       `json:"date"`
+	From money.Amount `json:"from"`
+	To   money.Amount `json:"to"`
+}
+
+// SubscriptionSummary totals the active subscriptions
+type SubscriptionSummary struct {
+	ActiveCount  int          `json:"activeCount"`
+	MonthlyTotal money.Amount `json:"monthlyTotal"`
+	AnnualTotal  money.Amount `json:"annualTotal"`
+}
+
+// SubscriptionListResponse is the subscriptions list
+type SubscriptionListResponse struct {
+	Subscriptions []SubscriptionResponse `json:"subscriptions"`
+	Summary       SubscriptionSummary    `json:"summary"`
+}
+
+// ListSubscriptions returns charges that repeat weekly, monthly or yearly at a
+// consistent amount, most expensive per year first. status filters by active,
+// lapsed or cancelled.
+func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != subscriptionActive && status != subscriptionLapsed && status != subscriptionCancelled {
+		utils.BadRequest(w, "Invalid status. Use active, lapsed or cancelled")
+		return
+	}
+
+	subscriptions, err := h.detect(r, userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch transactions")
+		return
+	}
+	cancellations, err := h.queries.ListSubscriptionCancellations(r.Context(), userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch cancelled subscriptions")
+		return
+	}
+	cancelled := make(map[string]time.Time, len(cancellations))
+	for _, c := range cancellations {
+		cancelled[c.SubscriptionID] = utils.TimestamptzToTime(c.CancelledAt)
+	}
+
+	response := SubscriptionListResponse{Subscriptions: []SubscriptionResponse{}}
+	var annual money.Amount
+	for _, s := range subscriptions {
+		var cancelledAt *time.Time
+		if at, ok := cancelled[s.ID]; ok {
+			cancelledAt = &at
+		}
+		resp := subscriptionToResponse(s, cancelledAt)
+		if resp.Status == subscriptionActive {
+			response.Summary.ActiveCount++
+			annual += s.AnnualCost
+		}
+		if status == "" || resp.Status == status {
+			response.Subscriptions = append(response.Subscriptions, resp)
+		}
+	}
+	response.Summary.AnnualTotal = annual
+	response.Summary.MonthlyTotal = annual.Div(12)
+
+	utils.SendSuccess(w, response)
+}
+
+// MakeRecurring turns a subscription into a recurring transaction by marking its
+// latest charge as recurring at the detected frequency, so it shows in upcoming
+// bills and forecasts
+func (h *SubscriptionHandler) MakeRecurring(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	s, ok := h.findSubscription(w, r, userID)
+	if !ok {
+		return
+	}
+	if s.RecurringTransactionID != "" {
+		utils.Conflict(w, "Subscription is already a recurring transaction")
+		return
+	}
+
+	latest := s.Charges[len(s.Charges)-1]
+	before, err := h.queries.GetTransactionByID(r.Context(), latest.ID)
+	if err != nil {
+		utils.InternalError(w, "Failed to update transaction")
+		return
+	}
+	pattern, err := json.Marshal(map[string]interface{}{
+		"frequency": s.Frequency,
+		"interval":  1,
+		"endDate":   nil,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to update transaction")
+		return
+	}
+
+	transaction, err := h.queries.UpdateTransaction(r.Context(), models.UpdateTransactionParams{
+		ID:                latest.ID,
+		IsRecurring:       pgBool(true),
+		RecurrencePattern: pattern,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to update transaction")
+		return
+	}
+
+	response := transactionToResponse(transaction)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "transaction.updated",
+		ResourceType: activity.ResourceTransaction,
+		ResourceID:   transaction.ID,
+		BudgetID:     utils.UUIDToString(transaction.BudgetID),
+		Before:       transactionToResponse(before),
+		After:        response,
+		Details: map[string]interface{}{
+			"subscription": s.ID,
+		},
+	})
+	checkAlerts(r, h.pool, h.queries, transaction)
+
+	utils.SendSuccess(w, response)
+}
+
+// CancelSubscription marks a subscription cancelled. If it's a recurring
+// transaction, the recurrence ends today so it stops showing as upcoming.
+func (h *SubscriptionHandler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	s, ok := h.findSubscription(w, r, userID)
+	if !ok {
+		return
+	}
+
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		utils.InternalError(w, "Failed to cancel subscription")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	qtx := h.queries.WithTx(tx)
+
+	cancellation, err := qtx.CancelSubscription(r.Context(), models.CancelSubscriptionParams{
+		UserID:         userID,
+		SubscriptionID: s.ID,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to cancel subscription")
+		return
+	}
+
+	var before, ended *models.Transaction
+	if s.RecurringTransactionID != "" {
+		t, err := qtx.GetTransactionByID(r.Context(), s.RecurringTransactionID)
+		if err != nil {
+			utils.InternalError(w, "Failed to cancel subscription")
+			return
+		}
+		if pattern, changed := endRecurrence(t.RecurrencePattern, time.Now()); changed {
+			updated, err := qtx.UpdateTransaction(r.Context(), models.UpdateTransactionParams{
+				ID:                t.ID,
+				RecurrencePattern: pattern,
+			})
+			if err != nil {
+				utils.InternalError(w, "Failed to cancel subscription")
+				return
+			}
+			before, ended = &t, &updated
+		}
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to cancel subscription")
+		return
+	}
+	if ended != nil {
+		recordActivity(r, h.queries, activity.Entry{
+			UserID:       userID,
+			Action:       "transaction.updated",
+			ResourceType: activity.ResourceTransaction,
+			ResourceID:   ended.ID,
+			BudgetID:     utils.UUIDToString(ended.BudgetID),
+			Before:       transactionToResponse(*before),
+			After:        transactionToResponse(*ended),
+			Details: map[string]interface{}{
+				"subscription": s.ID,
+			},
+		})
+	}
+
+	cancelledAt := utils.TimestamptzToTime(cancellation.CancelledAt)
+	utils.SendSuccess(w, subscriptionToResponse(s, &cancelledAt))
+}
+
+// RestoreSubscription undoes a cancellation. A recurrence ended by cancelling stays
+// ended; edit the transaction to resume it.
+func (h *SubscriptionHandler) RestoreSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
+	id := r.PathValue("id")
+	if id == "" || len(id) > 200 {
+		utils.BadRequest(w, "Invalid subscription ID")
+		return
+	}
+
+	rows, err := h.queries.RestoreSubscription(r.Context(), models.RestoreSubscriptionParams{
+		UserID:         userID,
+		SubscriptionID: id,
+	})
+	if err != nil {
+		utils.InternalError(w, "Failed to restore subscription")
+		return
+	}
+	if rows == 0 {
+		utils.NotFound(w, "Subscription is not cancelled")
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{
+		"message": "Subscription restored",
+	})
+}
+
+// Helper functions
+
+// detect finds the user's subscriptions in their recent expenses
+func (h *SubscriptionHandler) detect(r *http.Request, userID string) ([]insights.Subscription, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	rows, err := h.queries.ListInsightTransactions(r.Context(), models.ListInsightTransactionsParams{
+		UserID:    utils.PgUUID(userID),
+		StartDate: utils.PgDate(insights.SubscriptionHistoryStart(today)),
+		EndDate:   utils.PgDate(today),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]insights.Transaction, len(rows))
+	for i, row := range rows {
+		transactions[i] = insights.Transaction{
+			ID:              row.ID,
+			Date:            utils.DateToTime(row.TransactionDate),
+			CategoryID:      utils.UUIDToString(row.CategoryID),
+			CategoryName:    utils.TextToString(row.CategoryName),
+			Description:     utils.TextToString(row.Description),
+			Amount:          money.FromNumeric(row.HomeAmount),
+			PaymentMethodID: utils.UUIDToString(row.PaymentMethodID),
+			Recurring:       row.IsRecurring.Bool,
+		}
+	}
+	return insights.DetectSubscriptions(transactions, today), nil
+}
+
+// findSubscription detects the subscription in the path, responding with 404 if the
+// user has none with that ID
+func (h *SubscriptionHandler) findSubscription(w http.ResponseWriter, r *http.Request, userID string) (insights.Subscription, bool) {
+	id := r.PathValue("id")
+	subscriptions, err := h.detect(r, userID)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch transactions")
+		return insights.Subscription{}, false
+	}
+	for _, s := range subscriptions {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	utils.NotFound(w, "Subscription not found")
+	return insights.Subscription{}, false
+}
+
+// endRecurrence sets a recurrence pattern's end date to day, unless it already ends
+// on or before it. It reports whether the pattern changed.
+func endRecurrence(pattern []byte, day time.Time) ([]byte, bool) {
+	var p map[string]interface{}
+	if len(pattern) == 0 || json.Unmarshal(pattern, &p) != nil {
+		return nil, false
+	}
+	end := day.Format("2006-01-02")
+	if current, ok := p["endDate"].(string); ok && current <= end {
+		return nil, false
+	}
+	p["endDate"] = end
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// subscriptionToResponse converts a detected subscription, cancelled at cancelledAt
+// if it's been marked cancelled
+func subscriptionToResponse(s insights.Subscription, cancelledAt *time.Time) SubscriptionResponse {
+	first, last := s.Charges[0], s.Charges[len(s.Charges)-1]
+	resp := SubscriptionResponse{
+		ID:             s.ID,
+		Name:           s.Name,
+		Frequency:      s.Frequency,
+		Amount:         s.Amount,
+		AnnualCost:     s.AnnualCost,
+		Status:         subscriptionActive,
+		ChargeCount:    len(s.Charges),
+		FirstCharge:    first.Date.Format("2006-01-02"),
+		LastCharge:     last.Date.Format("2006-01-02"),
+		TransactionIDs: make([]string, len(s.Charges)),
+	}
+	for i, c := range s.Charges {
+		resp.TransactionIDs[i] = c.ID
+	}
+	if s.CategoryID != "" {
+		resp.CategoryID = &s.CategoryID
+	}
+	if s.PaymentMethodID != "" {
+		resp.PaymentMethodID = &s.PaymentMethodID
+	}
+	if s.RecurringTransactionID != "" {
+		resp.RecurringTransactionID = &s.RecurringTransactionID
+	}
+	if c := s.LastPriceChange; c != nil {
+		resp.LastPriceChange = &SubscriptionPriceChange{
+			Date: c.Date.Format("2006-01-02"),
+			From: c.From,
+			To:   c.To,
+		}
+	}
+
+	switch {
+	case cancelledAt != nil:
+		formatted := cancelledAt.Format(time.RFC3339)
+		resp.Status = subscriptionCancelled
+		resp.CancelledAt = &formatted
+		// A charge dated after the day it was cancelled means the cancellation didn't take
+		cancelledDay := time.Date(cancelledAt.Year(), cancelledAt.Month(), cancelledAt.Day(), 0, 0, 0, 0, time.UTC)
+		resp.ChargedSinceCancelled = last.Date.After(cancelledDay)
+	case !s.Active:
+		resp.Status = subscriptionLapsed
+	}
+	if resp.Status != subscriptionCancelled {
+		next := s.NextCharge.Format("2006-01-02")
+		resp.NextCharge = &next
+	}
+	return resp
+}
@@ -3,22 +3,30 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
 	"github.com/joselitophala/budget-planner-backend/internal/auth"
 	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
 	"github.com/joselitophala/budget-planner-backend/internal/utils"
 )
 
 // SyncHandler handles offline sync-related requests
 type SyncHandler struct {
 	queries *models.Queries
+	pool    *pgxpool.Pool
 }
 
 // NewSyncHandler creates a new sync handler
-func NewSyncHandler(queries *models.Queries) *SyncHandler {
-	return &SyncHandler{queries: queries}
+func NewSyncHandler(queries *models.Queries, pool *pgxpool.Pool) *SyncHandler {
+	return &SyncHandler{queries: queries, pool: pool}
 }
 
 // PushRequest represents a sync push request from the client
@@ -55,8 +63,12 @@ func (h *SyncHandler) Push(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Keep numbers as their literal text so amounts aren't rounded through float64
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+
 	var req PushRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decoder.Decode(&req); err != nil {
 		utils.BadRequest(w, "Invalid request body")
 		return
 	}
@@ -64,8 +76,29 @@ func (h *SyncHandler) Push(w http.ResponseWriter, r *http.Request) {
 	results := make([]map[string]interface{}, 0)
 
 	for _, op := range req.Operations {
-		result := h.processSyncOperation(r.Context(), userID, op)
+		result, change := h.processSyncOperation(r, userID, op)
 		results = append(results, result)
+
+		// Only operations that wrote something are logged, with the records as
+		// stored rather than what the client sent
+		if change != nil {
+			recordActivity(r, h.queries, activity.Entry{
+				UserID:       userID,
+				Action:       "sync." + op.Operation,
+				ResourceType: activity.ResourceSync,
+				ResourceID:   change.RecordID,
+				BudgetID:     change.BudgetID,
+				Before:       change.Before,
+				After:        change.After,
+				Details: map[string]interface{}{
+					"table": op.Table,
+				},
+			})
+
+			if op.Table == "transactions" && op.Operation != "delete" {
+				h.checkSyncedTransaction(r, userID, change.RecordID)
+			}
+		}
 	}
 
 	utils.SendSuccess(w, map[string]interface{}{
@@ -96,7 +129,8 @@ func (h *SyncHandler) Pull(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get changes since last sync
+	// Get changes since last sync. Records are sent as stored, so amounts are
+	// exact JSON numbers (pgtype.Numeric) rather than float64 values.
 	changes := make(map[string][]json.RawMessage)
 
 	// Fetch updated budgets
@@ -109,6 +143,16 @@ func (h *SyncHandler) Pull(w http.ResponseWriter, r *http.Request) {
 		changes["budgets"] = []json.RawMessage{budgetsJSON}
 	}
 
+	// Fetch updated budget categories
+	budgetCategories, err := h.queries.GetBudgetCategoriesSince(r.Context(), models.GetBudgetCategoriesSinceParams{
+		UserID:  utils.PgUUID(userID),
+		Column2: lastSyncTime,
+	})
+	if err == nil && len(budgetCategories) > 0 {
+		bcJSON, _ := json.Marshal(budgetCategories)
+		changes["budget_categories"] = []json.RawMessage{bcJSON}
+	}
+
 	// Fetch updated transactions
 	transactions, err := h.queries.GetTransactionsSince(r.Context(), models.GetTransactionsSinceParams{
 		UserID:  utils.PgUUID(userID),
@@ -159,7 +203,7 @@ func (h *SyncHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 
 // ResolveConflict handles conflict resolution for sync operations
 func (h *SyncHandler) ResolveConflict(w http.ResponseWriter, r *http.Request) {
-	_, ok := auth.GetUserID(r)
+	userID, ok := auth.GetUserID(r)
 	if !ok {
 		utils.Unauthorized(w, "Not authenticated")
 		return
@@ -185,13 +229,38 @@ func (h *SyncHandler) ResolveConflict(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "sync.conflict_resolved",
+		ResourceType: activity.ResourceSync,
+		ResourceID:   req.OperationID,
+		Details: map[string]interface{}{
+			"resolution": req.Resolution,
+			"mergedData": req.MergedData,
+		},
+	})
+
 	utils.SendSuccess(w, map[string]string{
 		"message": "Conflict resolved successfully",
 	})
 }
 
-// processSyncOperation processes a single sync operation
-func (h *SyncHandler) processSyncOperation(ctx context.Context, userID string, op SyncOperation) map[string]interface{} {
+// syncChange is what a sync operation wrote: the record before and after, as stored.
+// RecordID is the server's ID for it, which a create doesn't share with the client's.
+type syncChange struct {
+	RecordID string
+	BudgetID string
+	Before   interface{}
+	After    interface{}
+}
+
+// errSyncFailed is what a pushed operation reports when the database fails, rather
+// than the database's own error
+var errSyncFailed = errors.New("Failed to sync record")
+
+// processSyncOperation processes a single sync operation. The change is nil unless
+// the operation wrote to the database.
+func (h *SyncHandler) processSyncOperation(r *http.Request, userID string, op SyncOperation) (map[string]interface{}, *syncChange) {
 	result := map[string]interface{}{
 		"table":     op.Table,
 		"recordId":  op.RecordID,
@@ -199,19 +268,369 @@ func (h *SyncHandler) processSyncOperation(ctx context.Context, userID string, o
 		"status":    "success",
 	}
 
+	var change *syncChange
+	var err error
 	// Implement table-specific logic
 	switch op.Table {
 	case "transactions":
-		// Handle transaction sync
-		// This would call the appropriate query based on operation type
+		change, err = h.syncTransaction(r, userID, op)
 	case "budgets":
 		// Handle budget sync
 	case "categories":
 		// Handle category sync
 	default:
+		err = errors.New("unsupported table")
+	}
+
+	if err != nil {
 		result["status"] = "error"
-		result["error"] = "unsupported table"
+		result["error"] = err.Error()
+		return result, nil
+	}
+	if change != nil {
+		result["serverId"] = change.RecordID
+	}
+	return result, change
+}
+
+// syncTransaction applies a pushed transaction the way the transaction endpoints do:
+// amounts are converted to the home currency, a transaction without a budget is
+// filed under the user's budget for its date, and strict envelopes are enforced.
+// Only the user's own transactions can be updated or deleted.
+func (h *SyncHandler) syncTransaction(r *http.Request, userID string, op SyncOperation) (*syncChange, error) {
+	switch op.Operation {
+	case "create":
+		return h.createSyncedTransaction(r, userID, op.LocalData)
+	case "update", "delete":
+	default:
+		return nil, errors.New("unsupported operation")
+	}
+
+	before, err := h.queries.GetTransactionByID(r.Context(), op.RecordID)
+	if err != nil || !utils.UUIDEquals(before.UserID, userID) {
+		return nil, errors.New("Transaction not found")
+	}
+	if op.Operation == "update" {
+		return h.updateSyncedTransaction(r, before, op.LocalData)
+	}
+	if err := h.queries.DeleteTransaction(r.Context(), before.ID); err != nil {
+		return nil, errSyncFailed
+	}
+	return &syncChange{
+		RecordID: before.ID,
+		BudgetID: utils.UUIDToString(before.BudgetID),
+		Before:   transactionToResponse(before),
+	}, nil
+}
+
+func (h *SyncHandler) createSyncedTransaction(r *http.Request, userID string, data map[string]interface{}) (*syncChange, error) {
+	ctx := r.Context()
+	var req CreateTransactionRequest
+	if err := decodeLocalData(data, &req); err != nil {
+		return nil, err
+	}
+	date, err := time.Parse("2006-01-02", req.TransactionDate)
+	if err != nil {
+		return nil, errors.New("Invalid transaction date format. Use YYYY-MM-DD")
+	}
+
+	currency, err := transactionCurrency(ctx, h.queries, req.Currency, req.PaymentMethodID, auth.GetCurrency(r))
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Amount.CheckPrecision(currency); err != nil {
+		return nil, err
+	}
+	if err := h.checkLinks(ctx, req.GoalID, req.DebtID, userID); err != nil {
+		return nil, err
+	}
+	if err := h.checkBudget(ctx, req.BudgetID, userID); err != nil {
+		return nil, err
+	}
+
+	budgetID := utils.PgUUIDPtr(req.BudgetID)
+	if req.BudgetID == nil {
+		if budgetID, err = budgetForDate(ctx, h.queries, utils.PgUUID(userID), date); err != nil {
+			return nil, errSyncFailed
+		}
+	}
+	home, err := bookCurrency(ctx, r, h.queries, utils.PgUUID(userID), budgetID)
+	if err != nil {
+		return nil, errSyncFailed
+	}
+	homeAmount, rate, err := convertToHome(ctx, h.queries, req.Amount, currency, home, date)
+	if err != nil {
+		return nil, syncConversionError(err)
+	}
+
+	params := req.params(userID, date, currency, homeAmount, rate)
+	params.BudgetID = budgetID
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return nil, errSyncFailed
+	}
+	defer tx.Rollback(ctx)
+	qtx := h.queries.WithTx(tx)
+
+	draw := transactionDraw(models.Transaction{
+		BudgetID:        params.BudgetID,
+		CategoryID:      params.CategoryID,
+		Type:            params.Type,
+		IsTransfer:      params.IsTransfer,
+		TransactionDate: params.TransactionDate,
+		HomeAmount:      params.HomeAmount,
+	})
+	if err := checkDraw(ctx, qtx, draw, envelopeDraw{}); err != nil {
+		return nil, syncWriteError(err)
+	}
+	transaction, err := qtx.CreateTransaction(ctx, params)
+	if err != nil {
+		return nil, errSyncFailed
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errSyncFailed
+	}
+
+	return &syncChange{
+		RecordID: transaction.ID,
+		BudgetID: utils.UUIDToString(transaction.BudgetID),
+		After:    transactionToResponse(transaction),
+	}, nil
+}
+
+// updateSyncedTransaction applies a pushed edit like UpdateTransaction: a new date
+// moves the transaction to the user's budget for it unless a budget is given, and a
+// split follows the transaction's budget and amount
+func (h *SyncHandler) updateSyncedTransaction(r *http.Request, before models.Transaction, data map[string]interface{}) (*syncChange, error) {
+	ctx := r.Context()
+	var req UpdateTransactionRequest
+	if err := decodeLocalData(data, &req); err != nil {
+		return nil, err
+	}
+
+	date := utils.DateToTime(before.TransactionDate)
+	var transactionDate *time.Time
+	if req.TransactionDate != nil {
+		t, err := time.Parse("2006-01-02", *req.TransactionDate)
+		if err != nil {
+			return nil, errors.New("Invalid transaction date format. Use YYYY-MM-DD")
+		}
+		transactionDate, date = &t, t
+	}
+	amount := money.FromNumeric(before.Amount)
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	currency := before.Currency
+	if req.Currency != nil {
+		code, err := money.NormalizeCurrency(*req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		currency = code
+	}
+	if err := amount.CheckPrecision(currency); err != nil {
+		return nil, err
+	}
+	if err := h.checkLinks(ctx, req.GoalID, req.DebtID, utils.FormatUUID(before.UserID)); err != nil {
+		return nil, err
+	}
+	if req.BudgetID != nil && utils.PgUUIDPtr(req.BudgetID) != before.BudgetID {
+		if err := h.checkBudget(ctx, req.BudgetID, utils.FormatUUID(before.UserID)); err != nil {
+			return nil, err
+		}
+	}
+
+	budgetID := utils.PgUUIDPtr(req.BudgetID)
+	if req.BudgetID == nil && transactionDate != nil && before.BudgetID.Valid {
+		budget, err := h.queries.GetBudgetByID(ctx, utils.UUIDToString(before.BudgetID))
+		if err == nil && budget.UserID == before.UserID && !budgetPeriod(budget).Contains(date) {
+			if budgetID, err = budgetForDate(ctx, h.queries, before.UserID, date); err != nil {
+				return nil, errSyncFailed
+			}
+		}
+	}
+
+	// The stored conversion only changes when the amount, currency, date or the
+	// currency it's kept in does
+	filedUnder := before.BudgetID
+	if budgetID.Valid {
+		filedUnder = budgetID
+	}
+	home, err := bookCurrency(ctx, r, h.queries, before.UserID, filedUnder)
+	if err != nil {
+		return nil, errSyncFailed
+	}
+	rebook := false
+	if filedUnder != before.BudgetID {
+		previous, err := bookCurrency(ctx, r, h.queries, before.UserID, before.BudgetID)
+		if err != nil {
+			return nil, errSyncFailed
+		}
+		rebook = previous != home
+	}
+	var homeAmount, exchangeRate pgtype.Numeric
+	if req.Amount != nil || currency != before.Currency || transactionDate != nil || rebook {
+		converted, rate, err := convertToHome(ctx, h.queries, amount, currency, home, date)
+		if err != nil {
+			return nil, syncConversionError(err)
+		}
+		homeAmount, exchangeRate = converted.Numeric(), rate.Numeric()
 	}
 
-	return result
+	after := before
+	if budgetID.Valid {
+		after.BudgetID = budgetID
+	}
+	if id := utils.PgUUIDPtr(req.CategoryID); id.Valid {
+		after.CategoryID = id
+	}
+	if t := utils.PgTextPtr(req.Type); t.Valid {
+		after.Type = t
+	}
+	if req.IsTransfer != nil {
+		after.IsTransfer = pgBool(*req.IsTransfer)
+	}
+	if homeAmount.Valid {
+		after.HomeAmount = homeAmount
+	}
+	after.TransactionDate = utils.PgDate(date)
+
+	var recurrencePattern []byte
+	if req.RecurrencePattern != nil {
+		if data, err := json.Marshal(req.RecurrencePattern); err == nil {
+			recurrencePattern = data
+		}
+	}
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return nil, errSyncFailed
+	}
+	defer tx.Rollback(ctx)
+	qtx := h.queries.WithTx(tx)
+
+	if err := checkDraw(ctx, qtx, transactionDraw(after), transactionDraw(before)); err != nil {
+		return nil, syncWriteError(err)
+	}
+	transaction, err := qtx.UpdateTransaction(ctx, models.UpdateTransactionParams{
+		ID:                  before.ID,
+		BudgetID:            budgetID,
+		CategoryID:          utils.PgUUIDPtr(req.CategoryID),
+		PaymentMethodID:     utils.PgUUIDPtr(req.PaymentMethodID),
+		Amount:              money.NumericPtr(req.Amount),
+		Type:                utils.PgTextPtr(req.Type),
+		IsTransfer:          pgBoolPtr(req.IsTransfer),
+		TransferToAccountID: utils.PgUUIDPtr(req.TransferToAccountID),
+		Description:         utils.PgTextPtr(req.Description),
+		TransactionDate:     utils.PgDatePtr(transactionDate),
+		IsRecurring:         pgBoolPtr(req.IsRecurring),
+		RecurrencePattern:   recurrencePattern,
+		Currency:            utils.PgText(currency),
+		HomeAmount:          homeAmount,
+		ExchangeRate:        exchangeRate,
+		GoalID:              utils.PgUUIDPtr(req.GoalID),
+		DebtID:              utils.PgUUIDPtr(req.DebtID),
+	})
+	if err != nil {
+		return nil, errSyncFailed
+	}
+	if err := followSplit(ctx, qtx, before, transaction); err != nil {
+		return nil, syncWriteError(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errSyncFailed
+	}
+
+	return &syncChange{
+		RecordID: transaction.ID,
+		BudgetID: utils.UUIDToString(transaction.BudgetID),
+		Before:   transactionToResponse(before),
+		After:    transactionToResponse(transaction),
+	}, nil
+}
+
+// checkLinks verifies that a savings goal and debt being linked belong to the
+// transaction's owner, as checkGoal and checkDebt do
+func (h *SyncHandler) checkLinks(ctx context.Context, goalID, debtID *string, ownerID string) error {
+	if goalID != nil {
+		goal, err := h.queries.GetSavingsGoalByID(ctx, *goalID)
+		if err != nil || goal.UserID != ownerID {
+			return errors.New("Savings goal not found")
+		}
+	}
+	if debtID != nil {
+		debt, err := h.queries.GetDebtByID(ctx, *debtID)
+		if err != nil || debt.UserID != ownerID {
+			return errors.New("Debt not found")
+		}
+	}
+	return nil
+}
+
+// checkBudget verifies that the user can add transactions to a budget being filed
+// under, as checkBudgetWritable does. A nil budget ID is always fine.
+func (h *SyncHandler) checkBudget(ctx context.Context, budgetID *string, userID string) error {
+	if budgetID == nil {
+		return nil
+	}
+	if !utils.PgUUID(*budgetID).Valid {
+		return errors.New("Budget not found")
+	}
+	permission, err := budgetPermission(ctx, h.queries, userID, *budgetID)
+	if err != nil {
+		return errSyncFailed
+	}
+	switch permission {
+	case "":
+		return errors.New("Budget not found")
+	case "view":
+		return errors.New("You don't have permission to add transactions to this budget")
+	}
+	return nil
+}
+
+// decodeLocalData decodes a pushed record into a request. Push keeps numbers as
+// their literal text, so amounts come through exact.
+func decodeLocalData(data map[string]interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return errSyncFailed
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("Invalid record: %w", err)
+	}
+	return nil
+}
+
+// syncConversionError is the error sendConversionError would respond with
+func syncConversionError(err error) error {
+	var noRate *noExchangeRateError
+	switch {
+	case errors.As(err, &noRate):
+		return noRate
+	case errors.Is(err, money.ErrOutOfRange):
+		return errors.New("Converted amount is out of range")
+	default:
+		return errSyncFailed
+	}
+}
+
+// syncWriteError passes on a conflict and hides any other error
+func syncWriteError(err error) error {
+	var conflict conflictError
+	if errors.As(err, &conflict) {
+		return conflict
+	}
+	return errSyncFailed
+}
+
+// checkSyncedTransaction evaluates alert rules for a transaction written by a push
+func (h *SyncHandler) checkSyncedTransaction(r *http.Request, userID, transactionID string) {
+	transaction, err := h.queries.GetTransactionByID(r.Context(), transactionID)
+	if err != nil || !utils.UUIDEquals(transaction.UserID, userID) {
+		return
+	}
+	checkAlerts(r, h.pool, h.queries, transaction)
 }
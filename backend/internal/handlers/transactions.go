@@ -1,73 +1,91 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joselitophala/budget-planner-backend/internal/activity"
 	"github.com/joselitophala/budget-planner-backend/internal/auth"
 	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
 	"github.com/joselitophala/budget-planner-backend/internal/utils"
 )
 
 // TransactionHandler handles transaction-related requests
 type TransactionHandler struct {
 	queries *models.Queries
+	pool    *pgxpool.Pool
 }
 
 // NewTransactionHandler creates a new transaction handler
-func NewTransactionHandler(queries *models.Queries) *TransactionHandler {
-	return &TransactionHandler{queries: queries}
+func NewTransactionHandler(queries *models.Queries, pool *pgxpool.Pool) *TransactionHandler {
+	return &TransactionHandler{queries: queries, pool: pool}
 }
 
 // TransactionResponse represents a transaction in API responses
 type TransactionResponse struct {
-	ID                  string      `json:"id"`
-	BudgetID            *string     `json:"budgetId,omitempty"`
-	CategoryID          *string     `json:"categoryId,omitempty"`
-	PaymentMethodID     *string     `json:"paymentMethodId,omitempty"`
-	Amount              float64     `json:"amount"`
-	Type                string      `json:"type"`
-	IsTransfer          bool        `json:"isTransfer"`
-	TransferToAccountID *string     `json:"transferToAccountId,omitempty"`
-	Description         *string     `json:"description,omitempty"`
-	TransactionDate     string      `json:"transactionDate"`
-	IsRecurring         bool        `json:"isRecurring"`
-	RecurrencePattern   interface{} `json:"recurrencePattern,omitempty"`
-	CreatedAt           string      `json:"createdAt"`
-	UpdatedAt           string      `json:"updatedAt"`
+	ID                  string       `json:"id"`
+	BudgetID            *string      `json:"budgetId,omitempty"`
+	CategoryID          *string      `json:"categoryId,omitempty"`
+	PaymentMethodID     *string      `json:"paymentMethodId,omitempty"`
+	Amount              money.Amount `json:"amount"`
+	Currency            string       `json:"currency"`
+	HomeAmount          money.Amount `json:"homeAmount"`   // amount in the budget owner's home currency
+	ExchangeRate        money.Rate   `json:"exchangeRate"` // rate used for homeAmount, fixed when the amount was entered
+	GoalID              *string      `json:"goalId,omitempty"`
+	DebtID              *string      `json:"debtId,omitempty"`
+	Type                string       `json:"type"`
+	IsTransfer          bool         `json:"isTransfer"`
+	TransferToAccountID *string      `json:"transferToAccountId,omitempty"`
+	Description         *string      `json:"description,omitempty"`
+	TransactionDate     string       `json:"transactionDate"`
+	IsRecurring         bool         `json:"isRecurring"`
+	RecurrencePattern   interface{}  `json:"recurrencePattern,omitempty"`
+	CreatedAt           string       `json:"createdAt"`
+	UpdatedAt           string       `json:"updatedAt"`
 }
 
 // CreateTransactionRequest represents the create transaction request
 type CreateTransactionRequest struct {
-	BudgetID          *string     `json:"budgetId,omitempty"`
-	CategoryID        *string     `json:"categoryId,omitempty"`
-	PaymentMethodID   *string     `json:"paymentMethodId,omitempty"`
-	Amount            float64     `json:"amount"`
-	Type              string      `json:"type"`
-	IsTransfer        bool        `json:"isTransfer"`
-	TransferToAccountID *string   `json:"transferToAccountId,omitempty"`
-	Description       *string     `json:"description,omitempty"`
-	TransactionDate   string      `json:"transactionDate"`
-	IsRecurring       bool        `json:"isRecurring"`
-	RecurrencePattern interface{} `json:"recurrencePattern,omitempty"`
+	BudgetID            *string      `json:"budgetId,omitempty"`
+	CategoryID          *string      `json:"categoryId,omitempty"`
+	PaymentMethodID     *string      `json:"paymentMethodId,omitempty"`
+	Amount              money.Amount `json:"amount"`
+	Currency            *string      `json:"currency,omitempty"` // defaults to the payment method's, then the home currency
+	GoalID              *string      `json:"goalId,omitempty"`   // records the transaction as a savings goal contribution
+	DebtID              *string      `json:"debtId,omitempty"`   // records the transaction as a debt payment
+	Type                string       `json:"type"`
+	IsTransfer          bool         `json:"isTransfer"`
+	TransferToAccountID *string      `json:"transferToAccountId,omitempty"`
+	Description         *string      `json:"description,omitempty"`
+	TransactionDate     string       `json:"transactionDate"`
+	IsRecurring         bool         `json:"isRecurring"`
+	RecurrencePattern   interface{}  `json:"recurrencePattern,omitempty"`
 }
 
 // UpdateTransactionRequest represents the update transaction request
 type UpdateTransactionRequest struct {
-	BudgetID          *string     `json:"budgetId,omitempty"`
-	CategoryID        *string     `json:"categoryId,omitempty"`
-	PaymentMethodID   *string     `json:"paymentMethodId,omitempty"`
-	Amount            *float64    `json:"amount,omitempty"`
-	Type              *string     `json:"type,omitempty"`
-	IsTransfer        *bool       `json:"isTransfer,omitempty"`
-	TransferToAccountID *string   `json:"transferToAccountId,omitempty"`
-	Description       *string     `json:"description,omitempty"`
-	TransactionDate   *string     `json:"transactionDate,omitempty"`
-	IsRecurring       *bool       `json:"isRecurring,omitempty"`
-	RecurrencePattern interface{} `json:"recurrencePattern,omitempty"`
+	BudgetID            *string       `json:"budgetId,omitempty"`
+	CategoryID          *string       `json:"categoryId,omitempty"`
+	PaymentMethodID     *string       `json:"paymentMethodId,omitempty"`
+	Amount              *money.Amount `json:"amount,omitempty"`
+	Currency            *string       `json:"currency,omitempty"`
+	GoalID              *string       `json:"goalId,omitempty"`
+	DebtID              *string       `json:"debtId,omitempty"`
+	Type                *string       `json:"type,omitempty"`
+	IsTransfer          *bool         `json:"isTransfer,omitempty"`
+	TransferToAccountID *string       `json:"transferToAccountId,omitempty"`
+	Description         *string       `json:"description,omitempty"`
+	TransactionDate     *string       `json:"transactionDate,omitempty"`
+	IsRecurring         *bool         `json:"isRecurring,omitempty"`
+	RecurrencePattern   interface{}   `json:"recurrencePattern,omitempty"`
 }
 
 // ListTransactions returns transactions with optional filters
@@ -137,6 +155,12 @@ func (h *TransactionHandler) ListTransactions(w http.ResponseWriter, r *http.Req
 
 // GetTransaction returns a single transaction by ID
 func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
 	transactionID := r.PathValue("id")
 	if transactionID == "" {
 		utils.BadRequest(w, "Transaction ID is required")
@@ -149,6 +173,16 @@ func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	permission, err := transactionPermission(r.Context(), h.queries, userID, transaction)
+	if err != nil {
+		utils.InternalError(w, "Failed to fetch transaction")
+		return
+	}
+	if permission == "" {
+		utils.NotFound(w, "Transaction not found")
+		return
+	}
+
 	utils.SendSuccess(w, transactionToResponse(transaction))
 }
 
@@ -162,7 +196,7 @@ func (h *TransactionHandler) CreateTransaction(w http.ResponseWriter, r *http.Re
 
 	var req CreateTransactionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
 		return
 	}
 
@@ -172,48 +206,113 @@ func (h *TransactionHandler) CreateTransaction(w http.ResponseWriter, r *http.Re
 		utils.BadRequest(w, "Invalid transaction date format. Use YYYY-MM-DD")
 		return
 	}
+	if req.BudgetID != nil && !checkBudgetWritable(w, r, h.queries, userID, *req.BudgetID) {
+		return
+	}
 
-	// Handle recurrence pattern JSON
-	var recurrencePattern []byte
-	if req.RecurrencePattern != nil {
-		if data, err := json.Marshal(req.RecurrencePattern); err == nil {
-			recurrencePattern = data
+	currency, err := transactionCurrency(r.Context(), h.queries, req.Currency, req.PaymentMethodID, auth.GetCurrency(r))
+	if err != nil {
+		utils.BadRequest(w, err.Error())
+		return
+	}
+	if !checkAmountsIn(w, currency, &req.Amount) {
+		return
+	}
+	if !h.checkGoal(w, r, req.GoalID, userID) || !h.checkDebt(w, r, req.DebtID, userID) {
+		return
+	}
+
+	budgetID := utils.PgUUIDPtr(req.BudgetID)
+	if req.BudgetID == nil {
+		if budgetID, err = budgetForDate(r.Context(), h.queries, utils.PgUUID(userID), transactionDate); err != nil {
+			utils.InternalError(w, "Failed to create transaction")
+			return
 		}
 	}
+	home, err := bookCurrency(r.Context(), r, h.queries, utils.PgUUID(userID), budgetID)
+	if err != nil {
+		utils.InternalError(w, "Failed to create transaction")
+		return
+	}
+	homeAmount, rate, err := convertToHome(r.Context(), h.queries, req.Amount, currency, home, transactionDate)
+	if err != nil {
+		sendConversionError(w, err)
+		return
+	}
 
-	transaction, err := h.queries.CreateTransaction(r.Context(), models.CreateTransactionParams{
-		UserID:              utils.PgUUID(userID),
-		BudgetID:            utils.PgUUIDPtr(req.BudgetID),
-		CategoryID:          utils.PgUUIDPtr(req.CategoryID),
-		PaymentMethodID:     utils.PgUUIDPtr(req.PaymentMethodID),
-		Amount:              utils.PgNumeric(req.Amount),
-		Type:                utils.PgText(req.Type),
-		IsTransfer:          pgBool(req.IsTransfer),
-		TransferToAccountID: utils.PgUUIDPtr(req.TransferToAccountID),
-		Description:         utils.PgTextPtr(req.Description),
-		TransactionDate:     utils.PgDate(transactionDate),
-		IsRecurring:         pgBool(req.IsRecurring),
-		RecurrencePattern:   recurrencePattern,
+	params := req.params(userID, transactionDate, currency, homeAmount, rate)
+	params.BudgetID = budgetID
+	draw := transactionDraw(models.Transaction{
+		BudgetID:        params.BudgetID,
+		CategoryID:      params.CategoryID,
+		Type:            params.Type,
+		IsTransfer:      params.IsTransfer,
+		TransactionDate: params.TransactionDate,
+		HomeAmount:      params.HomeAmount,
 	})
+
+	// The envelope check locks the budget until the insert commits
+	tx, err := h.pool.Begin(r.Context())
 	if err != nil {
 		utils.InternalError(w, "Failed to create transaction")
 		return
 	}
+	defer tx.Rollback(r.Context())
+	qtx := h.queries.WithTx(tx)
 
-	utils.SendCreated(w, transactionToResponse(transaction))
+	if !checkEnvelope(w, r, qtx, draw, envelopeDraw{}) {
+		return
+	}
+	transaction, err := qtx.CreateTransaction(r.Context(), params)
+	if err != nil {
+		utils.InternalError(w, "Failed to create transaction")
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to create transaction")
+		return
+	}
+
+	response := transactionToResponse(transaction)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "transaction.created",
+		ResourceType: activity.ResourceTransaction,
+		ResourceID:   transaction.ID,
+		BudgetID:     utils.UUIDToString(transaction.BudgetID),
+		After:        response,
+	})
+	checkAlerts(r, h.pool, h.queries, transaction)
+
+	utils.SendCreated(w, response)
 }
 
 // UpdateTransaction updates an existing transaction
 func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
 	transactionID := r.PathValue("id")
 	if transactionID == "" {
 		utils.BadRequest(w, "Transaction ID is required")
 		return
 	}
 
+	before, err := h.queries.GetTransactionByID(r.Context(), transactionID)
+	if err != nil {
+		utils.NotFound(w, "Transaction not found")
+		return
+	}
+	if !h.checkEditable(w, r, userID, before, "update") {
+		return
+	}
+
 	var req UpdateTransactionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.BadRequest(w, "Invalid request body")
+		badRequestBody(w, err)
 		return
 	}
 
@@ -225,6 +324,114 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 		}
 	}
 
+	// The stored conversion only changes when the amount, currency, date or the
+	// currency it's kept in does, so unrelated edits never pick up newer exchange rates
+	amount := money.FromNumeric(before.Amount)
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	currency := before.Currency
+	if req.Currency != nil {
+		code, err := money.NormalizeCurrency(*req.Currency)
+		if err != nil {
+			utils.BadRequest(w, err.Error())
+			return
+		}
+		currency = code
+	}
+	date := utils.DateToTime(before.TransactionDate)
+	if transactionDate != nil {
+		date = *transactionDate
+	}
+	if !checkAmountsIn(w, currency, &amount) {
+		return
+	}
+	ownerID := utils.FormatUUID(before.UserID)
+	if !h.checkGoal(w, r, req.GoalID, ownerID) || !h.checkDebt(w, r, req.DebtID, ownerID) {
+		return
+	}
+
+	// A budget given moves the transaction there. The caller must be able to add to
+	// it, and so must the owner, so nobody can take a transaction out of a shared
+	// budget into one only they can see.
+	budgetID := utils.PgUUIDPtr(req.BudgetID)
+	if req.BudgetID != nil && budgetID != before.BudgetID {
+		if !checkBudgetWritable(w, r, h.queries, userID, *req.BudgetID) {
+			return
+		}
+		permission, err := budgetPermission(r.Context(), h.queries, ownerID, *req.BudgetID)
+		if err != nil {
+			utils.InternalError(w, "Failed to update transaction")
+			return
+		}
+		if permission == "" || permission == "view" {
+			utils.Forbidden(w, "The transaction's owner can't add to this budget")
+			return
+		}
+	}
+
+	// A new date moves the transaction to the owner's budget for it, unless a budget
+	// is given or it's filed under someone else's shared budget
+	if req.BudgetID == nil && transactionDate != nil && before.BudgetID.Valid {
+		budget, err := h.queries.GetBudgetByID(r.Context(), utils.UUIDToString(before.BudgetID))
+		if err == nil && budget.UserID == before.UserID && !budgetPeriod(budget).Contains(date) {
+			if budgetID, err = budgetForDate(r.Context(), h.queries, before.UserID, date); err != nil {
+				utils.InternalError(w, "Failed to update transaction")
+				return
+			}
+		}
+	}
+
+	// Amounts under a budget are kept in its owner's home currency, so moving the
+	// transaction to a budget kept in another currency converts it again
+	filedUnder := before.BudgetID
+	if budgetID.Valid {
+		filedUnder = budgetID
+	}
+	home, err := bookCurrency(r.Context(), r, h.queries, before.UserID, filedUnder)
+	if err != nil {
+		utils.InternalError(w, "Failed to update transaction")
+		return
+	}
+	rebook := false
+	if filedUnder != before.BudgetID {
+		previous, err := bookCurrency(r.Context(), r, h.queries, before.UserID, before.BudgetID)
+		if err != nil {
+			utils.InternalError(w, "Failed to update transaction")
+			return
+		}
+		rebook = previous != home
+	}
+	var homeAmount, exchangeRate pgtype.Numeric
+	if req.Amount != nil || currency != before.Currency || transactionDate != nil || rebook {
+		converted, rate, err := convertToHome(r.Context(), h.queries, amount, currency, home, date)
+		if err != nil {
+			sendConversionError(w, err)
+			return
+		}
+		homeAmount, exchangeRate = converted.Numeric(), rate.Numeric()
+	}
+
+	// Strict envelope budgets check the transaction as it will be after the edit,
+	// leaving fields the update doesn't set as they are
+	after := before
+	if budgetID.Valid {
+		after.BudgetID = budgetID
+	}
+	if id := utils.PgUUIDPtr(req.CategoryID); id.Valid {
+		after.CategoryID = id
+	}
+	if t := utils.PgTextPtr(req.Type); t.Valid {
+		after.Type = t
+	}
+	if req.IsTransfer != nil {
+		after.IsTransfer = pgBool(*req.IsTransfer)
+	}
+	if homeAmount.Valid {
+		after.HomeAmount = homeAmount
+	}
+	after.TransactionDate = utils.PgDate(date)
+
 	// Handle recurrence pattern JSON
 	var recurrencePattern []byte
 	if req.RecurrencePattern != nil {
@@ -233,12 +440,23 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 		}
 	}
 
-	transaction, err := h.queries.UpdateTransaction(r.Context(), models.UpdateTransactionParams{
+	tx, err := h.pool.Begin(r.Context())
+	if err != nil {
+		utils.InternalError(w, "Failed to update transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+	qtx := h.queries.WithTx(tx)
+
+	if !checkEnvelope(w, r, qtx, transactionDraw(after), transactionDraw(before)) {
+		return
+	}
+	transaction, err := qtx.UpdateTransaction(r.Context(), models.UpdateTransactionParams{
 		ID:                  transactionID,
-		BudgetID:            utils.PgUUIDPtr(req.BudgetID),
+		BudgetID:            budgetID,
 		CategoryID:          utils.PgUUIDPtr(req.CategoryID),
 		PaymentMethodID:     utils.PgUUIDPtr(req.PaymentMethodID),
-		Amount:              utils.PgNumericPtr(req.Amount),
+		Amount:              money.NumericPtr(req.Amount),
 		Type:                utils.PgTextPtr(req.Type),
 		IsTransfer:          pgBoolPtr(req.IsTransfer),
 		TransferToAccountID: utils.PgUUIDPtr(req.TransferToAccountID),
@@ -246,29 +464,78 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 		TransactionDate:     utils.PgDatePtr(transactionDate),
 		IsRecurring:         pgBoolPtr(req.IsRecurring),
 		RecurrencePattern:   recurrencePattern,
+		Currency:            utils.PgText(currency),
+		HomeAmount:          homeAmount,
+		ExchangeRate:        exchangeRate,
+		GoalID:              utils.PgUUIDPtr(req.GoalID),
+		DebtID:              utils.PgUUIDPtr(req.DebtID),
 	})
 	if err != nil {
 		utils.InternalError(w, "Failed to update transaction")
 		return
 	}
+	// A split follows the transaction's budget and amount in the same transaction
+	if !syncSplit(w, r, qtx, before, transaction) {
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		utils.InternalError(w, "Failed to update transaction")
+		return
+	}
 
-	utils.SendSuccess(w, transactionToResponse(transaction))
+	response := transactionToResponse(transaction)
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "transaction.updated",
+		ResourceType: activity.ResourceTransaction,
+		ResourceID:   transaction.ID,
+		BudgetID:     utils.UUIDToString(transaction.BudgetID),
+		Before:       transactionToResponse(before),
+		After:        response,
+	})
+	checkAlerts(r, h.pool, h.queries, transaction)
+
+	utils.SendSuccess(w, response)
 }
 
 // DeleteTransaction soft deletes a transaction
 func (h *TransactionHandler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetUserID(r)
+	if !ok {
+		utils.Unauthorized(w, "Not authenticated")
+		return
+	}
+
 	transactionID := r.PathValue("id")
 	if transactionID == "" {
 		utils.BadRequest(w, "Transaction ID is required")
 		return
 	}
 
-	err := h.queries.DeleteTransaction(r.Context(), transactionID)
+	before, err := h.queries.GetTransactionByID(r.Context(), transactionID)
+	if err != nil {
+		utils.NotFound(w, "Transaction not found")
+		return
+	}
+	if !h.checkEditable(w, r, userID, before, "delete") {
+		return
+	}
+
+	err = h.queries.DeleteTransaction(r.Context(), transactionID)
 	if err != nil {
 		utils.InternalError(w, "Failed to delete transaction")
 		return
 	}
 
+	recordActivity(r, h.queries, activity.Entry{
+		UserID:       userID,
+		Action:       "transaction.deleted",
+		ResourceType: activity.ResourceTransaction,
+		ResourceID:   before.ID,
+		BudgetID:     utils.UUIDToString(before.BudgetID),
+		Before:       transactionToResponse(before),
+	})
+
 	utils.SendSuccess(w, map[string]string{
 		"message": "Transaction deleted successfully",
 	})
@@ -296,14 +563,273 @@ func (h *TransactionHandler) GetBudgetTransactions(w http.ResponseWriter, r *htt
 	utils.SendSuccess(w, response)
 }
 
+// params builds the insert for a new transaction once its amount has been converted
+// to the home currency
+func (req CreateTransactionRequest) params(userID string, date time.Time, currency string, homeAmount money.Amount, rate money.Rate) models.CreateTransactionParams {
+	// Handle recurrence pattern JSON
+	var recurrencePattern []byte
+	if req.RecurrencePattern != nil {
+		if data, err := json.Marshal(req.RecurrencePattern); err == nil {
+			recurrencePattern = data
+		}
+	}
+
+	return models.CreateTransactionParams{
+		UserID:              utils.PgUUID(userID),
+		BudgetID:            utils.PgUUIDPtr(req.BudgetID),
+		CategoryID:          utils.PgUUIDPtr(req.CategoryID),
+		PaymentMethodID:     utils.PgUUIDPtr(req.PaymentMethodID),
+		Amount:              req.Amount.Numeric(),
+		Type:                utils.PgText(req.Type),
+		IsTransfer:          pgBool(req.IsTransfer),
+		TransferToAccountID: utils.PgUUIDPtr(req.TransferToAccountID),
+		Description:         utils.PgTextPtr(req.Description),
+		TransactionDate:     utils.PgDate(date),
+		IsRecurring:         pgBool(req.IsRecurring),
+		RecurrencePattern:   recurrencePattern,
+		Currency:            currency,
+		HomeAmount:          homeAmount.Numeric(),
+		ExchangeRate:        rate.Numeric(),
+		GoalID:              utils.PgUUIDPtr(req.GoalID),
+		DebtID:              utils.PgUUIDPtr(req.DebtID),
+	}
+}
+
+// transactionCurrency resolves the currency of a new transaction: the requested one,
+// else the payment method's, else the user's home currency
+func transactionCurrency(ctx context.Context, q *models.Queries, requested, paymentMethodID *string, home string) (string, error) {
+	if requested != nil {
+		return money.NormalizeCurrency(*requested)
+	}
+	if paymentMethodID != nil {
+		method, err := q.GetPaymentMethodByID(ctx, *paymentMethodID)
+		if err == nil && method.Currency.Valid {
+			return method.Currency.String, nil
+		}
+	}
+	return home, nil
+}
+
+// transferParams validates a goal contribution or debt payment and converts it to the
+// home currency, responding with 400 if it's invalid. The caller sets the destination.
+func transferParams(w http.ResponseWriter, r *http.Request, q *models.Queries, userID string, req TransferRequest) (models.CreateTransactionParams, bool) {
+	date := time.Now()
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			utils.BadRequest(w, "Invalid date format. Use YYYY-MM-DD")
+			return models.CreateTransactionParams{}, false
+		}
+		date = parsed
+	}
+
+	currency, err := transactionCurrency(r.Context(), q, req.Currency, req.FromPaymentMethodID, auth.GetCurrency(r))
+	if err != nil {
+		utils.BadRequest(w, err.Error())
+		return models.CreateTransactionParams{}, false
+	}
+	if !checkAmountsIn(w, currency, &req.Amount) {
+		return models.CreateTransactionParams{}, false
+	}
+	if req.BudgetID != nil && !checkBudgetWritable(w, r, q, userID, *req.BudgetID) {
+		return models.CreateTransactionParams{}, false
+	}
+	budgetID := utils.PgUUIDPtr(req.BudgetID)
+	if req.BudgetID == nil {
+		if budgetID, err = budgetForDate(r.Context(), q, utils.PgUUID(userID), date); err != nil {
+			utils.InternalError(w, "Failed to create transaction")
+			return models.CreateTransactionParams{}, false
+		}
+	}
+	home, err := bookCurrency(r.Context(), r, q, utils.PgUUID(userID), budgetID)
+	if err != nil {
+		utils.InternalError(w, "Failed to create transaction")
+		return models.CreateTransactionParams{}, false
+	}
+	homeAmount, rate, err := convertToHome(r.Context(), q, req.Amount, currency, home, date)
+	if err != nil {
+		sendConversionError(w, err)
+		return models.CreateTransactionParams{}, false
+	}
+
+	return models.CreateTransactionParams{
+		UserID:          utils.PgUUID(userID),
+		BudgetID:        budgetID,
+		PaymentMethodID: utils.PgUUIDPtr(req.FromPaymentMethodID),
+		Amount:          req.Amount.Numeric(),
+		Type:            utils.PgText("transfer"),
+		IsTransfer:      pgBool(true),
+		Description:     utils.PgTextPtr(req.Description),
+		TransactionDate: utils.PgDate(date),
+		IsRecurring:     pgBool(false),
+		Currency:        currency,
+		HomeAmount:      homeAmount.Numeric(),
+		ExchangeRate:    rate.Numeric(),
+	}, true
+}
+
+// checkGoal verifies that a savings goal being linked belongs to the transaction's owner,
+// responding with 400 if not. A nil goal ID is always fine.
+func (h *TransactionHandler) checkGoal(w http.ResponseWriter, r *http.Request, goalID *string, ownerID string) bool {
+	if goalID == nil {
+		return true
+	}
+	goal, err := h.queries.GetSavingsGoalByID(r.Context(), *goalID)
+	if err != nil || goal.UserID != ownerID {
+		utils.BadRequest(w, "Savings goal not found")
+		return false
+	}
+	return true
+}
+
+// checkDebt verifies that a debt being linked belongs to the transaction's owner,
+// responding with 400 if not. A nil debt ID is always fine.
+func (h *TransactionHandler) checkDebt(w http.ResponseWriter, r *http.Request, debtID *string, ownerID string) bool {
+	if debtID == nil {
+		return true
+	}
+	debt, err := h.queries.GetDebtByID(r.Context(), *debtID)
+	if err != nil || debt.UserID != ownerID {
+		utils.BadRequest(w, "Debt not found")
+		return false
+	}
+	return true
+}
+
+// checkEditable responds with 404 if the user can't see the transaction and 403 if
+// they can only view it
+func (h *TransactionHandler) checkEditable(w http.ResponseWriter, r *http.Request, userID string, t models.Transaction, action string) bool {
+	permission, err := transactionPermission(r.Context(), h.queries, userID, t)
+	if err != nil {
+		utils.InternalError(w, "Failed to "+action+" transaction")
+		return false
+	}
+	switch permission {
+	case "":
+		utils.NotFound(w, "Transaction not found")
+		return false
+	case "view":
+		utils.Forbidden(w, "You don't have permission to "+action+" this transaction")
+		return false
+	}
+	return true
+}
+
+// checkBudgetWritable responds with 404 if the user can't see the budget and 403 if
+// they can only view it, so transactions can't be filed under it
+func checkBudgetWritable(w http.ResponseWriter, r *http.Request, q *models.Queries, userID, budgetID string) bool {
+	if !utils.PgUUID(budgetID).Valid {
+		utils.NotFound(w, "Budget not found")
+		return false
+	}
+	permission, err := budgetPermission(r.Context(), q, userID, budgetID)
+	if err != nil {
+		utils.InternalError(w, "Failed to check budget access")
+		return false
+	}
+	switch permission {
+	case "":
+		utils.NotFound(w, "Budget not found")
+		return false
+	case "view":
+		utils.Forbidden(w, "You don't have permission to add transactions to this budget")
+		return false
+	}
+	return true
+}
+
+// budgetPermission returns the user's permission on a budget ("owner", "edit" or
+// "view"), empty if they have no access
+func budgetPermission(ctx context.Context, q *models.Queries, userID, budgetID string) (string, error) {
+	access, err := q.CheckBudgetAccess(ctx, models.CheckBudgetAccessParams{
+		ID:     budgetID,
+		UserID: utils.PgUUID(userID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return access.Permission, nil
+}
+
+// transactionPermission returns the user's permission on a transaction: "owner" for
+// their own, otherwise their access to the budget it's filed under. An empty
+// permission means the user has no access.
+func transactionPermission(ctx context.Context, q *models.Queries, userID string, t models.Transaction) (string, error) {
+	if utils.UUIDEquals(t.UserID, userID) {
+		return "owner", nil
+	}
+	if !t.BudgetID.Valid {
+		return "", nil
+	}
+	return budgetPermission(ctx, q, userID, utils.UUIDToString(t.BudgetID))
+}
+
+// bookCurrency returns the currency a transaction's home amount is kept in: the home
+// currency of whoever owns the budget it's filed under, so a shared budget's totals add
+// up in one currency, or its own owner's when it isn't under a budget
+func bookCurrency(ctx context.Context, r *http.Request, q *models.Queries, ownerID, budgetID pgtype.UUID) (string, error) {
+	if budgetID.Valid {
+		budget, err := q.GetBudgetByID(ctx, utils.UUIDToString(budgetID))
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return "", err
+		}
+		if err == nil {
+			ownerID = budget.UserID
+		}
+	}
+	return homeCurrency(ctx, r, q, ownerID)
+}
+
+// homeCurrency returns a user's home currency, taking the signed-in user's from the request
+func homeCurrency(ctx context.Context, r *http.Request, q *models.Queries, userID pgtype.UUID) (string, error) {
+	signedIn, _ := auth.GetUserID(r)
+	if !userID.Valid || utils.UUIDEquals(userID, signedIn) {
+		return auth.GetCurrency(r), nil
+	}
+	user, err := q.GetCurrentUser(ctx, utils.UUIDToString(userID))
+	if err != nil {
+		return "", err
+	}
+	if user.Currency.Valid && user.Currency.String != "" {
+		return user.Currency.String, nil
+	}
+	return auth.DefaultCurrency, nil
+}
+
+// budgetForDate returns the ID of the user's budget whose period includes date, which
+// is invalid if they have none
+func budgetForDate(ctx context.Context, q *models.Queries, userID pgtype.UUID, date time.Time) (pgtype.UUID, error) {
+	budget, err := q.GetBudgetForDate(ctx, models.GetBudgetForDateParams{
+		UserID: userID,
+		Date:   utils.PgDate(date),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.UUID{}, nil
+	}
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	return utils.PgUUID(budget.ID), nil
+}
+
 // Helper function to convert transaction model to response
 func transactionToResponse(t models.Transaction) TransactionResponse {
+	// Stored rates are always positive, so a failed conversion can't happen here
+	rate, _ := money.RateFromNumeric(t.ExchangeRate)
 	return TransactionResponse{
 		ID:                  t.ID,
 		BudgetID:            uuidPtrToString(t.BudgetID),
 		CategoryID:          uuidPtrToString(t.CategoryID),
 		PaymentMethodID:     uuidPtrToString(t.PaymentMethodID),
-		Amount:              utils.NumericToFloat64(t.Amount),
+		Amount:              money.FromNumeric(t.Amount),
+		Currency:            t.Currency,
+		HomeAmount:          money.FromNumeric(t.HomeAmount),
+		ExchangeRate:        rate,
+		GoalID:              uuidPtrToString(t.GoalID),
+		DebtID:              uuidPtrToString(t.DebtID),
 		Type:                utils.TextToString(t.Type),
 		IsTransfer:          t.IsTransfer.Bool,
 		TransferToAccountID: uuidPtrToString(t.TransferToAccountID),
@@ -316,6 +842,42 @@ func transactionToResponse(t models.Transaction) TransactionResponse {
 	}
 }
 
+// badRequestBody responds to a request body that failed to decode, explaining invalid amounts
+func badRequestBody(w http.ResponseWriter, err error) {
+	if errors.Is(err, money.ErrInvalidAmount) || errors.Is(err, money.ErrTooPrecise) || errors.Is(err, money.ErrOutOfRange) {
+		utils.BadRequest(w, "Invalid amount: "+err.Error())
+		return
+	}
+	utils.BadRequest(w, "Invalid request body")
+}
+
+// checkAmounts validates amounts against the precision of the user's currency,
+// responding with 400 if one has too many decimal places. Nil amounts are skipped.
+func checkAmounts(w http.ResponseWriter, r *http.Request, amounts ...*money.Amount) bool {
+	return checkAmountsIn(w, auth.GetCurrency(r), amounts...)
+}
+
+// checkAmountsIn is checkAmounts for amounts in a specific currency
+func checkAmountsIn(w http.ResponseWriter, currency string, amounts ...*money.Amount) bool {
+	for _, a := range amounts {
+		if a == nil {
+			continue
+		}
+		if err := a.CheckPrecision(currency); err != nil {
+			utils.BadRequest(w, err.Error())
+			return false
+		}
+	}
+	return true
+}
+
+// conflictError is a write that conflicts with what's stored, responded to with 409
+type conflictError string
+
+func (e conflictError) Error() string {
+	return string(e)
+}
+
 // Helper function to parse int
 func parseInt(s string) (int, error) {
 	var i int
@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/joselitophala/budget-planner-backend/internal/auth"
 	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
 	"github.com/joselitophala/budget-planner-backend/internal/utils"
 )
 
@@ -68,7 +69,25 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		name = utils.PgText(*req.Name)
 	}
 	if req.Currency != nil {
-		currency = utils.PgText(*req.Currency)
+		code, err := money.NormalizeCurrency(*req.Currency)
+		if err != nil {
+			utils.BadRequest(w, err.Error())
+			return
+		}
+		currency = utils.PgText(code)
+
+		// Stored amounts aren't converted, so the home currency is fixed once there are any
+		if code != auth.GetCurrency(r) {
+			hasAmounts, err := h.queries.UserHasHomeAmounts(r.Context(), userID)
+			if err != nil {
+				utils.InternalError(w, "Failed to update user")
+				return
+			}
+			if hasAmounts {
+				utils.Conflict(w, "Your home currency can't be changed once amounts have been recorded in it")
+				return
+			}
+		}
 	}
 
 	user, err := h.queries.UpdateUser(r.Context(), models.UpdateUserParams{
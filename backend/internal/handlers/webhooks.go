@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// maxWebhookBodySize caps webhook payloads; Clerk user events are a few KB
+const maxWebhookBodySize = 1 << 20
+
+// WebhookHandler handles webhooks from external services
+type WebhookHandler struct {
+	queries  *models.Queries
+	verifier *auth.WebhookVerifier
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(queries *models.Queries, verifier *auth.WebhookVerifier) *WebhookHandler {
+	return &WebhookHandler{
+		queries:  queries,
+		verifier: verifier,
+	}
+}
+
+// clerkEvent is the envelope of a Clerk webhook
+type clerkEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// clerkUser is the subset of a Clerk user object we keep in sync
+type clerkUser struct {
+	ID                    string `json:"id"`
+	FirstName             string `json:"first_name"`
+	LastName              string `json:"last_name"`
+	Username              string `json:"username"`
+	PrimaryEmailAddressID string `json:"primary_email_address_id"`
+	EmailAddresses        []struct {
+		ID           string `json:"id"`
+		EmailAddress string `json:"email_address"`
+	} `json:"email_addresses"`
+}
+
+// primaryEmail returns the user's primary email, falling back to the first one listed
+func (u clerkUser) primaryEmail() string {
+	for _, e := range u.EmailAddresses {
+		if e.ID == u.PrimaryEmailAddressID {
+			return strings.ToLower(e.EmailAddress)
+		}
+	}
+	if len(u.EmailAddresses) > 0 {
+		return strings.ToLower(u.EmailAddresses[0].EmailAddress)
+	}
+	return ""
+}
+
+// displayName builds the name shown in the app
+func (u clerkUser) displayName() string {
+	if name := strings.TrimSpace(u.FirstName + " " + u.LastName); name != "" {
+		return name
+	}
+	return u.Username
+}
+
+// ClerkWebhook syncs users from Clerk user.created, user.updated and user.deleted events
+func (h *WebhookHandler) ClerkWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		utils.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	if err := h.verifier.Verify(r.Header, body); err != nil {
+		utils.Unauthorized(w, "Invalid webhook signature")
+		return
+	}
+
+	var event clerkEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		utils.BadRequest(w, "Invalid request body")
+		return
+	}
+
+	var user clerkUser
+	if err := json.Unmarshal(event.Data, &user); err != nil || user.ID == "" {
+		// Not a user event we understand; acknowledge so it isn't retried
+		utils.SendSuccess(w, map[string]string{"status": "ignored"})
+		return
+	}
+
+	switch event.Type {
+	case "user.created", "user.updated":
+		email := user.primaryEmail()
+		if email == "" {
+			email = auth.PlaceholderEmail(user.ID)
+		}
+
+		params := models.UpsertClerkUserParams{
+			ClerkUserID: user.ID,
+			Email:       email,
+			Name:        utils.PgText(user.displayName()),
+		}
+		_, err := h.queries.UpsertClerkUser(r.Context(), params)
+
+		// Another account already uses this email. Failing would only have the event
+		// retried, so like provisioning, keep the address the user has, or a
+		// placeholder, until a later update settles it.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "users_email_key" {
+			log.Printf("clerk webhook: email for user %s is already in use", user.ID)
+			params.Email = auth.PlaceholderEmail(user.ID)
+			if existing, err := h.queries.GetUserByClerkID(r.Context(), user.ID); err == nil {
+				params.Email = existing.Email
+			}
+			_, err = h.queries.UpsertClerkUser(r.Context(), params)
+		}
+		if err != nil {
+			utils.InternalError(w, "Failed to sync user")
+			return
+		}
+
+	case "user.deleted":
+		if _, err := h.queries.DeleteUserByClerkID(r.Context(), user.ID); err != nil {
+			utils.InternalError(w, "Failed to delete user")
+			return
+		}
+
+	default:
+		utils.SendSuccess(w, map[string]string{"status": "ignored"})
+		return
+	}
+
+	utils.SendSuccess(w, map[string]string{"status": "processed"})
+}
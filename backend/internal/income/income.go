@@ -0,0 +1,228 @@
+// Package income plans the income a user expects each month, or budget period, from
+// their income sources, compares it with what arrived, and works out the savings
+// rate and how much income is left to budget.
+package income
+
+import (
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/periods"
+)
+
+// Source kinds
+const (
+	KindSalary          = "salary"
+	KindFreelance       = "freelance"
+	KindBonus           = "bonus"
+	KindThirteenthMonth = "thirteenth_month"
+	KindInvestment      = "investment"
+	KindOther           = "other"
+)
+
+// Kinds are the kinds a source can have
+var Kinds = []string{KindSalary, KindFreelance, KindBonus, KindThirteenthMonth, KindInvestment, KindOther}
+
+// Allocation statuses, as in zero-based budgeting
+const (
+	StatusUnder    = "under"    // income is left to give a job
+	StatusBalanced = "balanced" // every bit of income is allocated
+	StatusOver     = "over"     // more is allocated than comes in
+)
+
+// Allocation bases
+const (
+	BasisExpected = "expected"
+	BasisActual   = "actual" // without sources, budgeting works from what arrived
+)
+
+// Source is where a user expects income from
+type Source struct {
+	ID         string
+	Name       string
+	Kind       string
+	CategoryID string // income in this category counts as received from the source
+	Amount     money.Amount
+	Months     []int // months of the year it pays in, empty for every month
+	Start      time.Time
+	End        *time.Time
+}
+
+// Expects reports whether s pays in month
+func (s Source) Expects(month time.Time) bool {
+	month = monthStart(month)
+	if !sourceRunning(s, month) {
+		return false
+	}
+	if len(s.Months) == 0 {
+		return true
+	}
+	for _, m := range s.Months {
+		if time.Month(m) == month.Month() {
+			return true
+		}
+	}
+	return false
+}
+
+// Line is a source's expected and received income in a month
+type Line struct {
+	Source     Source
+	Expected   money.Amount
+	Actual     money.Amount
+	Variance   money.Amount // actual less expected
+	Overridden bool         // Expected was set for this month rather than taken from the source
+}
+
+// Plan is a month's expected income against what arrived
+type Plan struct {
+	Month    time.Time
+	Lines    []Line
+	Expected money.Amount
+	Actual   money.Amount
+	// Unplanned is income in categories no source claims, counted in Actual
+	Unplanned money.Amount
+	Variance  money.Amount
+	// HasSources is true if any source pays in the month
+	HasSources bool
+}
+
+// BuildPlan compares what sources expect in month with actual income per category
+// ("" for uncategorized). overrides replace a source's amount for the month, by
+// source ID. Sources that neither expect nor received anything are left out.
+func BuildPlan(month time.Time, sources []Source, overrides map[string]money.Amount, actual map[string]money.Amount) Plan {
+	month = monthStart(month)
+	return buildPlan(month, sources, actual, func(s Source) (money.Amount, bool, bool) {
+		return expectedIn(s, month, overrides)
+	})
+}
+
+// BuildPeriodPlan is BuildPlan for a budget period other than a calendar month, such
+// as a week or half a month. A source's expected income for each month the period
+// touches counts in proportion to the days of that month the period covers.
+// overrides are keyed by month, then source ID; actual is the period's income.
+func BuildPeriodPlan(p periods.Period, sources []Source, overrides map[time.Time]map[string]money.Amount, actual map[string]money.Amount) Plan {
+	return buildPlan(monthStart(p.Start), sources, actual, func(s Source) (money.Amount, bool, bool) {
+		var total money.Amount
+		var active, overridden bool
+		for month := monthStart(p.Start); month.Before(p.End); month = month.AddDate(0, 1, 0) {
+			amount, ok, o := expectedIn(s, month, overrides[month])
+			if !ok {
+				continue
+			}
+			next := month.AddDate(0, 1, 0)
+			covered := periods.Period{Start: later(p.Start, month), End: earlier(p.End, next)}
+			monthDays := periods.Period{Start: month, End: next}.Days()
+			total += (amount * money.Amount(covered.Days())).Div(int64(monthDays))
+			active, overridden = true, overridden || o
+		}
+		return total, active, overridden
+	})
+}
+
+// buildPlan lays out a plan from what expected says each source expects: its amount,
+// whether it pays at all, and whether the amount was overridden
+func buildPlan(month time.Time, sources []Source, actual map[string]money.Amount, expected func(Source) (money.Amount, bool, bool)) Plan {
+	p := Plan{Month: month}
+	claimed := make(map[string]bool)
+	for _, s := range sources {
+		line := Line{Source: s}
+		var active bool
+		line.Expected, active, line.Overridden = expected(s)
+		if s.CategoryID != "" {
+			line.Actual = actual[s.CategoryID]
+			claimed[s.CategoryID] = true
+		}
+		if !active && line.Actual == 0 {
+			continue
+		}
+		if active {
+			p.HasSources = true
+		}
+		line.Variance = line.Actual - line.Expected
+		p.Lines = append(p.Lines, line)
+		p.Expected += line.Expected
+	}
+	for categoryID, amount := range actual {
+		p.Actual += amount
+		if !claimed[categoryID] {
+			p.Unplanned += amount
+		}
+	}
+	p.Variance = p.Actual - p.Expected
+	return p
+}
+
+// expectedIn returns what s expects in month, whether it pays then, and whether
+// overrides set the amount
+func expectedIn(s Source, month time.Time, overrides map[string]money.Amount) (money.Amount, bool, bool) {
+	if override, ok := overrides[s.ID]; ok && sourceRunning(s, month) {
+		return override, true, true
+	}
+	if s.Expects(month) {
+		return s.Amount, true, false
+	}
+	return 0, false, false
+}
+
+// SavingsRate returns the share of income not spent, as a percentage. It's nil
+// without income.
+func SavingsRate(income, expenses money.Amount) *float64 {
+	if income <= 0 {
+		return nil
+	}
+	rate := money.Percent(income-expenses, income)
+	return &rate
+}
+
+// Allocation is how much of a month's income has been given to budget categories
+type Allocation struct {
+	Basis        string
+	Income       money.Amount
+	Allocated    money.Amount
+	LeftToBudget money.Amount
+	Status       string
+}
+
+// Allocate compares the income a month's budget works from with what's allocated to
+// its categories. Expected income is the basis when any source pays in the month,
+// actual income otherwise.
+func Allocate(p Plan, allocated money.Amount) Allocation {
+	a := Allocation{Basis: BasisExpected, Income: p.Expected, Allocated: allocated}
+	if !p.HasSources {
+		a.Basis, a.Income = BasisActual, p.Actual
+	}
+	a.LeftToBudget = a.Income - a.Allocated
+	switch {
+	case a.LeftToBudget > 0:
+		a.Status = StatusUnder
+	case a.LeftToBudget < 0:
+		a.Status = StatusOver
+	default:
+		a.Status = StatusBalanced
+	}
+	return a
+}
+
+// sourceRunning reports whether month is within s's start and end, whatever months it pays in
+func sourceRunning(s Source, month time.Time) bool {
+	return !month.Before(monthStart(s.Start)) && (s.End == nil || !month.After(monthStart(*s.End)))
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
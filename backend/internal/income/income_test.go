@@ -0,0 +1,78 @@
+package income
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/periods"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestBuildPeriodPlanSemiMonthly(t *testing.T) {
+	salary := Source{ID: "salary", Kind: KindSalary, Amount: money.MustParse("30000.00"), Start: date("2025-01-01")}
+	overrides := map[time.Time]map[string]money.Amount{
+		date("2025-04-01"): {"salary": money.MustParse("20000.00")},
+	}
+
+	tests := []struct {
+		start      string
+		expected   string
+		overridden bool
+	}{
+		// 15 of March's 31 days
+		{"2025-03-15", "14516.13", false},
+		// March 30-31 at the usual amount, then April 1-14 at the override
+		{"2025-03-30", "11268.81", true},
+	}
+	for _, tt := range tests {
+		period, err := periods.Starting(periods.SemiMonthly, date(tt.start), nil, nil)
+		if err != nil {
+			t.Fatalf("Starting(%s): %v", tt.start, err)
+		}
+		plan := BuildPeriodPlan(period, []Source{salary}, overrides, nil)
+		if plan.Expected.String() != tt.expected {
+			t.Errorf("period from %s expects %s, want %s", tt.start, plan.Expected, tt.expected)
+		}
+		if len(plan.Lines) != 1 || plan.Lines[0].Overridden != tt.overridden {
+			t.Errorf("period from %s lines = %+v, want one with overridden %v", tt.start, plan.Lines, tt.overridden)
+		}
+		if !plan.HasSources {
+			t.Errorf("period from %s has no sources", tt.start)
+		}
+	}
+
+	// The budget is judged against the half month's income, not the whole month's
+	period, _ := periods.Starting(periods.SemiMonthly, date("2025-03-15"), nil, nil)
+	plan := BuildPeriodPlan(period, []Source{salary}, nil, map[string]money.Amount{"": money.MustParse("15000.00")})
+	a := Allocate(plan, money.MustParse("14000.00"))
+	if a.LeftToBudget.String() != "516.13" || a.Status != StatusUnder {
+		t.Errorf("Allocate = %s left, %s; want 516.13 left, %s", a.LeftToBudget, a.Status, StatusUnder)
+	}
+	if plan.Variance.String() != "483.87" {
+		t.Errorf("variance = %s, want 483.87", plan.Variance)
+	}
+}
+
+func TestBuildPeriodPlanCalendarMonth(t *testing.T) {
+	sources := []Source{
+		{ID: "salary", Kind: KindSalary, Amount: money.MustParse("30000.00"), Start: date("2025-01-01")},
+		{ID: "bonus", Kind: KindBonus, Amount: money.MustParse("5000.00"), Months: []int{12}, Start: date("2025-01-01")},
+	}
+	overrides := map[string]money.Amount{"salary": money.MustParse("31000.00")}
+
+	// A whole month expects what the monthly plan does
+	period := periods.Period{Start: date("2025-02-01"), End: date("2025-03-01")}
+	got := BuildPeriodPlan(period, sources, map[time.Time]map[string]money.Amount{period.Start: overrides}, nil)
+	want := BuildPlan(period.Start, sources, overrides, nil)
+	if got.Expected != want.Expected || len(got.Lines) != len(want.Lines) {
+		t.Errorf("BuildPeriodPlan = %s over %d lines, BuildPlan = %s over %d", got.Expected, len(got.Lines), want.Expected, len(want.Lines))
+	}
+}
@@ -0,0 +1,380 @@
+// Package insights flags unusual spending in a user's transaction history: single
+// expenses far above what's typical, categories running well ahead of a normal
+// month, likely duplicate charges and recurring charges whose price changed. It also
+// finds subscriptions: charges that repeat weekly, monthly or yearly.
+//
+// Typical values are medians, and how unusual a value is is measured in median
+// absolute deviations (MAD), so a few past outliers don't hide new ones.
+package insights
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+// Insight kinds
+const (
+	// KindLargeTransaction is an expense much larger than usual for its category or merchant
+	KindLargeTransaction = "large_transaction"
+	// KindCategorySpike is a category whose month-to-date spending is far above normal
+	KindCategorySpike = "category_spike"
+	// KindDuplicateCharge is two expenses with the same amount and merchant days apart
+	KindDuplicateCharge = "duplicate_charge"
+	// KindPriceChange is a regular charge that now costs a different amount
+	KindPriceChange = "price_change"
+)
+
+const (
+	// WindowDays is how recent a transaction must be to be flagged
+	WindowDays = 30
+	// HistoryMonths is how many months before the current one typical spending comes from
+	HistoryMonths = 6
+
+	// minSamples is how many past expenses it takes to know what's typical
+	minSamples = 5
+	// minMonths is how many past months it takes to know what a normal month is
+	minMonths = 3
+	// outlierScore is the modified z-score (Iglewicz and Hoaglin) above which a value
+	// is an outlier
+	outlierScore = 3.5
+	// largeRatio and spikeRatio are how far above typical a value must also be, so
+	// small absolute differences in very regular spending aren't flagged
+	largeRatio = 2.0
+	spikeRatio = 1.5
+	// duplicateDays is how far apart two identical charges can be to look duplicated
+	duplicateDays = 3
+)
+
+// Transaction is an expense insights are computed from
+type Transaction struct {
+	ID              string
+	Date            time.Time
+	CategoryID      string
+	CategoryName    string
+	Description     string
+	Amount          money.Amount // in the home currency
+	PaymentMethodID string
+	Recurring       bool
+}
+
+// Insight is something unusual in a user's spending. ID is stable for as long as
+// the insight applies, so dismissals can be remembered.
+type Insight struct {
+	ID             string
+	Kind           string
+	Date           time.Time
+	Title          string
+	Body           string
+	Amount         money.Amount // the unusual amount
+	Typical        money.Amount // what it's compared against
+	CategoryID     string
+	TransactionIDs []string
+}
+
+// HistoryStart returns the first day of transactions Detect needs for a run on today
+func HistoryStart(today time.Time) time.Time {
+	return monthStart(truncateDay(today)).AddDate(0, -HistoryMonths, 0)
+}
+
+// Detect returns the insights in transactions from HistoryStart(today) through
+// today, newest first
+func Detect(transactions []Transaction, today time.Time) []Insight {
+	today = truncateDay(today)
+	sorted := make([]Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	var list []Insight
+	list = append(list, largeTransactions(sorted, today)...)
+	list = append(list, categorySpikes(sorted, today)...)
+	list = append(list, duplicateCharges(sorted, today)...)
+	list = append(list, priceChanges(sorted, today)...)
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if !list[i].Date.Equal(list[j].Date) {
+			return list[i].Date.After(list[j].Date)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// largeTransactions flags recent expenses that are outliers against earlier
+// expenses in the same category, or failing that with the same merchant
+func largeTransactions(sorted []Transaction, today time.Time) []Insight {
+	windowStart := today.AddDate(0, 0, -WindowDays)
+	byCategory := make(map[string][]float64)
+	byMerchant := make(map[string][]float64)
+
+	var list []Insight
+	for _, t := range sorted {
+		merchant := Merchant(t.Description)
+		if t.Date.After(windowStart) && !t.Date.After(today) {
+			amount := t.Amount.Float64()
+			var typical float64
+			var against string
+			if t.CategoryID != "" && isOutlier(amount, byCategory[t.CategoryID], largeRatio, minSamples) {
+				typical, against = median(byCategory[t.CategoryID]), "in "+t.CategoryName
+			} else if merchant != "" && isOutlier(amount, byMerchant[merchant], largeRatio, minSamples) {
+				typical, against = median(byMerchant[merchant]), "at "+t.Description
+			}
+			if against != "" {
+				list = append(list, Insight{
+					ID:             KindLargeTransaction + "." + t.ID,
+					Kind:           KindLargeTransaction,
+					Date:           t.Date,
+					Title:          fmt.Sprintf("%s is unusually large", name(t)),
+					Body:           fmt.Sprintf("%s is %.1f× your typical %s expense %s.", t.Amount, amount/typical, money.Round(typical), against),
+					Amount:         t.Amount,
+					Typical:        money.Round(typical),
+					CategoryID:     t.CategoryID,
+					TransactionIDs: []string{t.ID},
+				})
+			}
+		}
+
+		// Each expense is compared with the ones before it only
+		if t.CategoryID != "" {
+			byCategory[t.CategoryID] = append(byCategory[t.CategoryID], t.Amount.Float64())
+		}
+		if merchant != "" {
+			byMerchant[merchant] = append(byMerchant[merchant], t.Amount.Float64())
+		}
+	}
+	return list
+}
+
+// categorySpikes flags categories whose spending this month is far above what they
+// usually are by the same day of the month
+func categorySpikes(sorted []Transaction, today time.Time) []Insight {
+	if len(sorted) == 0 {
+		return nil
+	}
+	start := monthStart(today)
+	// Months before the user started tracking would look like months without spending
+	first := monthStart(sorted[0].Date)
+
+	type category struct {
+		name    string
+		current float64
+		months  [HistoryMonths]float64
+	}
+	categories := make(map[string]*category)
+	for _, t := range sorted {
+		if t.CategoryID == "" || t.Date.After(today) {
+			continue
+		}
+		c := categories[t.CategoryID]
+		if c == nil {
+			c = &category{name: t.CategoryName}
+			categories[t.CategoryID] = c
+		}
+		if !t.Date.Before(start) {
+			c.current += t.Amount.Float64()
+			continue
+		}
+		// Only the part of past months up to today's day of the month compares
+		back := (start.Year()-t.Date.Year())*12 + int(start.Month()-t.Date.Month())
+		if back >= 1 && back <= HistoryMonths && t.Date.Day() <= today.Day() {
+			c.months[back-1] += t.Amount.Float64()
+		}
+	}
+
+	tracked := 0
+	for back := 1; back <= HistoryMonths && !start.AddDate(0, -back, 0).Before(first); back++ {
+		tracked++
+	}
+	if tracked < minMonths {
+		return nil
+	}
+
+	var list []Insight
+	for id, c := range categories {
+		if !isOutlier(c.current, c.months[:tracked], spikeRatio, minMonths) {
+			continue
+		}
+		typical := median(c.months[:tracked])
+		current := money.Round(c.current)
+		list = append(list, Insight{
+			ID:         fmt.Sprintf("%s.%s.%s", KindCategorySpike, id, start.Format("2006-01")),
+			Kind:       KindCategorySpike,
+			Date:       today,
+			Title:      fmt.Sprintf("%s spending is up %.0f%% on a typical month", c.name, (c.current/typical-1)*100),
+			Body:       fmt.Sprintf("%s spent on %s so far this month, against a typical %s by this day.", current, c.name, money.Round(typical)),
+			Amount:     current,
+			Typical:    money.Round(typical),
+			CategoryID: id,
+		})
+	}
+	return list
+}
+
+// duplicateCharges flags recent pairs of expenses with the same amount, merchant
+// and payment method a few days apart or less. Pairs that close together are
+// normal for some purchases, like a daily coffee, so a pair is only flagged when
+// those charges usually come much further apart.
+func duplicateCharges(sorted []Transaction, today time.Time) []Insight {
+	windowStart := today.AddDate(0, 0, -WindowDays)
+	type charge struct {
+		merchant string
+		amount   money.Amount
+		method   string
+	}
+	groups := make(map[charge][]Transaction)
+	var order []charge
+	for _, t := range sorted {
+		merchant := Merchant(t.Description)
+		if merchant == "" || t.Date.After(today) {
+			continue
+		}
+		k := charge{merchant: merchant, amount: t.Amount, method: t.PaymentMethodID}
+		if groups[k] == nil {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], t)
+	}
+
+	var list []Insight
+	for _, k := range order {
+		charges := groups[k]
+		gaps := make([]float64, len(charges)-1)
+		for i := 1; i < len(charges); i++ {
+			gaps[i-1] = charges[i].Date.Sub(charges[i-1].Date).Hours() / 24
+		}
+		for i, gap := range gaps {
+			earlier, t := charges[i], charges[i+1]
+			if gap > duplicateDays || !t.Date.After(windowStart) {
+				continue
+			}
+			others := append(append([]float64{}, gaps[:i]...), gaps[i+1:]...)
+			if len(others) > 0 && gap*3 >= median(others) {
+				continue
+			}
+			body := fmt.Sprintf("%s was charged on %s and again on %s.", t.Amount, earlier.Date.Format("January 2"), t.Date.Format("January 2"))
+			if gap == 0 {
+				body = fmt.Sprintf("%s was charged twice on %s.", t.Amount, t.Date.Format("January 2"))
+			}
+			list = append(list, Insight{
+				ID:             fmt.Sprintf("%s.%s.%s", KindDuplicateCharge, earlier.ID, t.ID),
+				Kind:           KindDuplicateCharge,
+				Date:           t.Date,
+				Title:          fmt.Sprintf("Possible duplicate charge from %s", name(t)),
+				Body:           body,
+				Amount:         t.Amount,
+				Typical:        t.Amount,
+				CategoryID:     t.CategoryID,
+				TransactionIDs: []string{earlier.ID, t.ID},
+			})
+		}
+	}
+	return list
+}
+
+// priceChanges flags regular charges whose most recent amount, charged within the
+// window, differs from the price they had been charged at
+func priceChanges(sorted []Transaction, today time.Time) []Insight {
+	windowStart := today.AddDate(0, 0, -WindowDays)
+
+	var list []Insight
+	for _, s := range Series(sorted) {
+		last := s.Charges[len(s.Charges)-1]
+		if !last.Date.After(windowStart) || last.Date.After(today) {
+			continue
+		}
+		previous := s.Charges[len(s.Charges)-2].Amount
+		if last.Amount == previous || !steady(s.Charges[:len(s.Charges)-1]) {
+			continue
+		}
+		direction := "up"
+		if last.Amount < previous {
+			direction = "down"
+		}
+		list = append(list, Insight{
+			ID:             KindPriceChange + "." + last.ID,
+			Kind:           KindPriceChange,
+			Date:           last.Date,
+			Title:          fmt.Sprintf("%s went %s from %s to %s", name(last), direction, previous, last.Amount),
+			Body:           fmt.Sprintf("Your %s charge from %s changed from %s to %s on %s.", s.Frequency, name(last), previous, last.Amount, last.Date.Format("January 2")),
+			Amount:         last.Amount,
+			Typical:        previous,
+			CategoryID:     last.CategoryID,
+			TransactionIDs: []string{last.ID},
+		})
+	}
+	return list
+}
+
+// steady reports whether the most recent charges, up to three, were all the same
+// amount, so there was a price to change from
+func steady(charges []Transaction) bool {
+	last := charges[len(charges)-1].Amount
+	for i := len(charges) - 2; i >= 0 && i >= len(charges)-3; i-- {
+		if charges[i].Amount != last {
+			return false
+		}
+	}
+	return true
+}
+
+// isOutlier reports whether x is far above at least min samples: at least ratio
+// times their median and, when they vary, more than outlierScore MADs above it
+func isOutlier(x float64, samples []float64, ratio float64, min int) bool {
+	if len(samples) < min {
+		return false
+	}
+	m := median(samples)
+	if m <= 0 || x < ratio*m {
+		return false
+	}
+	d := mad(samples, m)
+	if d == 0 {
+		return true
+	}
+	return 0.6745*(x-m)/d > outlierScore
+}
+
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := make([]float64, len(xs))
+	copy(s, xs)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// mad is the median absolute deviation of xs from m
+func mad(xs []float64, m float64) float64 {
+	deviations := make([]float64, len(xs))
+	for i, x := range xs {
+		deviations[i] = math.Abs(x - m)
+	}
+	return median(deviations)
+}
+
+// name is how a transaction is referred to in messages
+func name(t Transaction) string {
+	if t.Description != "" {
+		return t.Description
+	}
+	if t.CategoryName != "" {
+		return "A " + t.CategoryName + " expense"
+	}
+	return "An expense"
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
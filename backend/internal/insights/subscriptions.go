@@ -0,0 +1,259 @@
+package insights
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+// SubscriptionHistoryMonths is how far back subscriptions are looked for: long
+// enough to see a yearly charge twice
+const SubscriptionHistoryMonths = 25
+
+// SubscriptionHistoryStart returns the first day of transactions DetectSubscriptions
+// needs for a run on today
+func SubscriptionHistoryStart(today time.Time) time.Time {
+	return monthStart(truncateDay(today)).AddDate(0, -SubscriptionHistoryMonths, 0)
+}
+
+// RegularSeries is a run of charges from one merchant at a steady interval
+type RegularSeries struct {
+	Merchant  string
+	Frequency string // weekly, monthly or yearly
+	Charges   []Transaction
+}
+
+// frequency is an interval a series can repeat at
+type frequency struct {
+	name       string
+	min, max   float64 // gaps in days that count as one interval
+	minCharges int
+	grace      int // days past the expected charge before a series counts as lapsed
+	perYear    int
+}
+
+var frequencies = []frequency{
+	{name: "weekly", min: 6, max: 8, minCharges: 3, grace: 4, perYear: 52},
+	{name: "monthly", min: 26, max: 35, minCharges: 3, grace: 10, perYear: 12},
+	{name: "yearly", min: 355, max: 375, minCharges: 2, grace: 30, perYear: 1},
+}
+
+// Series groups sorted expenses by merchant and returns the groups that repeat at a
+// weekly, monthly or yearly rhythm: the median gap between charges must match it
+// and at least two thirds of the gaps must fit, so a skipped month doesn't break a
+// series. Same-day charges from one merchant count once.
+func Series(sorted []Transaction) []RegularSeries {
+	groups := make(map[string][]Transaction)
+	var order []string
+	for _, t := range sorted {
+		merchant := Merchant(t.Description)
+		if merchant == "" {
+			continue
+		}
+		g := groups[merchant]
+		if len(g) > 0 && g[len(g)-1].Date.Equal(t.Date) {
+			continue
+		}
+		if g == nil {
+			order = append(order, merchant)
+		}
+		groups[merchant] = append(g, t)
+	}
+
+	var list []RegularSeries
+	for _, merchant := range order {
+		charges := groups[merchant]
+		if len(charges) < 2 {
+			continue
+		}
+		gaps := make([]float64, len(charges)-1)
+		for i := 1; i < len(charges); i++ {
+			gaps[i-1] = charges[i].Date.Sub(charges[i-1].Date).Hours() / 24
+		}
+		if f, ok := rhythm(gaps, len(charges)); ok {
+			list = append(list, RegularSeries{Merchant: merchant, Frequency: f.name, Charges: charges})
+		}
+	}
+	return list
+}
+
+// rhythm returns the frequency at least two thirds of gaps fit
+func rhythm(gaps []float64, charges int) (frequency, bool) {
+	m := median(gaps)
+	for _, f := range frequencies {
+		if charges < f.minCharges || m < f.min || m > f.max {
+			continue
+		}
+		fit := 0
+		for _, g := range gaps {
+			if g >= f.min && g <= f.max {
+				fit++
+			}
+		}
+		if fit*3 >= len(gaps)*2 {
+			return f, true
+		}
+	}
+	return frequency{}, false
+}
+
+// consistentAmounts reports whether at most a third of charges differ from the one
+// before, each by no more than half
+func consistentAmounts(charges []Transaction) bool {
+	changes := 0
+	for i := 1; i < len(charges); i++ {
+		prev, cur := charges[i-1].Amount.Float64(), charges[i].Amount.Float64()
+		if cur == prev {
+			continue
+		}
+		if prev <= 0 || math.Abs(cur-prev) > prev/2 {
+			return false
+		}
+		changes++
+	}
+	return changes*3 <= len(charges)-1
+}
+
+// Subscription is a detected regular charge
+type Subscription struct {
+	ID              string // Merchant with hyphens for spaces, stable across runs
+	Merchant        string
+	Name            string // the most recent charge's description
+	Frequency       string
+	Amount          money.Amount // the most recent price
+	AnnualCost      money.Amount
+	CategoryID      string
+	PaymentMethodID string
+	Charges         []Transaction
+	NextCharge      time.Time
+	Active          bool // false once the expected charge is well overdue
+	LastPriceChange *PriceChange
+
+	// RecurringTransactionID is a charge already marked as a recurring transaction
+	RecurringTransactionID string
+}
+
+// PriceChange is when a subscription's price last changed
+type PriceChange struct {
+	Date time.Time
+	From money.Amount
+	To   money.Amount
+}
+
+// SubscriptionID returns the ID of the subscription with a merchant
+func SubscriptionID(merchant string) string {
+	return strings.ReplaceAll(merchant, " ", "-")
+}
+
+// DetectSubscriptions returns the regular charges in transactions, most expensive
+// per year first. Charges before the latest must mostly be the same amount; the
+// latest can be anything, so a fresh price change doesn't hide a subscription.
+func DetectSubscriptions(transactions []Transaction, today time.Time) []Subscription {
+	today = truncateDay(today)
+	sorted := make([]Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		if !t.Date.After(today) {
+			sorted = append(sorted, t)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	var list []Subscription
+	for _, s := range Series(sorted) {
+		if !consistentAmounts(s.Charges[:len(s.Charges)-1]) {
+			continue
+		}
+		var f frequency
+		for _, candidate := range frequencies {
+			if candidate.name == s.Frequency {
+				f = candidate
+			}
+		}
+		last := s.Charges[len(s.Charges)-1]
+		sub := Subscription{
+			ID:              SubscriptionID(s.Merchant),
+			Merchant:        s.Merchant,
+			Name:            last.Description,
+			Frequency:       s.Frequency,
+			Amount:          last.Amount,
+			AnnualCost:      last.Amount * money.Amount(f.perYear),
+			CategoryID:      last.CategoryID,
+			PaymentMethodID: last.PaymentMethodID,
+			Charges:         s.Charges,
+			NextCharge:      NextCharge(s.Frequency, last.Date),
+		}
+		sub.Active = !today.After(sub.NextCharge.AddDate(0, 0, f.grace))
+		for i := len(s.Charges) - 1; i > 0; i-- {
+			if s.Charges[i].Amount != s.Charges[i-1].Amount {
+				sub.LastPriceChange = &PriceChange{
+					Date: s.Charges[i].Date,
+					From: s.Charges[i-1].Amount,
+					To:   s.Charges[i].Amount,
+				}
+				break
+			}
+		}
+		for i := len(s.Charges) - 1; i >= 0; i-- {
+			if s.Charges[i].Recurring {
+				sub.RecurringTransactionID = s.Charges[i].ID
+				break
+			}
+		}
+		list = append(list, sub)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].AnnualCost != list[j].AnnualCost {
+			return list[i].AnnualCost > list[j].AnnualCost
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// NextCharge returns when a charge made on last at frequency comes round again.
+// Monthly and yearly charges keep their day of the month, or the month's last day
+// when it's shorter.
+func NextCharge(frequency string, last time.Time) time.Time {
+	switch frequency {
+	case "weekly":
+		return last.AddDate(0, 0, 7)
+	case "yearly":
+		return addMonthsClamped(last, 12)
+	default:
+		return addMonthsClamped(last, 1)
+	}
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// Merchant normalizes a description so charges from the same merchant match:
+// lowercased, with digits (order and reference numbers) and punctuation dropped
+func Merchant(description string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(description) {
+		switch {
+		case unicode.IsLetter(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
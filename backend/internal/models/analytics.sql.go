@@ -13,8 +13,8 @@ import (
 
 const getCategoryReport = `-- name: GetCategoryReport :many
 SELECT 
-    DATE_TRUNC('day', transaction_date) as date,
-    COALESCE(SUM(amount), 0) as total,
+    transaction_date as date,
+    COALESCE(SUM(home_amount), 0)::numeric as total,
     COUNT(*) as transaction_count
 FROM transactions
 WHERE user_id = $1 
@@ -23,7 +23,7 @@ WHERE user_id = $1
   AND deleted = false
   AND transaction_date >= $3
   AND transaction_date <= $4
-GROUP BY DATE_TRUNC('day', transaction_date)
+GROUP BY transaction_date
 ORDER BY date ASC
 `
 
@@ -35,9 +35,9 @@ type GetCategoryReportParams struct {
 }
 
 type GetCategoryReportRow struct {
-	Date             pgtype.Interval `json:"date"`
-	Total            interface{}     `json:"total"`
-	TransactionCount int64           `json:"transactionCount"`
+	Date             pgtype.Date    `json:"date"`
+	Total            pgtype.Numeric `json:"total"`
+	TransactionCount int64          `json:"transactionCount"`
 }
 
 func (q *Queries) GetCategoryReport(ctx context.Context, arg GetCategoryReportParams) ([]GetCategoryReportRow, error) {
@@ -66,43 +66,22 @@ func (q *Queries) GetCategoryReport(ctx context.Context, arg GetCategoryReportPa
 }
 
 const getDashboardSummary = `-- name: GetDashboardSummary :one
-WITH budget_month AS (
-    SELECT month FROM budgets WHERE id = $1
-),
-spent AS (
-    SELECT COALESCE(SUM(amount), 0) as total
-    FROM transactions t
-    WHERE t.budget_id = $1 
-      AND t.type = 'expense' 
-      AND t.deleted = false
-      AND t.transaction_date >= (SELECT month FROM budget_month)
-      AND t.transaction_date < ((SELECT month FROM budget_month) + INTERVAL '1 month')
-),
-income AS (
-    SELECT COALESCE(SUM(amount), 0) as total
-    FROM transactions t
-    WHERE t.budget_id = $1 
-      AND t.type = 'income' 
-      AND t.deleted = false
-      AND t.transaction_date >= (SELECT month FROM budget_month)
-      AND t.transaction_date < ((SELECT month FROM budget_month) + INTERVAL '1 month')
-),
-transactions_count AS (
-    SELECT COUNT(*) as total
-    FROM transactions t
-    WHERE t.budget_id = $1 AND t.deleted = false
-      AND t.transaction_date >= (SELECT month FROM budget_month)
-      AND t.transaction_date < ((SELECT month FROM budget_month) + INTERVAL '1 month')
+WITH totals AS (
+    SELECT
+        COALESCE(SUM(mt.total) FILTER (WHERE mt.type = 'expense'), 0) as spent,
+        COALESCE(SUM(mt.total) FILTER (WHERE mt.type = 'income'), 0) as income,
+        COALESCE(SUM(mt.transaction_count), 0)::bigint as transaction_count
+    FROM budgets b
+    JOIN monthly_totals mt ON mt.budget_id = b.id
+    WHERE b.id = $1
 )
 SELECT 
-    b.id, b.user_id, b.name, b.month, b.total_limit, b.created_at, b.updated_at, b.deleted,
-    s.total as total_spent,
-    i.total as total_income,
-    tc.total as transaction_count
+    b.id, b.user_id, b.name, b.month, b.total_limit, b.created_at, b.updated_at, b.deleted, b.envelope_mode, b.strict_envelopes, b.period_type, b.period_end,
+    t.spent as total_spent,
+    t.income as total_income,
+    t.transaction_count
 FROM budgets b
-CROSS JOIN spent s
-CROSS JOIN income i
-CROSS JOIN transactions_count tc
+CROSS JOIN totals t
 WHERE b.id = $1
 `
 
@@ -115,6 +94,10 @@ type GetDashboardSummaryRow struct {
 	CreatedAt        pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt        pgtype.Timestamptz `json:"updatedAt"`
 	Deleted          pgtype.Bool        `json:"deleted"`
+	EnvelopeMode     bool               `json:"envelopeMode"`
+	StrictEnvelopes  bool               `json:"strictEnvelopes"`
+	PeriodType       string             `json:"periodType"`
+	PeriodEnd        pgtype.Date        `json:"periodEnd"`
 	TotalSpent       interface{}        `json:"totalSpent"`
 	TotalIncome      interface{}        `json:"totalIncome"`
 	TransactionCount int64              `json:"transactionCount"`
@@ -132,6 +115,10 @@ func (q *Queries) GetDashboardSummary(ctx context.Context, id string) (GetDashbo
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
+		&i.EnvelopeMode,
+		&i.StrictEnvelopes,
+		&i.PeriodType,
+		&i.PeriodEnd,
 		&i.TotalSpent,
 		&i.TotalIncome,
 		&i.TransactionCount,
@@ -140,7 +127,7 @@ func (q *Queries) GetDashboardSummary(ctx context.Context, id string) (GetDashbo
 }
 
 const getRecentTransactions = `-- name: GetRecentTransactions :many
-SELECT t.id, t.user_id, t.budget_id, t.category_id, t.payment_method_id, t.amount, t.type, t.is_transfer, t.transfer_to_account_id, t.description, t.transaction_date, t.is_recurring, t.recurrence_pattern, t.created_at, t.updated_at, t.deleted, c.name as category_name, c.icon as category_icon, c.color as category_color,
+SELECT t.id, t.user_id, t.budget_id, t.category_id, t.payment_method_id, t.amount, t.type, t.is_transfer, t.transfer_to_account_id, t.description, t.transaction_date, t.is_recurring, t.recurrence_pattern, t.created_at, t.updated_at, t.deleted, t.currency, t.home_amount, t.exchange_rate, t.goal_id, t.debt_id, c.name as category_name, c.icon as category_icon, c.color as category_color,
        pm.name as payment_method_name, pm.type as payment_method_type
 FROM transactions t
 LEFT JOIN categories c ON t.category_id = c.id
@@ -173,6 +160,11 @@ type GetRecentTransactionsRow struct {
 	CreatedAt           pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt           pgtype.Timestamptz `json:"updatedAt"`
 	Deleted             pgtype.Bool        `json:"deleted"`
+	Currency            string             `json:"currency"`
+	HomeAmount          pgtype.Numeric     `json:"homeAmount"`
+	ExchangeRate        pgtype.Numeric     `json:"exchangeRate"`
+	GoalID              pgtype.UUID        `json:"goalId"`
+	DebtID              pgtype.UUID        `json:"debtId"`
 	CategoryName        pgtype.Text        `json:"categoryName"`
 	CategoryIcon        pgtype.Text        `json:"categoryIcon"`
 	CategoryColor       pgtype.Text        `json:"categoryColor"`
@@ -206,6 +198,11 @@ func (q *Queries) GetRecentTransactions(ctx context.Context, arg GetRecentTransa
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Deleted,
+			&i.Currency,
+			&i.HomeAmount,
+			&i.ExchangeRate,
+			&i.GoalID,
+			&i.DebtID,
 			&i.CategoryName,
 			&i.CategoryIcon,
 			&i.CategoryColor,
@@ -228,16 +225,13 @@ SELECT
     c.name,
     c.icon,
     c.color,
-    COALESCE(SUM(t.amount), 0) as total_spent,
-    COALESCE(SUM(t.amount), 0) / bc.limit_amount * 100 as percentage
+    COALESCE(SUM(mt.total), 0) as total_spent,
+    COALESCE(ROUND(SUM(mt.total) / NULLIF(bc.limit_amount, 0) * 100), 0)::int as percentage
 FROM budget_categories bc
 JOIN categories c ON bc.category_id = c.id
-LEFT JOIN transactions t ON t.category_id = c.id 
-    AND t.budget_id = $1 
-    AND t.type = 'expense' 
-    AND t.deleted = false
-    AND t.transaction_date >= (SELECT month FROM budgets WHERE id = $1)
-    AND t.transaction_date < ((SELECT month FROM budgets WHERE id = $1) + INTERVAL '1 month')
+LEFT JOIN monthly_totals mt ON mt.category_id = c.id 
+    AND mt.budget_id = $1 
+    AND mt.type = 'expense' 
 WHERE bc.budget_id = $1
 GROUP BY c.id, c.name, c.icon, c.color, bc.limit_amount
 ORDER BY total_spent DESC
@@ -281,32 +275,31 @@ func (q *Queries) GetSpendingByCategory(ctx context.Context, budgetID pgtype.UUI
 
 const getSpendingTrends = `-- name: GetSpendingTrends :many
 SELECT 
-    DATE_TRUNC('month', transaction_date) as month,
-    SUM(CASE WHEN type = 'expense' THEN amount ELSE 0 END) as expenses,
-    SUM(CASE WHEN type = 'income' THEN amount ELSE 0 END) as income
-FROM transactions
+    month,
+    SUM(CASE WHEN type = 'expense' THEN total ELSE 0 END)::numeric as expenses,
+    SUM(CASE WHEN type = 'income' THEN total ELSE 0 END)::numeric as income
+FROM monthly_totals
 WHERE user_id = $1 
-  AND deleted = false
-  AND transaction_date >= $2
-  AND transaction_date <= $3
-GROUP BY DATE_TRUNC('month', transaction_date)
+  AND month >= $2
+  AND month <= $3
+GROUP BY month
 ORDER BY month ASC
 `
 
 type GetSpendingTrendsParams struct {
-	UserID            pgtype.UUID `json:"userId"`
-	TransactionDate   pgtype.Date `json:"transactionDate"`
-	TransactionDate_2 pgtype.Date `json:"transactionDate2"`
+	UserID     pgtype.UUID `json:"userId"`
+	StartMonth pgtype.Date `json:"startMonth"`
+	EndMonth   pgtype.Date `json:"endMonth"`
 }
 
 type GetSpendingTrendsRow struct {
-	Month    pgtype.Interval `json:"month"`
-	Expenses int64           `json:"expenses"`
-	Income   int64           `json:"income"`
+	Month    pgtype.Date    `json:"month"`
+	Expenses pgtype.Numeric `json:"expenses"`
+	Income   pgtype.Numeric `json:"income"`
 }
 
 func (q *Queries) GetSpendingTrends(ctx context.Context, arg GetSpendingTrendsParams) ([]GetSpendingTrendsRow, error) {
-	rows, err := q.db.Query(ctx, getSpendingTrends, arg.UserID, arg.TransactionDate, arg.TransactionDate_2)
+	rows, err := q.db.Query(ctx, getSpendingTrends, arg.UserID, arg.StartMonth, arg.EndMonth)
 	if err != nil {
 		return nil, err
 	}
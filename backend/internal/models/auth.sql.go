@@ -56,6 +56,20 @@ func (q *Queries) DeleteUser(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteUserByClerkID = `-- name: DeleteUserByClerkID :execrows
+UPDATE users
+SET deleted = true, updated_at = NOW()
+WHERE clerk_user_id = $1 AND deleted = false
+`
+
+func (q *Queries) DeleteUserByClerkID(ctx context.Context, clerkUserID string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserByClerkID, clerkUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getCurrentUser = `-- name: GetCurrentUser :one
 SELECT id, clerk_user_id, email, name, currency, created_at, updated_at, deleted FROM users
 WHERE id = $1 AND deleted = false
@@ -100,6 +114,28 @@ func (q *Queries) GetUserByClerkID(ctx context.Context, clerkUserID string) (Use
 	return i, err
 }
 
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, clerk_user_id, email, name, currency, created_at, updated_at, deleted FROM users
+WHERE email = $1 AND deleted = false
+LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.ClerkUserID,
+		&i.Email,
+		&i.Name,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+	)
+	return i, err
+}
+
 const listAllUsers = `-- name: ListAllUsers :many
 SELECT id, clerk_user_id, email, name, currency, created_at, updated_at, deleted FROM users
 WHERE deleted = false
@@ -141,6 +177,36 @@ func (q *Queries) ListAllUsers(ctx context.Context, arg ListAllUsersParams) ([]U
 	return items, nil
 }
 
+const provisionUser = `-- name: ProvisionUser :one
+INSERT INTO users (clerk_user_id, email, name)
+VALUES ($1, $2, $3)
+ON CONFLICT (clerk_user_id) DO UPDATE
+SET clerk_user_id = users.clerk_user_id
+RETURNING id, clerk_user_id, email, name, currency, created_at, updated_at, deleted
+`
+
+type ProvisionUserParams struct {
+	ClerkUserID string      `json:"clerkUserId"`
+	Email       string      `json:"email"`
+	Name        pgtype.Text `json:"name"`
+}
+
+func (q *Queries) ProvisionUser(ctx context.Context, arg ProvisionUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, provisionUser, arg.ClerkUserID, arg.Email, arg.Name)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.ClerkUserID,
+		&i.Email,
+		&i.Name,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
@@ -179,3 +245,56 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 	)
 	return i, err
 }
+
+const upsertClerkUser = `-- name: UpsertClerkUser :one
+INSERT INTO users (clerk_user_id, email, name)
+VALUES ($1, $2, $3)
+ON CONFLICT (clerk_user_id) DO UPDATE
+SET email = EXCLUDED.email,
+    name = COALESCE(EXCLUDED.name, users.name),
+    updated_at = NOW()
+RETURNING id, clerk_user_id, email, name, currency, created_at, updated_at, deleted
+`
+
+type UpsertClerkUserParams struct {
+	ClerkUserID string      `json:"clerkUserId"`
+	Email       string      `json:"email"`
+	Name        pgtype.Text `json:"name"`
+}
+
+func (q *Queries) UpsertClerkUser(ctx context.Context, arg UpsertClerkUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, upsertClerkUser, arg.ClerkUserID, arg.Email, arg.Name)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.ClerkUserID,
+		&i.Email,
+		&i.Name,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+	)
+	return i, err
+}
+
+const userHasHomeAmounts = `-- name: UserHasHomeAmounts :one
+SELECT (
+    EXISTS (SELECT 1 FROM transactions WHERE user_id = $1::uuid)
+    OR EXISTS (SELECT 1 FROM budgets WHERE user_id = $1::uuid)
+    OR EXISTS (SELECT 1 FROM savings_goals WHERE user_id = $1::uuid)
+    OR EXISTS (SELECT 1 FROM debts WHERE user_id = $1::uuid)
+    OR EXISTS (SELECT 1 FROM assets WHERE user_id = $1::uuid)
+    OR EXISTS (SELECT 1 FROM income_sources WHERE user_id = $1::uuid)
+    OR EXISTS (SELECT 1 FROM alert_rules WHERE user_id = $1::uuid AND amount IS NOT NULL)
+)::boolean as has_amounts
+`
+
+// Whether anything is recorded in the user's home currency, including deleted records
+// a restore could bring back. Changing the home currency would leave them in the old one.
+func (q *Queries) UserHasHomeAmounts(ctx context.Context, userID string) (bool, error) {
+	row := q.db.QueryRow(ctx, userHasHomeAmounts, userID)
+	var has_amounts bool
+	err := row.Scan(&has_amounts)
+	return has_amounts, err
+}
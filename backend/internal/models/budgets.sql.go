@@ -38,16 +38,20 @@ func (q *Queries) AddBudgetCategory(ctx context.Context, arg AddBudgetCategoryPa
 }
 
 const createBudget = `-- name: CreateBudget :one
-INSERT INTO budgets (user_id, name, month, total_limit)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, name, month, total_limit, created_at, updated_at, deleted
+INSERT INTO budgets (user_id, name, month, total_limit, envelope_mode, strict_envelopes, period_type, period_end)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, user_id, name, month, total_limit, created_at, updated_at, deleted, envelope_mode, strict_envelopes, period_type, period_end
 `
 
 type CreateBudgetParams struct {
-	UserID     pgtype.UUID    `json:"userId"`
-	Name       pgtype.Text    `json:"name"`
-	Month      pgtype.Date    `json:"month"`
-	TotalLimit pgtype.Numeric `json:"totalLimit"`
+	UserID          pgtype.UUID    `json:"userId"`
+	Name            pgtype.Text    `json:"name"`
+	Month           pgtype.Date    `json:"month"`
+	TotalLimit      pgtype.Numeric `json:"totalLimit"`
+	EnvelopeMode    bool           `json:"envelopeMode"`
+	StrictEnvelopes bool           `json:"strictEnvelopes"`
+	PeriodType      string         `json:"periodType"`
+	PeriodEnd       pgtype.Date    `json:"periodEnd"`
 }
 
 func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Budget, error) {
@@ -56,6 +60,10 @@ func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Bud
 		arg.Name,
 		arg.Month,
 		arg.TotalLimit,
+		arg.EnvelopeMode,
+		arg.StrictEnvelopes,
+		arg.PeriodType,
+		arg.PeriodEnd,
 	)
 	var i Budget
 	err := row.Scan(
@@ -67,6 +75,10 @@ func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Bud
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
+		&i.EnvelopeMode,
+		&i.StrictEnvelopes,
+		&i.PeriodType,
+		&i.PeriodEnd,
 	)
 	return i, err
 }
@@ -83,7 +95,7 @@ func (q *Queries) DeleteBudget(ctx context.Context, id string) error {
 }
 
 const getBudgetByID = `-- name: GetBudgetByID :one
-SELECT id, user_id, name, month, total_limit, created_at, updated_at, deleted FROM budgets
+SELECT id, user_id, name, month, total_limit, created_at, updated_at, deleted, envelope_mode, strict_envelopes, period_type, period_end FROM budgets
 WHERE id = $1 AND deleted = false
 LIMIT 1
 `
@@ -100,33 +112,10 @@ func (q *Queries) GetBudgetByID(ctx context.Context, id string) (Budget, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
-	)
-	return i, err
-}
-
-const getBudgetByMonth = `-- name: GetBudgetByMonth :one
-SELECT id, user_id, name, month, total_limit, created_at, updated_at, deleted FROM budgets
-WHERE user_id = $1 AND month = $2 AND deleted = false
-LIMIT 1
-`
-
-type GetBudgetByMonthParams struct {
-	UserID pgtype.UUID `json:"userId"`
-	Month  pgtype.Date `json:"month"`
-}
-
-func (q *Queries) GetBudgetByMonth(ctx context.Context, arg GetBudgetByMonthParams) (Budget, error) {
-	row := q.db.QueryRow(ctx, getBudgetByMonth, arg.UserID, arg.Month)
-	var i Budget
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.Name,
-		&i.Month,
-		&i.TotalLimit,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Deleted,
+		&i.EnvelopeMode,
+		&i.StrictEnvelopes,
+		&i.PeriodType,
+		&i.PeriodEnd,
 	)
 	return i, err
 }
@@ -180,16 +169,95 @@ func (q *Queries) GetBudgetCategories(ctx context.Context, budgetID pgtype.UUID)
 	return items, nil
 }
 
+const getBudgetCategoryByID = `-- name: GetBudgetCategoryByID :one
+SELECT id, budget_id, category_id, limit_amount, created_at, updated_at FROM budget_categories
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetBudgetCategoryByID(ctx context.Context, id string) (BudgetCategory, error) {
+	row := q.db.QueryRow(ctx, getBudgetCategoryByID, id)
+	var i BudgetCategory
+	err := row.Scan(
+		&i.ID,
+		&i.BudgetID,
+		&i.CategoryID,
+		&i.LimitAmount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getBudgetForDate = `-- name: GetBudgetForDate :one
+SELECT id, user_id, name, month, total_limit, created_at, updated_at, deleted, envelope_mode, strict_envelopes, period_type, period_end FROM budgets
+WHERE user_id = $1 AND month <= $2 AND period_end > $2 AND deleted = false
+LIMIT 1
+`
+
+type GetBudgetForDateParams struct {
+	UserID pgtype.UUID `json:"userId"`
+	Date   pgtype.Date `json:"date"`
+}
+
+// The user's budget whose period includes a date; budgets can't overlap
+func (q *Queries) GetBudgetForDate(ctx context.Context, arg GetBudgetForDateParams) (Budget, error) {
+	row := q.db.QueryRow(ctx, getBudgetForDate, arg.UserID, arg.Date)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Month,
+		&i.TotalLimit,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+		&i.EnvelopeMode,
+		&i.StrictEnvelopes,
+		&i.PeriodType,
+		&i.PeriodEnd,
+	)
+	return i, err
+}
+
+const getBudgetForUpdate = `-- name: GetBudgetForUpdate :one
+SELECT id, user_id, name, month, total_limit, created_at, updated_at, deleted, envelope_mode, strict_envelopes, period_type, period_end FROM budgets
+WHERE id = $1 AND deleted = false
+FOR UPDATE
+`
+
+// Locks the budget until the transaction ends, so envelope checks and the writes
+// they allow happen one at a time per budget
+func (q *Queries) GetBudgetForUpdate(ctx context.Context, id string) (Budget, error) {
+	row := q.db.QueryRow(ctx, getBudgetForUpdate, id)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Month,
+		&i.TotalLimit,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+		&i.EnvelopeMode,
+		&i.StrictEnvelopes,
+		&i.PeriodType,
+		&i.PeriodEnd,
+	)
+	return i, err
+}
+
 const getBudgetSpent = `-- name: GetBudgetSpent :one
-SELECT COALESCE(SUM(t.amount), 0) as total_spent
+SELECT COALESCE(SUM(t.home_amount), 0) as total_spent
 FROM transactions t
 WHERE t.budget_id = $1 
   AND t.type = 'expense' 
   AND t.deleted = false
-  AND t.transaction_date >= (SELECT month FROM budgets WHERE id = $1)
-  AND t.transaction_date < ((SELECT month FROM budgets WHERE id = $1) + INTERVAL '1 month')
 `
 
+// Everything filed under the budget counts against it, whatever its date
 func (q *Queries) GetBudgetSpent(ctx context.Context, budgetID pgtype.UUID) (interface{}, error) {
 	row := q.db.QueryRow(ctx, getBudgetSpent, budgetID)
 	var total_spent interface{}
@@ -198,7 +266,7 @@ func (q *Queries) GetBudgetSpent(ctx context.Context, budgetID pgtype.UUID) (int
 }
 
 const listUserBudgets = `-- name: ListUserBudgets :many
-SELECT id, user_id, name, month, total_limit, created_at, updated_at, deleted FROM budgets
+SELECT id, user_id, name, month, total_limit, created_at, updated_at, deleted, envelope_mode, strict_envelopes, period_type, period_end FROM budgets
 WHERE user_id = $1 AND deleted = false
 ORDER BY month DESC
 `
@@ -221,6 +289,10 @@ func (q *Queries) ListUserBudgets(ctx context.Context, userID pgtype.UUID) ([]Bu
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Deleted,
+			&i.EnvelopeMode,
+			&i.StrictEnvelopes,
+			&i.PeriodType,
+			&i.PeriodEnd,
 		); err != nil {
 			return nil, err
 		}
@@ -247,19 +319,29 @@ UPDATE budgets
 SET
     name = COALESCE($2, name),
     total_limit = COALESCE($3, total_limit),
+    envelope_mode = COALESCE($4, envelope_mode),
+    strict_envelopes = COALESCE($5, strict_envelopes),
     updated_at = NOW()
 WHERE id = $1 AND deleted = false
-RETURNING id, user_id, name, month, total_limit, created_at, updated_at, deleted
+RETURNING id, user_id, name, month, total_limit, created_at, updated_at, deleted, envelope_mode, strict_envelopes, period_type, period_end
 `
 
 type UpdateBudgetParams struct {
-	ID         string         `json:"id"`
-	Name       pgtype.Text    `json:"name"`
-	TotalLimit pgtype.Numeric `json:"totalLimit"`
+	ID              string         `json:"id"`
+	Name            pgtype.Text    `json:"name"`
+	TotalLimit      pgtype.Numeric `json:"totalLimit"`
+	EnvelopeMode    pgtype.Bool    `json:"envelopeMode"`
+	StrictEnvelopes pgtype.Bool    `json:"strictEnvelopes"`
 }
 
 func (q *Queries) UpdateBudget(ctx context.Context, arg UpdateBudgetParams) (Budget, error) {
-	row := q.db.QueryRow(ctx, updateBudget, arg.ID, arg.Name, arg.TotalLimit)
+	row := q.db.QueryRow(ctx, updateBudget,
+		arg.ID,
+		arg.Name,
+		arg.TotalLimit,
+		arg.EnvelopeMode,
+		arg.StrictEnvelopes,
+	)
 	var i Budget
 	err := row.Scan(
 		&i.ID,
@@ -270,6 +352,10 @@ func (q *Queries) UpdateBudget(ctx context.Context, arg UpdateBudgetParams) (Bud
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
+		&i.EnvelopeMode,
+		&i.StrictEnvelopes,
+		&i.PeriodType,
+		&i.PeriodEnd,
 	)
 	return i, err
 }
@@ -20,19 +20,71 @@ type ActivityLog struct {
 	IpAddress    *netip.Addr        `json:"ipAddress"`
 	UserAgent    pgtype.Text        `json:"userAgent"`
 	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
+	RequestID    pgtype.Text        `json:"requestId"`
+	BudgetID     pgtype.UUID        `json:"budgetId"`
 }
 
-type Budget struct {
+type AlertRule struct {
+	ID               string             `json:"id"`
+	UserID           string             `json:"userId"`
+	Kind             string             `json:"kind"`
+	CategoryID       pgtype.UUID        `json:"categoryId"`
+	ThresholdPercent pgtype.Int4        `json:"thresholdPercent"`
+	Amount           pgtype.Numeric     `json:"amount"`
+	DaysBefore       pgtype.Int4        `json:"daysBefore"`
+	Enabled          bool               `json:"enabled"`
+	CreatedAt        pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt        pgtype.Timestamptz `json:"updatedAt"`
+	Email            bool               `json:"email"`
+}
+
+type ApiToken struct {
+	ID          string             `json:"id"`
+	UserID      string             `json:"userId"`
+	Name        string             `json:"name"`
+	TokenPrefix string             `json:"tokenPrefix"`
+	TokenHash   string             `json:"tokenHash"`
+	Scopes      []string           `json:"scopes"`
+	ExpiresAt   pgtype.Timestamptz `json:"expiresAt"`
+	LastUsedAt  pgtype.Timestamptz `json:"lastUsedAt"`
+	RevokedAt   pgtype.Timestamptz `json:"revokedAt"`
+	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
+}
+
+type Asset struct {
 	ID         string             `json:"id"`
-	UserID     pgtype.UUID        `json:"userId"`
-	Name       pgtype.Text        `json:"name"`
-	Month      pgtype.Date        `json:"month"`
-	TotalLimit pgtype.Numeric     `json:"totalLimit"`
+	UserID     string             `json:"userId"`
+	Name       string             `json:"name"`
+	AssetClass string             `json:"assetClass"`
+	Notes      pgtype.Text        `json:"notes"`
 	CreatedAt  pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt  pgtype.Timestamptz `json:"updatedAt"`
 	Deleted    pgtype.Bool        `json:"deleted"`
 }
 
+type AssetValuation struct {
+	ID        string             `json:"id"`
+	AssetID   string             `json:"assetId"`
+	Value     pgtype.Numeric     `json:"value"`
+	ValuedOn  pgtype.Date        `json:"valuedOn"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
+
+type Budget struct {
+	ID              string             `json:"id"`
+	UserID          pgtype.UUID        `json:"userId"`
+	Name            pgtype.Text        `json:"name"`
+	Month           pgtype.Date        `json:"month"`
+	TotalLimit      pgtype.Numeric     `json:"totalLimit"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	Deleted         pgtype.Bool        `json:"deleted"`
+	EnvelopeMode    bool               `json:"envelopeMode"`
+	StrictEnvelopes bool               `json:"strictEnvelopes"`
+	PeriodType      string             `json:"periodType"`
+	PeriodEnd       pgtype.Date        `json:"periodEnd"`
+}
+
 type BudgetCategory struct {
 	ID          string             `json:"id"`
 	BudgetID    pgtype.UUID        `json:"budgetId"`
@@ -55,6 +107,149 @@ type Category struct {
 	Deleted      pgtype.Bool        `json:"deleted"`
 }
 
+type ChangeEvent struct {
+	ID         int64              `json:"id"`
+	TableName  string             `json:"tableName"`
+	RecordID   string             `json:"recordId"`
+	Operation  string             `json:"operation"`
+	BudgetID   pgtype.UUID        `json:"budgetId"`
+	Recipients []pgtype.UUID      `json:"recipients"`
+	CreatedAt  pgtype.Timestamptz `json:"createdAt"`
+}
+
+type Debt struct {
+	ID              string             `json:"id"`
+	UserID          string             `json:"userId"`
+	Name            string             `json:"name"`
+	Kind            string             `json:"kind"`
+	Principal       pgtype.Numeric     `json:"principal"`
+	BalanceDate     pgtype.Date        `json:"balanceDate"`
+	Apr             pgtype.Numeric     `json:"apr"`
+	MinimumPayment  pgtype.Numeric     `json:"minimumPayment"`
+	DueDay          int32              `json:"dueDay"`
+	PaymentMethodID pgtype.UUID        `json:"paymentMethodId"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	Deleted         pgtype.Bool        `json:"deleted"`
+}
+
+type EmailOutbox struct {
+	ID            string             `json:"id"`
+	UserID        pgtype.UUID        `json:"userId"`
+	ToAddress     string             `json:"toAddress"`
+	Template      string             `json:"template"`
+	Data          []byte             `json:"data"`
+	DedupeKey     pgtype.Text        `json:"dedupeKey"`
+	Status        string             `json:"status"`
+	Attempts      int32              `json:"attempts"`
+	LastError     pgtype.Text        `json:"lastError"`
+	NextAttemptAt pgtype.Timestamptz `json:"nextAttemptAt"`
+	SentAt        pgtype.Timestamptz `json:"sentAt"`
+	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
+}
+
+type EnvelopeMove struct {
+	ID             string             `json:"id"`
+	BudgetID       string             `json:"budgetId"`
+	UserID         pgtype.UUID        `json:"userId"`
+	FromCategoryID pgtype.UUID        `json:"fromCategoryId"`
+	ToCategoryID   pgtype.UUID        `json:"toCategoryId"`
+	Amount         pgtype.Numeric     `json:"amount"`
+	Note           pgtype.Text        `json:"note"`
+	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
+}
+
+type ExchangeRate struct {
+	ID        string             `json:"id"`
+	RateDate  pgtype.Date        `json:"rateDate"`
+	Base      string             `json:"base"`
+	Quote     string             `json:"quote"`
+	Rate      pgtype.Numeric     `json:"rate"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type ExpenseSplit struct {
+	ID            string             `json:"id"`
+	TransactionID string             `json:"transactionId"`
+	BudgetID      string             `json:"budgetId"`
+	PaidByID      string             `json:"paidById"`
+	SplitMethod   string             `json:"splitMethod"`
+	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt     pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type ExpenseSplitShare struct {
+	ID      string         `json:"id"`
+	SplitID string         `json:"splitId"`
+	UserID  string         `json:"userId"`
+	Value   pgtype.Numeric `json:"value"`
+	Amount  pgtype.Numeric `json:"amount"`
+}
+
+type IncomeExpectation struct {
+	SourceID  string             `json:"sourceId"`
+	Month     pgtype.Date        `json:"month"`
+	Amount    pgtype.Numeric     `json:"amount"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
+
+type IncomeSource struct {
+	ID         string             `json:"id"`
+	UserID     string             `json:"userId"`
+	Name       string             `json:"name"`
+	Kind       string             `json:"kind"`
+	CategoryID pgtype.UUID        `json:"categoryId"`
+	Amount     pgtype.Numeric     `json:"amount"`
+	Months     []int16            `json:"months"`
+	StartMonth pgtype.Date        `json:"startMonth"`
+	EndMonth   pgtype.Date        `json:"endMonth"`
+	CreatedAt  pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt  pgtype.Timestamptz `json:"updatedAt"`
+	Deleted    pgtype.Bool        `json:"deleted"`
+}
+
+type InsightDismissal struct {
+	UserID      string             `json:"userId"`
+	InsightID   string             `json:"insightId"`
+	DismissedAt pgtype.Timestamptz `json:"dismissedAt"`
+}
+
+type MonthlyTotal struct {
+	ID               int64          `json:"id"`
+	UserID           pgtype.UUID    `json:"userId"`
+	BudgetID         pgtype.UUID    `json:"budgetId"`
+	CategoryID       pgtype.UUID    `json:"categoryId"`
+	PaymentMethodID  pgtype.UUID    `json:"paymentMethodId"`
+	Month            pgtype.Date    `json:"month"`
+	Type             pgtype.Text    `json:"type"`
+	IsTransfer       pgtype.Bool    `json:"isTransfer"`
+	Total            pgtype.Numeric `json:"total"`
+	TransactionCount int32          `json:"transactionCount"`
+}
+
+type NetWorthSnapshot struct {
+	UserID      string             `json:"userId"`
+	Month       pgtype.Date        `json:"month"`
+	Assets      pgtype.Numeric     `json:"assets"`
+	Liabilities pgtype.Numeric     `json:"liabilities"`
+	NetWorth    pgtype.Numeric     `json:"netWorth"`
+	Breakdown   []byte             `json:"breakdown"`
+	RecordedAt  pgtype.Timestamptz `json:"recordedAt"`
+}
+
+type Notification struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"userId"`
+	Kind      string             `json:"kind"`
+	Title     string             `json:"title"`
+	Body      string             `json:"body"`
+	Data      []byte             `json:"data"`
+	DedupeKey string             `json:"dedupeKey"`
+	ReadAt    pgtype.Timestamptz `json:"readAt"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
+
 type PaymentMethod struct {
 	ID             string             `json:"id"`
 	UserID         pgtype.UUID        `json:"userId"`
@@ -69,6 +264,40 @@ type PaymentMethod struct {
 	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt      pgtype.Timestamptz `json:"updatedAt"`
 	Deleted        pgtype.Bool        `json:"deleted"`
+	Currency       pgtype.Text        `json:"currency"`
+}
+
+type PushOutbox struct {
+	ID             string             `json:"id"`
+	SubscriptionID string             `json:"subscriptionId"`
+	Payload        []byte             `json:"payload"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	LastError      pgtype.Text        `json:"lastError"`
+	NextAttemptAt  pgtype.Timestamptz `json:"nextAttemptAt"`
+	SentAt         pgtype.Timestamptz `json:"sentAt"`
+	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
+}
+
+type PushSubscription struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"userId"`
+	Endpoint  string             `json:"endpoint"`
+	P256dh    string             `json:"p256dh"`
+	Auth      string             `json:"auth"`
+	UserAgent pgtype.Text        `json:"userAgent"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type RecordRevision struct {
+	ID        string             `json:"id"`
+	TableName string             `json:"tableName"`
+	RecordID  string             `json:"recordId"`
+	Revision  int32              `json:"revision"`
+	Operation string             `json:"operation"`
+	Data      []byte             `json:"data"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
 }
 
 type Reflection struct {
@@ -103,6 +332,29 @@ type ReflectionTemplate struct {
 	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
 }
 
+type SavingsGoal struct {
+	ID              string             `json:"id"`
+	UserID          string             `json:"userId"`
+	Name            string             `json:"name"`
+	TargetAmount    pgtype.Numeric     `json:"targetAmount"`
+	TargetDate      pgtype.Date        `json:"targetDate"`
+	PaymentMethodID pgtype.UUID        `json:"paymentMethodId"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	Deleted         pgtype.Bool        `json:"deleted"`
+}
+
+type Settlement struct {
+	ID         string             `json:"id"`
+	BudgetID   string             `json:"budgetId"`
+	FromUserID string             `json:"fromUserId"`
+	ToUserID   string             `json:"toUserId"`
+	Amount     pgtype.Numeric     `json:"amount"`
+	Note       pgtype.Text        `json:"note"`
+	CreatedBy  pgtype.UUID        `json:"createdBy"`
+	CreatedAt  pgtype.Timestamptz `json:"createdAt"`
+}
+
 type ShareAccess struct {
 	ID           string             `json:"id"`
 	BudgetID     pgtype.UUID        `json:"budgetId"`
@@ -110,6 +362,7 @@ type ShareAccess struct {
 	SharedWithID pgtype.UUID        `json:"sharedWithId"`
 	Permission   string             `json:"permission"`
 	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
 }
 
 type ShareInvitation struct {
@@ -124,6 +377,12 @@ type ShareInvitation struct {
 	UpdatedAt      pgtype.Timestamptz `json:"updatedAt"`
 }
 
+type SubscriptionCancellation struct {
+	UserID         string             `json:"userId"`
+	SubscriptionID string             `json:"subscriptionId"`
+	CancelledAt    pgtype.Timestamptz `json:"cancelledAt"`
+}
+
 type SyncOperation struct {
 	ID            string             `json:"id"`
 	UserID        pgtype.UUID        `json:"userId"`
@@ -170,6 +429,11 @@ type Transaction struct {
 	CreatedAt           pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt           pgtype.Timestamptz `json:"updatedAt"`
 	Deleted             pgtype.Bool        `json:"deleted"`
+	Currency            string             `json:"currency"`
+	HomeAmount          pgtype.Numeric     `json:"homeAmount"`
+	ExchangeRate        pgtype.Numeric     `json:"exchangeRate"`
+	GoalID              pgtype.UUID        `json:"goalId"`
+	DebtID              pgtype.UUID        `json:"debtId"`
 }
 
 type User struct {
@@ -14,13 +14,13 @@ import (
 const createPaymentMethod = `-- name: CreatePaymentMethod :one
 INSERT INTO payment_methods (
     user_id, name, type, last_four, brand,
-    is_default, is_active, credit_limit, current_balance
+    is_default, is_active, credit_limit, current_balance, currency
 )
 VALUES (
     $1, $2, $3, $4, $5,
-    $6, $7, $8, $9
+    $6, $7, $8, $9, $10
 )
-RETURNING id, user_id, name, type, last_four, brand, is_default, is_active, credit_limit, current_balance, created_at, updated_at, deleted
+RETURNING id, user_id, name, type, last_four, brand, is_default, is_active, credit_limit, current_balance, created_at, updated_at, deleted, currency
 `
 
 type CreatePaymentMethodParams struct {
@@ -33,6 +33,7 @@ type CreatePaymentMethodParams struct {
 	IsActive       pgtype.Bool    `json:"isActive"`
 	CreditLimit    pgtype.Numeric `json:"creditLimit"`
 	CurrentBalance pgtype.Numeric `json:"currentBalance"`
+	Currency       pgtype.Text    `json:"currency"`
 }
 
 func (q *Queries) CreatePaymentMethod(ctx context.Context, arg CreatePaymentMethodParams) (PaymentMethod, error) {
@@ -46,6 +47,7 @@ func (q *Queries) CreatePaymentMethod(ctx context.Context, arg CreatePaymentMeth
 		arg.IsActive,
 		arg.CreditLimit,
 		arg.CurrentBalance,
+		arg.Currency,
 	)
 	var i PaymentMethod
 	err := row.Scan(
@@ -62,6 +64,7 @@ func (q *Queries) CreatePaymentMethod(ctx context.Context, arg CreatePaymentMeth
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
+		&i.Currency,
 	)
 	return i, err
 }
@@ -78,7 +81,7 @@ func (q *Queries) DeletePaymentMethod(ctx context.Context, id string) error {
 }
 
 const getPaymentMethodByID = `-- name: GetPaymentMethodByID :one
-SELECT id, user_id, name, type, last_four, brand, is_default, is_active, credit_limit, current_balance, created_at, updated_at, deleted FROM payment_methods
+SELECT id, user_id, name, type, last_four, brand, is_default, is_active, credit_limit, current_balance, created_at, updated_at, deleted, currency FROM payment_methods
 WHERE id = $1 AND deleted = false
 LIMIT 1
 `
@@ -100,12 +103,13 @@ func (q *Queries) GetPaymentMethodByID(ctx context.Context, id string) (PaymentM
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
+		&i.Currency,
 	)
 	return i, err
 }
 
 const listPaymentMethods = `-- name: ListPaymentMethods :many
-SELECT id, user_id, name, type, last_four, brand, is_default, is_active, credit_limit, current_balance, created_at, updated_at, deleted FROM payment_methods
+SELECT id, user_id, name, type, last_four, brand, is_default, is_active, credit_limit, current_balance, created_at, updated_at, deleted, currency FROM payment_methods
 WHERE user_id = $1 AND deleted = false
 ORDER BY is_default DESC, created_at DESC
 `
@@ -133,6 +137,7 @@ func (q *Queries) ListPaymentMethods(ctx context.Context, userID pgtype.UUID) ([
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Deleted,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -166,9 +171,10 @@ SET
     is_active = COALESCE($7, is_active),
     credit_limit = COALESCE($8, credit_limit),
     current_balance = COALESCE($9, current_balance),
+    currency = COALESCE($10, currency),
     updated_at = NOW()
 WHERE id = $1 AND deleted = false
-RETURNING id, user_id, name, type, last_four, brand, is_default, is_active, credit_limit, current_balance, created_at, updated_at, deleted
+RETURNING id, user_id, name, type, last_four, brand, is_default, is_active, credit_limit, current_balance, created_at, updated_at, deleted, currency
 `
 
 type UpdatePaymentMethodParams struct {
@@ -181,6 +187,7 @@ type UpdatePaymentMethodParams struct {
 	IsActive       pgtype.Bool    `json:"isActive"`
 	CreditLimit    pgtype.Numeric `json:"creditLimit"`
 	CurrentBalance pgtype.Numeric `json:"currentBalance"`
+	Currency       pgtype.Text    `json:"currency"`
 }
 
 func (q *Queries) UpdatePaymentMethod(ctx context.Context, arg UpdatePaymentMethodParams) (PaymentMethod, error) {
@@ -194,6 +201,7 @@ func (q *Queries) UpdatePaymentMethod(ctx context.Context, arg UpdatePaymentMeth
 		arg.IsActive,
 		arg.CreditLimit,
 		arg.CurrentBalance,
+		arg.Currency,
 	)
 	var i PaymentMethod
 	err := row.Scan(
@@ -210,6 +218,7 @@ func (q *Queries) UpdatePaymentMethod(ctx context.Context, arg UpdatePaymentMeth
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
+		&i.Currency,
 	)
 	return i, err
 }
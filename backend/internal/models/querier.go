@@ -12,52 +12,126 @@ import (
 
 type Querier interface {
 	AddBudgetCategory(ctx context.Context, arg AddBudgetCategoryParams) (BudgetCategory, error)
+	// Adds to what a budget assigns a category, creating its envelope if it has none
+	AdjustEnvelope(ctx context.Context, arg AdjustEnvelopeParams) (BudgetCategory, error)
+	CancelSubscription(ctx context.Context, arg CancelSubscriptionParams) (SubscriptionCancellation, error)
 	CheckBudgetAccess(ctx context.Context, arg CheckBudgetAccessParams) (CheckBudgetAccessRow, error)
+	// Groups whose stored totals don't match their transactions
+	CheckMonthlyTotals(ctx context.Context) ([]CheckMonthlyTotalsRow, error)
+	// Takes due emails for delivery. Claimed rows stay pending but aren't due again until
+	// the lease has passed, so a worker that dies mid-send leaves them to be retried.
+	ClaimEmails(ctx context.Context, arg ClaimEmailsParams) ([]EmailOutbox, error)
+	// Takes due messages for delivery together with their subscription, with the same
+	// lease as ClaimEmails
+	ClaimPushMessages(ctx context.Context, arg ClaimPushMessagesParams) ([]ClaimPushMessagesRow, error)
 	CountPendingSyncOperations(ctx context.Context, userID pgtype.UUID) (int64, error)
+	CountUnreadNotifications(ctx context.Context, userID string) (int64, error)
+	CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error)
+	CreateActivityLog(ctx context.Context, arg CreateActivityLogParams) (ActivityLog, error)
+	CreateAlertRule(ctx context.Context, arg CreateAlertRuleParams) (AlertRule, error)
+	CreateAsset(ctx context.Context, arg CreateAssetParams) (Asset, error)
 	CreateBudget(ctx context.Context, arg CreateBudgetParams) (Budget, error)
 	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+	CreateDebt(ctx context.Context, arg CreateDebtParams) (Debt, error)
+	CreateEnvelopeMove(ctx context.Context, arg CreateEnvelopeMoveParams) (EnvelopeMove, error)
+	CreateExpenseSplitShare(ctx context.Context, arg CreateExpenseSplitShareParams) (ExpenseSplitShare, error)
+	CreateIncomeSource(ctx context.Context, arg CreateIncomeSourceParams) (IncomeSource, error)
+	// Returns no rows when the user already has a notification with the same dedupe_key
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
 	CreatePaymentMethod(ctx context.Context, arg CreatePaymentMethodParams) (PaymentMethod, error)
 	CreateReflection(ctx context.Context, arg CreateReflectionParams) (Reflection, error)
 	CreateReflectionQuestion(ctx context.Context, arg CreateReflectionQuestionParams) (ReflectionQuestion, error)
 	CreateReflectionTemplate(ctx context.Context, arg CreateReflectionTemplateParams) (ReflectionTemplate, error)
+	CreateSavingsGoal(ctx context.Context, arg CreateSavingsGoalParams) (SavingsGoal, error)
+	CreateSettlement(ctx context.Context, arg CreateSettlementParams) (Settlement, error)
 	CreateShareAccess(ctx context.Context, arg CreateShareAccessParams) (ShareAccess, error)
 	CreateShareInvitation(ctx context.Context, arg CreateShareInvitationParams) (ShareInvitation, error)
 	CreateSyncOperation(ctx context.Context, arg CreateSyncOperationParams) (SyncOperation, error)
 	CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAlertRule(ctx context.Context, id string) error
+	DeleteAsset(ctx context.Context, id string) error
+	DeleteAssetValuation(ctx context.Context, arg DeleteAssetValuationParams) (int64, error)
 	DeleteBudget(ctx context.Context, id string) error
 	DeleteCategory(ctx context.Context, id string) error
+	DeleteDebt(ctx context.Context, id string) error
+	DeleteExpenseSplit(ctx context.Context, transactionID string) error
+	DeleteExpenseSplitShares(ctx context.Context, splitID string) error
+	DeleteIncomeExpectation(ctx context.Context, arg DeleteIncomeExpectationParams) (int64, error)
+	DeleteIncomeSource(ctx context.Context, id string) error
 	DeleteInvitation(ctx context.Context, id string) error
+	DeleteMonthlyTotals(ctx context.Context, userID pgtype.UUID) error
+	DeleteNotification(ctx context.Context, arg DeleteNotificationParams) (int64, error)
 	DeletePaymentMethod(ctx context.Context, id string) error
+	DeletePushSubscription(ctx context.Context, arg DeletePushSubscriptionParams) (int64, error)
+	DeletePushSubscriptionByEndpoint(ctx context.Context, arg DeletePushSubscriptionByEndpointParams) (int64, error)
 	DeleteReflection(ctx context.Context, id string) error
 	DeleteReflectionTemplate(ctx context.Context, id string) error
+	DeleteSavingsGoal(ctx context.Context, id string) error
 	DeleteShareAccess(ctx context.Context, id string) error
 	DeleteSyncOperation(ctx context.Context, id string) error
 	DeleteSyncedOperations(ctx context.Context, userID pgtype.UUID) error
 	DeleteTransaction(ctx context.Context, id string) error
 	DeleteUser(ctx context.Context, id string) error
+	DeleteUserByClerkID(ctx context.Context, clerkUserID string) (int64, error)
+	DismissInsight(ctx context.Context, arg DismissInsightParams) error
+	// Returns 0 when an email with the same dedupe_key is already queued
+	EnqueueEmail(ctx context.Context, arg EnqueueEmailParams) (int64, error)
+	// Queues a message for each of the user's devices and returns how many were queued
+	EnqueuePush(ctx context.Context, arg EnqueuePushParams) (int64, error)
+	FailEmail(ctx context.Context, arg FailEmailParams) error
+	FailPush(ctx context.Context, arg FailPushParams) error
+	GetActiveAPITokenByHash(ctx context.Context, tokenHash string) (GetActiveAPITokenByHashRow, error)
+	GetAlertRuleByID(ctx context.Context, id string) (AlertRule, error)
+	GetAssetByID(ctx context.Context, id string) (Asset, error)
 	GetBudgetByID(ctx context.Context, id string) (Budget, error)
-	GetBudgetByMonth(ctx context.Context, arg GetBudgetByMonthParams) (Budget, error)
 	GetBudgetCategories(ctx context.Context, budgetID pgtype.UUID) ([]GetBudgetCategoriesRow, error)
+	GetBudgetCategoriesSince(ctx context.Context, arg GetBudgetCategoriesSinceParams) ([]BudgetCategory, error)
+	GetBudgetCategoryByID(ctx context.Context, id string) (BudgetCategory, error)
+	GetBudgetCategoryForCategory(ctx context.Context, arg GetBudgetCategoryForCategoryParams) (BudgetCategory, error)
+	// The user's budget whose period includes a date; budgets can't overlap
+	GetBudgetForDate(ctx context.Context, arg GetBudgetForDateParams) (Budget, error)
+	// Locks the budget until the transaction ends, so envelope checks and the writes
+	// they allow happen one at a time per budget
+	GetBudgetForUpdate(ctx context.Context, id string) (Budget, error)
+	GetBudgetMembers(ctx context.Context, id string) ([]GetBudgetMembersRow, error)
+	// Everything filed under the budget counts against it, whatever its date
 	GetBudgetSpent(ctx context.Context, budgetID pgtype.UUID) (interface{}, error)
+	GetBudgetSplitShares(ctx context.Context, budgetID string) ([]GetBudgetSplitSharesRow, error)
 	// Sync pull queries - fetch records updated since last sync
 	GetBudgetsSince(ctx context.Context, arg GetBudgetsSinceParams) ([]Budget, error)
 	GetCategoriesSince(ctx context.Context, arg GetCategoriesSinceParams) ([]Category, error)
 	GetCategoryByID(ctx context.Context, id string) (Category, error)
 	GetCategoryReport(ctx context.Context, arg GetCategoryReportParams) ([]GetCategoryReportRow, error)
 	GetCategorySpent(ctx context.Context, arg GetCategorySpentParams) (interface{}, error)
+	GetChangeEvent(ctx context.Context, id int64) (ChangeEvent, error)
 	GetCurrentUser(ctx context.Context, id string) (User, error)
 	GetDashboardSummary(ctx context.Context, id string) (GetDashboardSummaryRow, error)
+	GetDebtByID(ctx context.Context, id string) (Debt, error)
+	// Net payments per debt since its balance_date, which the balance is measured from
+	GetDebtPayments(ctx context.Context, userID string) ([]GetDebtPaymentsRow, error)
+	// Latest rate on or before the date in either direction; a direct quote wins over an inverse one
+	GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error)
+	GetExpenseSplitByTransaction(ctx context.Context, transactionID string) (ExpenseSplit, error)
+	GetExpenseSplitShares(ctx context.Context, splitID string) ([]ExpenseSplitShare, error)
 	GetFailedSyncOperations(ctx context.Context, userID pgtype.UUID) ([]SyncOperation, error)
+	GetIncomeSourceByID(ctx context.Context, id string) (IncomeSource, error)
 	GetInvitationByID(ctx context.Context, id string) (ShareInvitation, error)
 	GetInvitationsByOwner(ctx context.Context, ownerID pgtype.UUID) ([]GetInvitationsByOwnerRow, error)
+	GetLatestRecordRevision(ctx context.Context, arg GetLatestRecordRevisionParams) (RecordRevision, error)
+	// Returns 0 when there are no events
+	GetOldestChangeEventID(ctx context.Context) (int64, error)
 	GetPaymentMethodByID(ctx context.Context, id string) (PaymentMethod, error)
 	GetPendingInvitationsByRecipient(ctx context.Context, recipientEmail string) ([]GetPendingInvitationsByRecipientRow, error)
 	GetPendingSyncOperations(ctx context.Context, userID pgtype.UUID) ([]SyncOperation, error)
 	GetRecentTransactions(ctx context.Context, arg GetRecentTransactionsParams) ([]GetRecentTransactionsRow, error)
+	GetRecordRevision(ctx context.Context, arg GetRecordRevisionParams) (RecordRevision, error)
 	GetReflectionByBudget(ctx context.Context, budgetID pgtype.UUID) (Reflection, error)
 	GetReflectionByID(ctx context.Context, id string) (Reflection, error)
 	GetReflectionQuestions(ctx context.Context, reflectionID pgtype.UUID) ([]ReflectionQuestion, error)
+	GetSavingsGoalByID(ctx context.Context, id string) (SavingsGoal, error)
+	// Net contributions per goal: all time, since recent_since (for the pace) and within the month
+	GetSavingsGoalTotals(ctx context.Context, arg GetSavingsGoalTotalsParams) ([]GetSavingsGoalTotalsRow, error)
 	GetShareAccessByBudget(ctx context.Context, budgetID pgtype.UUID) ([]GetShareAccessByBudgetRow, error)
 	GetShareAccessByID(ctx context.Context, id string) (ShareAccess, error)
 	GetShareAccessForBudgetAndUser(ctx context.Context, arg GetShareAccessForBudgetAndUserParams) (ShareAccess, error)
@@ -73,28 +147,129 @@ type Querier interface {
 	GetTransactionsByBudget(ctx context.Context, budgetID pgtype.UUID) ([]Transaction, error)
 	GetTransactionsSince(ctx context.Context, arg GetTransactionsSinceParams) ([]Transaction, error)
 	GetUserByClerkID(ctx context.Context, clerkUserID string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserCategories(ctx context.Context, userID pgtype.UUID) ([]Category, error)
+	ListAPITokensByUser(ctx context.Context, userID string) ([]ApiToken, error)
+	ListActivity(ctx context.Context, arg ListActivityParams) ([]ListActivityRow, error)
+	ListAlertRules(ctx context.Context, userID string) ([]AlertRule, error)
 	ListAllUsers(ctx context.Context, arg ListAllUsersParams) ([]User, error)
+	ListAssetValuations(ctx context.Context, assetID string) ([]AssetValuation, error)
+	// Assets with their latest valuation
+	ListAssets(ctx context.Context, userID string) ([]ListAssetsRow, error)
+	// Enabled rules of everyone with access to the budget: the owner and shared members
+	ListBudgetAlertRules(ctx context.Context, budgetID string) ([]AlertRule, error)
+	// Events for a user after a Last-Event-ID, oldest first
+	ListChangeEventsSince(ctx context.Context, arg ListChangeEventsSinceParams) ([]ChangeEvent, error)
+	// Daily totals per category and payment method for spending that happens at its own pace:
+	// recurring transactions, debt payments and transfers are projected separately
+	// Income and expenses per day, category and payment method for a custom report,
+	// over the user's own transactions or, with budget_ids, those budgets'. Every other
+	// filter is optional. Transfers are left out.
+	ListDailyTotals(ctx context.Context, arg ListDailyTotalsParams) ([]ListDailyTotalsRow, error)
+	ListDebtPayments(ctx context.Context, debtID pgtype.UUID) ([]Transaction, error)
+	ListDebts(ctx context.Context, userID string) ([]Debt, error)
+	ListEnvelopeMoves(ctx context.Context, budgetID string) ([]EnvelopeMove, error)
+	// Assigned amounts, income and spending per category for a user's envelope budgets up
+	// to a month, oldest first. is_envelope is false for categories the budget has no
+	// envelope for, whose spending comes out of the unassigned pool.
+	ListEnvelopeTotals(ctx context.Context, arg ListEnvelopeTotalsParams) ([]ListEnvelopeTotalsRow, error)
+	ListExchangeRates(ctx context.Context, arg ListExchangeRatesParams) ([]ExchangeRate, error)
+	ListGoalContributions(ctx context.Context, goalID pgtype.UUID) ([]Transaction, error)
+	// The user's per-month overrides of what a source is expected to pay in month
+	ListIncomeExpectations(ctx context.Context, arg ListIncomeExpectationsParams) ([]IncomeExpectation, error)
+	ListIncomeSources(ctx context.Context, userID string) ([]IncomeSource, error)
+	ListInsightDismissals(ctx context.Context, userID string) ([]InsightDismissal, error)
+	// Expenses insights and subscriptions are computed from, oldest first
+	ListInsightTransactions(ctx context.Context, arg ListInsightTransactionsParams) ([]ListInsightTransactionsRow, error)
+	// A user's own income and expenses in a month per category, transfers left out
+	ListMonthTotalsByCategory(ctx context.Context, arg ListMonthTotalsByCategoryParams) ([]ListMonthTotalsByCategoryRow, error)
+	// Monthly spending per category, scoped like ListPeriodTotals
+	ListMonthlyCategoryTotals(ctx context.Context, arg ListMonthlyCategoryTotalsParams) ([]ListMonthlyCategoryTotalsRow, error)
+	ListNetWorthSnapshots(ctx context.Context, arg ListNetWorthSnapshotsParams) ([]NetWorthSnapshot, error)
+	// Users with anything to put in a net worth snapshot
+	ListNetWorthUsers(ctx context.Context) ([]ListNetWorthUsersRow, error)
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error)
 	ListPaymentMethods(ctx context.Context, userID pgtype.UUID) ([]PaymentMethod, error)
+	// Income and expenses over a period per category and payment method, for one
+	// user's transactions or, with budget_id, a (possibly shared) budget's. Transfers
+	// only move money between accounts, so they're left out.
+	ListPeriodTotals(ctx context.Context, arg ListPeriodTotalsParams) ([]ListPeriodTotalsRow, error)
+	ListPushSubscriptions(ctx context.Context, userID string) ([]PushSubscription, error)
+	ListRecordRevisions(ctx context.Context, arg ListRecordRevisionsParams) ([]RecordRevision, error)
+	ListRecurringExpenses(ctx context.Context, userID pgtype.UUID) ([]Transaction, error)
+	// Recurring expenses and income, projected from their recurrence patterns
+	ListRecurringTransactions(ctx context.Context, userID pgtype.UUID) ([]Transaction, error)
 	ListReflectionTemplates(ctx context.Context) ([]ReflectionTemplate, error)
+	ListReportTotals(ctx context.Context, arg ListReportTotalsParams) ([]ListReportTotalsRow, error)
+	ListSavingsGoals(ctx context.Context, userID string) ([]SavingsGoal, error)
+	ListSettlementsByBudget(ctx context.Context, budgetID string) ([]Settlement, error)
+	ListSubscriptionCancellations(ctx context.Context, userID string) ([]SubscriptionCancellation, error)
+	// Monthly budgets starting on a date with the owner's address, for month-end summaries
+	ListSummaryBudgets(ctx context.Context, month pgtype.Date) ([]ListSummaryBudgetsRow, error)
 	ListTransactions(ctx context.Context, arg ListTransactionsParams) ([]Transaction, error)
+	ListUserAlertRulesByKind(ctx context.Context, arg ListUserAlertRulesByKindParams) ([]AlertRule, error)
 	ListUserBudgets(ctx context.Context, userID pgtype.UUID) ([]Budget, error)
 	ListUserReflections(ctx context.Context, userID pgtype.UUID) ([]Reflection, error)
+	// Holds off trigger updates to a user's totals until the end of the transaction
+	LockMonthlyTotals(ctx context.Context, pUser pgtype.UUID) error
+	MarkAllNotificationsRead(ctx context.Context, userID string) (int64, error)
+	MarkEmailSent(ctx context.Context, id string) error
+	MarkPushSent(ctx context.Context, id string) error
+	MarkRestoring(ctx context.Context) error
+	ProvisionUser(ctx context.Context, arg ProvisionUserParams) (User, error)
+	PruneChangeEvents(ctx context.Context, createdBefore pgtype.Timestamptz) (int64, error)
+	PrunePushSubscription(ctx context.Context, id string) error
+	// Recomputes a user's totals from their transactions, after DeleteMonthlyTotals
+	RebuildMonthlyTotals(ctx context.Context, userID pgtype.UUID) error
 	RemoveBudgetCategory(ctx context.Context, id string) error
 	ResolveSyncOperation(ctx context.Context, arg ResolveSyncOperationParams) error
+	RestoreBudget(ctx context.Context, arg RestoreBudgetParams) (Budget, error)
+	RestoreBudgetCategory(ctx context.Context, data []byte) (BudgetCategory, error)
+	RestoreInsight(ctx context.Context, arg RestoreInsightParams) (int64, error)
+	RestoreSubscription(ctx context.Context, arg RestoreSubscriptionParams) (int64, error)
+	RestoreTransaction(ctx context.Context, arg RestoreTransactionParams) (Transaction, error)
+	RetryEmail(ctx context.Context, arg RetryEmailParams) error
+	RetryPush(ctx context.Context, arg RetryPushParams) error
+	RevokeAPIToken(ctx context.Context, arg RevokeAPITokenParams) (int64, error)
 	SetDefaultPaymentMethod(ctx context.Context, userID pgtype.UUID) error
+	SetNotificationRead(ctx context.Context, arg SetNotificationReadParams) (Notification, error)
+	TouchAPIToken(ctx context.Context, id string) error
+	TransferBudgetOwnership(ctx context.Context, arg TransferBudgetOwnershipParams) (Budget, error)
+	TransferPendingInvitationsOwner(ctx context.Context, arg TransferPendingInvitationsOwnerParams) error
+	TransferShareAccessOwner(ctx context.Context, arg TransferShareAccessOwnerParams) error
+	UpdateAlertRule(ctx context.Context, arg UpdateAlertRuleParams) (AlertRule, error)
+	UpdateAsset(ctx context.Context, arg UpdateAssetParams) (Asset, error)
 	UpdateBudget(ctx context.Context, arg UpdateBudgetParams) (Budget, error)
 	UpdateBudgetCategory(ctx context.Context, arg UpdateBudgetCategoryParams) (BudgetCategory, error)
 	UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error)
+	UpdateDebt(ctx context.Context, arg UpdateDebtParams) (Debt, error)
+	UpdateExpenseSplitShareAmount(ctx context.Context, arg UpdateExpenseSplitShareAmountParams) error
+	// Replaces every editable field; the handler merges the request with the source first
+	UpdateIncomeSource(ctx context.Context, arg UpdateIncomeSourceParams) (IncomeSource, error)
 	UpdateInvitationStatus(ctx context.Context, arg UpdateInvitationStatusParams) (ShareInvitation, error)
 	UpdatePaymentMethod(ctx context.Context, arg UpdatePaymentMethodParams) (PaymentMethod, error)
 	UpdateReflection(ctx context.Context, arg UpdateReflectionParams) (Reflection, error)
 	UpdateReflectionQuestion(ctx context.Context, arg UpdateReflectionQuestionParams) (ReflectionQuestion, error)
 	UpdateReflectionTemplate(ctx context.Context, arg UpdateReflectionTemplateParams) (ReflectionTemplate, error)
+	UpdateSavingsGoal(ctx context.Context, arg UpdateSavingsGoalParams) (SavingsGoal, error)
 	UpdateShareAccess(ctx context.Context, arg UpdateShareAccessParams) (ShareAccess, error)
 	UpdateSyncOperationStatus(ctx context.Context, arg UpdateSyncOperationStatusParams) (SyncOperation, error)
 	UpdateTransaction(ctx context.Context, arg UpdateTransactionParams) (Transaction, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpsertAssetValuation(ctx context.Context, arg UpsertAssetValuationParams) (AssetValuation, error)
+	UpsertClerkUser(ctx context.Context, arg UpsertClerkUserParams) (User, error)
+	UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) error
+	UpsertExpenseSplit(ctx context.Context, arg UpsertExpenseSplitParams) (ExpenseSplit, error)
+	UpsertIncomeExpectation(ctx context.Context, arg UpsertIncomeExpectationParams) (IncomeExpectation, error)
+	UpsertNetWorthSnapshot(ctx context.Context, arg UpsertNetWorthSnapshotParams) error
+	// Registering an endpoint again updates its keys. It only moves to another user when
+	// they present the keys it was registered with, i.e. they hold the device's
+	// subscription, so knowing an endpoint URL isn't enough to take it over. Returns no
+	// row otherwise.
+	UpsertPushSubscription(ctx context.Context, arg UpsertPushSubscriptionParams) (PushSubscription, error)
+	// Whether anything is recorded in the user's home currency, including deleted records
+	// a restore could bring back. Changing the home currency would leave them in the old one.
+	UserHasHomeAmounts(ctx context.Context, userID string) (bool, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -42,7 +42,7 @@ func (q *Queries) CheckBudgetAccess(ctx context.Context, arg CheckBudgetAccessPa
 const createShareAccess = `-- name: CreateShareAccess :one
 INSERT INTO share_access (budget_id, owner_id, shared_with_id, permission)
 VALUES ($1, $2, $3, $4)
-RETURNING id, budget_id, owner_id, shared_with_id, permission, created_at
+RETURNING id, budget_id, owner_id, shared_with_id, permission, created_at, updated_at
 `
 
 type CreateShareAccessParams struct {
@@ -67,6 +67,7 @@ func (q *Queries) CreateShareAccess(ctx context.Context, arg CreateShareAccessPa
 		&i.SharedWithID,
 		&i.Permission,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -263,7 +264,7 @@ func (q *Queries) GetPendingInvitationsByRecipient(ctx context.Context, recipien
 }
 
 const getShareAccessByBudget = `-- name: GetShareAccessByBudget :many
-SELECT sa.id, sa.budget_id, sa.owner_id, sa.shared_with_id, sa.permission, sa.created_at, u.name as shared_with_name, u.email as shared_with_email
+SELECT sa.id, sa.budget_id, sa.owner_id, sa.shared_with_id, sa.permission, sa.created_at, sa.updated_at, u.name as shared_with_name, u.email as shared_with_email
 FROM share_access sa
 JOIN users u ON sa.shared_with_id = u.id
 WHERE sa.budget_id = $1
@@ -276,6 +277,7 @@ type GetShareAccessByBudgetRow struct {
 	SharedWithID    pgtype.UUID        `json:"sharedWithId"`
 	Permission      string             `json:"permission"`
 	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
 	SharedWithName  pgtype.Text        `json:"sharedWithName"`
 	SharedWithEmail string             `json:"sharedWithEmail"`
 }
@@ -296,6 +298,7 @@ func (q *Queries) GetShareAccessByBudget(ctx context.Context, budgetID pgtype.UU
 			&i.SharedWithID,
 			&i.Permission,
 			&i.CreatedAt,
+			&i.UpdatedAt,
 			&i.SharedWithName,
 			&i.SharedWithEmail,
 		); err != nil {
@@ -310,7 +313,7 @@ func (q *Queries) GetShareAccessByBudget(ctx context.Context, budgetID pgtype.UU
 }
 
 const getShareAccessByID = `-- name: GetShareAccessByID :one
-SELECT id, budget_id, owner_id, shared_with_id, permission, created_at FROM share_access
+SELECT id, budget_id, owner_id, shared_with_id, permission, created_at, updated_at FROM share_access
 WHERE id = $1
 LIMIT 1
 `
@@ -325,12 +328,13 @@ func (q *Queries) GetShareAccessByID(ctx context.Context, id string) (ShareAcces
 		&i.SharedWithID,
 		&i.Permission,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
 const getShareAccessForBudgetAndUser = `-- name: GetShareAccessForBudgetAndUser :one
-SELECT sa.id, sa.budget_id, sa.owner_id, sa.shared_with_id, sa.permission, sa.created_at
+SELECT sa.id, sa.budget_id, sa.owner_id, sa.shared_with_id, sa.permission, sa.created_at, sa.updated_at
 FROM share_access sa
 WHERE sa.budget_id = $1 AND sa.shared_with_id = $2
 LIMIT 1
@@ -351,12 +355,13 @@ func (q *Queries) GetShareAccessForBudgetAndUser(ctx context.Context, arg GetSha
 		&i.SharedWithID,
 		&i.Permission,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
 const getShareAccessForUser = `-- name: GetShareAccessForUser :many
-SELECT sa.id, sa.budget_id, sa.owner_id, sa.shared_with_id, sa.permission, sa.created_at, o.name as owner_name, o.email as owner_email, b.name as budget_name, b.month as budget_month
+SELECT sa.id, sa.budget_id, sa.owner_id, sa.shared_with_id, sa.permission, sa.created_at, sa.updated_at, o.name as owner_name, o.email as owner_email, b.name as budget_name, b.month as budget_month
 FROM share_access sa
 JOIN users o ON sa.owner_id = o.id
 JOIN budgets b ON sa.budget_id = b.id
@@ -371,6 +376,7 @@ type GetShareAccessForUserRow struct {
 	SharedWithID pgtype.UUID        `json:"sharedWithId"`
 	Permission   string             `json:"permission"`
 	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
 	OwnerName    pgtype.Text        `json:"ownerName"`
 	OwnerEmail   string             `json:"ownerEmail"`
 	BudgetName   pgtype.Text        `json:"budgetName"`
@@ -393,6 +399,7 @@ func (q *Queries) GetShareAccessForUser(ctx context.Context, sharedWithID pgtype
 			&i.SharedWithID,
 			&i.Permission,
 			&i.CreatedAt,
+			&i.UpdatedAt,
 			&i.OwnerName,
 			&i.OwnerEmail,
 			&i.BudgetName,
@@ -408,6 +415,70 @@ func (q *Queries) GetShareAccessForUser(ctx context.Context, sharedWithID pgtype
 	return items, nil
 }
 
+const transferBudgetOwnership = `-- name: TransferBudgetOwnership :one
+UPDATE budgets
+SET user_id = $2, updated_at = NOW()
+WHERE id = $1 AND deleted = false
+RETURNING id, user_id, name, month, total_limit, created_at, updated_at, deleted, envelope_mode, strict_envelopes, period_type, period_end
+`
+
+type TransferBudgetOwnershipParams struct {
+	ID     string      `json:"id"`
+	UserID pgtype.UUID `json:"userId"`
+}
+
+func (q *Queries) TransferBudgetOwnership(ctx context.Context, arg TransferBudgetOwnershipParams) (Budget, error) {
+	row := q.db.QueryRow(ctx, transferBudgetOwnership, arg.ID, arg.UserID)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Month,
+		&i.TotalLimit,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+		&i.EnvelopeMode,
+		&i.StrictEnvelopes,
+		&i.PeriodType,
+		&i.PeriodEnd,
+	)
+	return i, err
+}
+
+const transferPendingInvitationsOwner = `-- name: TransferPendingInvitationsOwner :exec
+UPDATE share_invitations
+SET owner_id = $2, updated_at = NOW()
+WHERE budget_id = $1 AND status = 'pending'
+`
+
+type TransferPendingInvitationsOwnerParams struct {
+	BudgetID pgtype.UUID `json:"budgetId"`
+	OwnerID  pgtype.UUID `json:"ownerId"`
+}
+
+func (q *Queries) TransferPendingInvitationsOwner(ctx context.Context, arg TransferPendingInvitationsOwnerParams) error {
+	_, err := q.db.Exec(ctx, transferPendingInvitationsOwner, arg.BudgetID, arg.OwnerID)
+	return err
+}
+
+const transferShareAccessOwner = `-- name: TransferShareAccessOwner :exec
+UPDATE share_access
+SET owner_id = $2, updated_at = NOW()
+WHERE budget_id = $1
+`
+
+type TransferShareAccessOwnerParams struct {
+	BudgetID pgtype.UUID `json:"budgetId"`
+	OwnerID  pgtype.UUID `json:"ownerId"`
+}
+
+func (q *Queries) TransferShareAccessOwner(ctx context.Context, arg TransferShareAccessOwnerParams) error {
+	_, err := q.db.Exec(ctx, transferShareAccessOwner, arg.BudgetID, arg.OwnerID)
+	return err
+}
+
 const updateInvitationStatus = `-- name: UpdateInvitationStatus :one
 UPDATE share_invitations
 SET status = $1, updated_at = NOW()
@@ -441,7 +512,7 @@ const updateShareAccess = `-- name: UpdateShareAccess :one
 UPDATE share_access
 SET permission = $1, updated_at = NOW()
 WHERE id = $2
-RETURNING id, budget_id, owner_id, shared_with_id, permission, created_at
+RETURNING id, budget_id, owner_id, shared_with_id, permission, created_at, updated_at
 `
 
 type UpdateShareAccessParams struct {
@@ -459,6 +530,7 @@ func (q *Queries) UpdateShareAccess(ctx context.Context, arg UpdateShareAccessPa
 		&i.SharedWithID,
 		&i.Permission,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -89,9 +89,50 @@ func (q *Queries) DeleteSyncedOperations(ctx context.Context, userID pgtype.UUID
 	return err
 }
 
+const getBudgetCategoriesSince = `-- name: GetBudgetCategoriesSince :many
+SELECT bc.id, bc.budget_id, bc.category_id, bc.limit_amount, bc.created_at, bc.updated_at FROM budget_categories bc
+JOIN budgets b ON bc.budget_id = b.id
+WHERE b.user_id = $1
+  AND b.deleted = false
+  AND ($2 IS NULL OR bc.updated_at > $2)
+ORDER BY bc.updated_at ASC
+`
+
+type GetBudgetCategoriesSinceParams struct {
+	UserID  pgtype.UUID `json:"userId"`
+	Column2 interface{} `json:"column2"`
+}
+
+func (q *Queries) GetBudgetCategoriesSince(ctx context.Context, arg GetBudgetCategoriesSinceParams) ([]BudgetCategory, error) {
+	rows, err := q.db.Query(ctx, getBudgetCategoriesSince, arg.UserID, arg.Column2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BudgetCategory{}
+	for rows.Next() {
+		var i BudgetCategory
+		if err := rows.Scan(
+			&i.ID,
+			&i.BudgetID,
+			&i.CategoryID,
+			&i.LimitAmount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBudgetsSince = `-- name: GetBudgetsSince :many
 
-SELECT id, user_id, name, month, total_limit, created_at, updated_at, deleted FROM budgets
+SELECT id, user_id, name, month, total_limit, created_at, updated_at, deleted, envelope_mode, strict_envelopes, period_type, period_end FROM budgets
 WHERE user_id = $1
   AND deleted = false
   AND ($2 IS NULL OR updated_at > $2)
@@ -122,6 +163,10 @@ func (q *Queries) GetBudgetsSince(ctx context.Context, arg GetBudgetsSinceParams
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Deleted,
+			&i.EnvelopeMode,
+			&i.StrictEnvelopes,
+			&i.PeriodType,
+			&i.PeriodEnd,
 		); err != nil {
 			return nil, err
 		}
@@ -332,7 +377,7 @@ func (q *Queries) GetSyncOperationsByUser(ctx context.Context, arg GetSyncOperat
 }
 
 const getTransactionsSince = `-- name: GetTransactionsSince :many
-SELECT id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted FROM transactions
+SELECT id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted, currency, home_amount, exchange_rate, goal_id, debt_id FROM transactions
 WHERE user_id = $1
   AND deleted = false
   AND ($2 IS NULL OR updated_at > $2)
@@ -370,6 +415,11 @@ func (q *Queries) GetTransactionsSince(ctx context.Context, arg GetTransactionsS
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Deleted,
+			&i.Currency,
+			&i.HomeAmount,
+			&i.ExchangeRate,
+			&i.GoalID,
+			&i.DebtID,
 		); err != nil {
 			return nil, err
 		}
@@ -15,14 +15,16 @@ const createTransaction = `-- name: CreateTransaction :one
 INSERT INTO transactions (
     user_id, budget_id, category_id, payment_method_id, 
     amount, type, is_transfer, transfer_to_account_id,
-    description, transaction_date, is_recurring, recurrence_pattern
+    description, transaction_date, is_recurring, recurrence_pattern,
+    currency, home_amount, exchange_rate, goal_id, debt_id
 )
 VALUES (
     $1, $2, $3, $4, 
     $5, $6, $7, $8, 
-    $9, $10, $11, $12
+    $9, $10, $11, $12,
+    $13, $14, $15, $16, $17
 )
-RETURNING id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted
+RETURNING id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted, currency, home_amount, exchange_rate, goal_id, debt_id
 `
 
 type CreateTransactionParams struct {
@@ -38,6 +40,11 @@ type CreateTransactionParams struct {
 	TransactionDate     pgtype.Date    `json:"transactionDate"`
 	IsRecurring         pgtype.Bool    `json:"isRecurring"`
 	RecurrencePattern   []byte         `json:"recurrencePattern"`
+	Currency            string         `json:"currency"`
+	HomeAmount          pgtype.Numeric `json:"homeAmount"`
+	ExchangeRate        pgtype.Numeric `json:"exchangeRate"`
+	GoalID              pgtype.UUID    `json:"goalId"`
+	DebtID              pgtype.UUID    `json:"debtId"`
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
@@ -54,6 +61,11 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		arg.TransactionDate,
 		arg.IsRecurring,
 		arg.RecurrencePattern,
+		arg.Currency,
+		arg.HomeAmount,
+		arg.ExchangeRate,
+		arg.GoalID,
+		arg.DebtID,
 	)
 	var i Transaction
 	err := row.Scan(
@@ -73,6 +85,11 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
+		&i.Currency,
+		&i.HomeAmount,
+		&i.ExchangeRate,
+		&i.GoalID,
+		&i.DebtID,
 	)
 	return i, err
 }
@@ -89,14 +106,12 @@ func (q *Queries) DeleteTransaction(ctx context.Context, id string) error {
 }
 
 const getCategorySpent = `-- name: GetCategorySpent :one
-SELECT COALESCE(SUM(t.amount), 0) as total_spent
+SELECT COALESCE(SUM(t.home_amount), 0) as total_spent
 FROM transactions t
 WHERE t.budget_id = $1
   AND t.category_id = $2
   AND t.type = 'expense'
   AND t.deleted = false
-  AND t.transaction_date >= (SELECT month FROM budgets WHERE id = $1)
-  AND t.transaction_date < ((SELECT month FROM budgets WHERE id = $1) + INTERVAL '1 month')
 `
 
 type GetCategorySpentParams struct {
@@ -112,7 +127,7 @@ func (q *Queries) GetCategorySpent(ctx context.Context, arg GetCategorySpentPara
 }
 
 const getTransactionByID = `-- name: GetTransactionByID :one
-SELECT id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted FROM transactions
+SELECT id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted, currency, home_amount, exchange_rate, goal_id, debt_id FROM transactions
 WHERE id = $1 AND deleted = false
 LIMIT 1
 `
@@ -137,12 +152,17 @@ func (q *Queries) GetTransactionByID(ctx context.Context, id string) (Transactio
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
+		&i.Currency,
+		&i.HomeAmount,
+		&i.ExchangeRate,
+		&i.GoalID,
+		&i.DebtID,
 	)
 	return i, err
 }
 
 const getTransactionsByBudget = `-- name: GetTransactionsByBudget :many
-SELECT id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted FROM transactions
+SELECT id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted, currency, home_amount, exchange_rate, goal_id, debt_id FROM transactions
 WHERE budget_id = $1 AND deleted = false
 ORDER BY transaction_date DESC
 `
@@ -173,6 +193,11 @@ func (q *Queries) GetTransactionsByBudget(ctx context.Context, budgetID pgtype.U
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Deleted,
+			&i.Currency,
+			&i.HomeAmount,
+			&i.ExchangeRate,
+			&i.GoalID,
+			&i.DebtID,
 		); err != nil {
 			return nil, err
 		}
@@ -185,7 +210,7 @@ func (q *Queries) GetTransactionsByBudget(ctx context.Context, budgetID pgtype.U
 }
 
 const listTransactions = `-- name: ListTransactions :many
-SELECT id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted FROM transactions
+SELECT id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted, currency, home_amount, exchange_rate, goal_id, debt_id FROM transactions
 WHERE user_id = $1 
   AND deleted = false
   AND ($2::date IS NULL OR transaction_date >= $2)
@@ -240,6 +265,11 @@ func (q *Queries) ListTransactions(ctx context.Context, arg ListTransactionsPara
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Deleted,
+			&i.Currency,
+			&i.HomeAmount,
+			&i.ExchangeRate,
+			&i.GoalID,
+			&i.DebtID,
 		); err != nil {
 			return nil, err
 		}
@@ -265,9 +295,14 @@ SET
     transaction_date = COALESCE($10, transaction_date),
     is_recurring = COALESCE($11, is_recurring),
     recurrence_pattern = COALESCE($12, recurrence_pattern),
+    currency = COALESCE($13, currency),
+    home_amount = COALESCE($14, home_amount),
+    exchange_rate = COALESCE($15, exchange_rate),
+    goal_id = COALESCE($16, goal_id),
+    debt_id = COALESCE($17, debt_id),
     updated_at = NOW()
 WHERE id = $1 AND deleted = false
-RETURNING id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted
+RETURNING id, user_id, budget_id, category_id, payment_method_id, amount, type, is_transfer, transfer_to_account_id, description, transaction_date, is_recurring, recurrence_pattern, created_at, updated_at, deleted, currency, home_amount, exchange_rate, goal_id, debt_id
 `
 
 type UpdateTransactionParams struct {
@@ -283,6 +318,11 @@ type UpdateTransactionParams struct {
 	TransactionDate     pgtype.Date    `json:"transactionDate"`
 	IsRecurring         pgtype.Bool    `json:"isRecurring"`
 	RecurrencePattern   []byte         `json:"recurrencePattern"`
+	Currency            pgtype.Text    `json:"currency"`
+	HomeAmount          pgtype.Numeric `json:"homeAmount"`
+	ExchangeRate        pgtype.Numeric `json:"exchangeRate"`
+	GoalID              pgtype.UUID    `json:"goalId"`
+	DebtID              pgtype.UUID    `json:"debtId"`
 }
 
 func (q *Queries) UpdateTransaction(ctx context.Context, arg UpdateTransactionParams) (Transaction, error) {
@@ -299,6 +339,11 @@ func (q *Queries) UpdateTransaction(ctx context.Context, arg UpdateTransactionPa
 		arg.TransactionDate,
 		arg.IsRecurring,
 		arg.RecurrencePattern,
+		arg.Currency,
+		arg.HomeAmount,
+		arg.ExchangeRate,
+		arg.GoalID,
+		arg.DebtID,
 	)
 	var i Transaction
 	err := row.Scan(
@@ -318,6 +363,11 @@ func (q *Queries) UpdateTransaction(ctx context.Context, arg UpdateTransactionPa
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Deleted,
+		&i.Currency,
+		&i.HomeAmount,
+		&i.ExchangeRate,
+		&i.GoalID,
+		&i.DebtID,
 	)
 	return i, err
 }
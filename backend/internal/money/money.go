@@ -0,0 +1,362 @@
+// Package money provides an exact decimal type for monetary amounts.
+//
+// Amounts are stored as an integer number of hundredths of the currency unit,
+// matching the DECIMAL(12, 2) columns they are read from and written to, so
+// arithmetic on them never drifts the way float64 sums of centavos do.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Scale is the number of decimal places amounts are stored with
+const Scale = 2
+
+// MaxStored is the largest magnitude a DECIMAL(12, 2) column holds, 9999999999.99
+const MaxStored Amount = 999_999_999_999
+
+// Amount is an exact monetary amount in hundredths of the currency unit
+type Amount int64
+
+// Errors returned when parsing or converting amounts
+var (
+	ErrInvalidAmount = errors.New("invalid amount")
+	ErrTooPrecise    = fmt.Errorf("amount has more than %d decimal places", Scale)
+	ErrOutOfRange    = errors.New("amount is out of range")
+
+	ErrInvalidCurrency = errors.New("currency must be a 3-letter ISO 4217 code")
+)
+
+// zeroDecimalCurrencies have no minor unit (ISO 4217 exponent 0)
+var zeroDecimalCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true,
+	"KMF": true, "KRW": true, "PYG": true, "RWF": true, "UGX": true, "UYI": true,
+	"VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+var pow10 = big.NewInt(10)
+
+// FromCents returns the amount of the given number of hundredths
+func FromCents(cents int64) Amount {
+	return Amount(cents)
+}
+
+// Cents returns the amount as an integer number of hundredths
+func (a Amount) Cents() int64 {
+	return int64(a)
+}
+
+// Parse parses a plain decimal string such as "1234.5" or "-0.25".
+// More than Scale decimal places is an error rather than being rounded away,
+// as is a magnitude above MaxStored, which could not be saved.
+func Parse(s string) (Amount, error) {
+	units, err := ParseUnits(s, Scale)
+	if err != nil {
+		return 0, err
+	}
+	a := Amount(units)
+	if !a.Storable() {
+		return 0, ErrOutOfRange
+	}
+	return a, nil
+}
+
+// ParseUnits parses a plain decimal string into an integer count of 10^-scale units,
+// for decimal columns other than money. More than scale decimal places is an error.
+func ParseUnits(s string, scale int) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidAmount
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, ErrInvalidAmount
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, ErrInvalidAmount
+	}
+
+	// Trailing zeros beyond the scale don't add precision
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > scale {
+		if scale == Scale {
+			return 0, ErrTooPrecise
+		}
+		return 0, precisionError(scale)
+	}
+	frac += strings.Repeat("0", scale-len(frac))
+
+	if whole == "" {
+		whole = "0"
+	}
+	units, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, ErrOutOfRange
+	}
+	if neg {
+		units = -units
+	}
+	return units, nil
+}
+
+// MustParse is like Parse but panics on error. Intended for constants.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(fmt.Sprintf("money: %q: %v", s, err))
+	}
+	return a
+}
+
+// String formats the amount with exactly Scale decimal places, e.g. "1234.50"
+func (a Amount) String() string {
+	units := int64(a)
+	sign := ""
+	// Work on the magnitude as uint64 so math.MinInt64 formats correctly
+	mag := uint64(units)
+	if units < 0 {
+		sign = "-"
+		mag = uint64(-(units + 1)) + 1
+	}
+	div := uint64(math.Pow10(Scale))
+	return fmt.Sprintf("%s%d.%0*d", sign, mag/div, Scale, mag%div)
+}
+
+// Storable reports whether the amount fits a DECIMAL(12, 2) column
+func (a Amount) Storable() bool {
+	return a >= -MaxStored && a <= MaxStored
+}
+
+// Float64 returns the amount as a float64, for ratios and display only
+func (a Amount) Float64() float64 {
+	return float64(a) / math.Pow10(Scale)
+}
+
+// MarshalJSON encodes the amount as a decimal string so clients never see float rounding
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + a.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts a decimal string ("12.50") or a plain JSON number (12.5).
+// Numbers are parsed from their literal text, never through float64.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Numeric converts the amount for use as a query parameter
+func (a Amount) Numeric() pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(int64(a)), Exp: -Scale, Valid: true}
+}
+
+// NumericPtr converts an optional amount, leaving the parameter NULL when a is nil
+func NumericPtr(a *Amount) pgtype.Numeric {
+	if a == nil {
+		return pgtype.Numeric{}
+	}
+	return a.Numeric()
+}
+
+// FromNumeric converts a money column. NULL reads as zero.
+// Money columns are DECIMAL(12, 2), which always fits; use FromAny for aggregates.
+func FromNumeric(n pgtype.Numeric) Amount {
+	a, _ := fromNumeric(n)
+	return a
+}
+
+// FromNumericPtr converts a nullable money column, returning nil for NULL
+func FromNumericPtr(n pgtype.Numeric) *Amount {
+	if !n.Valid {
+		return nil
+	}
+	a := FromNumeric(n)
+	return &a
+}
+
+// FromAny converts the result of an aggregate (such as COALESCE(SUM(amount), 0)),
+// which pgx returns as an untyped value. NULL reads as zero.
+func FromAny(v interface{}) (Amount, error) {
+	switch val := v.(type) {
+	case nil:
+		return 0, nil
+	case pgtype.Numeric:
+		return fromNumeric(val)
+	case int64:
+		return fromWhole(val)
+	case int32:
+		return fromWhole(int64(val))
+	case string:
+		units, err := ParseUnits(val, Scale)
+		return Amount(units), err
+	case []byte:
+		units, err := ParseUnits(string(val), Scale)
+		return Amount(units), err
+	default:
+		return 0, fmt.Errorf("%w: unsupported type %T", ErrInvalidAmount, v)
+	}
+}
+
+// Round rounds a float64 to the nearest hundredth, half away from zero.
+// Only for derived values such as averages and projections, never for stored amounts.
+func Round(f float64) Amount {
+	return Amount(math.Round(f * math.Pow10(Scale)))
+}
+
+// Div divides the amount by n, rounding to the nearest hundredth with halves away
+// from zero, for averages and per-period shares of exact totals. n must not be zero.
+func (a Amount) Div(n int64) Amount {
+	negative := (a < 0) != (n < 0)
+	q, r := int64(a)/n, int64(a)%n
+	if r < 0 {
+		r = -r
+	}
+	if n < 0 {
+		n = -n
+	}
+	if 2*r >= n {
+		if negative {
+			q--
+		} else {
+			q++
+		}
+	}
+	return Amount(q)
+}
+
+// Precision returns the number of decimal places amounts in currency may have.
+// Currencies with three-decimal minor units are limited to Scale by storage.
+func Precision(currency string) int {
+	if zeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return 0
+	}
+	return Scale
+}
+
+// NormalizeCurrency validates a currency code and returns it upper-cased
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", ErrInvalidCurrency
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < 'A' || code[i] > 'Z' {
+			return "", ErrInvalidCurrency
+		}
+	}
+	return code, nil
+}
+
+// CheckPrecision returns an error if the amount has more decimal places than currency allows
+func (a Amount) CheckPrecision(currency string) error {
+	places := Precision(currency)
+	if places >= Scale {
+		return nil
+	}
+	if int64(a)%int64(math.Pow10(Scale-places)) != 0 {
+		return fmt.Errorf("%s amounts can have at most %d decimal places", strings.ToUpper(currency), places)
+	}
+	return nil
+}
+
+// Percent returns part as a percentage of whole, or 0 when whole is zero
+func Percent(part, whole Amount) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+// NumericUnits converts a decimal column to an integer count of 10^-scale units,
+// rounding extra places half away from zero. NULL reads as zero.
+func NumericUnits(n pgtype.Numeric, scale int) (int64, error) {
+	if !n.Valid {
+		return 0, nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite || n.Int == nil {
+		return 0, ErrInvalidAmount
+	}
+
+	units := new(big.Int).Set(n.Int)
+	shift := int64(n.Exp) + int64(scale)
+	if shift >= 0 {
+		units.Mul(units, new(big.Int).Exp(pow10, big.NewInt(shift), nil))
+	} else {
+		// More decimal places than we keep (e.g. an AVG); round half away from zero
+		div := new(big.Int).Exp(pow10, big.NewInt(-shift), nil)
+		q, rem := new(big.Int).QuoRem(units, div, new(big.Int))
+		if rem.Abs(rem).Mul(rem, big.NewInt(2)).Cmp(div) >= 0 {
+			if units.Sign() < 0 {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+		units = q
+	}
+
+	if !units.IsInt64() {
+		return 0, ErrOutOfRange
+	}
+	return units.Int64(), nil
+}
+
+func fromNumeric(n pgtype.Numeric) (Amount, error) {
+	units, err := NumericUnits(n, Scale)
+	return Amount(units), err
+}
+
+func fromWhole(units int64) (Amount, error) {
+	scale := int64(math.Pow10(Scale))
+	if units > math.MaxInt64/scale || units < math.MinInt64/scale {
+		return 0, ErrOutOfRange
+	}
+	return Amount(units * scale), nil
+}
+
+// precisionError is ErrTooPrecise for a scale other than Scale
+type precisionError int
+
+func (e precisionError) Error() string {
+	return fmt.Sprintf("value has more than %d decimal places", int(e))
+}
+
+func (e precisionError) Is(target error) bool {
+	return target == ErrTooPrecise
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,328 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+		err  error
+	}{
+		{"0", 0, nil},
+		{"12", 1200, nil},
+		{"12.5", 1250, nil},
+		{"12.50", 1250, nil},
+		{"12.500", 1250, nil},
+		{".25", 25, nil},
+		{"7.", 700, nil},
+		{"+3.01", 301, nil},
+		{"-0.25", -25, nil},
+		{"-1234.56", -123456, nil},
+		{" 42.10 ", 4210, nil},
+		{"9999999999.99", MaxStored, nil},
+		{"-9999999999.99", -MaxStored, nil},
+
+		{"", 0, ErrInvalidAmount},
+		{"-", 0, ErrInvalidAmount},
+		{".", 0, ErrInvalidAmount},
+		{"1,000", 0, ErrInvalidAmount},
+		{"1e3", 0, ErrInvalidAmount},
+		{"12.3.4", 0, ErrInvalidAmount},
+		{"--1", 0, ErrInvalidAmount},
+		{"NaN", 0, ErrInvalidAmount},
+		{"0.125", 0, ErrTooPrecise},
+		{"-0.001", 0, ErrTooPrecise},
+
+		// DECIMAL(12, 2) holds at most ten whole digits
+		{"10000000000", 0, ErrOutOfRange},
+		{"10000000000.00", 0, ErrOutOfRange},
+		{"-10000000000", 0, ErrOutOfRange},
+		{"99999999999999999999", 0, ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseUnitsScale(t *testing.T) {
+	got, err := ParseUnits("33.3333", 4)
+	if err != nil || got != 333333 {
+		t.Fatalf("ParseUnits(33.3333, 4) = %d, %v", got, err)
+	}
+	_, err = ParseUnits("33.33333", 4)
+	if !errors.Is(err, ErrTooPrecise) {
+		t.Fatalf("ParseUnits(33.33333, 4) error = %v, want ErrTooPrecise", err)
+	}
+	if err.Error() != "value has more than 4 decimal places" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{1250, "12.50"},
+		{-123456, "-1234.56"},
+		{MaxStored, "9999999999.99"},
+		{math.MaxInt64, "92233720368547758.07"},
+		{math.MinInt64, "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", int64(tt.in), got, tt.want)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	for _, s := range []string{"0.00", "0.01", "-0.01", "19.99", "-4500.10", "9999999999.99"} {
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", s, err)
+		}
+		if got := a.String(); got != s {
+			t.Errorf("Parse(%q).String() = %q", s, got)
+		}
+	}
+}
+
+func numeric(units int64, exp int32) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(units), Exp: exp, Valid: true}
+}
+
+func TestFromNumeric(t *testing.T) {
+	tests := []struct {
+		name string
+		in   pgtype.Numeric
+		want Amount
+	}{
+		{"null", pgtype.Numeric{}, 0},
+		{"column scale", numeric(1250, -2), 1250},
+		{"whole number", numeric(12, 0), 1200},
+		{"positive exponent", numeric(5, 3), 500000},
+		{"negative", numeric(-1999, -2), -1999},
+
+		// Aggregates such as AVG come back with more places; round half away from zero
+		{"round down", numeric(12344, -3), 1234},
+		{"half rounds up", numeric(12345, -3), 1235},
+		{"round up", numeric(12346, -3), 1235},
+		{"negative half rounds away from zero", numeric(-12345, -3), -1235},
+		{"negative round toward zero", numeric(-12344, -3), -1234},
+		{"just under half", numeric(1234499999, -8), 1234},
+		{"many places", numeric(333333333333, -12), 33},
+		{"tiny", numeric(4, -3), 0},
+		{"tiny half", numeric(5, -3), 1},
+		{"tiny negative half", numeric(-5, -3), -1},
+	}
+	for _, tt := range tests {
+		if got := FromNumeric(tt.in); got != tt.want {
+			t.Errorf("%s: FromNumeric = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFromNumericPtr(t *testing.T) {
+	if got := FromNumericPtr(pgtype.Numeric{}); got != nil {
+		t.Errorf("FromNumericPtr(NULL) = %v, want nil", *got)
+	}
+	if got := FromNumericPtr(numeric(-75, -2)); got == nil || *got != -75 {
+		t.Errorf("FromNumericPtr(-0.75) = %v, want -75", got)
+	}
+}
+
+func TestNumericRoundTrip(t *testing.T) {
+	for _, a := range []Amount{0, 1, -1, 1250, -123456, MaxStored, -MaxStored} {
+		if got := FromNumeric(a.Numeric()); got != a {
+			t.Errorf("FromNumeric(%s.Numeric()) = %s", a, got)
+		}
+	}
+	if got := NumericPtr(nil); got.Valid {
+		t.Error("NumericPtr(nil) should be NULL")
+	}
+}
+
+func TestFromAny(t *testing.T) {
+	// Sums of many stored amounts exceed the column limit but must still read exactly
+	sum := new(big.Int).Mul(big.NewInt(int64(MaxStored)), big.NewInt(1000))
+	tests := []struct {
+		name string
+		in   interface{}
+		want Amount
+		err  error
+	}{
+		{"null", nil, 0, nil},
+		{"numeric", numeric(1250, -2), 1250, nil},
+		{"large sum", pgtype.Numeric{Int: sum, Exp: -2, Valid: true}, MaxStored * 1000, nil},
+		{"int64", int64(12), 1200, nil},
+		{"int32", int32(-3), -300, nil},
+		{"string", "12.34", 1234, nil},
+		{"string above column limit", "99999999999999.99", 9999999999999999, nil},
+		{"bytes", []byte("0.5"), 50, nil},
+		{"int64 overflow", int64(math.MaxInt64), 0, ErrOutOfRange},
+		{"numeric overflow", pgtype.Numeric{Int: new(big.Int).Lsh(big.NewInt(1), 70), Exp: 0, Valid: true}, 0, ErrOutOfRange},
+		{"NaN", pgtype.Numeric{NaN: true, Valid: true}, 0, ErrInvalidAmount},
+		{"float", 1.5, 0, ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		got, err := FromAny(tt.in)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: FromAny error = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: FromAny unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: FromAny = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSumManyAmounts(t *testing.T) {
+	// 0.10 a million times is exactly 100000.00, where a float64 sum drifts
+	var total Amount
+	dime := MustParse("0.10")
+	for i := 0; i < 1_000_000; i++ {
+		total += dime
+	}
+	if total.String() != "100000.00" {
+		t.Errorf("sum of dimes = %s, want 100000.00", total)
+	}
+
+	// A year of daily maximum-size amounts stays exact and within int64
+	var year Amount
+	for i := 0; i < 365; i++ {
+		year += MaxStored
+	}
+	if want := "3649999999996.35"; year.String() != want {
+		t.Errorf("sum of 365 maximum amounts = %s, want %s", year, want)
+	}
+	if year.Storable() {
+		t.Error("a sum above the column limit should not be storable")
+	}
+}
+
+func TestStorable(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want bool
+	}{
+		{0, true},
+		{MaxStored, true},
+		{-MaxStored, true},
+		{MaxStored + 1, false},
+		{-MaxStored - 1, false},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Storable(); got != tt.want {
+			t.Errorf("Amount(%d).Storable() = %v, want %v", int64(tt.in), got, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+		err  error
+	}{
+		{`"12.50"`, 1250, nil},
+		{`12.5`, 1250, nil},
+		{`-0.01`, -1, nil},
+		{`0.1`, 10, nil},
+		{`"1.005"`, 0, ErrTooPrecise},
+		{`1e2`, 0, ErrInvalidAmount},
+		{`10000000000`, 0, ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		var a Amount
+		err := a.UnmarshalJSON([]byte(tt.in))
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("UnmarshalJSON(%s) error = %v, want %v", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || a != tt.want {
+			t.Errorf("UnmarshalJSON(%s) = %d, %v, want %d", tt.in, a, err, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		amount string
+		rate   string
+		want   string
+	}{
+		{"100.00", "56.25", "5625.00"},
+		{"10.00", "0.0178", "0.18"},
+		{"0.01", "0.5", "0.01"}, // half rounds away from zero
+		{"-0.01", "0.5", "-0.01"},
+		{"0.01", "0.4999", "0.00"},
+	}
+	for _, tt := range tests {
+		rate, err := ParseRate(tt.rate)
+		if err != nil {
+			t.Fatalf("ParseRate(%q): %v", tt.rate, err)
+		}
+		got, err := MustParse(tt.amount).Convert(rate)
+		if err != nil {
+			t.Fatalf("Convert(%s, %s): %v", tt.amount, tt.rate, err)
+		}
+		if got.String() != tt.want {
+			t.Errorf("Convert(%s, %s) = %s, want %s", tt.amount, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestDiv(t *testing.T) {
+	tests := []struct {
+		amount string
+		n      int64
+		want   string
+	}{
+		{"100.00", 4, "25.00"},
+		{"100.00", 3, "33.33"},
+		{"200.00", 3, "66.67"},
+		{"0.05", 2, "0.03"}, // half rounds away from zero
+		{"-0.05", 2, "-0.03"},
+		{"0.05", -2, "-0.03"},
+		{"0.01", 3, "0.00"},
+		{"1199.99", 12, "100.00"},
+		{"9999999999.99", 1, "9999999999.99"},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.amount).Div(tt.n); got.String() != tt.want {
+			t.Errorf("Div(%s, %d) = %s, want %s", tt.amount, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RateScale is the number of decimal places exchange rates are stored with
+const RateScale = 8
+
+// ErrInvalidRate is returned for rates that aren't positive decimals
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// Rate is an exact exchange rate: units of the quote currency per unit of the base currency
+type Rate struct {
+	r *big.Rat
+}
+
+// OneRate is the rate between a currency and itself
+func OneRate() Rate {
+	return Rate{r: big.NewRat(1, 1)}
+}
+
+// ParseRate parses a positive decimal string such as "56.125".
+// More than RateScale decimal places is an error rather than being rounded away.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	whole, frac, _ := strings.Cut(s, ".")
+	if (whole == "" && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return Rate{}, ErrInvalidRate
+	}
+	if len(strings.TrimRight(frac, "0")) > RateScale {
+		return Rate{}, fmt.Errorf("%w: more than %d decimal places", ErrInvalidRate, RateScale)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{r: r}, nil
+}
+
+// RateFromNumeric converts an exchange rate column
+func RateFromNumeric(n pgtype.Numeric) (Rate, error) {
+	if !n.Valid || n.NaN || n.InfinityModifier != pgtype.Finite || n.Int == nil {
+		return Rate{}, ErrInvalidRate
+	}
+	r := new(big.Rat).SetInt(n.Int)
+	exp := new(big.Int).Exp(pow10, big.NewInt(int64(abs32(n.Exp))), nil)
+	if n.Exp < 0 {
+		r.Quo(r, new(big.Rat).SetInt(exp))
+	} else {
+		r.Mul(r, new(big.Rat).SetInt(exp))
+	}
+	if r.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{r: r}, nil
+}
+
+// IsZero reports whether the rate is unset
+func (r Rate) IsZero() bool {
+	return r.r == nil
+}
+
+// Inverse returns the rate in the opposite direction, rounded to RateScale places
+// so that what is stored is exactly what amounts are converted with
+func (r Rate) Inverse() Rate {
+	inv := new(big.Rat).Inv(r.r)
+	return Rate{r: new(big.Rat).SetInt(roundQuo(inv, RateScale))}.shift(RateScale)
+}
+
+// Numeric converts the rate for use as a query parameter, rounded to RateScale places
+func (r Rate) Numeric() pgtype.Numeric {
+	return pgtype.Numeric{Int: roundQuo(r.r, RateScale), Exp: -RateScale, Valid: true}
+}
+
+// String formats the rate without trailing zeros, e.g. "56.125"
+func (r Rate) String() string {
+	if r.r == nil {
+		return "0"
+	}
+	s := r.r.FloatString(RateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON encodes the rate as a decimal string
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + r.String() + `"`), nil
+}
+
+// Convert returns a in the quote currency of rate, rounded half away from zero
+// to the nearest hundredth
+func (a Amount) Convert(rate Rate) (Amount, error) {
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(a)), rate.r)
+	units := roundQuo(converted, 0)
+	if !units.IsInt64() {
+		return 0, ErrOutOfRange
+	}
+	return Amount(units.Int64()), nil
+}
+
+// shift divides the rate by 10^places
+func (r Rate) shift(places int) Rate {
+	div := new(big.Int).Exp(pow10, big.NewInt(int64(places)), nil)
+	return Rate{r: r.r.Quo(r.r, new(big.Rat).SetInt(div))}
+}
+
+// roundQuo returns x * 10^places rounded half away from zero to an integer
+func roundQuo(x *big.Rat, places int) *big.Int {
+	scaled := new(big.Rat).Mul(x, new(big.Rat).SetInt(new(big.Int).Exp(pow10, big.NewInt(int64(places)), nil)))
+	q, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if rem.Abs(rem).Mul(rem, big.NewInt(2)).Cmp(scaled.Denom()) >= 0 {
+		if scaled.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func abs32(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,225 @@
+// Package networth values a user's whole financial position: payment method
+// balances, manually tracked assets and debts. Statements are computed on request
+// and recorded once a month as snapshots for the net worth history.
+package networth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// Asset classes. Payment methods that aren't credit lines count as cash.
+const (
+	ClassCash       = "cash"
+	ClassProperty   = "property"
+	ClassVehicle    = "vehicle"
+	ClassInvestment = "investment"
+	ClassOther      = "other"
+)
+
+// AssetClasses are the classes an asset can be tracked as
+var AssetClasses = []string{ClassCash, ClassProperty, ClassVehicle, ClassInvestment, ClassOther}
+
+// Liability classes are debt kinds: loan, credit_card and other. Credit lines
+// without a tracked debt count as credit_card.
+const ClassCreditCard = "credit_card"
+
+// Item sources
+const (
+	SourceAccount = "account" // a payment method
+	SourceAsset   = "asset"
+	SourceDebt    = "debt"
+)
+
+// Item is one account, asset or debt in a statement
+type Item struct {
+	Source    string
+	ID        string
+	Name      string
+	Class     string
+	Liability bool
+	Amount    money.Amount // in the home currency
+	// Original and Currency are set for accounts in another currency
+	Original *money.Amount
+	Currency string
+	// ValuedOn is the date of an asset's latest valuation
+	ValuedOn *time.Time
+}
+
+// Statement is a user's net worth on a date. Amounts are in the home currency.
+type Statement struct {
+	Date             time.Time
+	Assets           money.Amount
+	Liabilities      money.Amount
+	NetWorth         money.Amount
+	AssetClasses     map[string]money.Amount
+	LiabilityClasses map[string]money.Amount
+	Items            []Item
+	// Unconverted are accounts in another currency without an exchange rate, left
+	// out of the totals
+	Unconverted []Item
+}
+
+// Build totals items into a statement. Unconverted items are listed but not counted.
+func Build(date time.Time, items, unconverted []Item) Statement {
+	s := Statement{
+		Date:             date,
+		AssetClasses:     make(map[string]money.Amount),
+		LiabilityClasses: make(map[string]money.Amount),
+		Items:            items,
+		Unconverted:      unconverted,
+	}
+	for _, item := range items {
+		if item.Liability {
+			s.Liabilities += item.Amount
+			s.LiabilityClasses[item.Class] += item.Amount
+		} else {
+			s.Assets += item.Amount
+			s.AssetClasses[item.Class] += item.Amount
+		}
+	}
+	s.NetWorth = s.Assets - s.Liabilities
+	sort.SliceStable(s.Items, func(i, j int) bool {
+		a, b := s.Items[i], s.Items[j]
+		if a.Liability != b.Liability {
+			return !a.Liability
+		}
+		return a.Amount > b.Amount
+	})
+	return s
+}
+
+// Load values a user's accounts, assets and debts on today, converting accounts in
+// other currencies to home at the latest rate.
+//
+// Credit lines (payment methods with a credit limit) hold what's owed, so they're
+// liabilities, unless a debt tracks the same account, in which case only the debt
+// counts. Inactive payment methods and ones without a balance are left out.
+func Load(ctx context.Context, q *models.Queries, userID, home string, today time.Time) (Statement, error) {
+	methods, err := q.ListPaymentMethods(ctx, utils.PgUUID(userID))
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to list payment methods: %w", err)
+	}
+	assets, err := q.ListAssets(ctx, userID)
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to list assets: %w", err)
+	}
+	debtList, err := q.ListDebts(ctx, userID)
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to list debts: %w", err)
+	}
+	payments, err := q.GetDebtPayments(ctx, userID)
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to fetch debt payments: %w", err)
+	}
+
+	var items, unconverted []Item
+	tracked := make(map[string]bool)
+	paid := make(map[string]money.Amount, len(payments))
+	for _, p := range payments {
+		paid[p.DebtID] = money.FromNumeric(p.Paid)
+	}
+	for _, d := range debtList {
+		if d.PaymentMethodID.Valid {
+			tracked[utils.FormatUUID(d.PaymentMethodID)] = true
+		}
+		balance := money.FromNumeric(d.Principal) - paid[d.ID]
+		if balance < 0 {
+			balance = 0
+		}
+		items = append(items, Item{
+			Source:    SourceDebt,
+			ID:        d.ID,
+			Name:      d.Name,
+			Class:     d.Kind,
+			Liability: true,
+			Amount:    balance,
+		})
+	}
+
+	for _, m := range methods {
+		if !m.IsActive.Bool || !m.CurrentBalance.Valid {
+			continue
+		}
+		credit := m.CreditLimit.Valid
+		if credit && tracked[m.ID] {
+			continue
+		}
+		item := Item{Source: SourceAccount, ID: m.ID, Name: m.Name, Class: ClassCash, Liability: credit}
+		if credit {
+			item.Class = ClassCreditCard
+		}
+		balance := money.FromNumeric(m.CurrentBalance)
+		currency := utils.TextToString(m.Currency)
+		if currency == "" || currency == home {
+			item.Amount = balance
+			items = append(items, item)
+			continue
+		}
+		item.Original, item.Currency = &balance, currency
+		converted, ok, err := convert(ctx, q, balance, currency, home, today)
+		if err != nil {
+			return Statement{}, fmt.Errorf("failed to convert %s: %w", currency, err)
+		}
+		if !ok {
+			unconverted = append(unconverted, item)
+			continue
+		}
+		item.Amount = converted
+		items = append(items, item)
+	}
+
+	for _, a := range assets {
+		item := Item{
+			Source: SourceAsset,
+			ID:     a.ID,
+			Name:   a.Name,
+			Class:  a.AssetClass,
+			Amount: money.FromNumeric(a.CurrentValue),
+		}
+		if a.ValuedOn.Valid {
+			valuedOn := utils.DateToTime(a.ValuedOn)
+			item.ValuedOn = &valuedOn
+		}
+		items = append(items, item)
+	}
+
+	return Build(today, items, unconverted), nil
+}
+
+// convert converts amount from currency to home at the latest rate on or before
+// date. It returns false if there's no rate.
+func convert(ctx context.Context, q *models.Queries, amount money.Amount, currency, home string, date time.Time) (money.Amount, bool, error) {
+	stored, err := q.GetExchangeRate(ctx, models.GetExchangeRateParams{
+		FromCurrency: currency,
+		ToCurrency:   home,
+		OnDate:       utils.PgDate(date),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	rate, err := money.RateFromNumeric(stored.Rate)
+	if err != nil {
+		return 0, false, err
+	}
+	if stored.Base != currency {
+		rate = rate.Inverse()
+	}
+	converted, err := amount.Convert(rate)
+	if err != nil {
+		return 0, false, err
+	}
+	return converted, true, nil
+}
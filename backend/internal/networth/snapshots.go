@@ -0,0 +1,107 @@
+package networth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/auth"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// SnapshotInterval is how often snapshots are recorded. The current month's
+// snapshot is rewritten each time, so a month's row ends up within this long of
+// its last day.
+const SnapshotInterval = time.Hour
+
+// Breakdown is a snapshot's totals by class, as stored in net_worth_snapshots.breakdown
+type Breakdown struct {
+	Assets      map[string]money.Amount `json:"assets"`
+	Liabilities map[string]money.Amount `json:"liabilities"`
+}
+
+// DecodeBreakdown reads a stored breakdown. Classes are never nil.
+func DecodeBreakdown(data []byte) Breakdown {
+	var b Breakdown
+	if len(data) > 0 {
+		// Written by RecordSnapshots, so a failure leaves the classes empty
+		_ = json.Unmarshal(data, &b)
+	}
+	if b.Assets == nil {
+		b.Assets = make(map[string]money.Amount)
+	}
+	if b.Liabilities == nil {
+		b.Liabilities = make(map[string]money.Amount)
+	}
+	return b
+}
+
+// RecordSnapshots records the current month's net worth of every user with
+// accounts, assets or debts. It returns how many snapshots were recorded.
+func RecordSnapshots(ctx context.Context, q *models.Queries, now time.Time) (int, error) {
+	users, err := q.ListNetWorthUsers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	recorded := 0
+	var errs []error
+	for _, u := range users {
+		if ctx.Err() != nil {
+			break
+		}
+		home := utils.TextToString(u.Currency)
+		if home == "" {
+			home = auth.DefaultCurrency
+		}
+		s, err := Load(ctx, q, u.ID, home, today)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", u.ID, err))
+			continue
+		}
+		breakdown, err := json.Marshal(Breakdown{Assets: s.AssetClasses, Liabilities: s.LiabilityClasses})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", u.ID, err))
+			continue
+		}
+		if err := q.UpsertNetWorthSnapshot(ctx, models.UpsertNetWorthSnapshotParams{
+			UserID:      u.ID,
+			Month:       utils.PgDate(month),
+			Assets:      s.Assets.Numeric(),
+			Liabilities: s.Liabilities.Numeric(),
+			NetWorth:    s.NetWorth.Numeric(),
+			Breakdown:   breakdown,
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", u.ID, err))
+			continue
+		}
+		recorded++
+	}
+	return recorded, errors.Join(errs...)
+}
+
+// RunSnapshots records snapshots every SnapshotInterval until ctx is done. Recording
+// is idempotent, so several instances can run at once.
+func RunSnapshots(ctx context.Context, q *models.Queries) {
+	ticker := time.NewTicker(SnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := RecordSnapshots(ctx, q, time.Now()); err != nil {
+			log.Printf("net worth snapshots: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
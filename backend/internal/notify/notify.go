@@ -0,0 +1,67 @@
+// Package notify delivers email. Emails are queued in the email_outbox table by
+// Enqueue and sent by an Outbox worker through a pluggable Notifier, so a slow or
+// unreachable mail server never blocks a request or loses a message.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// Message is a rendered email
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Notifier sends messages. Send returns an error for failures worth retrying.
+type Notifier interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// LogNotifier writes messages to the log instead of sending them. It is used in
+// development when no SMTP server is configured.
+type LogNotifier struct{}
+
+// Send logs m
+func (LogNotifier) Send(ctx context.Context, m Message) error {
+	log.Printf("email: to=%s subject=%q\n%s", m.To, m.Subject, m.Text)
+	return nil
+}
+
+// Email is an email to queue. Data is what its template renders.
+type Email struct {
+	UserID    string // the recipient's account, empty if they don't have one
+	To        string
+	Template  string
+	Data      map[string]interface{}
+	DedupeKey string // an email with a key that was already queued is dropped
+}
+
+// Enqueue adds e to the outbox and reports whether it was new. Pass the queries of
+// a transaction to queue the email together with the change that causes it.
+func Enqueue(ctx context.Context, q models.Querier, e Email) (bool, error) {
+	if !templates.has(e.Template) {
+		return false, fmt.Errorf("unknown email template %q", e.Template)
+	}
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode email data: %w", err)
+	}
+
+	n, err := q.EnqueueEmail(ctx, models.EnqueueEmailParams{
+		UserID:    utils.PgUUID(e.UserID),
+		ToAddress: e.To,
+		Template:  e.Template,
+		Data:      data,
+		DedupeKey: utils.PgText(e.DedupeKey),
+	})
+	return n > 0, err
+}
@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// OutboxOptions configures an Outbox
+type OutboxOptions struct {
+	AppURL       string        // base URL of the web app for links in emails
+	PollInterval time.Duration // how often to look for due emails
+	BatchSize    int           // emails claimed per poll
+	MaxAttempts  int           // attempts before an email is marked failed
+	Summaries    bool          // queue month-end summaries
+}
+
+// Outbox delivers queued emails. Failed sends are retried with exponential backoff;
+// several instances can run against the same database without sending twice.
+type Outbox struct {
+	queries  models.Querier
+	notifier Notifier
+	opts     OutboxOptions
+}
+
+const (
+	// sendLease is how long a claimed email is left alone before another attempt
+	sendLease = 5 * time.Minute
+	// retryBase and retryMax bound the backoff between attempts: 1m, 4m, 16m, ...
+	retryBase = time.Minute
+	retryMax  = 6 * time.Hour
+)
+
+// NewOutbox creates an outbox worker
+func NewOutbox(queries models.Querier, notifier Notifier, opts OutboxOptions) *Outbox {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 10 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	return &Outbox{queries: queries, notifier: notifier, opts: opts}
+}
+
+// Run delivers due emails until ctx is done. Month-end summaries are queued at most
+// hourly, during the first week of each month.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.opts.PollInterval)
+	defer ticker.Stop()
+
+	var summariesChecked time.Time
+	for {
+		if o.opts.Summaries && time.Since(summariesChecked) >= time.Hour {
+			summariesChecked = time.Now()
+			if n, err := EnqueueMonthlySummaries(ctx, o.queries, summariesChecked); err != nil {
+				log.Printf("email outbox: failed to queue monthly summaries: %v", err)
+			} else if n > 0 {
+				log.Printf("email outbox: queued %d monthly summaries", n)
+			}
+		}
+
+		for {
+			n, err := o.Flush(ctx)
+			if err != nil {
+				log.Printf("email outbox: %v", err)
+			}
+			// Keep going while full batches come back
+			if err != nil || n < o.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush sends one batch of due emails and returns how many were claimed
+func (o *Outbox) Flush(ctx context.Context) (int, error) {
+	emails, err := o.queries.ClaimEmails(ctx, models.ClaimEmailsParams{
+		LeaseSeconds: sendLease.Seconds(),
+		BatchSize:    int32(o.opts.BatchSize),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim emails: %w", err)
+	}
+
+	for _, e := range emails {
+		if ctx.Err() != nil {
+			// Unsent emails are retried once their lease expires
+			return len(emails), ctx.Err()
+		}
+		o.deliver(ctx, e)
+	}
+	return len(emails), nil
+}
+
+func (o *Outbox) deliver(ctx context.Context, e models.EmailOutbox) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		o.fail(ctx, e, fmt.Errorf("invalid data: %w", err))
+		return
+	}
+	msg, err := templates.render(e.Template, e.ToAddress, o.opts.AppURL, data)
+	if err != nil {
+		// Rendering is deterministic, so retrying wouldn't help
+		o.fail(ctx, e, err)
+		return
+	}
+
+	if err := o.notifier.Send(ctx, msg); err != nil {
+		if int(e.Attempts) >= o.opts.MaxAttempts {
+			o.fail(ctx, e, err)
+			return
+		}
+		retryAt := time.Now().Add(backoff(int(e.Attempts)))
+		log.Printf("email outbox: %s to %s failed (attempt %d, retrying at %s): %v",
+			e.Template, e.ToAddress, e.Attempts, retryAt.Format(time.RFC3339), err)
+		if err := o.queries.RetryEmail(ctx, models.RetryEmailParams{
+			ID:            e.ID,
+			LastError:     utils.PgText(err.Error()),
+			NextAttemptAt: utils.PgTimestamptz(retryAt),
+		}); err != nil {
+			log.Printf("email outbox: failed to reschedule %s: %v", e.ID, err)
+		}
+		return
+	}
+
+	if err := o.queries.MarkEmailSent(ctx, e.ID); err != nil {
+		// The lease expiring would send it again, but that's better than losing it
+		log.Printf("email outbox: failed to mark %s sent: %v", e.ID, err)
+	}
+}
+
+func (o *Outbox) fail(ctx context.Context, e models.EmailOutbox, cause error) {
+	log.Printf("email outbox: giving up on %s to %s after %d attempts: %v", e.Template, e.ToAddress, e.Attempts, cause)
+	if err := o.queries.FailEmail(ctx, models.FailEmailParams{
+		ID:        e.ID,
+		LastError: utils.PgText(cause.Error()),
+	}); err != nil {
+		log.Printf("email outbox: failed to mark %s failed: %v", e.ID, err)
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts
+func backoff(attempts int) time.Duration {
+	d := retryBase
+	for i := 1; i < attempts && d < retryMax; i++ {
+		d *= 4
+	}
+	if d > retryMax {
+		d = retryMax
+	}
+	return d
+}
@@ -0,0 +1,306 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// memOutbox keeps the email_outbox table in memory, following the queries in
+// sql/queries/email_outbox.sql. The embedded Querier is nil: the outbox uses no
+// other queries unless summaries are enabled.
+type memOutbox struct {
+	models.Querier
+
+	mu     sync.Mutex
+	emails []*models.EmailOutbox
+	// offset moves the table's clock ahead of real time, to make retries due
+	offset time.Duration
+}
+
+func (m *memOutbox) now() time.Time {
+	return time.Now().Add(m.offset)
+}
+
+func (m *memOutbox) advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offset += d
+}
+
+func (m *memOutbox) get(id string) models.EmailOutbox {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.emails {
+		if e.ID == id {
+			return *e
+		}
+	}
+	panic("no email " + id)
+}
+
+func (m *memOutbox) EnqueueEmail(ctx context.Context, arg models.EnqueueEmailParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.emails {
+		if arg.DedupeKey.Valid && e.DedupeKey == arg.DedupeKey {
+			return 0, nil
+		}
+	}
+	m.emails = append(m.emails, &models.EmailOutbox{
+		ID:            fmt.Sprintf("email-%d", len(m.emails)+1),
+		UserID:        arg.UserID,
+		ToAddress:     arg.ToAddress,
+		Template:      arg.Template,
+		Data:          arg.Data,
+		DedupeKey:     arg.DedupeKey,
+		Status:        "pending",
+		NextAttemptAt: utils.PgTimestamptz(m.now()),
+		CreatedAt:     utils.PgTimestamptz(m.now()),
+	})
+	return 1, nil
+}
+
+func (m *memOutbox) ClaimEmails(ctx context.Context, arg models.ClaimEmailsParams) ([]models.EmailOutbox, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	var claimed []models.EmailOutbox
+	for _, e := range m.emails {
+		if len(claimed) == int(arg.BatchSize) {
+			break
+		}
+		if e.Status != "pending" || e.NextAttemptAt.Time.After(now) {
+			continue
+		}
+		e.Attempts++
+		e.NextAttemptAt = utils.PgTimestamptz(now.Add(time.Duration(arg.LeaseSeconds * float64(time.Second))))
+		claimed = append(claimed, *e)
+	}
+	return claimed, nil
+}
+
+func (m *memOutbox) update(id string, fn func(e *models.EmailOutbox)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.emails {
+		if e.ID == id {
+			fn(e)
+			return nil
+		}
+	}
+	return fmt.Errorf("no email %s", id)
+}
+
+func (m *memOutbox) MarkEmailSent(ctx context.Context, id string) error {
+	return m.update(id, func(e *models.EmailOutbox) {
+		e.Status = "sent"
+		e.SentAt = utils.PgTimestamptz(m.now())
+		e.LastError = pgtype.Text{}
+	})
+}
+
+func (m *memOutbox) RetryEmail(ctx context.Context, arg models.RetryEmailParams) error {
+	return m.update(arg.ID, func(e *models.EmailOutbox) {
+		e.LastError = arg.LastError
+		e.NextAttemptAt = arg.NextAttemptAt
+	})
+}
+
+func (m *memOutbox) FailEmail(ctx context.Context, arg models.FailEmailParams) error {
+	return m.update(arg.ID, func(e *models.EmailOutbox) {
+		e.Status = "failed"
+		e.LastError = arg.LastError
+	})
+}
+
+func enqueueInvitation(t *testing.T, store *memOutbox) string {
+	t.Helper()
+	created, err := Enqueue(context.Background(), store, Email{
+		To:       "ana@example.com",
+		Template: TemplateShareInvitation,
+		Data: map[string]interface{}{
+			"inviterName":    "Ben",
+			"budgetName":     "Groceries",
+			"permission":     "edit",
+			"expiresAt":      "June 7, 2026",
+			"recipientEmail": "ana@example.com",
+		},
+		DedupeKey: "share_invitation:1",
+	})
+	if err != nil || !created {
+		t.Fatalf("Enqueue = %v, %v", created, err)
+	}
+	return store.emails[len(store.emails)-1].ID
+}
+
+func flush(t *testing.T, o *Outbox) int {
+	t.Helper()
+	n, err := o.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	return n
+}
+
+func TestOutboxDelivers(t *testing.T) {
+	server := newSMTPServer(t)
+	store := &memOutbox{}
+	outbox := NewOutbox(store, server.notifier(t), OutboxOptions{AppURL: "https://app.example.com/"})
+
+	id := enqueueInvitation(t, store)
+	if created, err := Enqueue(context.Background(), store, Email{
+		To:        "ana@example.com",
+		Template:  TemplateShareInvitation,
+		DedupeKey: "share_invitation:1",
+	}); err != nil || created {
+		t.Fatalf("Enqueue with a used dedupe key = %v, %v; want it dropped", created, err)
+	}
+
+	if n := flush(t, outbox); n != 1 {
+		t.Fatalf("Flush claimed %d emails, want 1", n)
+	}
+	if e := store.get(id); e.Status != "sent" || !e.SentAt.Valid || e.Attempts != 1 {
+		t.Errorf("after delivery: status=%s sentAt=%v attempts=%d", e.Status, e.SentAt.Valid, e.Attempts)
+	}
+
+	msgs := server.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(msgs))
+	}
+	header, text, html := parseMail(t, msgs[0].Data)
+	if subject := header.Get("Subject"); subject != `Ben shared "Groceries" with you` {
+		t.Errorf("Subject = %q", subject)
+	}
+	if !strings.Contains(text, "view and edit their budget \"Groceries\"") || !strings.Contains(text, "https://app.example.com/sharing\n") {
+		t.Errorf("text part = %q", text)
+	}
+	if !strings.Contains(html, "Groceries") {
+		t.Errorf("html part = %q", html)
+	}
+
+	// Sent emails are never claimed again
+	store.advance(24 * time.Hour)
+	if n := flush(t, outbox); n != 0 {
+		t.Errorf("Flush claimed %d emails after delivery, want 0", n)
+	}
+}
+
+func TestOutboxRetriesTransientFailure(t *testing.T) {
+	server := newSMTPServer(t)
+	server.reject = func(attempt int) string {
+		if attempt == 1 {
+			return "451 4.3.0 try again later"
+		}
+		return ""
+	}
+	store := &memOutbox{}
+	outbox := NewOutbox(store, server.notifier(t), OutboxOptions{MaxAttempts: 3})
+	id := enqueueInvitation(t, store)
+
+	before := time.Now()
+	flush(t, outbox)
+	e := store.get(id)
+	if e.Status != "pending" || e.Attempts != 1 {
+		t.Fatalf("after a transient failure: status=%s attempts=%d, want pending after 1", e.Status, e.Attempts)
+	}
+	if !strings.Contains(e.LastError.String, "451") {
+		t.Errorf("last error = %q, want the server's reply", e.LastError.String)
+	}
+	if retryIn := e.NextAttemptAt.Time.Sub(before); retryIn < retryBase || retryIn > retryBase+time.Minute {
+		t.Errorf("retry scheduled %s from now, want about %s", retryIn, retryBase)
+	}
+
+	// Not due again until the backoff has passed
+	if n := flush(t, outbox); n != 0 {
+		t.Fatalf("Flush claimed %d emails before the retry was due", n)
+	}
+	store.advance(retryBase + time.Second)
+	if n := flush(t, outbox); n != 1 {
+		t.Fatalf("Flush claimed %d emails once the retry was due, want 1", n)
+	}
+
+	e = store.get(id)
+	if e.Status != "sent" || e.Attempts != 2 || e.LastError.Valid {
+		t.Errorf("after the retry: status=%s attempts=%d lastError=%q", e.Status, e.Attempts, e.LastError.String)
+	}
+	if server.attemptCount() != 2 || len(server.messages()) != 1 {
+		t.Errorf("server saw %d attempts and accepted %d messages, want 2 and 1", server.attemptCount(), len(server.messages()))
+	}
+}
+
+func TestOutboxGivesUpAfterMaxAttempts(t *testing.T) {
+	server := newSMTPServer(t)
+	server.reject = func(int) string { return "451 4.3.0 try again later" }
+	store := &memOutbox{}
+	outbox := NewOutbox(store, server.notifier(t), OutboxOptions{MaxAttempts: 3})
+	id := enqueueInvitation(t, store)
+
+	var delays []time.Duration
+	for i := 0; i < 5; i++ {
+		// The outbox schedules retries by the real clock
+		before := time.Now()
+		flush(t, outbox)
+		if e := store.get(id); e.Status == "pending" {
+			delays = append(delays, e.NextAttemptAt.Time.Sub(before).Round(time.Minute))
+		}
+		store.advance(retryMax)
+	}
+
+	e := store.get(id)
+	if e.Status != "failed" || e.Attempts != 3 {
+		t.Errorf("status=%s attempts=%d, want failed after 3", e.Status, e.Attempts)
+	}
+	if !strings.Contains(e.LastError.String, "451") {
+		t.Errorf("last error = %q", e.LastError.String)
+	}
+	if server.attemptCount() != 3 {
+		t.Errorf("server saw %d attempts, want 3", server.attemptCount())
+	}
+	if want := []time.Duration{time.Minute, 4 * time.Minute}; fmt.Sprint(delays) != fmt.Sprint(want) {
+		t.Errorf("retry delays = %v, want %v", delays, want)
+	}
+}
+
+func TestOutboxFailsUnrenderableEmail(t *testing.T) {
+	server := newSMTPServer(t)
+	store := &memOutbox{}
+	outbox := NewOutbox(store, server.notifier(t), OutboxOptions{})
+	id := enqueueInvitation(t, store)
+	store.update(id, func(e *models.EmailOutbox) { e.Data = []byte("not json") })
+
+	flush(t, outbox)
+	if e := store.get(id); e.Status != "failed" || !strings.Contains(e.LastError.String, "invalid data") {
+		t.Errorf("status=%s lastError=%q, want failed without retrying", e.Status, e.LastError.String)
+	}
+	if server.attemptCount() != 0 {
+		t.Error("an unrenderable email reached the server")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 4 * time.Minute},
+		{3, 16 * time.Minute},
+		{4, 64 * time.Minute},
+		{5, 256 * time.Minute},
+		{6, retryMax},
+		{20, retryMax},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
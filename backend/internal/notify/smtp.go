@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTPOptions configures an SMTPNotifier
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string // AUTH is skipped when empty, e.g. for a local catcher like Mailpit
+	Password string
+	From     string        // "Budget Planner <no-reply@example.com>"
+	Timeout  time.Duration // for the whole conversation with the server
+}
+
+// SMTPNotifier sends messages through an SMTP server. Port 465 uses implicit TLS;
+// any other port upgrades with STARTTLS when the server offers it.
+type SMTPNotifier struct {
+	opts SMTPOptions
+	from *mail.Address
+}
+
+// NewSMTPNotifier creates an SMTP notifier
+func NewSMTPNotifier(opts SMTPOptions) (*SMTPNotifier, error) {
+	if opts.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	from, err := mail.ParseAddress(opts.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", opts.From, err)
+	}
+	if opts.Port == 0 {
+		opts.Port = 587
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	return &SMTPNotifier{opts: opts, from: from}, nil
+}
+
+// Send delivers m
+func (n *SMTPNotifier) Send(ctx context.Context, m Message) error {
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", m.To, err)
+	}
+	body, err := n.build(m, to)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(n.opts.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	addr := net.JoinHostPort(n.opts.Host, fmt.Sprint(n.opts.Port))
+	tlsConfig := &tls.Config{ServerName: n.opts.Host}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	if n.opts.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, n.opts.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && n.opts.Port != 465 {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+	if n.opts.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection
+		// to anything but localhost
+		auth := smtp.PlainAuth("", n.opts.Username, n.opts.Password, n.opts.Host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+	if err := c.Mail(n.from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp RCPT TO failed: %w", err)
+	}
+	wc, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := wc.Write(body); err != nil {
+		wc.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return c.Quit()
+}
+
+// build encodes m as a multipart/alternative MIME message with text and HTML parts
+func (n *SMTPNotifier) build(m Message, to *mail.Address) ([]byte, error) {
+	// The writer only emits parts, so the headers go into buf first
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := n.from.Address[strings.LastIndex(n.from.Address, "@")+1:]
+
+	// Header values can't contain line breaks, which also stops header injection
+	// through the subject
+	subject := strings.Join(strings.Fields(m.Subject), " ")
+	headers := []string{
+		"From: " + n.from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: <" + hex.EncodeToString(id) + "@" + domain + ">",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,278 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpServer is a minimal in-process SMTP server. Each message it accepts is
+// recorded; reject, when set, decides the reply to the end of DATA instead.
+type smtpServer struct {
+	ln   net.Listener
+	host string
+	port int
+
+	mu       sync.Mutex
+	received []receivedMail
+	attempts int
+	reject   func(attempt int) string // e.g. "451 try again later", "" to accept
+}
+
+type receivedMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	s := &smtpServer{ln: ln, host: addr.IP.String(), port: addr.Port}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 test ESMTP")
+
+	var current receivedMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-test\r\n250 8BITMIME")
+		case "MAIL":
+			current = receivedMail{From: addrArg(arg)}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			current.To = append(current.To, addrArg(arg))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			current.Data = string(data)
+
+			s.mu.Lock()
+			s.attempts++
+			reply := ""
+			if s.reject != nil {
+				reply = s.reject(s.attempts)
+			}
+			if reply == "" {
+				s.received = append(s.received, current)
+			}
+			s.mu.Unlock()
+
+			if reply != "" {
+				tp.PrintfLine("%s", reply)
+			} else {
+				tp.PrintfLine("250 queued")
+			}
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// addrArg extracts the address from "FROM:<a@b>" or "TO:<a@b>"
+func addrArg(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(addr, " ")
+	return strings.Trim(addr, "<>")
+}
+
+func (s *smtpServer) messages() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.received...)
+}
+
+func (s *smtpServer) attemptCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+func (s *smtpServer) notifier(t *testing.T) *SMTPNotifier {
+	t.Helper()
+	n, err := NewSMTPNotifier(SMTPOptions{
+		Host:    s.host,
+		Port:    s.port,
+		From:    "Budget Planner <no-reply@example.com>",
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// parseMail decodes a received message into its headers and text and HTML parts
+func parseMail(t *testing.T, data string) (mail.Header, string, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+
+	var text, html string
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NextPart undoes the quoted-printable encoding
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain"):
+			text = string(body)
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/html"):
+			html = string(body)
+		}
+	}
+	return msg.Header, text, html
+}
+
+func TestSMTPNotifierDelivers(t *testing.T) {
+	server := newSMTPServer(t)
+	n := server.notifier(t)
+
+	long := strings.Repeat("a very long line of text ", 10)
+	err := n.Send(context.Background(), Message{
+		To:      "Ana Cruz <ana@example.com>",
+		Subject: "Budget\r\nBcc: attacker@example.com alert ₱",
+		Text:    "Hello Ana\n" + long,
+		HTML:    "<p>Hello Ana</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msgs := server.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(msgs))
+	}
+	got := msgs[0]
+	if got.From != "no-reply@example.com" {
+		t.Errorf("MAIL FROM = %q", got.From)
+	}
+	if len(got.To) != 1 || got.To[0] != "ana@example.com" {
+		t.Errorf("RCPT TO = %v", got.To)
+	}
+
+	header, text, html := parseMail(t, got.Data)
+	if header.Get("Bcc") != "" {
+		t.Error("a line break in the subject injected a header")
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Budget Bcc: attacker@example.com alert ₱" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if to, err := header.AddressList("To"); err != nil || to[0].Address != "ana@example.com" || to[0].Name != "Ana Cruz" {
+		t.Errorf("To = %v, %v", to, err)
+	}
+	if !strings.HasSuffix(header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Message-ID = %q", header.Get("Message-ID"))
+	}
+	if text != "Hello Ana\n"+long {
+		t.Errorf("text part = %q", text)
+	}
+	if html != "<p>Hello Ana</p>" {
+		t.Errorf("html part = %q", html)
+	}
+}
+
+func TestSMTPNotifierReportsRejection(t *testing.T) {
+	server := newSMTPServer(t)
+	server.reject = func(int) string { return "451 4.3.0 try again later" }
+
+	err := server.notifier(t).Send(context.Background(), Message{To: "ana@example.com", Subject: "Hi", Text: "Hi"})
+	if err == nil || !strings.Contains(err.Error(), "451") {
+		t.Fatalf("Send error = %v, want the 451 reply", err)
+	}
+	if len(server.messages()) != 0 {
+		t.Error("a rejected message was recorded as delivered")
+	}
+}
+
+func TestSMTPNotifierUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	n, err := NewSMTPNotifier(SMTPOptions{Host: "127.0.0.1", Port: port, From: "no-reply@example.com", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.Send(context.Background(), Message{To: "ana@example.com", Subject: "Hi", Text: "Hi"})
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("failed to connect to 127.0.0.1:%d", port)) {
+		t.Fatalf("Send error = %v", err)
+	}
+}
+
+func TestSMTPNotifierRejectsInvalidRecipient(t *testing.T) {
+	server := newSMTPServer(t)
+	err := server.notifier(t).Send(context.Background(), Message{To: "not an address", Subject: "Hi", Text: "Hi"})
+	if err == nil || !strings.Contains(err.Error(), "invalid recipient") {
+		t.Fatalf("Send error = %v", err)
+	}
+	if server.attemptCount() != 0 {
+		t.Error("an invalid recipient still reached the server")
+	}
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// summaryWindow is how many days into a month last month's summaries are still
+// queued, so a server that was down on the 1st catches up
+const summaryWindow = 7
+
+// summaryCategories is how many of the top spending categories a summary lists
+const summaryCategories = 5
+
+// EnqueueMonthlySummaries queues an end-of-month summary to the owner of every budget
+// of the month before now that has transactions. Each budget is summarized once, so
+// it is safe to call repeatedly. It returns how many summaries were queued.
+func EnqueueMonthlySummaries(ctx context.Context, q models.Querier, now time.Time) (int, error) {
+	if now.Day() > summaryWindow {
+		return 0, nil
+	}
+	month := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+
+	budgets, err := q.ListSummaryBudgets(ctx, utils.PgDate(month))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list budgets: %w", err)
+	}
+
+	queued := 0
+	var errs []error
+	for _, b := range budgets {
+		data, ok, err := summaryData(ctx, q, b, month)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("budget %s: %w", b.ID, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		created, err := Enqueue(ctx, q, Email{
+			UserID:    utils.UUIDToString(b.UserID),
+			To:        b.Email,
+			Template:  TemplateMonthlySummary,
+			Data:      data,
+			DedupeKey: fmt.Sprintf("%s:%s", TemplateMonthlySummary, b.ID),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("budget %s: %w", b.ID, err))
+		} else if created {
+			queued++
+		}
+	}
+	return queued, errors.Join(errs...)
+}
+
+// summaryData collects what the monthly_summary template shows for a budget. It
+// returns false for budgets without transactions.
+func summaryData(ctx context.Context, q models.Querier, b models.ListSummaryBudgetsRow, month time.Time) (map[string]interface{}, bool, error) {
+	summary, err := q.GetDashboardSummary(ctx, b.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if summary.TransactionCount == 0 {
+		return nil, false, nil
+	}
+	spent, err := money.FromAny(summary.TotalSpent)
+	if err != nil {
+		return nil, false, err
+	}
+	income, err := money.FromAny(summary.TotalIncome)
+	if err != nil {
+		return nil, false, err
+	}
+	limit := money.FromNumeric(summary.TotalLimit)
+
+	rows, err := q.GetSpendingByCategory(ctx, utils.PgUUID(b.ID))
+	if err != nil {
+		return nil, false, err
+	}
+	categories := make([]map[string]interface{}, 0, summaryCategories)
+	for _, row := range rows {
+		if len(categories) == summaryCategories {
+			break
+		}
+		categorySpent, err := money.FromAny(row.TotalSpent)
+		if err != nil {
+			return nil, false, err
+		}
+		if categorySpent <= 0 {
+			continue
+		}
+		categories = append(categories, map[string]interface{}{
+			"name":       row.Name,
+			"spent":      categorySpent.String(),
+			"percentage": row.Percentage,
+		})
+	}
+
+	name := utils.TextToString(b.Name)
+	if name == "" {
+		name = "there"
+	}
+	budgetName := utils.TextToString(summary.Name)
+	if budgetName == "" {
+		budgetName = "your budget"
+	}
+	currency := utils.TextToString(b.Currency)
+	if currency == "" {
+		currency = "PHP"
+	}
+
+	return map[string]interface{}{
+		"name":             name,
+		"budgetId":         b.ID,
+		"budgetName":       budgetName,
+		"month":            month.Format("January 2006"),
+		"currency":         currency,
+		"hasLimit":         limit > 0,
+		"totalLimit":       limit.String(),
+		"totalSpent":       spent.String(),
+		"totalIncome":      income.String(),
+		"remaining":        (limit - spent).String(),
+		"overBudget":       spent > limit,
+		"overBy":           (spent - limit).String(),
+		"transactionCount": summary.TransactionCount,
+		"categories":       categories,
+	}, true, nil
+}
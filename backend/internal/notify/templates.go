@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Email templates. Each has a text version in templates/<name>.txt, which also
+// defines the subject, and an HTML version in templates/<name>.html rendered inside
+// templates/layout.html.
+const (
+	TemplateShareInvitation = "share_invitation"
+	TemplateAlert           = "alert"
+	TemplateMonthlySummary  = "monthly_summary"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var templates = mustParseTemplates(TemplateShareInvitation, TemplateAlert, TemplateMonthlySummary)
+
+type templateSet struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func mustParseTemplates(names ...string) *templateSet {
+	s := &templateSet{
+		text: make(map[string]*texttemplate.Template, len(names)),
+		html: make(map[string]*htmltemplate.Template, len(names)),
+	}
+	for _, name := range names {
+		s.text[name] = texttemplate.Must(texttemplate.New(name+".txt").Option("missingkey=zero").
+			ParseFS(templateFS, "templates/"+name+".txt"))
+		s.html[name] = htmltemplate.Must(htmltemplate.New(name+".html").Option("missingkey=zero").
+			ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html"))
+	}
+	return s
+}
+
+func (s *templateSet) has(name string) bool {
+	_, ok := s.text[name]
+	return ok
+}
+
+// render renders the named template for to. data is extended with appUrl, the
+// base URL that links in the email point at.
+func (s *templateSet) render(name, to, appURL string, data map[string]interface{}) (Message, error) {
+	text, ok := s.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	vars := make(map[string]interface{}, len(data)+2)
+	for k, v := range data {
+		vars[k] = v
+	}
+	vars["appUrl"] = strings.TrimRight(appURL, "/")
+
+	var subject, body, html bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", vars); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := text.Execute(&body, vars); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	vars["subject"] = subject.String()
+	if err := s.html[name].ExecuteTemplate(&html, "layout", vars); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(body.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,176 @@
+// Package periods lays out budget periods. Besides calendar months, a budget can run
+// for a week, two weeks, the half month between two paydays, a month from a payday
+// to the next, or any span of days.
+package periods
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Period types
+const (
+	Monthly     = "monthly" // a month from its start day, e.g. the 25th to the 24th
+	SemiMonthly = "semimonthly"
+	BiWeekly    = "biweekly"
+	Weekly      = "weekly"
+	Custom      = "custom"
+)
+
+// Types are the types a budget period can have
+var Types = []string{Monthly, SemiMonthly, BiWeekly, Weekly, Custom}
+
+// DefaultPaydays are the semi-monthly paydays when none are given. The 30th falls on
+// the last day of shorter months.
+var DefaultPaydays = [2]int{15, 30}
+
+// MaxCustomDays caps the length of a custom period
+const MaxCustomDays = 366
+
+// Errors returned by Starting
+var (
+	ErrInvalidType    = errors.New("invalid period type")
+	ErrInvalidPaydays = errors.New("paydays must be two different days of the month")
+	ErrInvalidAnchor  = errors.New("a monthly period must start on its payday, or the last day of a shorter month")
+	ErrInvalidEnd     = errors.New("a custom period needs an end on or after its start")
+	ErrTooLong        = fmt.Errorf("a custom period can't be longer than %d days", MaxCustomDays)
+)
+
+// Period is the days from Start up to End, exclusive
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t falls on a day of p
+func (p Period) Contains(t time.Time) bool {
+	t = day(t)
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Days returns how many days p has
+func (p Period) Days() int {
+	return int(p.End.Sub(p.Start).Hours()/24 + 0.5)
+}
+
+// Last returns the last day of p
+func (p Period) Last() time.Time {
+	return p.End.AddDate(0, 0, -1)
+}
+
+// Label names p for display: "January 2006" for a calendar month, its first and
+// last days otherwise
+func (p Period) Label() string {
+	last := p.Last()
+	switch {
+	case p.Start.Day() == 1 && p.End.Equal(nextMonth(p.Start)):
+		return p.Start.Format("January 2006")
+	case p.Start.Year() != last.Year():
+		return p.Start.Format("Jan 2, 2006") + " – " + last.Format("Jan 2, 2006")
+	default:
+		return p.Start.Format("Jan 2") + " – " + last.Format("Jan 2, 2006")
+	}
+}
+
+// Anchor returns the day of the month monthly period p runs from. It's the larger
+// of its start and end days: at most one of them falls on a month too short for it.
+func (p Period) Anchor() int {
+	return max(p.Start.Day(), p.End.Day())
+}
+
+// Starting returns the period of periodType that starts on start. A monthly period
+// runs to the same day of the next month, or to paydays' one day (the period's
+// Anchor) if given, so a period from the 31st to February 28 is followed by one to
+// March 31. A semi-monthly period runs to the next of paydays (DefaultPaydays if
+// nil), and a custom one to last, inclusive.
+func Starting(periodType string, start time.Time, paydays []int, last *time.Time) (Period, error) {
+	p := Period{Start: day(start)}
+	switch periodType {
+	case Monthly:
+		anchor := p.Start.Day()
+		if paydays != nil {
+			if len(paydays) != 1 || !validDay(paydays[0]) || !onDay(p.Start, paydays[0]).Equal(p.Start) {
+				return Period{}, ErrInvalidAnchor
+			}
+			anchor = paydays[0]
+		}
+		p.End = onDay(nextMonth(p.Start), anchor)
+	case SemiMonthly:
+		days := DefaultPaydays
+		if paydays != nil {
+			if len(paydays) != 2 || !validDay(paydays[0]) || !validDay(paydays[1]) || paydays[0] == paydays[1] {
+				return Period{}, ErrInvalidPaydays
+			}
+			days = [2]int{paydays[0], paydays[1]}
+		}
+		p.End = nextPayday(p.Start, days)
+	case BiWeekly:
+		p.End = p.Start.AddDate(0, 0, 14)
+	case Weekly:
+		p.End = p.Start.AddDate(0, 0, 7)
+	case Custom:
+		if last == nil || day(*last).Before(p.Start) {
+			return Period{}, ErrInvalidEnd
+		}
+		p.End = day(*last).AddDate(0, 0, 1)
+		if p.Days() > MaxCustomDays {
+			return Period{}, ErrTooLong
+		}
+	default:
+		return Period{}, ErrInvalidType
+	}
+	return p, nil
+}
+
+// IsType reports whether t is a period type
+func IsType(t string) bool {
+	for _, pt := range Types {
+		if t == pt {
+			return true
+		}
+	}
+	return false
+}
+
+// nextPayday returns the first of paydays after t, in t's month or the next
+func nextPayday(t time.Time, paydays [2]int) time.Time {
+	var next time.Time
+	for _, month := range []time.Time{monthStart(t), nextMonth(t)} {
+		for _, d := range paydays {
+			payday := onDay(month, d)
+			if payday.After(t) && (next.IsZero() || payday.Before(next)) {
+				next = payday
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return next
+}
+
+// nextMonth returns the first day of the month after t's
+func nextMonth(t time.Time) time.Time {
+	return monthStart(t).AddDate(0, 1, 0)
+}
+
+// onDay returns day d of month's month, or its last day if the month is shorter
+func onDay(month time.Time, d int) time.Time {
+	if last := monthStart(month).AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return time.Date(month.Year(), month.Month(), d, 0, 0, 0, 0, time.UTC)
+}
+
+func validDay(d int) bool {
+	return d >= 1 && d <= 31
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
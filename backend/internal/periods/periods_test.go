@@ -0,0 +1,101 @@
+package periods
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestMonthlyChainKeepsAnchor(t *testing.T) {
+	tests := []struct {
+		start string
+		ends  []string
+	}{
+		{"2025-01-31", []string{"2025-02-28", "2025-03-31", "2025-04-30", "2025-05-31"}},
+		{"2024-01-31", []string{"2024-02-29", "2024-03-31"}},
+		{"2025-01-30", []string{"2025-02-28", "2025-03-30", "2025-04-30"}},
+		{"2025-10-31", []string{"2025-11-30", "2025-12-31", "2026-01-31"}},
+		{"2025-01-25", []string{"2025-02-25", "2025-03-25"}},
+	}
+	for _, tt := range tests {
+		p, err := Starting(Monthly, date(tt.start), nil, nil)
+		if err != nil {
+			t.Fatalf("Starting(%s): %v", tt.start, err)
+		}
+		for i, want := range tt.ends {
+			if !p.End.Equal(date(want)) {
+				t.Errorf("from %s, period %d ends %s, want %s", tt.start, i+1, p.End.Format("2006-01-02"), want)
+				break
+			}
+			// The next budget starts where this one ends, on this one's payday
+			p, err = Starting(Monthly, p.End, []int{p.Anchor()}, nil)
+			if err != nil {
+				t.Fatalf("from %s, period %d: %v", tt.start, i+2, err)
+			}
+		}
+	}
+}
+
+func TestMonthlyAnchorMustMatchStart(t *testing.T) {
+	for _, paydays := range [][]int{{15}, {0}, {32}, {28, 31}} {
+		if _, err := Starting(Monthly, date("2025-02-28"), paydays, nil); err != ErrInvalidAnchor {
+			t.Errorf("Starting on Feb 28 with paydays %v: error = %v, want ErrInvalidAnchor", paydays, err)
+		}
+	}
+	for _, anchor := range []int{28, 29, 30, 31} {
+		if _, err := Starting(Monthly, date("2025-02-28"), []int{anchor}, nil); err != nil {
+			t.Errorf("Starting on Feb 28 with payday %d: %v", anchor, err)
+		}
+	}
+}
+
+func TestStarting(t *testing.T) {
+	last := date("2025-03-10")
+	tests := []struct {
+		periodType string
+		start      string
+		paydays    []int
+		end        string
+	}{
+		{Monthly, "2025-03-01", nil, "2025-04-01"},
+		{SemiMonthly, "2025-02-15", nil, "2025-02-28"},
+		{SemiMonthly, "2025-02-28", nil, "2025-03-15"},
+		{SemiMonthly, "2025-03-05", []int{5, 20}, "2025-03-20"},
+		{BiWeekly, "2025-03-03", nil, "2025-03-17"},
+		{Weekly, "2025-03-03", nil, "2025-03-10"},
+		{Custom, "2025-03-01", nil, "2025-03-11"},
+	}
+	for _, tt := range tests {
+		p, err := Starting(tt.periodType, date(tt.start), tt.paydays, &last)
+		if err != nil {
+			t.Errorf("Starting(%s, %s): %v", tt.periodType, tt.start, err)
+			continue
+		}
+		if !p.End.Equal(date(tt.end)) {
+			t.Errorf("Starting(%s, %s) ends %s, want %s", tt.periodType, tt.start, p.End.Format("2006-01-02"), tt.end)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		p    Period
+		want string
+	}{
+		{Period{date("2025-03-01"), date("2025-04-01")}, "March 2025"},
+		{Period{date("2025-01-31"), date("2025-02-28")}, "Jan 31 – Feb 27, 2025"},
+		{Period{date("2025-12-25"), date("2026-01-25")}, "Dec 25, 2025 – Jan 24, 2026"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Label(); got != tt.want {
+			t.Errorf("Label() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// OutboxOptions configures an Outbox
+type OutboxOptions struct {
+	PollInterval time.Duration // how often to look for due messages
+	BatchSize    int           // messages claimed per poll
+	MaxAttempts  int           // attempts before a message is marked failed
+}
+
+// Outbox delivers queued push messages. Failed sends are retried with exponential
+// backoff until the message is older than the TTL push services keep it for.
+type Outbox struct {
+	queries *models.Queries
+	sender  *Sender
+	opts    OutboxOptions
+}
+
+const (
+	// sendLease is how long a claimed message is left alone before another attempt
+	sendLease = 2 * time.Minute
+	// retryBase and retryMax bound the backoff between attempts: 30s, 2m, 8m, ...
+	retryBase = 30 * time.Second
+	retryMax  = time.Hour
+)
+
+// NewOutbox creates a push outbox worker
+func NewOutbox(queries *models.Queries, sender *Sender, opts OutboxOptions) *Outbox {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 6
+	}
+	return &Outbox{queries: queries, sender: sender, opts: opts}
+}
+
+// Run delivers due messages until ctx is done
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := o.Flush(ctx)
+			if err != nil {
+				log.Printf("push outbox: %v", err)
+			}
+			// Keep going while full batches come back
+			if err != nil || n < o.opts.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush sends one batch of due messages and returns how many were claimed
+func (o *Outbox) Flush(ctx context.Context) (int, error) {
+	messages, err := o.queries.ClaimPushMessages(ctx, models.ClaimPushMessagesParams{
+		LeaseSeconds: sendLease.Seconds(),
+		BatchSize:    int32(o.opts.BatchSize),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim messages: %w", err)
+	}
+
+	for _, m := range messages {
+		if ctx.Err() != nil {
+			// Unsent messages are retried once their lease expires
+			return len(messages), ctx.Err()
+		}
+		o.deliver(ctx, m)
+	}
+	return len(messages), nil
+}
+
+func (o *Outbox) deliver(ctx context.Context, m models.ClaimPushMessagesRow) {
+	err := o.sender.Send(ctx, Subscription{Endpoint: m.Endpoint, P256dh: m.P256dh, Auth: m.Auth}, m.Payload)
+
+	var permanent *PermanentError
+	switch {
+	case err == nil:
+		if err := o.queries.MarkPushSent(ctx, m.ID); err != nil {
+			log.Printf("push outbox: failed to mark %s sent: %v", m.ID, err)
+		}
+
+	case errors.Is(err, ErrGone):
+		// Deleting the subscription also drops this and any other queued message for it
+		if err := o.queries.PrunePushSubscription(ctx, m.SubscriptionID); err != nil {
+			log.Printf("push outbox: failed to prune subscription %s: %v", m.SubscriptionID, err)
+		}
+
+	case errors.As(err, &permanent),
+		int(m.Attempts) >= o.opts.MaxAttempts,
+		time.Since(utils.TimestamptzToTime(m.CreatedAt)) > o.sender.ttl:
+		log.Printf("push outbox: giving up on %s after %d attempts: %v", m.ID, m.Attempts, err)
+		if err := o.queries.FailPush(ctx, models.FailPushParams{
+			ID:        m.ID,
+			LastError: utils.PgText(err.Error()),
+		}); err != nil {
+			log.Printf("push outbox: failed to mark %s failed: %v", m.ID, err)
+		}
+
+	default:
+		retryAt := time.Now().Add(backoff(int(m.Attempts)))
+		if err := o.queries.RetryPush(ctx, models.RetryPushParams{
+			ID:            m.ID,
+			LastError:     utils.PgText(err.Error()),
+			NextAttemptAt: utils.PgTimestamptz(retryAt),
+		}); err != nil {
+			log.Printf("push outbox: failed to reschedule %s: %v", m.ID, err)
+		}
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts
+func backoff(attempts int) time.Duration {
+	d := retryBase
+	for i := 1; i < attempts && d < retryMax; i++ {
+		d *= 4
+	}
+	if d > retryMax {
+		d = retryMax
+	}
+	return d
+}
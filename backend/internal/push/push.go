@@ -0,0 +1,139 @@
+// Package push sends Web Push notifications to users' browsers and devices.
+// Messages are queued per subscription by Enqueue and delivered by an Outbox
+// worker, which encrypts them (RFC 8291), signs requests with VAPID (RFC 8292) and
+// deletes subscriptions the push service reports as gone.
+package push
+
+import (
+	"context"
+	"crypto/ecdh"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+)
+
+// Message is what the service worker receives and shows as a notification
+type Message struct {
+	Title string                 `json:"title"`
+	Body  string                 `json:"body,omitempty"`
+	URL   string                 `json:"url,omitempty"` // app path to open when the notification is clicked
+	Tag   string                 `json:"tag,omitempty"` // replaces a shown notification with the same tag
+	Kind  string                 `json:"kind,omitempty"`
+	Data  map[string]interface{} `json:"data,omitempty"`
+}
+
+// Enqueue queues m for every device userID has subscribed and returns how many
+// messages were queued. Users without subscriptions get nothing.
+func Enqueue(ctx context.Context, q *models.Queries, userID string, m Message) (int64, error) {
+	payload, err := encode(m)
+	if err != nil {
+		return 0, err
+	}
+	return q.EnqueuePush(ctx, models.EnqueuePushParams{
+		UserID:  userID,
+		Payload: payload,
+	})
+}
+
+// encode marshals m, shortening the body until it fits in one push message
+func encode(m Message) ([]byte, error) {
+	for {
+		payload, err := json.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode push message: %w", err)
+		}
+		if len(payload) <= MaxPayload {
+			return payload, nil
+		}
+		if m.Body == "" {
+			return nil, fmt.Errorf("push message is %d bytes, the limit is %d", len(payload), MaxPayload)
+		}
+		m.Body = truncate(m.Body, len(m.Body)-(len(payload)-MaxPayload)-len("…"))
+	}
+}
+
+// truncate cuts s to at most n bytes on a rune boundary and marks the cut
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
+
+// ValidateSubscription checks a subscription a browser handed out. Endpoints must
+// be https URLs on a public host unless allowLocal is set, e.g. for a local push
+// service stand-in; the Sender checks the addresses it connects to as well.
+func ValidateSubscription(sub Subscription, allowLocal bool) error {
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Host == "" || !(endpoint.Scheme == "https" || (allowLocal && endpoint.Scheme == "http")) {
+		return errors.New("endpoint must be an https URL")
+	}
+	if !allowLocal && !publicHost(endpoint.Hostname()) {
+		return errors.New("endpoint must be on a public push service")
+	}
+	p256dh, err := decodeKey(sub.P256dh)
+	if err != nil {
+		return errors.New("keys.p256dh must be base64url")
+	}
+	if _, err := ecdh.P256().NewPublicKey(p256dh); err != nil {
+		return errors.New("keys.p256dh is not a P-256 public key")
+	}
+	if auth, err := decodeKey(sub.Auth); err != nil || len(auth) != 16 {
+		return errors.New("keys.auth must be a base64url 16 byte secret")
+	}
+	return nil
+}
+
+// publicHost reports whether host can name a public push service: not a local name
+// and, for an IP literal, a publicly routable address
+func publicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	// Single-label names only resolve on a local network
+	if host == "" || (!strings.Contains(host, ".") && !strings.Contains(host, ":")) {
+		return false
+	}
+	for _, suffix := range []string{"localhost", ".local", ".internal", ".home.arpa"} {
+		if host == strings.TrimPrefix(suffix, ".") || strings.HasSuffix(host, suffix) {
+			return false
+		}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return publicAddr(addr)
+	}
+	return true
+}
+
+// nonPublic are ranges that aren't reachable on the internet beyond what the
+// netip.Addr predicates cover
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64, which can reach private IPv4
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// publicAddr reports whether addr is a publicly routable unicast address
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
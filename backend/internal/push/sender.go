@@ -0,0 +1,201 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrGone means the push service no longer knows the subscription (404 or 410), so
+// it should be deleted
+var ErrGone = errors.New("push subscription expired or unsubscribed")
+
+// errLocalEndpoint stops a connection to an endpoint that resolved to a private or
+// loopback address, so a subscription can't be used to reach internal services
+var errLocalEndpoint = errors.New("endpoint does not resolve to a public address")
+
+// PermanentError is a rejection that retrying won't fix, e.g. a malformed request
+type PermanentError struct {
+	Status int
+	Body   string
+}
+
+func (e *PermanentError) Error() string {
+	return fmt.Sprintf("push service rejected the message: %d %s", e.Status, e.Body)
+}
+
+// VAPIDOptions configures the application server identity (RFC 8292)
+type VAPIDOptions struct {
+	PublicKey  string // base64url uncompressed P-256 point, given to browsers as applicationServerKey
+	PrivateKey string // base64url 32 byte P-256 scalar
+	Subject    string // mailto: or https: contact for push service operators
+	AllowLocal bool   // deliver to private and loopback addresses, for a local push service in development
+}
+
+// Subscription is where and for whom a message is encrypted
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// Sender encrypts and delivers Web Push messages
+type Sender struct {
+	client    *http.Client
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+	ttl       time.Duration
+}
+
+// NewSender creates a sender from VAPID keys
+func NewSender(opts VAPIDOptions) (*Sender, error) {
+	if opts.Subject == "" || !(strings.HasPrefix(opts.Subject, "mailto:") || strings.HasPrefix(opts.Subject, "https:")) {
+		return nil, errors.New("VAPID subject must be a mailto: or https: URL")
+	}
+	raw, err := decodeKey(opts.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	priv, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	public := priv.PublicKey().Bytes()
+	if configured, err := decodeKey(opts.PublicKey); err != nil || !bytes.Equal(configured, public) {
+		return nil, errors.New("VAPID public key doesn't match the private key")
+	}
+
+	// The JWT library signs with crypto/ecdsa keys; the uncompressed point is 0x04 || X || Y
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !opts.AllowLocal {
+		// Checked on the resolved address, so a public name pointing at an internal
+		// address is refused too
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddr(addr.Addr()) {
+				return errLocalEndpoint
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Through a proxy the check above would only see the proxy's address
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+			// Push services answer directly; a redirect could point anywhere
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		key:       key,
+		publicKey: base64.RawURLEncoding.EncodeToString(public),
+		subject:   opts.Subject,
+		ttl:       24 * time.Hour,
+	}, nil
+}
+
+// PublicKey returns the base64url VAPID public key browsers subscribe with
+func (s *Sender) PublicKey() string {
+	return s.publicKey
+}
+
+// Send encrypts payload for sub and posts it to the push service. It returns
+// ErrGone when the subscription should be deleted and a *PermanentError when the
+// message should be dropped; other errors are worth retrying.
+func (s *Sender) Send(ctx context.Context, sub Subscription, payload []byte) error {
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		return &PermanentError{Body: "invalid endpoint"}
+	}
+
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	body, err := encrypt(payload, sub.P256dh, sub.Auth, asKey)
+	if err != nil {
+		return &PermanentError{Body: err.Error()}
+	}
+	authorization, err := s.vapid(endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(int(s.ttl.Seconds())))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := s.client.Do(req)
+	if errors.Is(err, errLocalEndpoint) {
+		return &PermanentError{Body: err.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrGone
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("push service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	default:
+		return &PermanentError{Status: resp.StatusCode, Body: strings.TrimSpace(string(detail))}
+	}
+}
+
+// vapid returns the Authorization header for a push service: a short-lived ES256
+// JWT for the endpoint's origin and the public key to check it with
+func (s *Sender) vapid(endpoint *url.URL) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": s.subject,
+	})
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	return "vapid t=" + signed + ", k=" + s.publicKey, nil
+}
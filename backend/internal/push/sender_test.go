@@ -0,0 +1,436 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSubject = "mailto:push@example.com"
+
+// device is a browser's push subscription: the key pair and auth secret the push
+// service hands out with the endpoint
+type device struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newDevice(t *testing.T) *device {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	if _, err := rand.Read(auth); err != nil {
+		t.Fatal(err)
+	}
+	return &device{key: key, auth: auth}
+}
+
+func (d *device) subscription(endpoint string) Subscription {
+	return Subscription{
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(d.key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(d.auth),
+	}
+}
+
+// hkdfSHA256 is RFC 5869 written out in full, independent of the package's
+// single-block version
+func hkdfSHA256(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	prk := extract.Sum(nil)
+
+	var okm, block []byte
+	for i := byte(1); len(okm) < length; i++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(block)
+		expand.Write(info)
+		expand.Write([]byte{i})
+		block = expand.Sum(nil)
+		okm = append(okm, block...)
+	}
+	return okm[:length]
+}
+
+// decrypt is the user agent's side of RFC 8291: it reads the aes128gcm header,
+// derives the content key from its own private key and auth secret, and strips
+// the padding
+func (d *device) decrypt(body []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, errors.New("body shorter than the header")
+	}
+	salt := body[:16]
+	rs := binary.BigEndian.Uint32(body[16:20])
+	idlen := int(body[20])
+	if len(body) < 21+idlen {
+		return nil, errors.New("truncated key id")
+	}
+	keyID, ciphertext := body[21:21+idlen], body[21+idlen:]
+	if len(ciphertext) > int(rs) {
+		return nil, fmt.Errorf("record of %d bytes exceeds rs %d", len(ciphertext), rs)
+	}
+
+	asPublic, err := ecdh.P256().NewPublicKey(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("key id is not the sender's public key: %w", err)
+	}
+	shared, err := d.key.ECDH(asPublic)
+	if err != nil {
+		return nil, err
+	}
+	info := append([]byte("WebPush: info\x00"), d.key.PublicKey().Bytes()...)
+	info = append(info, keyID...)
+	ikm := hkdfSHA256(d.auth, shared, info, 32)
+	cek := hkdfSHA256(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdfSHA256(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The last record ends with 0x02 followed by zero padding
+	end := bytes.LastIndexByte(plaintext, 0x02)
+	if end < 0 || len(bytes.Trim(plaintext[end+1:], "\x00")) != 0 {
+		return nil, errors.New("missing last record delimiter")
+	}
+	return plaintext[:end], nil
+}
+
+// pushService is a stand-in push service. It checks the VAPID Authorization
+// header like a real one and hands the decrypted payload to the test.
+type pushService struct {
+	*httptest.Server
+	device *device
+	status int
+
+	mu       sync.Mutex
+	requests int
+	payloads [][]byte
+	errs     []error
+}
+
+func newPushService(t *testing.T, d *device, vapidKey string) *pushService {
+	t.Helper()
+	s := &pushService{device: d, status: http.StatusCreated}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		payload, err := s.accept(r, vapidKey)
+		if err != nil {
+			s.errs = append(s.errs, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.payloads = append(s.payloads, payload)
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *pushService) accept(r *http.Request, vapidKey string) ([]byte, error) {
+	if r.Method != http.MethodPost {
+		return nil, fmt.Errorf("method %s", r.Method)
+	}
+	if got := r.Header.Get("Content-Encoding"); got != "aes128gcm" {
+		return nil, fmt.Errorf("Content-Encoding = %q", got)
+	}
+	if r.Header.Get("TTL") == "" {
+		return nil, errors.New("missing TTL")
+	}
+	if err := checkVAPID(r.Header.Get("Authorization"), s.URL, vapidKey); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return s.device.decrypt(body)
+}
+
+// checkVAPID verifies an RFC 8292 "vapid t=<jwt>, k=<key>" header for origin
+func checkVAPID(header, origin, wantKey string) error {
+	params, ok := strings.CutPrefix(header, "vapid ")
+	if !ok {
+		return fmt.Errorf("Authorization = %q, want the vapid scheme", header)
+	}
+	var token, key string
+	for _, p := range strings.Split(params, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(p), "=")
+		switch name {
+		case "t":
+			token = value
+		case "k":
+			key = value
+		}
+	}
+	if key != wantKey {
+		return fmt.Errorf("k = %q, want the sender's public key", key)
+	}
+	point, err := base64.RawURLEncoding.DecodeString(key)
+	if err != nil || len(point) != 65 || point[0] != 4 {
+		return errors.New("k is not an uncompressed P-256 point")
+	}
+	public := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(point[1:33]),
+		Y:     new(big.Int).SetBytes(point[33:]),
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return public, nil
+	}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(origin), jwt.WithExpirationRequired())
+	if err != nil {
+		return fmt.Errorf("invalid VAPID token: %w", err)
+	}
+	exp, _ := claims.GetExpirationTime()
+	if time.Until(exp.Time) > 24*time.Hour {
+		return fmt.Errorf("exp is %s away, RFC 8292 allows at most 24h", time.Until(exp.Time))
+	}
+	if claims["sub"] != testSubject {
+		return fmt.Errorf("sub = %v", claims["sub"])
+	}
+	return nil
+}
+
+func newTestSender(t *testing.T, allowLocal bool) *Sender {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSender(VAPIDOptions{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		PrivateKey: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+		Subject:    testSubject,
+		AllowLocal: allowLocal,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSendEncryptsForTheSubscription(t *testing.T) {
+	sender := newTestSender(t, true)
+	d := newDevice(t)
+	service := newPushService(t, d, sender.PublicKey())
+
+	payloads := [][]byte{
+		[]byte(`{"title":"Budget alert","body":"You've used 80% of Groceries"}`),
+		{},
+		bytes.Repeat([]byte("x"), MaxPayload),
+	}
+	for _, payload := range payloads {
+		if err := sender.Send(context.Background(), d.subscription(service.URL+"/push/abc"), payload); err != nil {
+			t.Fatalf("Send(%d bytes): %v", len(payload), err)
+		}
+	}
+
+	if len(service.errs) != 0 {
+		t.Fatalf("push service rejected requests: %v", service.errs)
+	}
+	for i, want := range payloads {
+		if !bytes.Equal(service.payloads[i], want) {
+			t.Errorf("decrypted payload %d = %q, want %q", i, service.payloads[i], want)
+		}
+	}
+}
+
+func TestSendIsUnreadableToOtherDevices(t *testing.T) {
+	sender := newTestSender(t, true)
+	d, other := newDevice(t), newDevice(t)
+	// The service decrypts with another device's keys, as anyone but the subscriber would
+	service := newPushService(t, other, sender.PublicKey())
+
+	err := sender.Send(context.Background(), d.subscription(service.URL), []byte(`{"title":"hi"}`))
+	var permanent *PermanentError
+	if !errors.As(err, &permanent) || len(service.payloads) != 0 {
+		t.Fatalf("Send = %v with %d payloads decrypted, want the message unreadable", err, len(service.payloads))
+	}
+}
+
+func TestSendRejectsOversizedPayload(t *testing.T) {
+	sender := newTestSender(t, true)
+	d := newDevice(t)
+	service := newPushService(t, d, sender.PublicKey())
+
+	err := sender.Send(context.Background(), d.subscription(service.URL), make([]byte, MaxPayload+1))
+	var permanent *PermanentError
+	if !errors.As(err, &permanent) || service.requests != 0 {
+		t.Fatalf("Send = %v after %d requests, want a PermanentError before sending", err, service.requests)
+	}
+}
+
+func TestSendResponses(t *testing.T) {
+	tests := []struct {
+		status    int
+		gone      bool
+		permanent bool
+	}{
+		{http.StatusCreated, false, false},
+		{http.StatusNotFound, true, false},
+		{http.StatusGone, true, false},
+		{http.StatusTooManyRequests, false, false},
+		{http.StatusServiceUnavailable, false, false},
+		{http.StatusRequestEntityTooLarge, false, true},
+		{http.StatusUnauthorized, false, true},
+	}
+	sender := newTestSender(t, true)
+	d := newDevice(t)
+	service := newPushService(t, d, sender.PublicKey())
+
+	for _, tt := range tests {
+		service.status = tt.status
+		err := sender.Send(context.Background(), d.subscription(service.URL), []byte("{}"))
+
+		var permanent *PermanentError
+		switch {
+		case tt.status < 300 && err != nil:
+			t.Errorf("%d: Send = %v, want success", tt.status, err)
+		case tt.gone != errors.Is(err, ErrGone):
+			t.Errorf("%d: Send = %v, want ErrGone: %v", tt.status, err, tt.gone)
+		case tt.permanent != errors.As(err, &permanent):
+			t.Errorf("%d: Send = %v, want PermanentError: %v", tt.status, err, tt.permanent)
+		case tt.status >= 300 && err == nil:
+			t.Errorf("%d: Send succeeded", tt.status)
+		}
+	}
+}
+
+func TestSendDoesNotFollowRedirects(t *testing.T) {
+	sender := newTestSender(t, true)
+	d := newDevice(t)
+	internal := newPushService(t, d, sender.PublicKey())
+	redirect := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	err := sender.Send(context.Background(), d.subscription(redirect.URL), []byte("{}"))
+	var permanent *PermanentError
+	if !errors.As(err, &permanent) || internal.requests != 0 {
+		t.Fatalf("Send = %v with %d requests to the redirect target, want it not followed", err, internal.requests)
+	}
+}
+
+func TestSendRefusesLocalAddresses(t *testing.T) {
+	sender := newTestSender(t, false)
+	d := newDevice(t)
+	service := newPushService(t, d, sender.PublicKey())
+
+	port := service.URL[strings.LastIndex(service.URL, ":"):]
+	for _, endpoint := range []string{service.URL, "http://localhost" + port} {
+		err := sender.Send(context.Background(), d.subscription(endpoint), []byte("{}"))
+		var permanent *PermanentError
+		if !errors.As(err, &permanent) {
+			t.Errorf("Send to %s = %v, want a PermanentError", endpoint, err)
+		}
+	}
+	if service.requests != 0 {
+		t.Errorf("push service got %d requests, want none", service.requests)
+	}
+}
+
+func TestValidateSubscription(t *testing.T) {
+	d := newDevice(t)
+	valid := d.subscription("https://fcm.googleapis.com/fcm/send/abc")
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		allowLocal bool
+		ok         bool
+	}{
+		{"fcm", "https://fcm.googleapis.com/fcm/send/abc", false, true},
+		{"mozilla", "https://updates.push.services.mozilla.com/wpush/v2/abc", false, true},
+		{"public ip", "https://203.0.114.10/push", false, true},
+		{"http", "http://fcm.googleapis.com/fcm/send/abc", false, false},
+		{"not a url", "::", false, false},
+		{"no host", "https:///push", false, false},
+		{"loopback", "https://127.0.0.1/push", false, false},
+		{"loopback v6", "https://[::1]/push", false, false},
+		{"mapped loopback", "https://[::ffff:127.0.0.1]/push", false, false},
+		{"private", "https://10.1.2.3/push", false, false},
+		{"private 192", "https://192.168.0.10:8443/push", false, false},
+		{"link local metadata", "https://169.254.169.254/latest", false, false},
+		{"unspecified", "https://0.0.0.0/push", false, false},
+		{"carrier nat", "https://100.64.0.1/push", false, false},
+		{"unique local v6", "https://[fd00::1]/push", false, false},
+		{"localhost", "https://localhost/push", false, false},
+		{"localhost subdomain", "https://push.localhost/push", false, false},
+		{"single label", "https://metadata/push", false, false},
+		{"internal name", "https://push.corp.internal/push", false, false},
+		{"local in development", "http://localhost:8081/push", true, true},
+		{"private in development", "https://10.1.2.3/push", true, true},
+	}
+	for _, tt := range tests {
+		sub := valid
+		sub.Endpoint = tt.endpoint
+		if err := ValidateSubscription(sub, tt.allowLocal); (err == nil) != tt.ok {
+			t.Errorf("%s: ValidateSubscription(%q) = %v, want ok: %v", tt.name, tt.endpoint, err, tt.ok)
+		}
+	}
+
+	badKeys := []Subscription{
+		{Endpoint: valid.Endpoint, P256dh: "not base64!", Auth: valid.Auth},
+		{Endpoint: valid.Endpoint, P256dh: base64.RawURLEncoding.EncodeToString([]byte("short")), Auth: valid.Auth},
+		{Endpoint: valid.Endpoint, P256dh: valid.P256dh, Auth: base64.RawURLEncoding.EncodeToString(make([]byte, 8))},
+	}
+	for _, sub := range badKeys {
+		if err := ValidateSubscription(sub, false); err == nil {
+			t.Errorf("ValidateSubscription accepted keys %q, %q", sub.P256dh, sub.Auth)
+		}
+	}
+}
+
+func TestEncodeTruncatesBody(t *testing.T) {
+	m := Message{Title: "Weekly digest", Body: strings.Repeat("€", MaxPayload)}
+	payload, err := encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload) > MaxPayload {
+		t.Errorf("payload is %d bytes, over %d", len(payload), MaxPayload)
+	}
+	if !bytes.HasSuffix(payload, []byte(`…"}`)) {
+		t.Errorf("payload isn't cut on a rune boundary with a marker: ...%s", payload[len(payload)-10:])
+	}
+
+	if _, err := encode(Message{Title: strings.Repeat("x", MaxPayload)}); err == nil {
+		t.Error("a title too long to fit was accepted")
+	}
+}
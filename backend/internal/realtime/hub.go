@@ -0,0 +1,230 @@
+// Package realtime fans out change events to connected clients. Database triggers
+// record every change to shared data in change_events and announce it with
+// NOTIFY, so a Hub in each API instance sees changes made through any instance.
+package realtime
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// Channel is the NOTIFY channel the publish_change trigger announces event IDs on
+const Channel = "change_events"
+
+const (
+	// subscriberBuffer is how many events a stream may fall behind before it's dropped
+	subscriberBuffer = 64
+	// Retention is how long events are kept for clients resuming with Last-Event-ID
+	Retention = 24 * time.Hour
+	// reconnectMax bounds the backoff between attempts to re-establish LISTEN
+	reconnectMax = 30 * time.Second
+)
+
+// Event is a change to a record a user has access to. Clients refetch the record
+// or its budget rather than relying on the event for data.
+type Event struct {
+	ID        int64  `json:"-"`
+	Table     string `json:"table"`
+	Operation string `json:"operation"` // create, update or delete
+	RecordID  string `json:"recordId"`
+	BudgetID  string `json:"budgetId,omitempty"`
+
+	recipients []string
+}
+
+// EventFromModel converts a stored change event
+func EventFromModel(e models.ChangeEvent) Event {
+	recipients := make([]string, len(e.Recipients))
+	for i, r := range e.Recipients {
+		recipients[i] = utils.FormatUUID(r)
+	}
+	return Event{
+		ID:         e.ID,
+		Table:      e.TableName,
+		Operation:  e.Operation,
+		RecordID:   e.RecordID,
+		BudgetID:   utils.FormatUUID(e.BudgetID),
+		recipients: recipients,
+	}
+}
+
+// Subscription receives a user's live events on C. C is closed when the hub drops
+// the subscription, either because it fell behind or because the hub lost its
+// database connection and events may have been missed; the client should
+// reconnect and resume from its last event.
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	userID string
+}
+
+// Hub listens for change notifications and dispatches them to subscribers
+type Hub struct {
+	pool    *pgxpool.Pool
+	queries *models.Queries
+
+	mu   sync.Mutex
+	subs map[string]map[*Subscription]struct{}
+}
+
+// NewHub creates a hub. Nothing is delivered until Run is started.
+func NewHub(pool *pgxpool.Pool, queries *models.Queries) *Hub {
+	return &Hub{
+		pool:    pool,
+		queries: queries,
+		subs:    make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a stream for userID's events
+func (h *Hub) Subscribe(userID string) *Subscription {
+	c := make(chan Event, subscriberBuffer)
+	s := &Subscription{C: c, c: c, userID: userID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*Subscription]struct{})
+	}
+	h.subs[userID][s] = struct{}{}
+	return s
+}
+
+// Unsubscribe removes a stream. It's safe to call after the hub dropped it.
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(s)
+}
+
+// remove deletes and closes s; h.mu must be held
+func (h *Hub) remove(s *Subscription) {
+	subs := h.subs[s.userID]
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(h.subs, s.userID)
+	}
+	close(s.c)
+}
+
+// publish hands e to every subscriber among its recipients, dropping any that are
+// too far behind to take it
+func (h *Hub) publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, userID := range e.recipients {
+		for s := range h.subs[userID] {
+			select {
+			case s.c <- e:
+			default:
+				h.remove(s)
+			}
+		}
+	}
+}
+
+// dropAll closes every subscription
+func (h *Hub) dropAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, subs := range h.subs {
+		for s := range subs {
+			h.remove(s)
+		}
+	}
+}
+
+// Run listens for changes until ctx is done, reconnecting with backoff when the
+// connection is lost, and prunes events older than Retention hourly. Open streams
+// are closed when it returns.
+func (h *Hub) Run(ctx context.Context) {
+	defer h.dropAll()
+	go h.prune(ctx)
+
+	delay := time.Second
+	for {
+		started := time.Now()
+		err := h.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// Events published while the listener was down never reach open streams;
+		// closing them makes clients reconnect and replay from their Last-Event-ID
+		h.dropAll()
+
+		if time.Since(started) > time.Minute {
+			delay = time.Second
+		}
+		log.Printf("realtime: listener stopped: %v; reconnecting in %s", err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, reconnectMax)
+	}
+}
+
+// listen holds a dedicated connection in LISTEN and publishes each announced event
+func (h *Hub) listen(ctx context.Context) error {
+	pooled, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// LISTEN is per session, so the connection is taken out of the pool for good
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseInt(n.Payload, 10, 64)
+		if err != nil {
+			log.Printf("realtime: ignoring notification %q", n.Payload)
+			continue
+		}
+		event, err := h.queries.GetChangeEvent(ctx, id)
+		if err != nil {
+			log.Printf("realtime: failed to load event %d: %v", id, err)
+			continue
+		}
+		h.publish(EventFromModel(event))
+	}
+}
+
+// prune deletes events past Retention every hour until ctx is done
+func (h *Hub) prune(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().Add(-Retention)
+		if n, err := h.queries.PruneChangeEvents(ctx, utils.PgTimestamptz(cutoff)); err != nil && ctx.Err() == nil {
+			log.Printf("realtime: failed to prune events: %v", err)
+		} else if n > 0 {
+			log.Printf("realtime: pruned %d events", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
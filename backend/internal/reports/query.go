@@ -0,0 +1,217 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+// Dimensions a query can group by
+const (
+	DimDay           = "day"
+	DimWeek          = "week" // weeks start on Monday
+	DimMonth         = "month"
+	DimQuarter       = "quarter"
+	DimYear          = "year"
+	DimWeekday       = "weekday"
+	DimCategory      = "category"
+	DimPaymentMethod = "paymentMethod"
+	DimType          = "type"
+)
+
+// Dimensions are the dimensions a query can group by, in no particular order
+var Dimensions = []string{DimDay, DimWeek, DimMonth, DimQuarter, DimYear, DimWeekday, DimCategory, DimPaymentMethod, DimType}
+
+// MaxDimensions caps how many dimensions a query groups by
+const MaxDimensions = 3
+
+// MaxQueryDays caps the date range of a query
+const MaxQueryDays = 5*366 + 1
+
+// Errors returned by ParseDimensions
+var (
+	ErrInvalidDimension  = errors.New("invalid dimension")
+	ErrTooManyDimensions = errors.New("too many dimensions")
+	ErrTwoTimeDimensions = errors.New("only one of day, week, month, quarter and year can be grouped by")
+)
+
+// DailyTotal is the income or expenses in one category with one payment method on one day
+type DailyTotal struct {
+	Date time.Time
+	Total
+}
+
+// Key is a group's value for one dimension. Value is empty for uncategorized
+// transactions and ones without a payment method.
+type Key struct {
+	Dimension string
+	Value     string
+	Label     string // category and payment method names, weekday names
+}
+
+// Group is the transactions sharing a value for every dimension grouped by
+type Group struct {
+	Keys    []Key
+	Amount  money.Amount
+	Count   int64
+	Average money.Amount // per transaction
+}
+
+// Result is a query's groups and their overall totals
+type Result struct {
+	Groups  []Group
+	Amount  money.Amount
+	Count   int64
+	Average money.Amount
+}
+
+// ParseDimensions validates a comma-separated list of dimensions, dropping repeats
+func ParseDimensions(list string) ([]string, error) {
+	var dims []string
+	seen := make(map[string]bool)
+	timeDims := 0
+	for _, d := range strings.Split(list, ",") {
+		d = strings.TrimSpace(d)
+		if d == "" || seen[d] {
+			continue
+		}
+		if !isDimension(d) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidDimension, d)
+		}
+		if isTimeDimension(d) {
+			timeDims++
+		}
+		seen[d] = true
+		dims = append(dims, d)
+	}
+	if len(dims) > MaxDimensions {
+		return nil, ErrTooManyDimensions
+	}
+	if timeDims > 1 {
+		return nil, ErrTwoTimeDimensions
+	}
+	return dims, nil
+}
+
+// Aggregate groups daily totals by dims. Groups are ordered by their keys in the
+// order of dims: chronologically for time dimensions and weekdays (Monday first),
+// by label otherwise, with empty values last.
+func Aggregate(totals []DailyTotal, dims []string) Result {
+	type entry struct {
+		group Group
+		sort  []string
+	}
+	var res Result
+	index := make(map[string]*entry)
+	var entries []*entry
+	for _, t := range totals {
+		keys := make([]Key, len(dims))
+		sortKeys := make([]string, len(dims))
+		for i, d := range dims {
+			keys[i], sortKeys[i] = key(d, t)
+		}
+		id := keyValues(keys)
+		e, ok := index[id]
+		if !ok {
+			e = &entry{group: Group{Keys: keys}, sort: sortKeys}
+			index[id] = e
+			entries = append(entries, e)
+		}
+		e.group.Amount += t.Amount
+		e.group.Count += t.Count
+		res.Amount += t.Amount
+		res.Count += t.Count
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		for k := range dims {
+			a, b := entries[i].sort[k], entries[j].sort[k]
+			if a != b {
+				return a < b
+			}
+		}
+		return false
+	})
+	res.Groups = make([]Group, len(entries))
+	for i, e := range entries {
+		e.group.Average = perTransaction(e.group.Amount, e.group.Count)
+		res.Groups[i] = e.group
+	}
+	res.Average = perTransaction(res.Amount, res.Count)
+	return res
+}
+
+// key returns t's key for dimension d and the string it sorts by
+func key(d string, t DailyTotal) (Key, string) {
+	k := Key{Dimension: d}
+	switch d {
+	case DimDay:
+		k.Value = t.Date.Format("2006-01-02")
+	case DimWeek:
+		k.Value = weekStart(t.Date).Format("2006-01-02")
+	case DimMonth:
+		k.Value = t.Date.Format("2006-01")
+	case DimQuarter:
+		k.Value = fmt.Sprintf("%d-Q%d", t.Date.Year(), (int(t.Date.Month())+2)/3)
+	case DimYear:
+		k.Value = t.Date.Format("2006")
+	case DimWeekday:
+		k.Value = strings.ToLower(t.Date.Weekday().String())
+		k.Label = t.Date.Weekday().String()
+		return k, fmt.Sprint((int(t.Date.Weekday()) + 6) % 7)
+	case DimCategory:
+		k.Value, k.Label = t.CategoryID, t.CategoryName
+	case DimPaymentMethod:
+		k.Value, k.Label = t.PaymentMethodID, t.PaymentMethodName
+	case DimType:
+		k.Value = t.Type
+	}
+	if k.Value == "" {
+		return k, "\xff" // empty values sort last
+	}
+	if k.Label != "" {
+		return k, strings.ToLower(k.Label) + "\x00" + k.Value
+	}
+	return k, k.Value
+}
+
+func keyValues(keys []Key) string {
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = k.Value
+	}
+	return strings.Join(values, "\x00")
+}
+
+func perTransaction(amount money.Amount, count int64) money.Amount {
+	if count == 0 {
+		return 0
+	}
+	return amount.Div(count)
+}
+
+func isDimension(d string) bool {
+	for _, dim := range Dimensions {
+		if d == dim {
+			return true
+		}
+	}
+	return false
+}
+
+func isTimeDimension(d string) bool {
+	switch d {
+	case DimDay, DimWeek, DimMonth, DimQuarter, DimYear:
+		return true
+	}
+	return false
+}
+
+// weekStart returns the Monday of t's week
+func weekStart(t time.Time) time.Time {
+	return truncateDay(t).AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
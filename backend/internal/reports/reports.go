@@ -0,0 +1,344 @@
+// Package reports compares income and spending between two periods, broken down
+// by category and payment method, averages category spending over rolling windows
+// of months, and groups transactions by the dimensions a custom report asks for.
+package reports
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+// Period units
+const (
+	UnitMonth   = "month"
+	UnitQuarter = "quarter"
+	UnitYear    = "year"
+)
+
+// What a period is compared against
+const (
+	AgainstPrevious = "previous"  // the period just before
+	AgainstLastYear = "last_year" // the same period a year earlier
+)
+
+// RollingWindows are the month counts rolling averages are taken over
+var RollingWindows = []int{3, 6, 12}
+
+// MaxRollingMonths caps how many months of rolling averages a report covers
+const MaxRollingMonths = 36
+
+// ErrInvalidUnit is returned for a period unit other than month, quarter or year
+var ErrInvalidUnit = errors.New("invalid period unit")
+
+// Period is a range of days, both ends included
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Days returns how many days the period covers
+func (p Period) Days() int {
+	return int(p.End.Sub(p.Start).Hours()/24+0.5) + 1
+}
+
+// PeriodContaining returns the month, quarter or year day falls in
+func PeriodContaining(unit string, day time.Time) (Period, error) {
+	day = truncateDay(day)
+	var start time.Time
+	var months int
+	switch unit {
+	case UnitMonth:
+		start, months = monthStart(day), 1
+	case UnitQuarter:
+		start, months = time.Date(day.Year(), day.Month()-(day.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC), 3
+	case UnitYear:
+		start, months = time.Date(day.Year(), 1, 1, 0, 0, 0, 0, time.UTC), 12
+	default:
+		return Period{}, ErrInvalidUnit
+	}
+	return Period{Start: start, End: start.AddDate(0, months, -1)}, nil
+}
+
+// Baseline returns the period p is compared against: the unit just before it, or
+// the same dates a year earlier
+func Baseline(p Period, unit, against string) (Period, error) {
+	if against == AgainstLastYear {
+		return Period{Start: addYearsClamped(p.Start, -1), End: addYearsClamped(p.End, -1)}, nil
+	}
+	return PeriodContaining(unit, p.Start.AddDate(0, 0, -1))
+}
+
+// ToDate cuts current short at today and baseline to the same number of days, so a
+// period in progress is compared like for like. Periods that have ended are unchanged.
+func ToDate(current, baseline Period, today time.Time) (Period, Period) {
+	today = truncateDay(today)
+	if !today.Before(current.End) || today.Before(current.Start) {
+		return current, baseline
+	}
+	current.End = today
+	if end := baseline.Start.AddDate(0, 0, current.Days()-1); end.Before(baseline.End) {
+		baseline.End = end
+	}
+	return current, baseline
+}
+
+// Total is the income or expenses in one category with one payment method over a
+// period. Empty IDs group uncategorized transactions and ones without a payment method.
+type Total struct {
+	Type              string // expense or income
+	CategoryID        string
+	CategoryName      string
+	PaymentMethodID   string
+	PaymentMethodName string
+	Amount            money.Amount
+	Count             int64
+}
+
+// Summary is a period's totals
+type Summary struct {
+	Period           Period
+	Expenses         money.Amount
+	Income           money.Amount
+	Net              money.Amount // income less expenses
+	TransactionCount int64
+}
+
+// Delta is how much a value changed from the baseline
+type Delta struct {
+	Amount  money.Amount
+	Percent *float64 // nil when the baseline was zero
+}
+
+// Line compares one category's or payment method's income or expenses
+type Line struct {
+	Type     string
+	ID       string
+	Name     string
+	Current  money.Amount
+	Baseline money.Amount
+	Change   Delta
+}
+
+// Comparison is one period compared against a baseline period
+type Comparison struct {
+	Current        Summary
+	Baseline       Summary
+	Expenses       Delta
+	Income         Delta
+	Net            Delta
+	Categories     []Line
+	PaymentMethods []Line
+}
+
+// Compare compares the totals of current against those of baseline. Lines are
+// ordered expenses first, then by the larger of their two amounts.
+func Compare(current, baseline Period, currentTotals, baselineTotals []Total) Comparison {
+	c := Comparison{
+		Current:  summarize(current, currentTotals),
+		Baseline: summarize(baseline, baselineTotals),
+	}
+	c.Expenses = delta(c.Current.Expenses, c.Baseline.Expenses)
+	c.Income = delta(c.Current.Income, c.Baseline.Income)
+	c.Net = delta(c.Current.Net, c.Baseline.Net)
+
+	category := func(t Total) (string, string) { return t.CategoryID, t.CategoryName }
+	method := func(t Total) (string, string) { return t.PaymentMethodID, t.PaymentMethodName }
+	c.Categories = lines(currentTotals, baselineTotals, category)
+	c.PaymentMethods = lines(currentTotals, baselineTotals, method)
+	return c
+}
+
+func summarize(p Period, totals []Total) Summary {
+	s := Summary{Period: p}
+	for _, t := range totals {
+		if t.Type == "income" {
+			s.Income += t.Amount
+		} else {
+			s.Expenses += t.Amount
+		}
+		s.TransactionCount += t.Count
+	}
+	s.Net = s.Income - s.Expenses
+	return s
+}
+
+// lines groups both periods' totals by type and the ID key returns
+func lines(current, baseline []Total, key func(Total) (string, string)) []Line {
+	type lineKey struct{ typ, id string }
+	byKey := make(map[lineKey]*Line)
+	var list []*Line
+	add := func(t Total, isCurrent bool) {
+		id, name := key(t)
+		k := lineKey{typ: t.Type, id: id}
+		l := byKey[k]
+		if l == nil {
+			l = &Line{Type: t.Type, ID: id, Name: name}
+			byKey[k] = l
+			list = append(list, l)
+		}
+		if isCurrent {
+			l.Current += t.Amount
+		} else {
+			l.Baseline += t.Amount
+		}
+	}
+	for _, t := range current {
+		add(t, true)
+	}
+	for _, t := range baseline {
+		add(t, false)
+	}
+
+	result := make([]Line, len(list))
+	for i, l := range list {
+		l.Change = delta(l.Current, l.Baseline)
+		result[i] = *l
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Type != b.Type {
+			return a.Type == "expense"
+		}
+		if ma, mb := max(a.Current, a.Baseline), max(b.Current, b.Baseline); ma != mb {
+			return ma > mb
+		}
+		return a.Name < b.Name
+	})
+	return result
+}
+
+func delta(current, baseline money.Amount) Delta {
+	d := Delta{Amount: current - baseline}
+	if baseline != 0 {
+		percent := float64(current-baseline) / float64(abs(baseline)) * 100
+		d.Percent = &percent
+	}
+	return d
+}
+
+// MonthlyTotal is a category's spending in one month
+type MonthlyTotal struct {
+	Month        time.Time
+	CategoryID   string
+	CategoryName string
+	Amount       money.Amount
+}
+
+// RollingPoint is a category's spending in a month with its averages over the
+// windows of months ending with it. An average is nil before there's any data.
+type RollingPoint struct {
+	Month    time.Time
+	Total    money.Amount
+	Averages map[int]*money.Amount // by window length in months
+}
+
+// RollingSeries is a category's rolling averages
+type RollingSeries struct {
+	CategoryID   string
+	CategoryName string
+	Points       []RollingPoint
+}
+
+// RollingStart returns the first month whose totals RollingAverages needs for a
+// report of months ending with last
+func RollingStart(last time.Time, months int) time.Time {
+	return monthStart(last).AddDate(0, -(months - 1 + RollingWindows[len(RollingWindows)-1] - 1), 0)
+}
+
+// RollingAverages returns each category's spending for the months ending with last
+// with its rolling averages, largest total over the report first. Months without
+// spending count as zero, but only from the first month with any spending in
+// totals, so a new user isn't averaged against months they weren't tracking.
+func RollingAverages(totals []MonthlyTotal, last time.Time, months int) []RollingSeries {
+	last = monthStart(last)
+	first := last.AddDate(0, -(months - 1), 0)
+
+	var since time.Time
+	byCategory := make(map[string]map[time.Time]money.Amount)
+	names := make(map[string]string)
+	var order []string
+	for _, t := range totals {
+		month := monthStart(t.Month)
+		if since.IsZero() || month.Before(since) {
+			since = month
+		}
+		if byCategory[t.CategoryID] == nil {
+			byCategory[t.CategoryID] = make(map[time.Time]money.Amount)
+			names[t.CategoryID] = t.CategoryName
+			order = append(order, t.CategoryID)
+		}
+		byCategory[t.CategoryID][month] += t.Amount
+	}
+
+	list := make([]RollingSeries, 0, len(order))
+	sums := make(map[string]money.Amount, len(order))
+	for _, id := range order {
+		amounts := byCategory[id]
+		series := RollingSeries{CategoryID: id, CategoryName: names[id], Points: make([]RollingPoint, 0, months)}
+		for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+			point := RollingPoint{Month: month, Total: amounts[month], Averages: make(map[int]*money.Amount, len(RollingWindows))}
+			sums[id] += point.Total
+			for _, window := range RollingWindows {
+				point.Averages[window] = average(amounts, month, window, since)
+			}
+			series.Points = append(series.Points, point)
+		}
+		list = append(list, series)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if a, b := sums[list[i].CategoryID], sums[list[j].CategoryID]; a != b {
+			return a > b
+		}
+		return list[i].CategoryName < list[j].CategoryName
+	})
+	return list
+}
+
+// average returns the mean monthly amount over the window months ending with month,
+// counting only months from since
+func average(amounts map[time.Time]money.Amount, month time.Time, window int, since time.Time) *money.Amount {
+	var sum money.Amount
+	n := 0
+	for i := 0; i < window; i++ {
+		m := month.AddDate(0, -i, 0)
+		if m.Before(since) {
+			break
+		}
+		sum += amounts[m]
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	avg := sum.Div(int64(n))
+	return &avg
+}
+
+// addYearsClamped moves t by years, keeping Feb 29 in February
+func addYearsClamped(t time.Time, years int) time.Time {
+	moved := time.Date(t.Year()+years, t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	day := t.Day()
+	if last := moved.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return moved.AddDate(0, 0, day-1)
+}
+
+func abs(a money.Amount) money.Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,381 @@
+package splits
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+// Method is how a transaction amount is divided among budget members
+type Method string
+
+const (
+	// MethodEqual divides the amount evenly among the participants
+	MethodEqual Method = "equal"
+	// MethodExact assigns each participant an explicit amount
+	MethodExact Method = "exact"
+	// MethodPercentage assigns each participant a percentage of the amount
+	MethodPercentage Method = "percentage"
+	// MethodShares divides the amount proportionally to each participant's share count
+	MethodShares Method = "shares"
+)
+
+// ValueScale is the number of decimal places split values are stored with
+const ValueScale = 4
+
+// maxValue is the largest value a DECIMAL(12, 4) column holds, 99999999.9999
+const maxValue Value = 999_999_999_999
+
+// Value is an exact split value (an amount, percentage or share count)
+// in ten-thousandths, matching the DECIMAL(12, 4) column it is stored in
+type Value int64
+
+// valueUnit is one whole unit of a Value
+const valueUnit = 10_000
+
+// Part is a participant in a split along with the value entered for them.
+// Value is ignored for equal splits.
+type Part struct {
+	UserID string
+	Value  Value
+}
+
+// Transfer is a payment of Amount (in centavos) from one member to another
+type Transfer struct {
+	From   string
+	To     string
+	Amount int64
+}
+
+// ErrNoParticipants is returned when a split has no members to divide across
+var ErrNoParticipants = errors.New("split needs at least one participant")
+
+// IsValidMethod reports whether m is a supported split method
+func IsValidMethod(m Method) bool {
+	switch m {
+	case MethodEqual, MethodExact, MethodPercentage, MethodShares:
+		return true
+	}
+	return false
+}
+
+// ParseValue parses a plain decimal string such as "33.3333"
+func ParseValue(s string) (Value, error) {
+	units, err := money.ParseUnits(s, ValueScale)
+	if err != nil {
+		return 0, err
+	}
+	v := Value(units)
+	if v > maxValue || v < -maxValue {
+		return 0, money.ErrOutOfRange
+	}
+	return v, nil
+}
+
+// ValueFromNumeric converts a split value column, returning nil for NULL
+func ValueFromNumeric(n pgtype.Numeric) (*Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	units, err := money.NumericUnits(n, ValueScale)
+	if err != nil {
+		return nil, err
+	}
+	v := Value(units)
+	return &v, nil
+}
+
+// Numeric converts the value for use as a query parameter
+func (v Value) Numeric() pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(int64(v)), Exp: -ValueScale, Valid: true}
+}
+
+// String formats the value without trailing zeros, e.g. "33.3333" or "2"
+func (v Value) String() string {
+	sign := ""
+	mag := uint64(v)
+	if v < 0 {
+		sign = "-"
+		mag = uint64(-(v + 1)) + 1
+	}
+	s := sign + strconv.FormatUint(mag/valueUnit, 10)
+	if frac := mag % valueUnit; frac != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%0*d", ValueScale, frac), "0")
+	}
+	return s
+}
+
+// MarshalJSON encodes the value as a decimal string, like money amounts
+func (v Value) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + v.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts a decimal string ("33.3333") or a plain JSON number (33.3333).
+// Numbers are parsed from their literal text, never through float64.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseValue(s)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// Allocate divides total (in centavos) among parts using the given method.
+// The returned amounts are in the same order as parts and always sum to total;
+// rounding leftovers go to the participants with the largest remainders.
+func Allocate(total int64, method Method, parts []Part) ([]int64, error) {
+	if len(parts) == 0 {
+		return nil, ErrNoParticipants
+	}
+
+	seen := make(map[string]bool, len(parts))
+	for _, p := range parts {
+		if p.UserID == "" {
+			return nil, fmt.Errorf("participant user ID is required")
+		}
+		if seen[p.UserID] {
+			return nil, fmt.Errorf("participant %s is listed more than once", p.UserID)
+		}
+		seen[p.UserID] = true
+		if p.Value < 0 {
+			return nil, fmt.Errorf("split values cannot be negative")
+		}
+	}
+
+	switch method {
+	case MethodEqual:
+		weights := make([]int64, len(parts))
+		for i := range weights {
+			weights[i] = 1
+		}
+		return proportional(total, weights), nil
+
+	case MethodExact:
+		amounts := make([]int64, len(parts))
+		var sum int64
+		for i, p := range parts {
+			// Values are in ten-thousandths; an amount can't go below a centavo
+			if p.Value%(valueUnit/100) != 0 {
+				return nil, fmt.Errorf("exact amounts can have at most 2 decimal places")
+			}
+			amounts[i] = int64(p.Value) / (valueUnit / 100)
+			sum += amounts[i]
+		}
+		if sum != total {
+			return nil, fmt.Errorf("exact amounts add up to %s but the transaction is %s", money.FromCents(sum), money.FromCents(total))
+		}
+		return amounts, nil
+
+	case MethodPercentage:
+		weights := make([]int64, len(parts))
+		var sum Value
+		for i, p := range parts {
+			weights[i] = int64(p.Value)
+			sum += p.Value
+		}
+		if sum != 100*valueUnit {
+			return nil, fmt.Errorf("percentages add up to %s, expected 100", sum)
+		}
+		return proportional(total, weights), nil
+
+	case MethodShares:
+		weights := make([]int64, len(parts))
+		var sum Value
+		for i, p := range parts {
+			weights[i] = int64(p.Value)
+			sum += p.Value
+		}
+		if sum <= 0 {
+			return nil, fmt.Errorf("at least one participant needs a share")
+		}
+		return proportional(total, weights), nil
+	}
+
+	return nil, fmt.Errorf("unsupported split method: %s", method)
+}
+
+// proportional divides total by weight using the largest remainder method.
+// The products are computed exactly, so the result never depends on float rounding.
+func proportional(total int64, weights []int64) []int64 {
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+
+	amounts := make([]int64, len(weights))
+	// Every remainder is a fraction of sum, so comparing numerators orders them
+	remainders := make([]int64, len(weights))
+	var allocated int64
+	bigTotal, bigSum := big.NewInt(total), big.NewInt(sum)
+	for i, w := range weights {
+		q, rem := new(big.Int).QuoRem(new(big.Int).Mul(bigTotal, big.NewInt(w)), bigSum, new(big.Int))
+		amounts[i] = q.Int64()
+		remainders[i] = rem.Int64()
+		allocated += amounts[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+
+	for i := int64(0); i < total-allocated; i++ {
+		amounts[order[int(i)%len(order)]]++
+	}
+
+	return amounts
+}
+
+// Ledger accumulates who owes whom within a shared budget
+type Ledger struct {
+	// owes[a][b] is how much a owes b, in centavos
+	owes map[string]map[string]int64
+}
+
+// NewLedger creates an empty ledger
+func NewLedger() *Ledger {
+	return &Ledger{owes: make(map[string]map[string]int64)}
+}
+
+// AddShare records that debtor owes creditor amount for a split transaction.
+// A member's own share of an expense they paid is not a debt.
+func (l *Ledger) AddShare(creditor, debtor string, amount int64) {
+	if creditor == debtor || amount == 0 {
+		return
+	}
+	l.add(debtor, creditor, amount)
+}
+
+// AddSettlement records that from paid to amount to settle up
+func (l *Ledger) AddSettlement(from, to string, amount int64) {
+	if from == to || amount == 0 {
+		return
+	}
+	l.add(to, from, amount)
+}
+
+func (l *Ledger) add(debtor, creditor string, amount int64) {
+	if l.owes[debtor] == nil {
+		l.owes[debtor] = make(map[string]int64)
+	}
+	l.owes[debtor][creditor] += amount
+}
+
+// Owed returns how much from still owes to after netting both directions.
+// A negative result means to owes from.
+func (l *Ledger) Owed(from, to string) int64 {
+	return l.owes[from][to] - l.owes[to][from]
+}
+
+// Pairwise returns the outstanding debt between each pair of members, netted in both directions
+func (l *Ledger) Pairwise() []Transfer {
+	members := l.members()
+	var transfers []Transfer
+	for i, a := range members {
+		for _, b := range members[i+1:] {
+			net := l.Owed(a, b)
+			switch {
+			case net > 0:
+				transfers = append(transfers, Transfer{From: a, To: b, Amount: net})
+			case net < 0:
+				transfers = append(transfers, Transfer{From: b, To: a, Amount: -net})
+			}
+		}
+	}
+	return transfers
+}
+
+// Net returns each member's overall balance: positive means they are owed money
+func (l *Ledger) Net() map[string]int64 {
+	net := make(map[string]int64)
+	for debtor, creditors := range l.owes {
+		for creditor, amount := range creditors {
+			net[debtor] -= amount
+			net[creditor] += amount
+		}
+	}
+	return net
+}
+
+func (l *Ledger) members() []string {
+	set := make(map[string]bool)
+	for debtor, creditors := range l.owes {
+		set[debtor] = true
+		for creditor := range creditors {
+			set[creditor] = true
+		}
+	}
+	members := make([]string, 0, len(set))
+	for m := range set {
+		members = append(members, m)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// Simplify suggests a minimal set of payments that settles the given net balances.
+// It repeatedly matches the largest debtor with the largest creditor.
+func Simplify(net map[string]int64) []Transfer {
+	type balance struct {
+		userID string
+		amount int64
+	}
+
+	var debtors, creditors []balance
+	for userID, amount := range net {
+		switch {
+		case amount < 0:
+			debtors = append(debtors, balance{userID, -amount})
+		case amount > 0:
+			creditors = append(creditors, balance{userID, amount})
+		}
+	}
+
+	byAmount := func(list []balance) func(i, j int) bool {
+		return func(i, j int) bool {
+			if list[i].amount != list[j].amount {
+				return list[i].amount > list[j].amount
+			}
+			return list[i].userID < list[j].userID
+		}
+	}
+	sort.Slice(debtors, byAmount(debtors))
+	sort.Slice(creditors, byAmount(creditors))
+
+	var transfers []Transfer
+	i, j := 0, 0
+	for i < len(debtors) && j < len(creditors) {
+		amount := debtors[i].amount
+		if creditors[j].amount < amount {
+			amount = creditors[j].amount
+		}
+		transfers = append(transfers, Transfer{From: debtors[i].userID, To: creditors[j].userID, Amount: amount})
+		debtors[i].amount -= amount
+		creditors[j].amount -= amount
+		if debtors[i].amount == 0 {
+			i++
+		}
+		if creditors[j].amount == 0 {
+			j++
+		}
+	}
+
+	return transfers
+}
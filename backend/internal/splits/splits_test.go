@@ -0,0 +1,152 @@
+package splits
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+)
+
+func mustValue(t *testing.T, s string) Value {
+	t.Helper()
+	v, err := ParseValue(s)
+	if err != nil {
+		t.Fatalf("ParseValue(%q): %v", s, err)
+	}
+	return v
+}
+
+func parts(t *testing.T, values ...string) []Part {
+	t.Helper()
+	ps := make([]Part, len(values))
+	for i, v := range values {
+		ps[i] = Part{UserID: string(rune('a' + i)), Value: mustValue(t, v)}
+	}
+	return ps
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  int64
+		method Method
+		values []string
+		want   []int64
+	}{
+		{"equal even", 900, MethodEqual, []string{"0", "0", "0"}, []int64{300, 300, 300}},
+		{"equal leftover", 1000, MethodEqual, []string{"0", "0", "0"}, []int64{334, 333, 333}},
+		{"exact", 1000, MethodExact, []string{"2.50", "7.5"}, []int64{250, 750}},
+		{"percentage thirds", 10000, MethodPercentage, []string{"33.3333", "33.3333", "33.3334"}, []int64{3333, 3333, 3334}},
+		{"percentage leftover", 1001, MethodPercentage, []string{"50", "50"}, []int64{501, 500}},
+		{"shares", 1000, MethodShares, []string{"1", "2", "2"}, []int64{200, 400, 400}},
+		{"fractional shares", 1000, MethodShares, []string{"0.5", "1.5"}, []int64{250, 750}},
+		{"zero share", 1000, MethodShares, []string{"0", "1"}, []int64{0, 1000}},
+		// Products of the largest amount and share values would overflow int64
+		{"large", int64(money.MaxStored), MethodShares, []string{"99999999.9999", "99999999.9999"}, []int64{500000000000, 499999999999}},
+	}
+	for _, tt := range tests {
+		got, err := Allocate(tt.total, tt.method, parts(t, tt.values...))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		var sum int64
+		for i := range got {
+			sum += got[i]
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: Allocate = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+		if sum != tt.total {
+			t.Errorf("%s: allocations sum to %d, want %d", tt.name, sum, tt.total)
+		}
+	}
+}
+
+func TestAllocateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method Method
+		parts  []Part
+	}{
+		{"no participants", MethodEqual, nil},
+		{"missing user", MethodEqual, []Part{{}}},
+		{"duplicate user", MethodEqual, []Part{{UserID: "a"}, {UserID: "a"}}},
+		{"negative value", MethodShares, []Part{{UserID: "a", Value: -1}}},
+		{"exact mismatch", MethodExact, parts(t, "3", "3")},
+		{"exact below a centavo", MethodExact, parts(t, "9.995", "0.005")},
+		{"percentages short", MethodPercentage, parts(t, "33.3333", "33.3333", "33.3333")},
+		{"percentages over", MethodPercentage, parts(t, "50", "50.0001")},
+		{"no shares", MethodShares, parts(t, "0", "0")},
+		{"unknown method", Method("weighted"), parts(t, "1")},
+	}
+	for _, tt := range tests {
+		if _, err := Allocate(1000, tt.method, tt.parts); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Value
+		err  error
+	}{
+		{"0", 0, nil},
+		{"2", 20000, nil},
+		{"33.3333", 333333, nil},
+		{"12.50", 125000, nil},
+		{"99999999.9999", maxValue, nil},
+		{"33.33333", 0, money.ErrTooPrecise},
+		{"100000000", 0, money.ErrOutOfRange},
+		{"abc", 0, money.ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		got, err := ParseValue(tt.in)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ParseValue(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseValue(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestValueRoundTrip(t *testing.T) {
+	for _, s := range []string{"0", "2", "0.5", "33.3333", "-1.25", "99999999.9999"} {
+		v := mustValue(t, s)
+		if got := v.String(); got != s {
+			t.Errorf("ParseValue(%q).String() = %q", s, got)
+		}
+		got, err := ValueFromNumeric(v.Numeric())
+		if err != nil || got == nil || *got != v {
+			t.Errorf("ValueFromNumeric(%s.Numeric()) = %v, %v", s, got, err)
+		}
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	var req struct {
+		A Value `json:"a"`
+		B Value `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(`{"a": 33.3333, "b": "0.1"}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.A != 333333 || req.B != 1000 {
+		t.Errorf("decoded %d and %d", req.A, req.B)
+	}
+	out, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"a":"33.3333","b":"0.1"}` {
+		t.Errorf("encoded %s", out)
+	}
+}
@@ -0,0 +1,97 @@
+// Package totals looks after monthly_totals, the per-month transaction totals
+// dashboards and reports read instead of scanning transactions. A trigger keeps them
+// current on every write; this package checks them against transactions and
+// rebuilds any user whose totals have drifted.
+package totals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// CheckInterval is how often totals are checked. A check reads every transaction,
+// so it runs rarely.
+const CheckInterval = 24 * time.Hour
+
+// Mismatch is a group whose stored totals differ from its transactions
+type Mismatch = models.CheckMonthlyTotalsRow
+
+// Check compares the stored totals with transactions and rebuilds every user with a
+// mismatch. It returns the mismatches found.
+func Check(ctx context.Context, pool *pgxpool.Pool, q *models.Queries) ([]Mismatch, error) {
+	mismatches, err := q.CheckMonthlyTotals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check monthly totals: %w", err)
+	}
+
+	var errs []error
+	rebuilt := make(map[string]bool)
+	for _, m := range mismatches {
+		userID := utils.UUIDToString(m.UserID)
+		if rebuilt[userID] {
+			continue
+		}
+		rebuilt[userID] = true
+		if err := Rebuild(ctx, pool, q, m.UserID); err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", userID, err))
+		}
+	}
+	return mismatches, errors.Join(errs...)
+}
+
+// Rebuild recomputes a user's totals from their transactions. Writes to the user's
+// transactions wait for it, so nothing committed meanwhile is lost.
+func Rebuild(ctx context.Context, pool *pgxpool.Pool, q *models.Queries, userID pgtype.UUID) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := q.WithTx(tx)
+	if err := qtx.LockMonthlyTotals(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteMonthlyTotals(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.RebuildMonthlyTotals(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RunChecks checks totals every CheckInterval until ctx is done, logging what it
+// repairs. A rebuild is idempotent, so several instances can run at once.
+func RunChecks(ctx context.Context, pool *pgxpool.Pool, q *models.Queries) {
+	ticker := time.NewTicker(CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		mismatches, err := Check(ctx, pool, q)
+		for _, m := range mismatches {
+			log.Printf("monthly totals: user %s, %s %s: stored %s over %d, transactions %s over %d",
+				utils.UUIDToString(m.UserID), utils.DateToTime(m.Month).Format("2006-01"), utils.TextToString(m.Type),
+				money.FromNumeric(m.StoredTotal), m.StoredCount, money.FromNumeric(m.RawTotal), m.RawCount)
+		}
+		if err != nil {
+			log.Printf("monthly totals: %v", err)
+		}
+	}
+}
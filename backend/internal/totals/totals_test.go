@@ -0,0 +1,251 @@
+package totals
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/joselitophala/budget-planner-backend/internal/models"
+	"github.com/joselitophala/budget-planner-backend/internal/money"
+	"github.com/joselitophala/budget-planner-backend/internal/utils"
+)
+
+// benchTransactions is how many transactions the benchmark seeds: three years of a
+// heavy user's spending
+const benchTransactions = 300_000
+
+// The dashboard queries as they would read transactions without monthly_totals. A
+// budget's totals cover everything filed under it, whatever its date.
+const (
+	rawDashboardSummary = `
+WITH spent AS (
+    SELECT COALESCE(SUM(home_amount), 0) as total
+    FROM transactions t
+    WHERE t.budget_id = $1 AND t.type = 'expense' AND t.deleted = false
+),
+income AS (
+    SELECT COALESCE(SUM(home_amount), 0) as total
+    FROM transactions t
+    WHERE t.budget_id = $1 AND t.type = 'income' AND t.deleted = false
+),
+transactions_count AS (
+    SELECT COUNT(*) as total
+    FROM transactions t
+    WHERE t.budget_id = $1 AND t.deleted = false
+)
+SELECT s.total, i.total, tc.total
+FROM budgets b CROSS JOIN spent s CROSS JOIN income i CROSS JOIN transactions_count tc
+WHERE b.id = $1`
+
+	rawSpendingByCategory = `
+SELECT c.id, COALESCE(SUM(t.home_amount), 0) as total_spent
+FROM budget_categories bc
+JOIN categories c ON bc.category_id = c.id
+LEFT JOIN transactions t ON t.category_id = c.id
+    AND t.budget_id = $1 AND t.type = 'expense' AND t.deleted = false
+WHERE bc.budget_id = $1
+GROUP BY c.id, bc.limit_amount`
+
+	rawSpendingTrends = `
+SELECT DATE_TRUNC('month', transaction_date)::date as month,
+       SUM(CASE WHEN type = 'expense' THEN home_amount ELSE 0 END)::numeric,
+       SUM(CASE WHEN type = 'income' THEN home_amount ELSE 0 END)::numeric
+FROM transactions
+WHERE user_id = $1 AND deleted = false
+  AND transaction_date >= $2 AND transaction_date < $3
+GROUP BY DATE_TRUNC('month', transaction_date)`
+)
+
+// seedTransactions spreads $1 transactions evenly over the benchmark user's last
+// 36 months, categories and payment methods
+const seedTransactions = `
+INSERT INTO transactions (user_id, budget_id, category_id, payment_method_id, amount, type,
+                          transaction_date, currency, home_amount, exchange_rate)
+SELECT u.id, b.id, c.id, pm.id, amt, CASE WHEN n % 10 = 0 THEN 'income' ELSE 'expense' END,
+       d, 'PHP', amt, 1
+FROM users u
+CROSS JOIN (
+    SELECT n,
+           (DATE_TRUNC('month', CURRENT_DATE) - INTERVAL '35 months' + (n % 1080) * INTERVAL '1 day')::date AS d,
+           ROUND((random() * 2000 + 10)::numeric, 2) AS amt
+    FROM generate_series(1, $1::int) n
+) s
+JOIN budgets b ON b.user_id = u.id AND s.d >= b.month AND s.d < b.period_end
+JOIN LATERAL (SELECT id FROM categories WHERE user_id = u.id ORDER BY id OFFSET s.n % 20 LIMIT 1) c ON true
+JOIN LATERAL (SELECT id FROM payment_methods WHERE user_id = u.id ORDER BY id OFFSET s.n % 5 LIMIT 1) pm ON true
+WHERE u.clerk_user_id = 'bench_monthly_totals'`
+
+// seedMisfiled moves a few of the current budget's transactions into last month
+// without refiling them
+const seedMisfiled = `
+UPDATE transactions t
+SET transaction_date = (DATE_TRUNC('month', CURRENT_DATE) - INTERVAL '1 day')::date
+FROM budgets b JOIN users u ON u.id = b.user_id
+WHERE t.budget_id = b.id AND t.id IN (
+    SELECT id FROM transactions WHERE budget_id = b.id ORDER BY id LIMIT 10
+)
+  AND u.clerk_user_id = 'bench_monthly_totals' AND b.month = DATE_TRUNC('month', CURRENT_DATE)::date`
+
+// BenchmarkDashboardQueries compares the dashboard and trend queries on raw
+// transactions with the ones the app runs on monthly_totals. It needs a migrated
+// development database and is skipped without one:
+//
+//	BENCH_DATABASE_URL=postgres://... go test -run '^$' -bench DashboardQueries ./internal/totals
+//
+// Everything it seeds is rolled back, but the seed locks transactions until then,
+// so don't point it at a database anything else is using.
+func BenchmarkDashboardQueries(b *testing.B) {
+	url := os.Getenv("BENCH_DATABASE_URL")
+	if url == "" {
+		b.Skip("BENCH_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pool.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+
+	start := time.Now()
+	userID, budgetID := seed(ctx, b, tx)
+	b.Logf("seeded %d transactions in %s", benchTransactions, time.Since(start).Round(time.Millisecond))
+
+	q := models.New(pool).WithTx(tx)
+	thisMonth := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
+	trendStart := thisMonth.AddDate(0, -11, 0)
+
+	// Both sides must agree before their timings mean anything
+	summary, err := q.GetDashboardSummary(ctx, budgetID)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var rawSpent, rawIncome pgtype.Numeric
+	var rawCount int64
+	if err := tx.QueryRow(ctx, rawDashboardSummary, budgetID).Scan(&rawSpent, &rawIncome, &rawCount); err != nil {
+		b.Fatal(err)
+	}
+	spent, err := money.FromAny(summary.TotalSpent)
+	if err != nil {
+		b.Fatal(err)
+	}
+	income, err := money.FromAny(summary.TotalIncome)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if rawCount != summary.TransactionCount || spent != money.FromNumeric(rawSpent) || income != money.FromNumeric(rawIncome) {
+		b.Fatalf("monthly_totals has %d transactions, %s spent and %s income; transactions has %d, %s and %s",
+			summary.TransactionCount, spent, income, rawCount, money.FromNumeric(rawSpent), money.FromNumeric(rawIncome))
+	}
+
+	run := func(name string, query func() error) {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := query(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	exec := func(sql string, args ...interface{}) func() error {
+		return func() error {
+			rows, err := tx.Query(ctx, sql, args...)
+			if err != nil {
+				return err
+			}
+			rows.Close()
+			return rows.Err()
+		}
+	}
+
+	run("summary/transactions", exec(rawDashboardSummary, budgetID))
+	run("summary/monthly_totals", func() error {
+		_, err := q.GetDashboardSummary(ctx, budgetID)
+		return err
+	})
+	run("by_category/transactions", exec(rawSpendingByCategory, budgetID))
+	run("by_category/monthly_totals", func() error {
+		_, err := q.GetSpendingByCategory(ctx, utils.PgUUID(budgetID))
+		return err
+	})
+	run("trend/transactions", exec(rawSpendingTrends, userID, trendStart, thisMonth.AddDate(0, 1, 0)))
+	run("trend/monthly_totals", func() error {
+		_, err := q.GetSpendingTrends(ctx, models.GetSpendingTrendsParams{
+			UserID:     userID,
+			StartMonth: utils.PgDate(trendStart),
+			EndMonth:   utils.PgDate(thisMonth),
+		})
+		return err
+	})
+}
+
+// seed creates a user with 36 monthly budgets and benchTransactions transactions
+// spread over them, through the monthly_totals trigger, and returns the user and
+// the current month's budget
+func seed(ctx context.Context, b *testing.B, tx pgx.Tx) (pgtype.UUID, string) {
+	b.Helper()
+	statements := []string{
+		// Revisions and change events aren't what's being measured
+		`ALTER TABLE transactions DISABLE TRIGGER transactions_record_revision`,
+		`ALTER TABLE transactions DISABLE TRIGGER transactions_publish_change`,
+		`INSERT INTO users (clerk_user_id, email, name, currency)
+		 VALUES ('bench_monthly_totals', 'bench-monthly-totals@example.com', 'Benchmark', 'PHP')`,
+		`INSERT INTO categories (user_id, name)
+		 SELECT u.id, 'Category ' || n FROM users u, generate_series(1, 20) n
+		 WHERE u.clerk_user_id = 'bench_monthly_totals'`,
+		`INSERT INTO payment_methods (user_id, name, type)
+		 SELECT u.id, 'Account ' || n, 'bank' FROM users u, generate_series(1, 5) n
+		 WHERE u.clerk_user_id = 'bench_monthly_totals'`,
+		`INSERT INTO budgets (user_id, name, month, period_end, total_limit)
+		 SELECT u.id, 'Budget', m::date, (m + INTERVAL '1 month')::date, 50000
+		 FROM users u, generate_series(DATE_TRUNC('month', CURRENT_DATE) - INTERVAL '35 months',
+		                               DATE_TRUNC('month', CURRENT_DATE), INTERVAL '1 month') m
+		 WHERE u.clerk_user_id = 'bench_monthly_totals'`,
+		`INSERT INTO budget_categories (budget_id, category_id, limit_amount)
+		 SELECT b.id, c.id, 2500
+		 FROM budgets b JOIN categories c ON c.user_id = b.user_id
+		 JOIN users u ON u.id = b.user_id
+		 WHERE u.clerk_user_id = 'bench_monthly_totals'`,
+	}
+	for _, sql := range statements {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			b.Fatalf("seed failed: %v\n%s", err, sql)
+		}
+	}
+
+	// Inserting through the trigger also shows its write overhead
+	if _, err := tx.Exec(ctx, seedTransactions, benchTransactions); err != nil {
+		b.Fatalf("seed failed: %v", err)
+	}
+	// Transactions can stay filed under a budget after their date moves out of its
+	// period; both sides must still count them
+	if _, err := tx.Exec(ctx, seedMisfiled); err != nil {
+		b.Fatalf("seed failed: %v", err)
+	}
+	for _, table := range []string{"transactions", "monthly_totals"} {
+		if _, err := tx.Exec(ctx, "ANALYZE "+table); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	var userID pgtype.UUID
+	var budgetID string
+	err := tx.QueryRow(ctx, `
+		SELECT u.id, b.id FROM users u JOIN budgets b ON b.user_id = u.id
+		WHERE u.clerk_user_id = 'bench_monthly_totals' AND b.month = DATE_TRUNC('month', CURRENT_DATE)::date`,
+	).Scan(&userID, &budgetID)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return userID, budgetID
+}
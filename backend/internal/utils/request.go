@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+)
+
+// ClientIP returns the client IP address for the request, or nil if it can't be parsed.
+// Chi's RealIP middleware rewrites RemoteAddr from X-Forwarded-For / X-Real-IP when present.
+func ClientIP(r *http.Request) *netip.Addr {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return nil
+	}
+	return &addr
+}
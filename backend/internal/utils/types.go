@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -32,20 +33,12 @@ func PgDate(t interface{}) pgtype.Date {
 	return d
 }
 
-// numericToFloat64 converts pgtype.Numeric to float64
-func NumericToFloat64(n pgtype.Numeric) float64 {
-	if !n.Valid {
-		return 0
-	}
-	// Use the underlying float representation
-	f, _ := n.Float64Value()
-	return f.Float64
-}
-
 // pgNumeric converts a float64 to pgtype.Numeric
+// (pgtype.Numeric only scans from strings, so the float is formatted first).
+// For ratios and counts only; amounts use the money package.
 func PgNumeric(f float64) pgtype.Numeric {
 	var n pgtype.Numeric
-	_ = n.Scan(f)
+	_ = n.Scan(strconv.FormatFloat(f, 'f', -1, 64))
 	return n
 }
 
@@ -57,6 +50,23 @@ func UUIDToString(u pgtype.UUID) string {
 	return ""
 }
 
+// FormatUUID converts pgtype.UUID to the dashed form Postgres uses, which is how
+// IDs read into strings and the authenticated user's ID are written. Use it for
+// IDs that are compared or used as map keys alongside those.
+func FormatUUID(u pgtype.UUID) string {
+	if u.Valid {
+		b := u.Bytes
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	}
+	return ""
+}
+
+// UUIDEquals reports whether u holds the UUID id, whether or not id is dashed
+func UUIDEquals(u pgtype.UUID, id string) bool {
+	other := PgUUID(id)
+	return u.Valid && other.Valid && u.Bytes == other.Bytes
+}
+
 // pgText converts a string to pgtype.Text
 func PgText(s string) pgtype.Text {
 	var t pgtype.Text
@@ -82,7 +92,8 @@ func TextToStringPtr(t pgtype.Text) *string {
 	return nil
 }
 
-// numericToFloat64Ptr converts pgtype.Numeric to *float64
+// numericToFloat64Ptr converts pgtype.Numeric to *float64.
+// For ratios and counts only; amounts use the money package.
 func NumericToFloat64Ptr(n pgtype.Numeric) *float64 {
 	if !n.Valid {
 		return nil
@@ -91,15 +102,6 @@ func NumericToFloat64Ptr(n pgtype.Numeric) *float64 {
 	return &f.Float64
 }
 
-// pgNumericPtr converts *float64 to pgtype.Numeric
-func PgNumericPtr(f *float64) pgtype.Numeric {
-	var n pgtype.Numeric
-	if f != nil {
-		_ = n.Scan(*f)
-	}
-	return n
-}
-
 // pgTextPtr converts *string to pgtype.Text
 func PgTextPtr(s *string) pgtype.Text {
 	var t pgtype.Text
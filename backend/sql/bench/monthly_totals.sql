@@ -0,0 +1,160 @@
+-- Benchmark: dashboard and trend queries on raw transactions vs monthly_totals
+--
+-- Seeds one user with three years of transactions (about 300,000), runs the old
+-- queries against transactions and the current ones against monthly_totals with
+-- EXPLAIN ANALYZE, checks they agree, and rolls everything back. Run it against a
+-- migrated development database:
+--
+--   psql "$DATABASE_URL" -f sql/bench/monthly_totals.sql
+--
+-- This shows the plans. For timings over many runs of the queries the app actually
+-- sends, use BenchmarkDashboardQueries in internal/totals.
+--
+-- The seed takes an exclusive lock on transactions until it rolls back, so don't
+-- point it at a database anything else is using.
+
+\set ON_ERROR_STOP on
+\timing on
+
+BEGIN;
+
+-- Revisions and change events aren't what's being measured
+ALTER TABLE transactions DISABLE TRIGGER transactions_record_revision;
+ALTER TABLE transactions DISABLE TRIGGER transactions_publish_change;
+
+INSERT INTO users (clerk_user_id, email, name, currency)
+VALUES ('bench_monthly_totals', 'bench-monthly-totals@example.com', 'Benchmark', 'PHP')
+RETURNING id AS user_id \gset
+
+INSERT INTO categories (user_id, name)
+SELECT :'user_id', 'Category ' || n FROM generate_series(1, 20) n;
+
+INSERT INTO payment_methods (user_id, name, type)
+SELECT :'user_id', 'Account ' || n, 'bank' FROM generate_series(1, 5) n;
+
+INSERT INTO budgets (user_id, name, month, period_end, total_limit)
+SELECT :'user_id', 'Budget', m::date, (m + INTERVAL '1 month')::date, 50000
+FROM generate_series(DATE_TRUNC('month', CURRENT_DATE) - INTERVAL '35 months', DATE_TRUNC('month', CURRENT_DATE), INTERVAL '1 month') m;
+
+INSERT INTO budget_categories (budget_id, category_id, limit_amount)
+SELECT b.id, c.id, 2500
+FROM budgets b CROSS JOIN categories c
+WHERE b.user_id = :'user_id' AND c.user_id = :'user_id';
+
+-- Inserting through the trigger also shows its write overhead
+INSERT INTO transactions (user_id, budget_id, category_id, payment_method_id, amount, type, transaction_date, currency, home_amount, exchange_rate)
+SELECT :'user_id', b.id, c.id, pm.id, amt, CASE WHEN n % 10 = 0 THEN 'income' ELSE 'expense' END, d, 'PHP', amt, 1
+FROM (
+    SELECT n,
+           (DATE_TRUNC('month', CURRENT_DATE) - INTERVAL '35 months' + (n % 1080) * INTERVAL '1 day')::date AS d,
+           ROUND((random() * 2000 + 10)::numeric, 2) AS amt
+    FROM generate_series(1, 300000) n
+) s
+JOIN budgets b ON b.user_id = :'user_id' AND s.d >= b.month AND s.d < b.period_end
+JOIN LATERAL (SELECT id FROM categories WHERE user_id = :'user_id' ORDER BY id OFFSET s.n % 20 LIMIT 1) c ON true
+JOIN LATERAL (SELECT id FROM payment_methods WHERE user_id = :'user_id' ORDER BY id OFFSET s.n % 5 LIMIT 1) pm ON true;
+
+ANALYZE transactions;
+ANALYZE monthly_totals;
+
+SELECT id AS budget_id FROM budgets
+WHERE user_id = :'user_id' AND month = DATE_TRUNC('month', CURRENT_DATE)::date \gset
+
+\echo '== Dashboard summary: transactions'
+EXPLAIN (ANALYZE, BUFFERS, COSTS OFF)
+WITH spent AS (
+    SELECT COALESCE(SUM(home_amount), 0) as total
+    FROM transactions t
+    WHERE t.budget_id = :'budget_id' AND t.type = 'expense' AND t.deleted = false
+),
+income AS (
+    SELECT COALESCE(SUM(home_amount), 0) as total
+    FROM transactions t
+    WHERE t.budget_id = :'budget_id' AND t.type = 'income' AND t.deleted = false
+),
+transactions_count AS (
+    SELECT COUNT(*) as total
+    FROM transactions t
+    WHERE t.budget_id = :'budget_id' AND t.deleted = false
+)
+SELECT b.*, s.total, i.total, tc.total
+FROM budgets b CROSS JOIN spent s CROSS JOIN income i CROSS JOIN transactions_count tc
+WHERE b.id = :'budget_id';
+
+\echo '== Dashboard summary: monthly_totals'
+EXPLAIN (ANALYZE, BUFFERS, COSTS OFF)
+WITH totals AS (
+    SELECT
+        COALESCE(SUM(mt.total) FILTER (WHERE mt.type = 'expense'), 0) as spent,
+        COALESCE(SUM(mt.total) FILTER (WHERE mt.type = 'income'), 0) as income,
+        COALESCE(SUM(mt.transaction_count), 0)::bigint as transaction_count
+    FROM budgets b
+    JOIN monthly_totals mt ON mt.budget_id = b.id
+    WHERE b.id = :'budget_id'
+)
+SELECT b.*, t.spent, t.income, t.transaction_count
+FROM budgets b CROSS JOIN totals t
+WHERE b.id = :'budget_id';
+
+\echo '== Spending by category: transactions'
+EXPLAIN (ANALYZE, BUFFERS, COSTS OFF)
+SELECT c.id, COALESCE(SUM(t.home_amount), 0) as total_spent
+FROM budget_categories bc
+JOIN categories c ON bc.category_id = c.id
+LEFT JOIN transactions t ON t.category_id = c.id
+    AND t.budget_id = :'budget_id' AND t.type = 'expense' AND t.deleted = false
+WHERE bc.budget_id = :'budget_id'
+GROUP BY c.id, bc.limit_amount;
+
+\echo '== Spending by category: monthly_totals'
+EXPLAIN (ANALYZE, BUFFERS, COSTS OFF)
+SELECT c.id, COALESCE(SUM(mt.total), 0) as total_spent
+FROM budget_categories bc
+JOIN categories c ON bc.category_id = c.id
+LEFT JOIN monthly_totals mt ON mt.category_id = c.id
+    AND mt.budget_id = :'budget_id' AND mt.type = 'expense'
+WHERE bc.budget_id = :'budget_id'
+GROUP BY c.id, bc.limit_amount;
+
+\echo '== Twelve month trend: transactions'
+EXPLAIN (ANALYZE, BUFFERS, COSTS OFF)
+SELECT DATE_TRUNC('month', transaction_date)::date as month,
+       SUM(CASE WHEN type = 'expense' THEN home_amount ELSE 0 END)::numeric,
+       SUM(CASE WHEN type = 'income' THEN home_amount ELSE 0 END)::numeric
+FROM transactions
+WHERE user_id = :'user_id' AND deleted = false
+  AND transaction_date >= DATE_TRUNC('month', CURRENT_DATE) - INTERVAL '11 months'
+  AND transaction_date < DATE_TRUNC('month', CURRENT_DATE) + INTERVAL '1 month'
+GROUP BY DATE_TRUNC('month', transaction_date);
+
+\echo '== Twelve month trend: monthly_totals'
+EXPLAIN (ANALYZE, BUFFERS, COSTS OFF)
+SELECT month,
+       SUM(CASE WHEN type = 'expense' THEN total ELSE 0 END)::numeric,
+       SUM(CASE WHEN type = 'income' THEN total ELSE 0 END)::numeric
+FROM monthly_totals
+WHERE user_id = :'user_id'
+  AND month >= DATE_TRUNC('month', CURRENT_DATE) - INTERVAL '11 months'
+  AND month <= DATE_TRUNC('month', CURRENT_DATE)
+GROUP BY month;
+
+\echo '== Consistency check (expect 0 rows)'
+SELECT COUNT(*) AS mismatched_groups FROM (
+    SELECT 1
+    FROM (
+        SELECT user_id, budget_id, category_id, payment_method_id,
+               DATE_TRUNC('month', transaction_date)::date as month, type, is_transfer,
+               home_amount as raw_total, 1 as raw_count, 0 as stored_total, 0 as stored_count
+        FROM transactions
+        WHERE deleted = false AND user_id = :'user_id'
+        UNION ALL
+        SELECT user_id, budget_id, category_id, payment_method_id, month, type, is_transfer,
+               0, 0, total, transaction_count
+        FROM monthly_totals
+        WHERE user_id = :'user_id'
+    ) combined
+    GROUP BY user_id, budget_id, category_id, payment_method_id, month, type, is_transfer
+    HAVING SUM(raw_total) <> SUM(stored_total) OR SUM(raw_count) <> SUM(stored_count)
+) m;
+
+ROLLBACK;
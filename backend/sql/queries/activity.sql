@@ -0,0 +1,27 @@
+-- name: CreateActivityLog :one
+INSERT INTO activity_log (user_id, action, resource_type, resource_id, budget_id, details, request_id, ip_address, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: ListActivity :many
+SELECT a.id, a.user_id, a.action, a.resource_type, a.resource_id, a.budget_id, a.details,
+       a.request_id, a.ip_address, a.created_at, u.name AS user_name, u.email AS user_email
+FROM activity_log a
+LEFT JOIN users u ON a.user_id = u.id
+WHERE (
+    a.user_id = sqlc.arg('user_id')
+    OR a.budget_id IN (
+        SELECT b.id FROM budgets b WHERE b.user_id = sqlc.arg('user_id')
+        UNION
+        SELECT sa.budget_id FROM share_access sa WHERE sa.shared_with_id = sqlc.arg('user_id')
+    )
+  )
+  AND (sqlc.narg('budget_id')::uuid IS NULL OR a.budget_id = sqlc.narg('budget_id'))
+  AND (sqlc.narg('resource_type')::text IS NULL OR a.resource_type = sqlc.narg('resource_type'))
+  AND (sqlc.narg('resource_id')::uuid IS NULL OR a.resource_id = sqlc.narg('resource_id'))
+  AND (sqlc.narg('action')::text IS NULL OR a.action = sqlc.narg('action'))
+  AND (sqlc.narg('actor_id')::uuid IS NULL OR a.user_id = sqlc.narg('actor_id'))
+  AND (sqlc.narg('since')::timestamptz IS NULL OR a.created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::timestamptz IS NULL OR a.created_at < sqlc.narg('until'))
+ORDER BY a.created_at DESC, a.id DESC
+LIMIT sqlc.arg('page_limit') OFFSET sqlc.arg('page_offset');
@@ -0,0 +1,52 @@
+-- name: ListAlertRules :many
+SELECT * FROM alert_rules
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetAlertRuleByID :one
+SELECT * FROM alert_rules
+WHERE id = $1
+LIMIT 1;
+
+-- name: CreateAlertRule :one
+INSERT INTO alert_rules (user_id, kind, category_id, threshold_percent, amount, days_before, enabled, email)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: UpdateAlertRule :one
+UPDATE alert_rules
+SET
+    category_id = COALESCE(sqlc.narg('category_id'), category_id),
+    threshold_percent = COALESCE(sqlc.narg('threshold_percent'), threshold_percent),
+    amount = COALESCE(sqlc.narg('amount'), amount),
+    days_before = COALESCE(sqlc.narg('days_before'), days_before),
+    enabled = COALESCE(sqlc.narg('enabled'), enabled),
+    email = COALESCE(sqlc.narg('email'), email),
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteAlertRule :exec
+DELETE FROM alert_rules
+WHERE id = $1;
+
+-- name: ListBudgetAlertRules :many
+-- Enabled rules of everyone with access to the budget: the owner and shared members
+SELECT * FROM alert_rules
+WHERE enabled
+  AND user_id IN (
+    SELECT b.user_id FROM budgets b WHERE b.id = $1 AND b.deleted = false
+    UNION
+    SELECT sa.shared_with_id FROM share_access sa WHERE sa.budget_id = $1
+  )
+ORDER BY created_at ASC;
+
+-- name: ListUserAlertRulesByKind :many
+SELECT * FROM alert_rules
+WHERE user_id = $1 AND kind = $2 AND enabled
+ORDER BY created_at ASC;
+
+-- name: ListRecurringExpenses :many
+SELECT * FROM transactions
+WHERE user_id = $1 AND is_recurring = true AND type = 'expense' AND deleted = false
+ORDER BY transaction_date ASC;
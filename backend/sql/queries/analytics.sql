@@ -1,41 +1,20 @@
 -- name: GetDashboardSummary :one
-WITH budget_month AS (
-    SELECT month FROM budgets WHERE id = $1
-),
-spent AS (
-    SELECT COALESCE(SUM(amount), 0) as total
-    FROM transactions t
-    WHERE t.budget_id = $1 
-      AND t.type = 'expense' 
-      AND t.deleted = false
-      AND t.transaction_date >= (SELECT month FROM budget_month)
-      AND t.transaction_date < ((SELECT month FROM budget_month) + INTERVAL '1 month')
-),
-income AS (
-    SELECT COALESCE(SUM(amount), 0) as total
-    FROM transactions t
-    WHERE t.budget_id = $1 
-      AND t.type = 'income' 
-      AND t.deleted = false
-      AND t.transaction_date >= (SELECT month FROM budget_month)
-      AND t.transaction_date < ((SELECT month FROM budget_month) + INTERVAL '1 month')
-),
-transactions_count AS (
-    SELECT COUNT(*) as total
-    FROM transactions t
-    WHERE t.budget_id = $1 AND t.deleted = false
-      AND t.transaction_date >= (SELECT month FROM budget_month)
-      AND t.transaction_date < ((SELECT month FROM budget_month) + INTERVAL '1 month')
+WITH totals AS (
+    SELECT
+        COALESCE(SUM(mt.total) FILTER (WHERE mt.type = 'expense'), 0) as spent,
+        COALESCE(SUM(mt.total) FILTER (WHERE mt.type = 'income'), 0) as income,
+        COALESCE(SUM(mt.transaction_count), 0)::bigint as transaction_count
+    FROM budgets b
+    JOIN monthly_totals mt ON mt.budget_id = b.id
+    WHERE b.id = $1
 )
 SELECT 
     b.*,
-    s.total as total_spent,
-    i.total as total_income,
-    tc.total as transaction_count
+    t.spent as total_spent,
+    t.income as total_income,
+    t.transaction_count
 FROM budgets b
-CROSS JOIN spent s
-CROSS JOIN income i
-CROSS JOIN transactions_count tc
+CROSS JOIN totals t
 WHERE b.id = $1;
 
 -- name: GetSpendingByCategory :many
@@ -44,37 +23,33 @@ SELECT
     c.name,
     c.icon,
     c.color,
-    COALESCE(SUM(t.amount), 0) as total_spent,
-    COALESCE(SUM(t.amount), 0) / bc.limit_amount * 100 as percentage
+    COALESCE(SUM(mt.total), 0) as total_spent,
+    COALESCE(ROUND(SUM(mt.total) / NULLIF(bc.limit_amount, 0) * 100), 0)::int as percentage
 FROM budget_categories bc
 JOIN categories c ON bc.category_id = c.id
-LEFT JOIN transactions t ON t.category_id = c.id 
-    AND t.budget_id = $1 
-    AND t.type = 'expense' 
-    AND t.deleted = false
-    AND t.transaction_date >= (SELECT month FROM budgets WHERE id = $1)
-    AND t.transaction_date < ((SELECT month FROM budgets WHERE id = $1) + INTERVAL '1 month')
+LEFT JOIN monthly_totals mt ON mt.category_id = c.id 
+    AND mt.budget_id = $1 
+    AND mt.type = 'expense' 
 WHERE bc.budget_id = $1
 GROUP BY c.id, c.name, c.icon, c.color, bc.limit_amount
 ORDER BY total_spent DESC;
 
 -- name: GetSpendingTrends :many
 SELECT 
-    DATE_TRUNC('month', transaction_date) as month,
-    SUM(CASE WHEN type = 'expense' THEN amount ELSE 0 END) as expenses,
-    SUM(CASE WHEN type = 'income' THEN amount ELSE 0 END) as income
-FROM transactions
+    month,
+    SUM(CASE WHEN type = 'expense' THEN total ELSE 0 END)::numeric as expenses,
+    SUM(CASE WHEN type = 'income' THEN total ELSE 0 END)::numeric as income
+FROM monthly_totals
 WHERE user_id = $1 
-  AND deleted = false
-  AND transaction_date >= $2
-  AND transaction_date <= $3
-GROUP BY DATE_TRUNC('month', transaction_date)
+  AND month >= sqlc.arg('start_month')
+  AND month <= sqlc.arg('end_month')
+GROUP BY month
 ORDER BY month ASC;
 
 -- name: GetCategoryReport :many
 SELECT 
-    DATE_TRUNC('day', transaction_date) as date,
-    COALESCE(SUM(amount), 0) as total,
+    transaction_date as date,
+    COALESCE(SUM(home_amount), 0)::numeric as total,
     COUNT(*) as transaction_count
 FROM transactions
 WHERE user_id = $1 
@@ -83,7 +58,7 @@ WHERE user_id = $1
   AND deleted = false
   AND transaction_date >= $3
   AND transaction_date <= $4
-GROUP BY DATE_TRUNC('day', transaction_date)
+GROUP BY transaction_date
 ORDER BY date ASC;
 
 -- name: GetRecentTransactions :many
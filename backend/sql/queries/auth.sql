@@ -28,8 +28,47 @@ SELECT * FROM users
 WHERE id = $1 AND deleted = false
 LIMIT 1;
 
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 AND deleted = false
+LIMIT 1;
+
 -- name: ListAllUsers :many
 SELECT * FROM users
 WHERE deleted = false
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
+
+-- name: ProvisionUser :one
+INSERT INTO users (clerk_user_id, email, name)
+VALUES ($1, $2, $3)
+ON CONFLICT (clerk_user_id) DO UPDATE
+SET clerk_user_id = users.clerk_user_id
+RETURNING *;
+
+-- name: UpsertClerkUser :one
+INSERT INTO users (clerk_user_id, email, name)
+VALUES ($1, $2, $3)
+ON CONFLICT (clerk_user_id) DO UPDATE
+SET email = EXCLUDED.email,
+    name = COALESCE(EXCLUDED.name, users.name),
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteUserByClerkID :execrows
+UPDATE users
+SET deleted = true, updated_at = NOW()
+WHERE clerk_user_id = $1 AND deleted = false;
+
+-- name: UserHasHomeAmounts :one
+-- Whether anything is recorded in the user's home currency, including deleted records
+-- a restore could bring back. Changing the home currency would leave them in the old one.
+SELECT (
+    EXISTS (SELECT 1 FROM transactions WHERE user_id = sqlc.arg('user_id')::uuid)
+    OR EXISTS (SELECT 1 FROM budgets WHERE user_id = sqlc.arg('user_id')::uuid)
+    OR EXISTS (SELECT 1 FROM savings_goals WHERE user_id = sqlc.arg('user_id')::uuid)
+    OR EXISTS (SELECT 1 FROM debts WHERE user_id = sqlc.arg('user_id')::uuid)
+    OR EXISTS (SELECT 1 FROM assets WHERE user_id = sqlc.arg('user_id')::uuid)
+    OR EXISTS (SELECT 1 FROM income_sources WHERE user_id = sqlc.arg('user_id')::uuid)
+    OR EXISTS (SELECT 1 FROM alert_rules WHERE user_id = sqlc.arg('user_id')::uuid AND amount IS NOT NULL)
+)::boolean as has_amounts;
@@ -8,14 +8,22 @@ SELECT * FROM budgets
 WHERE id = $1 AND deleted = false
 LIMIT 1;
 
--- name: GetBudgetByMonth :one
+-- name: GetBudgetForUpdate :one
+-- Locks the budget until the transaction ends, so envelope checks and the writes
+-- they allow happen one at a time per budget
 SELECT * FROM budgets
-WHERE user_id = $1 AND month = $2 AND deleted = false
+WHERE id = $1 AND deleted = false
+FOR UPDATE;
+
+-- name: GetBudgetForDate :one
+-- The user's budget whose period includes a date; budgets can't overlap
+SELECT * FROM budgets
+WHERE user_id = $1 AND month <= sqlc.arg('date') AND period_end > sqlc.arg('date') AND deleted = false
 LIMIT 1;
 
 -- name: CreateBudget :one
-INSERT INTO budgets (user_id, name, month, total_limit)
-VALUES ($1, $2, $3, $4)
+INSERT INTO budgets (user_id, name, month, total_limit, envelope_mode, strict_envelopes, period_type, period_end)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: UpdateBudget :one
@@ -23,6 +31,8 @@ UPDATE budgets
 SET
     name = COALESCE(sqlc.narg('name'), name),
     total_limit = COALESCE(sqlc.narg('total_limit'), total_limit),
+    envelope_mode = COALESCE(sqlc.narg('envelope_mode'), envelope_mode),
+    strict_envelopes = COALESCE(sqlc.narg('strict_envelopes'), strict_envelopes),
     updated_at = NOW()
 WHERE id = $1 AND deleted = false
 RETURNING *;
@@ -38,6 +48,11 @@ FROM budget_categories bc
 JOIN categories c ON bc.category_id = c.id
 WHERE bc.budget_id = $1;
 
+-- name: GetBudgetCategoryByID :one
+SELECT * FROM budget_categories
+WHERE id = $1
+LIMIT 1;
+
 -- name: AddBudgetCategory :one
 INSERT INTO budget_categories (budget_id, category_id, limit_amount)
 VALUES ($1, $2, $3)
@@ -54,10 +69,9 @@ DELETE FROM budget_categories
 WHERE id = $1;
 
 -- name: GetBudgetSpent :one
-SELECT COALESCE(SUM(t.amount), 0) as total_spent
+-- Everything filed under the budget counts against it, whatever its date
+SELECT COALESCE(SUM(t.home_amount), 0) as total_spent
 FROM transactions t
 WHERE t.budget_id = $1 
   AND t.type = 'expense' 
-  AND t.deleted = false
-  AND t.transaction_date >= (SELECT month FROM budgets WHERE id = $1)
-  AND t.transaction_date < ((SELECT month FROM budgets WHERE id = $1) + INTERVAL '1 month');
+  AND t.deleted = false;
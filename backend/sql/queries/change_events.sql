@@ -0,0 +1,18 @@
+-- name: GetChangeEvent :one
+SELECT * FROM change_events
+WHERE id = $1;
+
+-- name: ListChangeEventsSince :many
+-- Events for a user after a Last-Event-ID, oldest first
+SELECT * FROM change_events
+WHERE recipients @> ARRAY[sqlc.arg('user_id')::uuid] AND id > sqlc.arg('after_id')
+ORDER BY id ASC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetOldestChangeEventID :one
+-- Returns 0 when there are no events
+SELECT COALESCE(MIN(id), 0)::bigint AS oldest_id FROM change_events;
+
+-- name: PruneChangeEvents :execrows
+DELETE FROM change_events
+WHERE created_at < sqlc.arg('created_before');
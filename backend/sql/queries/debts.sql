@@ -0,0 +1,54 @@
+-- name: ListDebts :many
+SELECT * FROM debts
+WHERE user_id = $1 AND deleted = false
+ORDER BY created_at ASC;
+
+-- name: GetDebtByID :one
+SELECT * FROM debts
+WHERE id = $1 AND deleted = false
+LIMIT 1;
+
+-- name: CreateDebt :one
+INSERT INTO debts (
+    user_id, name, kind, principal, balance_date,
+    apr, minimum_payment, due_day, payment_method_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: UpdateDebt :one
+UPDATE debts
+SET
+    name = COALESCE(sqlc.narg('name'), name),
+    kind = COALESCE(sqlc.narg('kind'), kind),
+    principal = COALESCE(sqlc.narg('principal'), principal),
+    balance_date = COALESCE(sqlc.narg('balance_date'), balance_date),
+    apr = COALESCE(sqlc.narg('apr'), apr),
+    minimum_payment = COALESCE(sqlc.narg('minimum_payment'), minimum_payment),
+    due_day = COALESCE(sqlc.narg('due_day'), due_day),
+    payment_method_id = COALESCE(sqlc.narg('payment_method_id'), payment_method_id),
+    updated_at = NOW()
+WHERE id = $1 AND deleted = false
+RETURNING *;
+
+-- name: DeleteDebt :exec
+UPDATE debts
+SET deleted = true, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetDebtPayments :many
+-- Net payments per debt since its balance_date, which the balance is measured from
+SELECT
+    d.id as debt_id,
+    COALESCE(SUM(CASE WHEN t.type = 'income' THEN -t.home_amount ELSE t.home_amount END), 0)::numeric as paid,
+    MAX(t.transaction_date)::date as last_payment_date
+FROM debts d
+LEFT JOIN transactions t ON t.debt_id = d.id AND t.deleted = false
+    AND t.transaction_date >= d.balance_date
+WHERE d.user_id = $1 AND d.deleted = false
+GROUP BY d.id;
+
+-- name: ListDebtPayments :many
+SELECT * FROM transactions
+WHERE debt_id = $1 AND deleted = false
+ORDER BY transaction_date DESC, created_at DESC;
@@ -0,0 +1,43 @@
+-- name: EnqueueEmail :execrows
+-- Returns 0 when an email with the same dedupe_key is already queued
+INSERT INTO email_outbox (user_id, to_address, template, data, dedupe_key)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (dedupe_key) DO NOTHING;
+
+-- name: ClaimEmails :many
+-- Takes due emails for delivery. Claimed rows stay pending but aren't due again until
+-- the lease has passed, so a worker that dies mid-send leaves them to be retried.
+UPDATE email_outbox
+SET attempts = attempts + 1,
+    next_attempt_at = NOW() + make_interval(secs => sqlc.arg('lease_seconds')::double precision)
+WHERE id IN (
+    SELECT id FROM email_outbox
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at ASC
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkEmailSent :exec
+UPDATE email_outbox
+SET status = 'sent', sent_at = NOW(), last_error = NULL
+WHERE id = $1;
+
+-- name: RetryEmail :exec
+UPDATE email_outbox
+SET last_error = $2, next_attempt_at = $3
+WHERE id = $1;
+
+-- name: FailEmail :exec
+UPDATE email_outbox
+SET status = 'failed', last_error = $2
+WHERE id = $1;
+
+-- name: ListSummaryBudgets :many
+-- Monthly budgets starting on a date with the owner's address, for month-end summaries
+SELECT b.id, b.user_id, u.email, u.name, u.currency
+FROM budgets b
+JOIN users u ON u.id = b.user_id
+WHERE b.month = $1 AND b.period_type = 'monthly' AND b.deleted = false AND u.deleted = false
+ORDER BY b.id;
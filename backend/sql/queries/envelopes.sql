@@ -0,0 +1,58 @@
+-- name: ListEnvelopeTotals :many
+-- Assigned amounts, income and spending per category for a user's envelope budgets up
+-- to a month, oldest first. is_envelope is false for categories the budget has no
+-- envelope for, whose spending comes out of the unassigned pool.
+WITH envelope_budgets AS (
+    SELECT id, month
+    FROM budgets
+    WHERE user_id = $1 AND envelope_mode = true AND deleted = false AND month <= $2
+),
+totals AS (
+    SELECT
+        mt.budget_id,
+        mt.category_id,
+        SUM(mt.total) FILTER (WHERE mt.type = 'income') as income,
+        SUM(mt.total) FILTER (WHERE mt.type = 'expense') as spent
+    FROM monthly_totals mt
+    JOIN envelope_budgets b ON mt.budget_id = b.id
+    WHERE mt.is_transfer = false
+    GROUP BY mt.budget_id, mt.category_id
+)
+SELECT
+    b.id as budget_id,
+    b.month,
+    COALESCE(bc.category_id, t.category_id) as category_id,
+    (bc.id IS NOT NULL)::boolean as is_envelope,
+    COALESCE(bc.limit_amount, 0)::numeric as assigned,
+    COALESCE(t.income, 0)::numeric as income,
+    COALESCE(t.spent, 0)::numeric as spent
+FROM (
+    SELECT bc.* FROM budget_categories bc
+    JOIN envelope_budgets b ON bc.budget_id = b.id
+) bc
+FULL JOIN totals t ON t.budget_id = bc.budget_id AND t.category_id = bc.category_id
+JOIN envelope_budgets b ON b.id = COALESCE(bc.budget_id, t.budget_id)
+ORDER BY b.month, b.id;
+
+-- name: GetBudgetCategoryForCategory :one
+SELECT * FROM budget_categories
+WHERE budget_id = $1 AND category_id = $2
+LIMIT 1;
+
+-- name: AdjustEnvelope :one
+-- Adds to what a budget assigns a category, creating its envelope if it has none
+INSERT INTO budget_categories (budget_id, category_id, limit_amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (budget_id, category_id) DO UPDATE
+SET limit_amount = budget_categories.limit_amount + EXCLUDED.limit_amount, updated_at = NOW()
+RETURNING *;
+
+-- name: CreateEnvelopeMove :one
+INSERT INTO envelope_moves (budget_id, user_id, from_category_id, to_category_id, amount, note)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListEnvelopeMoves :many
+SELECT * FROM envelope_moves
+WHERE budget_id = $1
+ORDER BY created_at DESC;
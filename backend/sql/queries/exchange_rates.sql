@@ -0,0 +1,23 @@
+-- name: GetExchangeRate :one
+-- Latest rate on or before the date in either direction; a direct quote wins over an inverse one
+SELECT * FROM exchange_rates
+WHERE ((base = sqlc.arg('from_currency') AND quote = sqlc.arg('to_currency'))
+    OR (base = sqlc.arg('to_currency') AND quote = sqlc.arg('from_currency')))
+  AND rate_date <= sqlc.arg('on_date')
+ORDER BY rate_date DESC, base = sqlc.arg('from_currency') DESC
+LIMIT 1;
+
+-- name: ListExchangeRates :many
+SELECT * FROM exchange_rates
+WHERE (sqlc.narg('base')::text IS NULL OR base = sqlc.narg('base'))
+  AND (sqlc.narg('quote')::text IS NULL OR quote = sqlc.narg('quote'))
+  AND (sqlc.narg('from_date')::date IS NULL OR rate_date >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::date IS NULL OR rate_date <= sqlc.narg('to_date'))
+ORDER BY rate_date DESC, base, quote
+LIMIT sqlc.arg('page_limit') OFFSET sqlc.arg('page_offset');
+
+-- name: UpsertExchangeRate :exec
+INSERT INTO exchange_rates (rate_date, base, quote, rate)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (base, quote, rate_date) DO UPDATE
+SET rate = EXCLUDED.rate, updated_at = NOW();
@@ -0,0 +1,25 @@
+-- name: ListDailyTotals :many
+-- Daily totals per category and payment method for spending that happens at its own pace:
+-- recurring transactions, debt payments and transfers are projected separately
+SELECT
+    transaction_date,
+    type,
+    category_id,
+    payment_method_id,
+    SUM(home_amount)::numeric as total
+FROM transactions
+WHERE user_id = sqlc.arg('user_id')
+  AND deleted = false
+  AND is_transfer = false
+  AND is_recurring = false
+  AND debt_id IS NULL
+  AND transaction_date >= sqlc.arg('start_date')
+  AND transaction_date <= sqlc.arg('end_date')
+GROUP BY transaction_date, type, category_id, payment_method_id
+ORDER BY transaction_date ASC;
+
+-- name: ListRecurringTransactions :many
+-- Recurring expenses and income, projected from their recurrence patterns
+SELECT * FROM transactions
+WHERE user_id = $1 AND is_recurring = true AND is_transfer = false AND deleted = false
+ORDER BY transaction_date ASC;
@@ -0,0 +1,49 @@
+-- name: ListSavingsGoals :many
+SELECT * FROM savings_goals
+WHERE user_id = $1 AND deleted = false
+ORDER BY target_date ASC NULLS LAST, created_at ASC;
+
+-- name: GetSavingsGoalByID :one
+SELECT * FROM savings_goals
+WHERE id = $1 AND deleted = false
+LIMIT 1;
+
+-- name: CreateSavingsGoal :one
+INSERT INTO savings_goals (user_id, name, target_amount, target_date, payment_method_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: UpdateSavingsGoal :one
+UPDATE savings_goals
+SET
+    name = COALESCE(sqlc.narg('name'), name),
+    target_amount = COALESCE(sqlc.narg('target_amount'), target_amount),
+    target_date = COALESCE(sqlc.narg('target_date'), target_date),
+    payment_method_id = COALESCE(sqlc.narg('payment_method_id'), payment_method_id),
+    updated_at = NOW()
+WHERE id = $1 AND deleted = false
+RETURNING *;
+
+-- name: DeleteSavingsGoal :exec
+UPDATE savings_goals
+SET deleted = true, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetSavingsGoalTotals :many
+-- Net contributions per goal: all time, since recent_since (for the pace) and within the month
+SELECT
+    g.id as goal_id,
+    COALESCE(SUM(CASE WHEN t.type = 'income' THEN -t.home_amount ELSE t.home_amount END), 0)::numeric as saved,
+    COALESCE(SUM(CASE WHEN t.type = 'income' THEN -t.home_amount ELSE t.home_amount END) FILTER (WHERE t.transaction_date > sqlc.arg('recent_since')), 0)::numeric as recent,
+    COALESCE(SUM(CASE WHEN t.type = 'income' THEN -t.home_amount ELSE t.home_amount END) FILTER (
+        WHERE t.transaction_date >= sqlc.arg('month_start') AND t.transaction_date < (sqlc.arg('month_start')::date + INTERVAL '1 month')
+    ), 0)::numeric as month_total
+FROM savings_goals g
+LEFT JOIN transactions t ON t.goal_id = g.id AND t.deleted = false
+WHERE g.user_id = sqlc.arg('user_id') AND g.deleted = false
+GROUP BY g.id;
+
+-- name: ListGoalContributions :many
+SELECT * FROM transactions
+WHERE goal_id = $1 AND deleted = false
+ORDER BY transaction_date DESC, created_at DESC;
@@ -0,0 +1,73 @@
+-- name: ListRecordRevisions :many
+SELECT * FROM record_revisions
+WHERE table_name = $1 AND record_id = $2
+ORDER BY revision DESC;
+
+-- name: GetRecordRevision :one
+SELECT * FROM record_revisions
+WHERE table_name = $1 AND record_id = $2 AND revision = $3
+LIMIT 1;
+
+-- name: GetLatestRecordRevision :one
+SELECT * FROM record_revisions
+WHERE table_name = $1 AND record_id = $2
+ORDER BY revision DESC
+LIMIT 1;
+
+-- name: MarkRestoring :exec
+SELECT set_config('app.restoring', 'on', true);
+
+-- name: RestoreTransaction :one
+UPDATE transactions t
+SET
+    budget_id = r.budget_id,
+    category_id = r.category_id,
+    payment_method_id = r.payment_method_id,
+    amount = r.amount,
+    type = r.type,
+    is_transfer = r.is_transfer,
+    transfer_to_account_id = r.transfer_to_account_id,
+    description = r.description,
+    transaction_date = r.transaction_date,
+    is_recurring = r.is_recurring,
+    recurrence_pattern = r.recurrence_pattern,
+    -- Revisions from before multi-currency were in the home currency
+    currency = COALESCE(r.currency, t.currency),
+    home_amount = COALESCE(r.home_amount, r.amount),
+    exchange_rate = COALESCE(r.exchange_rate, 1),
+    goal_id = r.goal_id,
+    debt_id = r.debt_id,
+    deleted = false,
+    updated_at = NOW()
+FROM jsonb_populate_record(NULL::transactions, sqlc.arg('data')::jsonb) r
+WHERE t.id = sqlc.arg('id')
+RETURNING t.*;
+
+-- name: RestoreBudget :one
+UPDATE budgets b
+SET
+    name = r.name,
+    month = r.month,
+    total_limit = r.total_limit,
+    -- Revisions from before envelope budgeting had neither flag
+    envelope_mode = COALESCE(r.envelope_mode, false),
+    strict_envelopes = COALESCE(r.strict_envelopes, false),
+    -- and before budget periods were calendar months
+    period_type = COALESCE(r.period_type, 'monthly'),
+    period_end = COALESCE(r.period_end, (r.month + INTERVAL '1 month')::date),
+    deleted = false,
+    updated_at = NOW()
+FROM jsonb_populate_record(NULL::budgets, sqlc.arg('data')::jsonb) r
+WHERE b.id = sqlc.arg('id')
+RETURNING b.*;
+
+-- name: RestoreBudgetCategory :one
+INSERT INTO budget_categories (id, budget_id, category_id, limit_amount, created_at, updated_at)
+SELECT r.id, r.budget_id, r.category_id, r.limit_amount, r.created_at, NOW()
+FROM jsonb_populate_record(NULL::budget_categories, sqlc.arg('data')::jsonb) r
+ON CONFLICT (id) DO UPDATE
+SET
+    category_id = EXCLUDED.category_id,
+    limit_amount = EXCLUDED.limit_amount,
+    updated_at = NOW()
+RETURNING *;
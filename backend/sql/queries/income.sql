@@ -0,0 +1,59 @@
+-- name: ListIncomeSources :many
+SELECT * FROM income_sources
+WHERE user_id = $1 AND deleted = false
+ORDER BY created_at ASC;
+
+-- name: GetIncomeSourceByID :one
+SELECT * FROM income_sources
+WHERE id = $1 AND deleted = false
+LIMIT 1;
+
+-- name: CreateIncomeSource :one
+INSERT INTO income_sources (user_id, name, kind, category_id, amount, months, start_month, end_month)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: UpdateIncomeSource :one
+-- Replaces every editable field; the handler merges the request with the source first
+UPDATE income_sources
+SET
+    name = $2,
+    kind = $3,
+    category_id = $4,
+    amount = $5,
+    months = $6,
+    start_month = $7,
+    end_month = $8,
+    updated_at = NOW()
+WHERE id = $1 AND deleted = false
+RETURNING *;
+
+-- name: DeleteIncomeSource :exec
+UPDATE income_sources
+SET deleted = true, updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListIncomeExpectations :many
+-- The user's per-month overrides of what a source is expected to pay in month
+SELECT e.* FROM income_expectations e
+JOIN income_sources s ON e.source_id = s.id
+WHERE s.user_id = $1 AND s.deleted = false AND e.month = $2;
+
+-- name: UpsertIncomeExpectation :one
+INSERT INTO income_expectations (source_id, month, amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (source_id, month) DO UPDATE SET amount = EXCLUDED.amount
+RETURNING *;
+
+-- name: DeleteIncomeExpectation :execrows
+DELETE FROM income_expectations
+WHERE source_id = $1 AND month = $2;
+
+-- name: ListMonthTotalsByCategory :many
+-- A user's own income and expenses in a month per category, transfers left out
+SELECT type, category_id, SUM(total)::numeric as total
+FROM monthly_totals
+WHERE user_id = $1
+  AND month = $2
+  AND is_transfer = false
+GROUP BY type, category_id;
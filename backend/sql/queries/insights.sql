@@ -0,0 +1,33 @@
+-- name: ListInsightTransactions :many
+-- Expenses insights and subscriptions are computed from, oldest first
+SELECT
+    t.id,
+    t.transaction_date,
+    t.category_id,
+    c.name as category_name,
+    t.description,
+    t.home_amount,
+    t.payment_method_id,
+    t.is_recurring
+FROM transactions t
+LEFT JOIN categories c ON t.category_id = c.id
+WHERE t.user_id = sqlc.arg('user_id')
+  AND t.type = 'expense'
+  AND t.is_transfer = false
+  AND t.deleted = false
+  AND t.transaction_date >= sqlc.arg('start_date')
+  AND t.transaction_date <= sqlc.arg('end_date')
+ORDER BY t.transaction_date ASC, t.created_at ASC;
+
+-- name: ListInsightDismissals :many
+SELECT * FROM insight_dismissals
+WHERE user_id = $1;
+
+-- name: DismissInsight :exec
+INSERT INTO insight_dismissals (user_id, insight_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, insight_id) DO NOTHING;
+
+-- name: RestoreInsight :execrows
+DELETE FROM insight_dismissals
+WHERE user_id = $1 AND insight_id = $2;
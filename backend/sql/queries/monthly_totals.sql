@@ -0,0 +1,40 @@
+-- name: CheckMonthlyTotals :many
+-- Groups whose stored totals don't match their transactions
+SELECT
+    user_id, budget_id, category_id, payment_method_id, month, type, is_transfer,
+    SUM(raw_total)::numeric as raw_total,
+    SUM(raw_count)::bigint as raw_count,
+    SUM(stored_total)::numeric as stored_total,
+    SUM(stored_count)::bigint as stored_count
+FROM (
+    SELECT user_id, budget_id, category_id, payment_method_id,
+           DATE_TRUNC('month', transaction_date)::date as month, type, is_transfer,
+           home_amount as raw_total, 1 as raw_count, 0 as stored_total, 0 as stored_count
+    FROM transactions
+    WHERE deleted = false AND user_id IS NOT NULL
+    UNION ALL
+    SELECT user_id, budget_id, category_id, payment_method_id, month, type, is_transfer,
+           0, 0, total, transaction_count
+    FROM monthly_totals
+    WHERE user_id IS NOT NULL
+) combined
+GROUP BY user_id, budget_id, category_id, payment_method_id, month, type, is_transfer
+HAVING SUM(raw_total) <> SUM(stored_total) OR SUM(raw_count) <> SUM(stored_count)
+ORDER BY user_id, month;
+
+-- name: DeleteMonthlyTotals :exec
+DELETE FROM monthly_totals
+WHERE user_id = $1;
+
+-- name: LockMonthlyTotals :exec
+-- Holds off trigger updates to a user's totals until the end of the transaction
+SELECT pg_advisory_xact_lock(monthly_totals_lock_key($1));
+
+-- name: RebuildMonthlyTotals :exec
+-- Recomputes a user's totals from their transactions, after DeleteMonthlyTotals
+INSERT INTO monthly_totals (user_id, budget_id, category_id, payment_method_id, month, type, is_transfer, total, transaction_count)
+SELECT user_id, budget_id, category_id, payment_method_id, DATE_TRUNC('month', transaction_date)::date,
+       type, is_transfer, SUM(home_amount), COUNT(*)
+FROM transactions
+WHERE user_id = $1 AND deleted = false
+GROUP BY user_id, budget_id, category_id, payment_method_id, DATE_TRUNC('month', transaction_date), type, is_transfer;
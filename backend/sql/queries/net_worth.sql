@@ -0,0 +1,78 @@
+-- name: ListAssets :many
+-- Assets with their latest valuation
+SELECT a.*, v.value as current_value, v.valued_on
+FROM assets a
+LEFT JOIN LATERAL (
+    SELECT value, valued_on FROM asset_valuations
+    WHERE asset_id = a.id
+    ORDER BY valued_on DESC
+    LIMIT 1
+) v ON true
+WHERE a.user_id = $1 AND a.deleted = false
+ORDER BY a.created_at ASC;
+
+-- name: GetAssetByID :one
+SELECT * FROM assets
+WHERE id = $1 AND deleted = false
+LIMIT 1;
+
+-- name: CreateAsset :one
+INSERT INTO assets (user_id, name, asset_class, notes)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpdateAsset :one
+UPDATE assets
+SET
+    name = COALESCE(sqlc.narg('name'), name),
+    asset_class = COALESCE(sqlc.narg('asset_class'), asset_class),
+    notes = COALESCE(sqlc.narg('notes'), notes),
+    updated_at = NOW()
+WHERE id = $1 AND deleted = false
+RETURNING *;
+
+-- name: DeleteAsset :exec
+UPDATE assets
+SET deleted = true, updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListAssetValuations :many
+SELECT * FROM asset_valuations
+WHERE asset_id = $1
+ORDER BY valued_on DESC;
+
+-- name: UpsertAssetValuation :one
+INSERT INTO asset_valuations (asset_id, value, valued_on)
+VALUES ($1, $2, $3)
+ON CONFLICT (asset_id, valued_on) DO UPDATE SET value = EXCLUDED.value
+RETURNING *;
+
+-- name: DeleteAssetValuation :execrows
+DELETE FROM asset_valuations
+WHERE id = $1 AND asset_id = $2;
+
+-- name: ListNetWorthUsers :many
+-- Users with anything to put in a net worth snapshot
+SELECT u.id, u.currency
+FROM users u
+WHERE u.deleted = false
+  AND (
+    EXISTS (SELECT 1 FROM payment_methods pm WHERE pm.user_id = u.id AND pm.deleted = false AND pm.current_balance IS NOT NULL)
+    OR EXISTS (SELECT 1 FROM assets a WHERE a.user_id = u.id AND a.deleted = false)
+    OR EXISTS (SELECT 1 FROM debts d WHERE d.user_id = u.id AND d.deleted = false)
+  );
+
+-- name: UpsertNetWorthSnapshot :exec
+INSERT INTO net_worth_snapshots (user_id, month, assets, liabilities, net_worth, breakdown)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, month) DO UPDATE SET
+    assets = EXCLUDED.assets,
+    liabilities = EXCLUDED.liabilities,
+    net_worth = EXCLUDED.net_worth,
+    breakdown = EXCLUDED.breakdown,
+    recorded_at = NOW();
+
+-- name: ListNetWorthSnapshots :many
+SELECT * FROM net_worth_snapshots
+WHERE user_id = $1 AND month >= $2
+ORDER BY month ASC;
@@ -0,0 +1,32 @@
+-- name: CreateNotification :one
+-- Returns no rows when the user already has a notification with the same dedupe_key
+INSERT INTO notifications (user_id, kind, title, body, data, dedupe_key)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, dedupe_key) DO NOTHING
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg('user_id')
+  AND (NOT sqlc.arg('unread_only')::boolean OR read_at IS NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit') OFFSET sqlc.arg('page_offset');
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: SetNotificationRead :one
+UPDATE notifications
+SET read_at = CASE WHEN sqlc.arg('read')::boolean THEN COALESCE(read_at, NOW()) ELSE NULL END
+WHERE id = sqlc.arg('id') AND user_id = sqlc.arg('user_id')
+RETURNING *;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: DeleteNotification :execrows
+DELETE FROM notifications
+WHERE id = $1 AND user_id = $2;
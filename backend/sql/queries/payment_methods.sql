@@ -11,11 +11,11 @@ LIMIT 1;
 -- name: CreatePaymentMethod :one
 INSERT INTO payment_methods (
     user_id, name, type, last_four, brand,
-    is_default, is_active, credit_limit, current_balance
+    is_default, is_active, credit_limit, current_balance, currency
 )
 VALUES (
     $1, $2, $3, $4, $5,
-    $6, $7, $8, $9
+    $6, $7, $8, $9, $10
 )
 RETURNING *;
 
@@ -30,6 +30,7 @@ SET
     is_active = COALESCE(sqlc.narg('is_active'), is_active),
     credit_limit = COALESCE(sqlc.narg('credit_limit'), credit_limit),
     current_balance = COALESCE(sqlc.narg('current_balance'), current_balance),
+    currency = COALESCE(sqlc.narg('currency'), currency),
     updated_at = NOW()
 WHERE id = $1 AND deleted = false
 RETURNING *;
@@ -0,0 +1,71 @@
+-- name: UpsertPushSubscription :one
+-- Registering an endpoint again updates its keys. It only moves to another user when
+-- they present the keys it was registered with, i.e. they hold the device's
+-- subscription, so knowing an endpoint URL isn't enough to take it over. Returns no
+-- row otherwise.
+INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (endpoint) DO UPDATE
+SET user_id = EXCLUDED.user_id,
+    p256dh = EXCLUDED.p256dh,
+    auth = EXCLUDED.auth,
+    user_agent = EXCLUDED.user_agent,
+    updated_at = NOW()
+WHERE push_subscriptions.user_id = EXCLUDED.user_id
+   OR (push_subscriptions.p256dh = EXCLUDED.p256dh AND push_subscriptions.auth = EXCLUDED.auth)
+RETURNING *;
+
+-- name: ListPushSubscriptions :many
+SELECT * FROM push_subscriptions
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeletePushSubscription :execrows
+DELETE FROM push_subscriptions
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeletePushSubscriptionByEndpoint :execrows
+DELETE FROM push_subscriptions
+WHERE endpoint = $1 AND user_id = $2;
+
+-- name: PrunePushSubscription :exec
+DELETE FROM push_subscriptions
+WHERE id = $1;
+
+-- name: EnqueuePush :execrows
+-- Queues a message for each of the user's devices and returns how many were queued
+INSERT INTO push_outbox (subscription_id, payload)
+SELECT id, $2 FROM push_subscriptions
+WHERE user_id = $1;
+
+-- name: ClaimPushMessages :many
+-- Takes due messages for delivery together with their subscription, with the same
+-- lease as ClaimEmails
+UPDATE push_outbox o
+SET attempts = o.attempts + 1,
+    next_attempt_at = NOW() + make_interval(secs => sqlc.arg('lease_seconds')::double precision)
+FROM push_subscriptions s
+WHERE s.id = o.subscription_id
+  AND o.id IN (
+    SELECT id FROM push_outbox
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at ASC
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING o.id, o.subscription_id, o.payload, o.attempts, o.created_at, s.endpoint, s.p256dh, s.auth;
+
+-- name: MarkPushSent :exec
+UPDATE push_outbox
+SET status = 'sent', sent_at = NOW(), last_error = NULL
+WHERE id = $1;
+
+-- name: RetryPush :exec
+UPDATE push_outbox
+SET last_error = $2, next_attempt_at = $3
+WHERE id = $1;
+
+-- name: FailPush :exec
+UPDATE push_outbox
+SET status = 'failed', last_error = $2
+WHERE id = $1;
@@ -0,0 +1,73 @@
+-- name: ListPeriodTotals :many
+-- Income and expenses over a period per category and payment method, for one
+-- user's transactions or, with budget_id, a (possibly shared) budget's. Transfers
+-- only move money between accounts, so they're left out.
+SELECT
+    t.type,
+    t.category_id,
+    c.name as category_name,
+    t.payment_method_id,
+    pm.name as payment_method_name,
+    SUM(t.home_amount)::numeric as total,
+    COUNT(*) as transaction_count
+FROM transactions t
+LEFT JOIN categories c ON t.category_id = c.id
+LEFT JOIN payment_methods pm ON t.payment_method_id = pm.id
+WHERE t.deleted = false
+  AND t.is_transfer = false
+  AND (sqlc.narg('user_id')::uuid IS NULL OR t.user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('budget_id')::uuid IS NULL OR t.budget_id = sqlc.narg('budget_id'))
+  AND t.transaction_date >= sqlc.arg('start_date')
+  AND t.transaction_date <= sqlc.arg('end_date')
+GROUP BY t.type, t.category_id, c.name, t.payment_method_id, pm.name;
+
+-- name: ListMonthlyCategoryTotals :many
+-- Monthly spending per category, scoped like ListPeriodTotals
+SELECT
+    mt.month,
+    mt.category_id,
+    c.name as category_name,
+    SUM(mt.total)::numeric as total
+FROM monthly_totals mt
+LEFT JOIN categories c ON mt.category_id = c.id
+WHERE mt.is_transfer = false
+  AND mt.type = 'expense'
+  AND (sqlc.narg('user_id')::uuid IS NULL OR mt.user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('budget_id')::uuid IS NULL OR mt.budget_id = sqlc.narg('budget_id'))
+  AND mt.month >= sqlc.arg('start_date')
+  AND mt.month <= sqlc.arg('end_date')
+GROUP BY mt.month, mt.category_id, c.name
+ORDER BY month ASC;
+
+-- name: ListReportTotals :many
+-- Income and expenses per day, category and payment method for a custom report,
+-- over the user's own transactions or, with budget_ids, those budgets'. Every other
+-- filter is optional. Transfers are left out.
+SELECT
+    t.transaction_date,
+    t.type,
+    t.category_id,
+    c.name as category_name,
+    t.payment_method_id,
+    pm.name as payment_method_name,
+    SUM(t.home_amount)::numeric as total,
+    COUNT(*) as transaction_count
+FROM transactions t
+LEFT JOIN categories c ON t.category_id = c.id
+LEFT JOIN payment_methods pm ON t.payment_method_id = pm.id
+WHERE t.deleted = false
+  AND t.is_transfer = false
+  AND (
+    (t.user_id = sqlc.arg('user_id') AND sqlc.narg('budget_ids')::uuid[] IS NULL)
+    OR t.budget_id = ANY(sqlc.narg('budget_ids')::uuid[])
+  )
+  AND t.transaction_date >= sqlc.arg('start_date')
+  AND t.transaction_date <= sqlc.arg('end_date')
+  AND (sqlc.narg('category_ids')::uuid[] IS NULL OR t.category_id = ANY(sqlc.narg('category_ids')::uuid[]))
+  AND (sqlc.narg('payment_method_ids')::uuid[] IS NULL OR t.payment_method_id = ANY(sqlc.narg('payment_method_ids')::uuid[]))
+  AND (sqlc.narg('type')::text IS NULL OR t.type = sqlc.narg('type'))
+  AND (sqlc.narg('min_amount')::numeric IS NULL OR t.home_amount >= sqlc.narg('min_amount'))
+  AND (sqlc.narg('max_amount')::numeric IS NULL OR t.home_amount <= sqlc.narg('max_amount'))
+  AND (sqlc.narg('search')::text IS NULL OR STRPOS(LOWER(t.description), LOWER(sqlc.narg('search'))) > 0)
+  AND (sqlc.narg('shared')::boolean IS NULL OR EXISTS (SELECT 1 FROM share_access sa WHERE sa.budget_id = t.budget_id) = sqlc.narg('shared'))
+GROUP BY t.transaction_date, t.type, t.category_id, c.name, t.payment_method_id, pm.name;
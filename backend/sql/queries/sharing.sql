@@ -82,3 +82,19 @@ SELECT sa.permission, false as is_owner
 FROM share_access sa
 WHERE sa.budget_id = $1 AND sa.shared_with_id = $2
 LIMIT 1;
+
+-- name: TransferBudgetOwnership :one
+UPDATE budgets
+SET user_id = $2, updated_at = NOW()
+WHERE id = $1 AND deleted = false
+RETURNING *;
+
+-- name: TransferShareAccessOwner :exec
+UPDATE share_access
+SET owner_id = $2, updated_at = NOW()
+WHERE budget_id = $1;
+
+-- name: TransferPendingInvitationsOwner :exec
+UPDATE share_invitations
+SET owner_id = $2, updated_at = NOW()
+WHERE budget_id = $1 AND status = 'pending';
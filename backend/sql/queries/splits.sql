@@ -0,0 +1,65 @@
+-- name: UpsertExpenseSplit :one
+INSERT INTO expense_splits (transaction_id, budget_id, paid_by_id, split_method)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (transaction_id) DO UPDATE
+SET budget_id = EXCLUDED.budget_id,
+    paid_by_id = EXCLUDED.paid_by_id,
+    split_method = EXCLUDED.split_method,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetExpenseSplitByTransaction :one
+SELECT * FROM expense_splits
+WHERE transaction_id = $1
+LIMIT 1;
+
+-- name: DeleteExpenseSplit :exec
+DELETE FROM expense_splits
+WHERE transaction_id = $1;
+
+-- name: CreateExpenseSplitShare :one
+INSERT INTO expense_split_shares (split_id, user_id, value, amount)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetExpenseSplitShares :many
+SELECT * FROM expense_split_shares
+WHERE split_id = $1
+ORDER BY amount DESC;
+
+-- name: UpdateExpenseSplitShareAmount :exec
+UPDATE expense_split_shares
+SET amount = $2
+WHERE id = $1;
+
+-- name: DeleteExpenseSplitShares :exec
+DELETE FROM expense_split_shares
+WHERE split_id = $1;
+
+-- name: GetBudgetSplitShares :many
+SELECT es.paid_by_id, ess.user_id, ess.amount
+FROM expense_splits es
+JOIN expense_split_shares ess ON ess.split_id = es.id
+JOIN transactions t ON t.id = es.transaction_id
+WHERE es.budget_id = $1 AND t.deleted = false;
+
+-- name: GetBudgetMembers :many
+SELECT u.id, u.name, u.email, 'owner'::varchar AS permission
+FROM budgets b
+JOIN users u ON b.user_id = u.id
+WHERE b.id = $1
+UNION ALL
+SELECT u.id, u.name, u.email, sa.permission
+FROM share_access sa
+JOIN users u ON sa.shared_with_id = u.id
+WHERE sa.budget_id = $1;
+
+-- name: CreateSettlement :one
+INSERT INTO settlements (budget_id, from_user_id, to_user_id, amount, note, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListSettlementsByBudget :many
+SELECT * FROM settlements
+WHERE budget_id = $1
+ORDER BY created_at DESC;
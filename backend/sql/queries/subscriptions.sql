@@ -0,0 +1,13 @@
+-- name: ListSubscriptionCancellations :many
+SELECT * FROM subscription_cancellations
+WHERE user_id = $1;
+
+-- name: CancelSubscription :one
+INSERT INTO subscription_cancellations (user_id, subscription_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, subscription_id) DO UPDATE SET cancelled_at = subscription_cancellations.cancelled_at
+RETURNING *;
+
+-- name: RestoreSubscription :execrows
+DELETE FROM subscription_cancellations
+WHERE user_id = $1 AND subscription_id = $2;
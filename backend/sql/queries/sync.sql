@@ -77,3 +77,11 @@ SET
     status = $2,
     updated_at = NOW()
 WHERE id = $1;
+
+-- name: GetBudgetCategoriesSince :many
+SELECT bc.* FROM budget_categories bc
+JOIN budgets b ON bc.budget_id = b.id
+WHERE b.user_id = $1
+  AND b.deleted = false
+  AND ($2 IS NULL OR bc.updated_at > $2)
+ORDER BY bc.updated_at ASC;
@@ -18,12 +18,14 @@ LIMIT 1;
 INSERT INTO transactions (
     user_id, budget_id, category_id, payment_method_id, 
     amount, type, is_transfer, transfer_to_account_id,
-    description, transaction_date, is_recurring, recurrence_pattern
+    description, transaction_date, is_recurring, recurrence_pattern,
+    currency, home_amount, exchange_rate, goal_id, debt_id
 )
 VALUES (
     $1, $2, $3, $4, 
     $5, $6, $7, $8, 
-    $9, $10, $11, $12
+    $9, $10, $11, $12,
+    $13, $14, $15, $16, $17
 )
 RETURNING *;
 
@@ -41,6 +43,11 @@ SET
     transaction_date = COALESCE(sqlc.narg('transaction_date'), transaction_date),
     is_recurring = COALESCE(sqlc.narg('is_recurring'), is_recurring),
     recurrence_pattern = COALESCE(sqlc.narg('recurrence_pattern'), recurrence_pattern),
+    currency = COALESCE(sqlc.narg('currency'), currency),
+    home_amount = COALESCE(sqlc.narg('home_amount'), home_amount),
+    exchange_rate = COALESCE(sqlc.narg('exchange_rate'), exchange_rate),
+    goal_id = COALESCE(sqlc.narg('goal_id'), goal_id),
+    debt_id = COALESCE(sqlc.narg('debt_id'), debt_id),
     updated_at = NOW()
 WHERE id = $1 AND deleted = false
 RETURNING *;
@@ -56,11 +63,9 @@ WHERE budget_id = $1 AND deleted = false
 ORDER BY transaction_date DESC;
 
 -- name: GetCategorySpent :one
-SELECT COALESCE(SUM(t.amount), 0) as total_spent
+SELECT COALESCE(SUM(t.home_amount), 0) as total_spent
 FROM transactions t
 WHERE t.budget_id = $1
   AND t.category_id = $2
   AND t.type = 'expense'
-  AND t.deleted = false
-  AND t.transaction_date >= (SELECT month FROM budgets WHERE id = $1)
-  AND t.transaction_date < ((SELECT month FROM budgets WHERE id = $1) + INTERVAL '1 month');
+  AND t.deleted = false;
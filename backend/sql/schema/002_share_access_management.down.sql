@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_activity_log_resource;
+
+ALTER TABLE share_access DROP COLUMN IF EXISTS updated_at;
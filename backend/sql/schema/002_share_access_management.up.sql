@@ -0,0 +1,5 @@
+-- Track permission changes on share access records
+ALTER TABLE share_access ADD COLUMN updated_at TIMESTAMPTZ DEFAULT NOW();
+
+-- Activity log lookups by resource (ownership transfers, permission changes)
+CREATE INDEX idx_activity_log_resource ON activity_log(resource_type, resource_id);
@@ -0,0 +1,36 @@
+-- Expense Splits Table (who paid a shared budget transaction and how it is split)
+CREATE TABLE expense_splits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID UNIQUE NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    paid_by_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    split_method VARCHAR(20) NOT NULL CHECK (split_method IN ('equal', 'exact', 'percentage', 'shares')),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Expense Split Shares Table (each member's portion of a split)
+CREATE TABLE expense_split_shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    split_id UUID NOT NULL REFERENCES expense_splits(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    value DECIMAL(12, 4), -- exact amount, percentage or share count as entered
+    amount DECIMAL(12, 2) NOT NULL, -- resolved amount owed for this transaction
+    UNIQUE(split_id, user_id)
+);
+
+-- Settlements Table (payments between members that settle up balances)
+CREATE TABLE settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(12, 2) NOT NULL CHECK (amount > 0),
+    note VARCHAR(255),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_expense_splits_budget ON expense_splits(budget_id);
+CREATE INDEX idx_expense_split_shares_split ON expense_split_shares(split_id);
+CREATE INDEX idx_settlements_budget ON settlements(budget_id);
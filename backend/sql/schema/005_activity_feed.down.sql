@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_activity_log_user_created;
+DROP INDEX IF EXISTS idx_activity_log_budget;
+ALTER TABLE activity_log DROP COLUMN IF EXISTS budget_id;
+ALTER TABLE activity_log DROP COLUMN IF EXISTS request_id;
@@ -0,0 +1,7 @@
+-- Activity feed: correlate entries with requests and with the budget they belong to,
+-- so collaborators on shared budgets can see who changed what
+ALTER TABLE activity_log ADD COLUMN request_id VARCHAR(100);
+ALTER TABLE activity_log ADD COLUMN budget_id UUID; -- no FK: entries outlive the budget
+
+CREATE INDEX idx_activity_log_budget ON activity_log(budget_id, created_at DESC) WHERE budget_id IS NOT NULL;
+CREATE INDEX idx_activity_log_user_created ON activity_log(user_id, created_at DESC);
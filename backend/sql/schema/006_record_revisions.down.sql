@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS budget_categories_record_revision ON budget_categories;
+DROP TRIGGER IF EXISTS budgets_record_revision ON budgets;
+DROP TRIGGER IF EXISTS transactions_record_revision ON transactions;
+DROP FUNCTION IF EXISTS record_revision();
+DROP TABLE IF EXISTS record_revisions;
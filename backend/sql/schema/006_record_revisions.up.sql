@@ -0,0 +1,79 @@
+-- Record Revisions Table (versioned history of transactions, budgets and budget categories)
+CREATE TABLE record_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    table_name VARCHAR(50) NOT NULL,
+    record_id UUID NOT NULL,
+    revision INTEGER NOT NULL,
+    operation VARCHAR(10) NOT NULL CHECK (operation IN ('create', 'update', 'delete', 'restore')),
+    data JSONB NOT NULL, -- full row as it was after the change (before it, for hard deletes)
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(table_name, record_id, revision)
+);
+
+-- Writes a revision for every change to a versioned table. Restores run with the
+-- transaction-local setting app.restoring = 'on' so they are labelled as such.
+CREATE OR REPLACE FUNCTION record_revision() RETURNS TRIGGER AS $$
+DECLARE
+    op VARCHAR(10);
+    row_data JSONB;
+    rec_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        op := 'delete';
+        row_data := to_jsonb(OLD);
+        rec_id := OLD.id;
+    ELSE
+        row_data := to_jsonb(NEW);
+        rec_id := NEW.id;
+
+        IF TG_OP = 'UPDATE' AND (row_data - 'updated_at') = (to_jsonb(OLD) - 'updated_at') THEN
+            RETURN NULL; -- nothing but the timestamp changed
+        END IF;
+
+        IF current_setting('app.restoring', true) = 'on' THEN
+            op := 'restore';
+        ELSIF TG_OP = 'INSERT' THEN
+            op := 'create';
+        ELSIF (row_data->>'deleted')::boolean IS TRUE AND (to_jsonb(OLD)->>'deleted')::boolean IS NOT TRUE THEN
+            op := 'delete';
+        ELSE
+            op := 'update';
+        END IF;
+    END IF;
+
+    INSERT INTO record_revisions (table_name, record_id, revision, operation, data)
+    VALUES (
+        TG_TABLE_NAME,
+        rec_id,
+        COALESCE((SELECT MAX(revision) FROM record_revisions WHERE table_name = TG_TABLE_NAME AND record_id = rec_id), 0) + 1,
+        op,
+        row_data
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_record_revision
+    AFTER INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION record_revision();
+
+CREATE TRIGGER budgets_record_revision
+    AFTER INSERT OR UPDATE OR DELETE ON budgets
+    FOR EACH ROW EXECUTE FUNCTION record_revision();
+
+CREATE TRIGGER budget_categories_record_revision
+    AFTER INSERT OR UPDATE OR DELETE ON budget_categories
+    FOR EACH ROW EXECUTE FUNCTION record_revision();
+
+-- Baseline revision for existing records
+INSERT INTO record_revisions (table_name, record_id, revision, operation, data)
+SELECT 'transactions', t.id, 1, 'create', to_jsonb(t) FROM transactions t;
+
+INSERT INTO record_revisions (table_name, record_id, revision, operation, data)
+SELECT 'budgets', b.id, 1, 'create', to_jsonb(b) FROM budgets b;
+
+INSERT INTO record_revisions (table_name, record_id, revision, operation, data)
+SELECT 'budget_categories', bc.id, 1, 'create', to_jsonb(bc) FROM budget_categories bc;
+
+CREATE INDEX idx_record_revisions_record ON record_revisions(table_name, record_id, revision DESC);
@@ -0,0 +1,5 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE transactions DROP COLUMN IF EXISTS home_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS currency;
+ALTER TABLE payment_methods DROP COLUMN IF EXISTS currency;
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,37 @@
+-- Multi-currency: transactions and payment methods carry their own currency.
+-- A transaction keeps its original amount and currency, plus the amount converted to
+-- the owner's home currency at the rate in effect on the transaction date. The
+-- conversion is stored rather than recomputed so later rate imports don't rewrite history.
+
+-- Exchange Rates Table (1 unit of base = rate units of quote, as of rate_date)
+CREATE TABLE exchange_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rate_date DATE NOT NULL,
+    base VARCHAR(3) NOT NULL,
+    quote VARCHAR(3) NOT NULL,
+    rate DECIMAL(18, 8) NOT NULL CHECK (rate > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(base, quote, rate_date)
+);
+
+ALTER TABLE payment_methods ADD COLUMN currency VARCHAR(3); -- NULL: the owner's home currency
+
+ALTER TABLE transactions ADD COLUMN currency VARCHAR(3);
+ALTER TABLE transactions ADD COLUMN home_amount DECIMAL(12, 2);
+ALTER TABLE transactions ADD COLUMN exchange_rate DECIMAL(18, 8);
+
+-- Existing transactions were entered in their owner's home currency.
+-- Backfilling isn't a user edit, so it doesn't get a revision.
+ALTER TABLE transactions DISABLE TRIGGER transactions_record_revision;
+
+UPDATE transactions t
+SET currency = COALESCE((SELECT u.currency FROM users u WHERE u.id = t.user_id), 'PHP'),
+    home_amount = t.amount,
+    exchange_rate = 1;
+
+ALTER TABLE transactions ENABLE TRIGGER transactions_record_revision;
+
+ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE transactions ALTER COLUMN home_amount SET NOT NULL;
+ALTER TABLE transactions ALTER COLUMN exchange_rate SET NOT NULL;
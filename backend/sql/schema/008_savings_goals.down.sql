@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_goal;
+ALTER TABLE transactions DROP COLUMN IF EXISTS goal_id;
+DROP TABLE IF EXISTS savings_goals;
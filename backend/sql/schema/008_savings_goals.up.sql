@@ -0,0 +1,20 @@
+-- Savings Goals Table
+CREATE TABLE savings_goals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    target_amount DECIMAL(12, 2) NOT NULL CHECK (target_amount > 0), -- in the home currency
+    target_date DATE,
+    payment_method_id UUID REFERENCES payment_methods(id) ON DELETE SET NULL, -- account the savings are held in
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted BOOLEAN DEFAULT FALSE
+);
+
+CREATE INDEX idx_savings_goals_user ON savings_goals(user_id) WHERE deleted = false;
+
+-- Contributions are transactions linked to a goal. Income linked to a goal is a
+-- withdrawal; anything else (usually a transfer) adds its amount to the goal.
+ALTER TABLE transactions ADD COLUMN goal_id UUID REFERENCES savings_goals(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transactions_goal ON transactions(goal_id, transaction_date) WHERE goal_id IS NOT NULL;
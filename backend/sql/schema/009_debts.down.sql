@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_debt;
+ALTER TABLE transactions DROP COLUMN IF EXISTS debt_id;
+DROP TABLE IF EXISTS debts;
@@ -0,0 +1,24 @@
+-- Debts Table
+CREATE TABLE debts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'loan' CHECK (kind IN ('loan', 'credit_card', 'other')),
+    principal DECIMAL(12, 2) NOT NULL CHECK (principal >= 0), -- balance owed on balance_date, in the home currency
+    balance_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    apr DECIMAL(6, 3) NOT NULL DEFAULT 0 CHECK (apr >= 0 AND apr < 1000), -- annual percentage rate, e.g. 19.990
+    minimum_payment DECIMAL(12, 2) NOT NULL DEFAULT 0 CHECK (minimum_payment >= 0),
+    due_day INTEGER NOT NULL DEFAULT 1 CHECK (due_day BETWEEN 1 AND 31),
+    payment_method_id UUID REFERENCES payment_methods(id) ON DELETE SET NULL, -- the credit card or loan account
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted BOOLEAN DEFAULT FALSE
+);
+
+CREATE INDEX idx_debts_user ON debts(user_id) WHERE deleted = false;
+
+-- Payments are transactions linked to a debt, dated on or after the debt's balance_date.
+-- Income linked to a debt (a refund or new borrowing) adds to the balance.
+ALTER TABLE transactions ADD COLUMN debt_id UUID REFERENCES debts(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transactions_debt ON transactions(debt_id, transaction_date) WHERE debt_id IS NOT NULL;
@@ -0,0 +1,37 @@
+-- Alert Rules Table
+CREATE TABLE alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('category_threshold', 'budget_exceeded', 'large_transaction', 'bill_due')),
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE, -- category_threshold only; NULL means every category
+    threshold_percent INTEGER CHECK (threshold_percent BETWEEN 1 AND 1000), -- category_threshold: percent of limit_amount
+    amount DECIMAL(12, 2) CHECK (amount > 0), -- large_transaction: in the home currency
+    days_before INTEGER CHECK (days_before BETWEEN 0 AND 60), -- bill_due: how far ahead to warn
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (kind <> 'category_threshold' OR threshold_percent IS NOT NULL),
+    CHECK (kind <> 'large_transaction' OR amount IS NOT NULL),
+    CHECK (kind <> 'bill_due' OR days_before IS NOT NULL)
+);
+
+CREATE INDEX idx_alert_rules_user ON alert_rules(user_id) WHERE enabled;
+
+-- Notifications Table (in-app inbox)
+-- dedupe_key identifies what a notification is about, e.g. a category crossing 80% in
+-- a given month, so the same alert is only ever delivered once.
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    data JSONB NOT NULL DEFAULT '{}',
+    dedupe_key VARCHAR(255) NOT NULL,
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(user_id, dedupe_key)
+);
+
+CREATE INDEX idx_notifications_user_created ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE alert_rules DROP COLUMN IF EXISTS email;
+DROP TABLE IF EXISTS email_outbox;
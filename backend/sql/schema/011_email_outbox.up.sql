@@ -0,0 +1,23 @@
+-- Email Outbox Table
+-- Emails are queued here in the same request that causes them and delivered by a
+-- background worker, so an unreachable SMTP server delays mail instead of losing it.
+-- Messages are rendered from template and data when they are sent.
+CREATE TABLE email_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- NULL when the recipient has no account yet
+    to_address VARCHAR(255) NOT NULL,
+    template VARCHAR(50) NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    dedupe_key VARCHAR(255) UNIQUE, -- e.g. one month-end summary per budget
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_outbox_due ON email_outbox(next_attempt_at) WHERE status = 'pending';
+
+-- Whether an alert rule also emails its notifications
+ALTER TABLE alert_rules ADD COLUMN email BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS push_outbox;
+DROP TABLE IF EXISTS push_subscriptions;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS share_invitations_publish_change ON share_invitations;
+DROP TRIGGER IF EXISTS share_access_publish_change ON share_access;
+DROP TRIGGER IF EXISTS categories_publish_change ON categories;
+DROP TRIGGER IF EXISTS transactions_publish_change ON transactions;
+DROP TRIGGER IF EXISTS budget_categories_publish_change ON budget_categories;
+DROP TRIGGER IF EXISTS budgets_publish_change ON budgets;
+DROP FUNCTION IF EXISTS publish_change();
+DROP FUNCTION IF EXISTS budget_audience(UUID);
+DROP TABLE IF EXISTS change_events;
@@ -0,0 +1,130 @@
+-- Change Events Table (real-time updates)
+-- Triggers record a row per change to data a user can see, listing everyone who can
+-- see it, and announce it with NOTIFY change_events so every API instance can push it
+-- to connected clients. Clients resume from the last id they saw; rows are kept for a
+-- day, after which a client has to do a full sync instead.
+CREATE TABLE change_events (
+    id BIGSERIAL PRIMARY KEY,
+    table_name VARCHAR(50) NOT NULL,
+    record_id UUID NOT NULL,
+    operation VARCHAR(10) NOT NULL CHECK (operation IN ('create', 'update', 'delete')),
+    budget_id UUID,
+    recipients UUID[] NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_change_events_recipients ON change_events USING GIN (recipients);
+CREATE INDEX idx_change_events_created ON change_events(created_at);
+
+-- The owner of a budget and everyone it is shared with
+CREATE OR REPLACE FUNCTION budget_audience(b UUID) RETURNS UUID[] AS $$
+    SELECT COALESCE(array_agg(DISTINCT u), '{}') FROM (
+        SELECT user_id AS u FROM budgets WHERE id = b
+        UNION
+        SELECT shared_with_id FROM share_access WHERE budget_id = b
+    ) audience
+    WHERE u IS NOT NULL
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION publish_change() RETURNS TRIGGER AS $$
+DECLARE
+    rec JSONB;
+    old_rec JSONB;
+    op VARCHAR(10);
+    budget UUID;
+    audience UUID[] := '{}';
+    event_id BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := to_jsonb(OLD);
+        op := 'delete';
+    ELSE
+        rec := to_jsonb(NEW);
+        IF TG_OP = 'UPDATE' THEN
+            old_rec := to_jsonb(OLD);
+            IF (rec - 'updated_at') = (old_rec - 'updated_at') THEN
+                RETURN NULL; -- nothing but the timestamp changed
+            END IF;
+        END IF;
+
+        IF TG_OP = 'INSERT' THEN
+            op := 'create';
+        ELSIF (rec->>'deleted')::boolean IS TRUE AND (old_rec->>'deleted')::boolean IS NOT TRUE THEN
+            op := 'delete';
+        ELSE
+            op := 'update';
+        END IF;
+    END IF;
+
+    CASE TG_TABLE_NAME
+    WHEN 'budgets' THEN
+        budget := (rec->>'id')::uuid;
+        audience := budget_audience(budget) || (rec->>'user_id')::uuid;
+    WHEN 'budget_categories' THEN
+        budget := (rec->>'budget_id')::uuid;
+        audience := budget_audience(budget);
+    WHEN 'transactions' THEN
+        budget := (rec->>'budget_id')::uuid;
+        audience := budget_audience(budget) || (rec->>'user_id')::uuid;
+        -- Moving a transaction out of a budget is a change for that budget's members too
+        IF old_rec IS NOT NULL AND old_rec->>'budget_id' IS DISTINCT FROM rec->>'budget_id' THEN
+            audience := audience || budget_audience((old_rec->>'budget_id')::uuid);
+        END IF;
+    WHEN 'categories' THEN
+        IF rec->>'user_id' IS NULL THEN
+            RETURN NULL; -- system categories don't change at runtime
+        END IF;
+        -- Custom categories show up in the owner's shared budgets
+        audience := (rec->>'user_id')::uuid || ARRAY(
+            SELECT shared_with_id FROM share_access WHERE owner_id = (rec->>'user_id')::uuid
+        );
+    WHEN 'share_access' THEN
+        budget := (rec->>'budget_id')::uuid;
+        -- Includes a member who was just removed
+        audience := budget_audience(budget) || (rec->>'shared_with_id')::uuid;
+    WHEN 'share_invitations' THEN
+        budget := (rec->>'budget_id')::uuid;
+        audience := (rec->>'owner_id')::uuid || ARRAY(
+            SELECT id FROM users WHERE email = rec->>'recipient_email' AND deleted = false
+        );
+    END CASE;
+
+    audience := ARRAY(SELECT DISTINCT a FROM unnest(audience) a WHERE a IS NOT NULL);
+    IF cardinality(audience) = 0 THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO change_events (table_name, record_id, operation, budget_id, recipients)
+    VALUES (TG_TABLE_NAME, (rec->>'id')::uuid, op, budget, audience)
+    RETURNING id INTO event_id;
+
+    -- Delivered on commit; listeners load the event by id
+    PERFORM pg_notify('change_events', event_id::text);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER budgets_publish_change
+    AFTER INSERT OR UPDATE OR DELETE ON budgets
+    FOR EACH ROW EXECUTE FUNCTION publish_change();
+
+CREATE TRIGGER budget_categories_publish_change
+    AFTER INSERT OR UPDATE OR DELETE ON budget_categories
+    FOR EACH ROW EXECUTE FUNCTION publish_change();
+
+CREATE TRIGGER transactions_publish_change
+    AFTER INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION publish_change();
+
+CREATE TRIGGER categories_publish_change
+    AFTER INSERT OR UPDATE OR DELETE ON categories
+    FOR EACH ROW EXECUTE FUNCTION publish_change();
+
+CREATE TRIGGER share_access_publish_change
+    AFTER INSERT OR UPDATE OR DELETE ON share_access
+    FOR EACH ROW EXECUTE FUNCTION publish_change();
+
+CREATE TRIGGER share_invitations_publish_change
+    AFTER INSERT OR UPDATE OR DELETE ON share_invitations
+    FOR EACH ROW EXECUTE FUNCTION publish_change();
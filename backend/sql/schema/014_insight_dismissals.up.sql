@@ -0,0 +1,11 @@
+-- Insight Dismissals Table
+-- Insights (unusual transactions, spending spikes, duplicate charges, price changes)
+-- are computed from transaction history when they're requested. Each has a stable
+-- id derived from what it's about, e.g. large_transaction.<transaction id>, and a
+-- user dismissing one records that id here so it isn't shown again.
+CREATE TABLE insight_dismissals (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    insight_id VARCHAR(200) NOT NULL,
+    dismissed_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_id, insight_id)
+);
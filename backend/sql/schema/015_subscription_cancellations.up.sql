@@ -0,0 +1,11 @@
+-- Subscription Cancellations Table
+-- Subscriptions are detected from transaction history when they're requested, and
+-- identified by their merchant, e.g. netflix or spotify-premium. A user marking one
+-- cancelled records that id here, so it's shown as cancelled and a charge arriving
+-- after cancelled_at can be pointed out.
+CREATE TABLE subscription_cancellations (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subscription_id VARCHAR(200) NOT NULL,
+    cancelled_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_id, subscription_id)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS net_worth_snapshots;
+DROP TABLE IF EXISTS asset_valuations;
+DROP TABLE IF EXISTS assets;
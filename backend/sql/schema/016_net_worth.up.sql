@@ -0,0 +1,42 @@
+-- Assets Table
+-- Things a user owns outside their payment methods: property, vehicles,
+-- investments. Their value over time comes from valuations.
+CREATE TABLE assets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    asset_class VARCHAR(20) NOT NULL DEFAULT 'other' CHECK (asset_class IN ('property', 'vehicle', 'investment', 'cash', 'other')),
+    notes TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted BOOLEAN DEFAULT FALSE
+);
+
+CREATE INDEX idx_assets_user ON assets(user_id) WHERE deleted = false;
+
+-- Asset Valuations Table
+-- What an asset was worth on a date, in the home currency. The latest one is its
+-- current value; one valuation per day.
+CREATE TABLE asset_valuations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    value DECIMAL(14, 2) NOT NULL CHECK (value >= 0),
+    valued_on DATE NOT NULL DEFAULT CURRENT_DATE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(asset_id, valued_on)
+);
+
+-- Net Worth Snapshots Table
+-- A user's net worth for each month, recorded by a background worker. The current
+-- month's snapshot is rewritten until the month ends, so each row holds the last
+-- value seen in its month. breakdown is {"assets": {class: amount}, "liabilities": {kind: amount}}.
+CREATE TABLE net_worth_snapshots (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month DATE NOT NULL, -- first day of the month
+    assets DECIMAL(14, 2) NOT NULL,
+    liabilities DECIMAL(14, 2) NOT NULL,
+    net_worth DECIMAL(14, 2) NOT NULL,
+    breakdown JSONB NOT NULL DEFAULT '{}',
+    recorded_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_id, month)
+);
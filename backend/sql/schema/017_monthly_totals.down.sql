@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS transactions_monthly_totals ON transactions;
+DROP FUNCTION IF EXISTS maintain_monthly_totals();
+DROP FUNCTION IF EXISTS adjust_monthly_total(UUID, UUID, UUID, UUID, DATE, VARCHAR, BOOLEAN, DECIMAL, INTEGER);
+DROP FUNCTION IF EXISTS monthly_totals_lock_key(UUID);
+DROP TABLE IF EXISTS monthly_totals;
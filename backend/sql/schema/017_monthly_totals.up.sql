@@ -0,0 +1,99 @@
+-- Monthly Totals Table
+-- Transaction totals in the home currency per user, budget, category, payment method,
+-- month, type and transfer flag, kept current by a trigger on transactions so
+-- dashboards and reports don't scan raw transactions. Rows are derived data: there are
+-- no foreign keys, because deleting a user, budget or category deletes or moves its
+-- transactions and the trigger settles the totals itself. A background check compares
+-- them with transactions and rebuilds any user that has drifted.
+CREATE TABLE monthly_totals (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID,
+    budget_id UUID,
+    category_id UUID,
+    payment_method_id UUID,
+    month DATE NOT NULL,
+    type VARCHAR(10),
+    is_transfer BOOLEAN,
+    total DECIMAL(14, 2) NOT NULL DEFAULT 0,
+    transaction_count INTEGER NOT NULL DEFAULT 0,
+    CONSTRAINT monthly_totals_key UNIQUE NULLS NOT DISTINCT
+        (user_id, budget_id, category_id, payment_method_id, month, type, is_transfer)
+);
+
+CREATE INDEX idx_monthly_totals_budget ON monthly_totals(budget_id, month);
+CREATE INDEX idx_monthly_totals_user_month ON monthly_totals(user_id, month);
+
+-- Advisory lock key for a user's totals. Writes take it shared and a rebuild takes it
+-- exclusive, so rebuilding one user never holds up anyone else's writes.
+CREATE OR REPLACE FUNCTION monthly_totals_lock_key(p_user UUID) RETURNS BIGINT AS $$
+    SELECT hashtextextended('monthly_totals:' || p_user::text, 0);
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Adds amount and count to a group's totals, dropping the row once no transactions are left
+CREATE OR REPLACE FUNCTION adjust_monthly_total(
+    p_user UUID, p_budget UUID, p_category UUID, p_payment_method UUID,
+    p_date DATE, p_type VARCHAR(10), p_transfer BOOLEAN,
+    p_amount DECIMAL, p_count INTEGER
+) RETURNS VOID AS $$
+DECLARE
+    row_id BIGINT;
+    remaining INTEGER;
+BEGIN
+    INSERT INTO monthly_totals (user_id, budget_id, category_id, payment_method_id, month, type, is_transfer, total, transaction_count)
+    VALUES (p_user, p_budget, p_category, p_payment_method, DATE_TRUNC('month', p_date)::date, p_type, p_transfer, p_amount, p_count)
+    ON CONFLICT ON CONSTRAINT monthly_totals_key DO UPDATE SET
+        total = monthly_totals.total + EXCLUDED.total,
+        transaction_count = monthly_totals.transaction_count + EXCLUDED.transaction_count
+    RETURNING id, transaction_count INTO row_id, remaining;
+
+    IF remaining <= 0 THEN
+        DELETE FROM monthly_totals WHERE id = row_id;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Moves a transaction's amount out of its old group and into its new one. Deleted
+-- transactions aren't counted.
+CREATE OR REPLACE FUNCTION maintain_monthly_totals() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND (NEW.user_id, NEW.budget_id, NEW.category_id, NEW.payment_method_id,
+             DATE_TRUNC('month', NEW.transaction_date), NEW.type, NEW.is_transfer, NEW.home_amount, NEW.deleted)
+        IS NOT DISTINCT FROM
+            (OLD.user_id, OLD.budget_id, OLD.category_id, OLD.payment_method_id,
+             DATE_TRUNC('month', OLD.transaction_date), OLD.type, OLD.is_transfer, OLD.home_amount, OLD.deleted) THEN
+        RETURN NULL; -- nothing the totals depend on changed
+    END IF;
+
+    -- Waits for a rebuild of either user's totals to commit
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.user_id IS NOT NULL THEN
+        PERFORM pg_advisory_xact_lock_shared(monthly_totals_lock_key(OLD.user_id));
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.user_id IS NOT NULL THEN
+        PERFORM pg_advisory_xact_lock_shared(monthly_totals_lock_key(NEW.user_id));
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.deleted = false THEN
+        PERFORM adjust_monthly_total(OLD.user_id, OLD.budget_id, OLD.category_id, OLD.payment_method_id,
+            OLD.transaction_date, OLD.type, OLD.is_transfer, -OLD.home_amount, -1);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.deleted = false THEN
+        PERFORM adjust_monthly_total(NEW.user_id, NEW.budget_id, NEW.category_id, NEW.payment_method_id,
+            NEW.transaction_date, NEW.type, NEW.is_transfer, NEW.home_amount, 1);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_monthly_totals
+    AFTER INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION maintain_monthly_totals();
+
+-- Backfill from existing transactions
+INSERT INTO monthly_totals (user_id, budget_id, category_id, payment_method_id, month, type, is_transfer, total, transaction_count)
+SELECT user_id, budget_id, category_id, payment_method_id, DATE_TRUNC('month', transaction_date)::date,
+       type, is_transfer, SUM(home_amount), COUNT(*)
+FROM transactions
+WHERE deleted = false
+GROUP BY user_id, budget_id, category_id, payment_method_id, DATE_TRUNC('month', transaction_date), type, is_transfer;
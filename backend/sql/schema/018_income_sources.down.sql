@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS income_expectations;
+DROP TABLE IF EXISTS income_sources;
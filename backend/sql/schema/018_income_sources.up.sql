@@ -0,0 +1,35 @@
+-- Income Sources Table
+-- Where a user expects income from each month: a salary, freelance work, 13th-month
+-- pay. Income transactions in a source's category count as received from it. months
+-- lists the months of the year a source pays in (13th-month pay in December), NULL
+-- for every month.
+CREATE TABLE income_sources (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'other'
+        CHECK (kind IN ('salary', 'freelance', 'bonus', 'thirteenth_month', 'investment', 'other')),
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+    amount DECIMAL(12, 2) NOT NULL CHECK (amount >= 0), -- in the home currency
+    months SMALLINT[],
+    start_month DATE NOT NULL,
+    end_month DATE, -- NULL while the income continues
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    deleted BOOLEAN DEFAULT FALSE
+);
+
+CREATE INDEX idx_income_sources_user ON income_sources(user_id) WHERE NOT deleted;
+-- A category's income belongs to one source
+CREATE UNIQUE INDEX idx_income_sources_category ON income_sources(user_id, category_id)
+    WHERE NOT deleted AND category_id IS NOT NULL;
+
+-- Income Expectations Table
+-- A different amount expected from a source in one month, e.g. a smaller freelance month
+CREATE TABLE income_expectations (
+    source_id UUID NOT NULL REFERENCES income_sources(id) ON DELETE CASCADE,
+    month DATE NOT NULL,
+    amount DECIMAL(12, 2) NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (source_id, month)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS envelope_moves;
+ALTER TABLE budgets DROP COLUMN IF EXISTS strict_envelopes;
+ALTER TABLE budgets DROP COLUMN IF EXISTS envelope_mode;
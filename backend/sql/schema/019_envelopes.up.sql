@@ -0,0 +1,24 @@
+-- Envelope budgeting, opt-in per budget. Income lands in an unassigned pool and is
+-- assigned to category envelopes, whose assigned amount is the budget category's
+-- limit_amount. Whatever an envelope doesn't spend carries over to the owner's next
+-- envelope budget. Strict budgets reject expenses and moves that would overdraw an
+-- envelope or the pool.
+ALTER TABLE budgets ADD COLUMN envelope_mode BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE budgets ADD COLUMN strict_envelopes BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Envelope Moves Table
+-- Every change to what an envelope budget assigns, for auditing. A NULL category is
+-- the unassigned pool.
+CREATE TABLE envelope_moves (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL, -- who moved it
+    from_category_id UUID, -- no FK: moves outlive the category
+    to_category_id UUID,
+    amount DECIMAL(12, 2) NOT NULL CHECK (amount > 0),
+    note VARCHAR(255),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (from_category_id IS DISTINCT FROM to_category_id)
+);
+
+CREATE INDEX idx_envelope_moves_budget ON envelope_moves(budget_id, created_at);
@@ -0,0 +1,27 @@
+-- Budget periods. A budget runs from month, its first day whatever the period, up to
+-- period_end, exclusive. Besides calendar months it can be weekly, bi-weekly,
+-- semi-monthly between two paydays, or a month from one payday to the next.
+ALTER TABLE budgets ADD COLUMN period_type VARCHAR(20) NOT NULL DEFAULT 'monthly'
+    CHECK (period_type IN ('monthly', 'semimonthly', 'biweekly', 'weekly', 'custom'));
+ALTER TABLE budgets ADD COLUMN period_end DATE;
+
+-- Existing budgets are calendar months. Backfilling isn't a user edit, so it gets
+-- no revision and isn't published.
+ALTER TABLE budgets DISABLE TRIGGER budgets_record_revision;
+ALTER TABLE budgets DISABLE TRIGGER budgets_publish_change;
+
+UPDATE budgets SET period_end = (month + INTERVAL '1 month')::date;
+
+ALTER TABLE budgets ENABLE TRIGGER budgets_record_revision;
+ALTER TABLE budgets ENABLE TRIGGER budgets_publish_change;
+
+ALTER TABLE budgets ALTER COLUMN period_end SET NOT NULL;
+ALTER TABLE budgets ADD CONSTRAINT budgets_period_check CHECK (period_end > month);
+
+-- A user's budgets can't overlap, so every date has at most one budget. This
+-- replaces UNIQUE(user_id, month) and, unlike it, ignores deleted budgets.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_user_id_month_key;
+ALTER TABLE budgets ADD CONSTRAINT budgets_no_overlap
+    EXCLUDE USING gist (user_id WITH =, daterange(month, period_end) WITH &&)
+    WHERE (deleted IS NOT TRUE);